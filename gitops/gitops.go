@@ -0,0 +1,211 @@
+// Package gitops defines the git operations mdrelease needs, so programs
+// that embed it, and tests, can supply their own backend: go-git, a mock,
+// or a remote agent. New returns the default backend, which runs the git
+// executable.
+package gitops
+
+import (
+	"time"
+
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+)
+
+type (
+	// Options configures the default backend: output streams, dry-run,
+	// credentials, committer identity, and the directory to run in.
+	Options = gitutil.Options
+	// Version is a git version, as reported by Client.Version.
+	Version = gitutil.Version
+	// Commit is one commit listed by Client.CommitsSince.
+	Commit = gitutil.Commit
+	// Tag is one tag listed by Client.TagDetails.
+	Tag = gitutil.Tag
+	// GitError is the error the default backend returns for a failed git
+	// command, with the command, exit code, and output.
+	GitError = gitutil.GitError
+	// RemoteTagCache lets default backends that share it, through
+	// Options.TagCache, list each remote's tags once.
+	RemoteTagCache = gitutil.RemoteTagCache
+	// RemoteLockHeldError is the error Client.AcquireRemoteLock returns when
+	// another release holds the lock ref.
+	RemoteLockHeldError = gitutil.RemoteLockHeldError
+)
+
+// Client is every git operation mdrelease performs.
+//
+// Methods that change the repository or a remote must honor dry-run
+// themselves: the default backend prints the command, prefixed with
+// "[dry-run]", to Options.Stdout and returns nil without running it, so a
+// dry-run caller sees the operation succeed. Methods that only read run in a
+// dry run too. Tag and branch names are checked as refs/tags/<tag> and
+// refs/heads/<branch>, never as other refs of the same name. A lookup that
+// finds nothing returns false, "", or an empty list with a nil error unless
+// its method says it fails. The default backend reports a failed git command
+// as a *GitError; other backends may return any error.
+type Client interface {
+	// Version reports the version of the git executable.
+	Version() (Version, error)
+	// HasCommitterIdentity reports whether git can name a committer, from
+	// its config or from Options.UserName and Options.UserEmail.
+	HasCommitterIdentity() (ok bool, err error)
+	// EnsureRepo fails unless the directory is inside a git work tree.
+	EnsureRepo() error
+	// EnsureRemote fails unless remote is configured.
+	EnsureRemote(remote string) error
+	// EnsureRemoteReachable fails unless remote answers, asking only for
+	// its HEAD.
+	EnsureRemoteReachable(remote string) error
+	// RemoteURL returns the fetch URL of remote.
+	RemoteURL(remote string) (url string, err error)
+	// Remotes lists the configured remote names.
+	Remotes() (names []string, err error)
+	// DefaultBranch returns the branch remote's HEAD points at, or "" when
+	// the clone did not record it.
+	DefaultBranch(remote string) (branch string, err error)
+	// TopLevel returns the absolute path of the work tree root.
+	TopLevel() (dir string, err error)
+	// PreviousTag returns the newest tag starting with prefix that is
+	// reachable from rev, or "" when there is none.
+	PreviousTag(rev, prefix string) (tag string, err error)
+	// CommitsSince lists the commits after the tag since up to HEAD, newest
+	// first, that touch one of paths (any path when none are given). An
+	// empty since lists the whole history.
+	CommitsSince(since string, paths ...string) ([]Commit, error)
+	// Tags lists the local tags that start with prefix.
+	Tags(prefix string) ([]string, error)
+	// RemoteTags lists remote's tags that start with prefix.
+	RemoteTags(remote, prefix string) ([]string, error)
+	// GitDir returns the absolute path of the git directory every worktree
+	// shares, where mdrelease keeps its journal and lock.
+	GitDir() (dir string, err error)
+	// IsShallow reports whether the repository is a shallow clone.
+	IsShallow() (bool, error)
+	// Unshallow fetches the full history and tags from remote.
+	Unshallow(remote string) error
+	// FetchTags fetches tags from the default remote.
+	FetchTags() error
+	// FetchRemote fetches remote's branches and tags, pruning deleted ones.
+	FetchRemote(remote string) error
+	// PullFFOnly fast-forwards the current branch from remote and fails
+	// when that is not possible.
+	PullFFOnly(remote string) error
+	// PullRebase rebases the current branch onto remote.
+	PullRebase(remote string) error
+	// RemoteAhead counts the commits on remote's copy of the current branch
+	// that HEAD lacks, as of the last fetch, and names that branch as
+	// "<remote>/<branch>". It returns 0 and "" on a detached HEAD or when
+	// remote has no such branch.
+	RemoteAhead(remote string) (count int, upstream string, err error)
+	// EnsureTagAbsent fails when tag exists locally.
+	EnsureTagAbsent(tag string) error
+	// EnsureTagPresent fails unless tag exists locally.
+	EnsureTagPresent(tag string) error
+	// HasLocalTag reports whether tag exists locally.
+	HasLocalTag(tag string) (bool, error)
+	// HasRemoteTag reports whether tag exists on remote.
+	HasRemoteTag(remote, tag string) (bool, error)
+	// DeleteLocalTag deletes tag from the repository.
+	DeleteLocalTag(tag string) error
+	// DeleteRemoteTag deletes tag from remote.
+	DeleteRemoteTag(remote, tag string) error
+	// HasLocalBranch reports whether branch exists locally.
+	HasLocalBranch(branch string) (bool, error)
+	// HasRemoteBranch reports whether branch exists on remote.
+	HasRemoteBranch(remote, branch string) (bool, error)
+	// CreateBranch creates branch at start, or at HEAD when start is "",
+	// without checking it out.
+	CreateBranch(branch, start string) error
+	// PushBranch pushes the local branch to the branch of the same name on
+	// remote.
+	PushBranch(remote, branch string) error
+	// CurrentBranch returns the checked-out branch, or "" on a detached
+	// HEAD.
+	CurrentBranch() (branch string, err error)
+	// ResolveCommit returns the full hash of the commit rev names, and fails
+	// when rev does not name one.
+	ResolveCommit(rev string) (hash string, err error)
+	// ShowFile returns the content of path at rev.
+	ShowFile(rev, path string) (content string, err error)
+	// HasUncommittedChanges reports whether tracked files differ from HEAD,
+	// staged or not; untracked files do not count.
+	HasUncommittedChanges() (bool, error)
+	// Checkout switches the work tree to branch.
+	Checkout(branch string) error
+	// CherryPick applies commit onto HEAD, recording its origin in the
+	// message (cherry-pick -x).
+	CherryPick(commit string) error
+	// Revert commits the inverse of commit with the default message.
+	Revert(commit string) error
+	// DeleteLocalBranch deletes branch, even when it is not merged.
+	DeleteLocalBranch(branch string) error
+	// ResetSoft moves the current branch to rev, keeping the changes staged.
+	ResetSoft(rev string) error
+	// RemoteBranchRef returns remote's copy of the current branch, such as
+	// "refs/remotes/origin/main", falling back to the ref remote's HEAD
+	// points at; it returns "" when neither exists.
+	RemoteBranchRef(remote string) (ref string, err error)
+	// IsAncestor reports whether commit is reachable from ref.
+	IsAncestor(commit, ref string) (bool, error)
+	// VerifyCommit reports whether rev carries a signature that git
+	// verify-commit accepts; a missing or untrusted signature is false, not
+	// an error.
+	VerifyCommit(rev string) (ok bool, err error)
+	// LineAddedAt returns the commit time of the oldest commit whose diff of
+	// path adds or removes a line matching pattern, an extended regular
+	// expression, or the zero time when no commit has.
+	LineAddedAt(path, pattern string) (time.Time, error)
+	// TagMessage returns the message of the annotated tag, or of the commit
+	// a lightweight tag points at.
+	TagMessage(tag string) (message string, err error)
+	// TagDetails lists the local tags that start with prefix, in refname
+	// order.
+	TagDetails(prefix string) ([]Tag, error)
+	// UsesLFS reports whether any tracked file uses the Git LFS filter.
+	UsesLFS() (bool, error)
+	// HasLFS reports whether git-lfs is installed.
+	HasLFS() bool
+	// LFSPush uploads the LFS objects ref needs to remote.
+	LFSPush(remote, ref string) error
+	// RemoteTagCommit returns the commit tag points at on remote, peeling
+	// annotated tags, or "" when remote does not have tag.
+	RemoteTagCommit(remote, tag string) (commit string, err error)
+	// StageAll stages every change, as git add -A.
+	StageAll() error
+	// StagePaths stages the changes under paths only.
+	StagePaths(paths ...string) error
+	// HasStagedChanges reports whether anything is staged for commit.
+	HasStagedChanges() (bool, error)
+	// ChangedFiles lists the paths a commit would include: every change git
+	// add -A would stage when all is set, otherwise only what is staged.
+	ChangedFiles(all bool) (paths []string, err error)
+	// Commit commits the staged changes with summary as the subject and
+	// description, when not "", as the body, dated Options.CommitDate when
+	// set.
+	Commit(summary, description string) error
+	// CreateTag creates the annotated tag at target, or at HEAD when target
+	// is "", with summary and description as its message, dated like Commit.
+	CreateTag(tag, target, summary, description string) error
+	// PushHead pushes HEAD to the branch of the same name on remote.
+	PushHead(remote string) error
+	// PushTag pushes tag to remote.
+	PushTag(remote, tag string) error
+	// AcquireRemoteLock pushes ref to remote, pointing at a new empty commit
+	// whose message is message, only if remote does not have ref yet; of two
+	// callers racing for it, one fails with a *RemoteLockHeldError. With
+	// force it replaces an existing lock. It returns the lock commit to pass
+	// to ReleaseRemoteLock, which is "" in a dry run.
+	AcquireRemoteLock(remote, ref, message string, force bool) (commit string, err error)
+	// ReleaseRemoteLock deletes ref from remote if it still points at
+	// commit, leaving a lock another caller has taken since alone.
+	ReleaseRemoteLock(remote, ref, commit string) error
+}
+
+var _ Client = (*gitutil.Client)(nil)
+
+// NewRemoteTagCache returns an empty cache for Options.TagCache.
+func NewRemoteTagCache() *RemoteTagCache { return gitutil.NewRemoteTagCache() }
+
+// New returns the default backend, which runs the git executable.
+func New(opts Options) Client {
+	return gitutil.New(opts)
+}
@@ -0,0 +1,152 @@
+package gitops
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultBackendTagsAndLists(t *testing.T) {
+	repo, remote := initRepo(t)
+	var stdout, stderr bytes.Buffer
+	c := New(Options{Dir: repo, Stdout: &stdout, Stderr: &stderr})
+	if err := c.EnsureRepo(); err != nil {
+		t.Fatalf("EnsureRepo: %v", err)
+	}
+	if err := c.CreateTag("v1.0.0", "", "First release", ""); err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	writeFile(t, filepath.Join(repo, "main.go"), "package main\n")
+	if err := c.StageAll(); err != nil {
+		t.Fatalf("StageAll: %v", err)
+	}
+	if staged, err := c.HasStagedChanges(); err != nil || !staged {
+		t.Fatalf("HasStagedChanges = %t, %v", staged, err)
+	}
+	if err := c.Commit("feat: add main", ""); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if tag, err := c.PreviousTag("HEAD", "v"); err != nil || tag != "v1.0.0" {
+		t.Fatalf("PreviousTag = %q, %v", tag, err)
+	}
+	commits, err := c.CommitsSince("v1.0.0")
+	if err != nil || len(commits) != 1 || commits[0].Subject != "feat: add main" {
+		t.Fatalf("CommitsSince = %+v, %v", commits, err)
+	}
+	head, err := c.ResolveCommit("HEAD")
+	if err != nil || head != git(t, repo, "rev-parse", "HEAD") {
+		t.Fatalf("ResolveCommit = %q, %v", head, err)
+	}
+	if err := c.EnsureTagAbsent("v1.0.0"); err == nil {
+		t.Fatal("EnsureTagAbsent accepted an existing tag")
+	}
+
+	if err := c.PushTag("origin", "v1.0.0"); err != nil {
+		t.Fatalf("PushTag: %v", err)
+	}
+	if tags, err := c.RemoteTags("origin", "v"); err != nil || len(tags) != 1 || tags[0] != "v1.0.0" {
+		t.Fatalf("RemoteTags = %v, %v", tags, err)
+	}
+	if got := git(t, remote, "tag", "--list"); got != "v1.0.0" {
+		t.Fatalf("remote tags = %q", got)
+	}
+}
+
+func TestDefaultBackendSharesRemoteTagCache(t *testing.T) {
+	repo, remote := initRepo(t)
+	git(t, repo, "tag", "v1.0.0")
+	git(t, repo, "push", "origin", "v1.0.0")
+
+	var trace bytes.Buffer
+	cache := NewRemoteTagCache()
+	for _, tag := range []string{"v1.0.0", "v2.0.0"} {
+		c := New(Options{Dir: repo, Trace: &trace, TagCache: cache})
+		has, err := c.HasRemoteTag("origin", tag)
+		if err != nil || has != (tag == "v1.0.0") {
+			t.Fatalf("HasRemoteTag(%s) = %t, %v", tag, has, err)
+		}
+	}
+	if n := strings.Count(trace.String(), " ls-remote "); n != 1 {
+		t.Fatalf("listed remote tags %d times, want 1:\n%s", n, trace.String())
+	}
+
+	// Pushing through a client that shares the cache refreshes it.
+	git(t, repo, "tag", "v2.0.0")
+	if err := New(Options{Dir: repo, TagCache: cache}).PushTag("origin", "v2.0.0"); err != nil {
+		t.Fatalf("PushTag: %v", err)
+	}
+	if has, err := New(Options{Dir: repo, TagCache: cache}).HasRemoteTag("origin", "v2.0.0"); err != nil || !has {
+		t.Fatalf("HasRemoteTag after push = %t, %v", has, err)
+	}
+	if got := git(t, remote, "tag", "--list"); got != "v1.0.0\nv2.0.0" {
+		t.Fatalf("remote tags = %q", got)
+	}
+}
+
+func TestDefaultBackendDryRun(t *testing.T) {
+	repo, _ := initRepo(t)
+	var stdout bytes.Buffer
+	c := New(Options{Dir: repo, Stdout: &stdout, DryRun: true})
+	if err := c.CreateTag("v1.0.0", "", "First release", ""); err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	if err := c.PushTag("origin", "v1.0.0"); err != nil {
+		t.Fatalf("PushTag: %v", err)
+	}
+	if got := stdout.String(); !strings.Contains(got, `[dry-run] git tag -a v1.0.0 -m "First release"`) || !strings.Contains(got, "[dry-run] git push origin v1.0.0") {
+		t.Fatalf("dry-run output = %q", got)
+	}
+	if got := git(t, repo, "tag", "--list"); got != "" {
+		t.Fatalf("dry run created tags %q", got)
+	}
+}
+
+func TestDefaultBackendReturnsGitError(t *testing.T) {
+	repo, _ := initRepo(t)
+	_, err := New(Options{Dir: repo}).ResolveCommit("no-such-rev")
+	var ge *GitError
+	if !errors.As(err, &ge) || ge.Op != "resolve commit" || len(ge.Args) == 0 || ge.Args[0] != "git" || ge.ExitCode == 0 {
+		t.Fatalf("error = %#v, want GitError", err)
+	}
+	if !strings.Contains(err.Error(), `cannot resolve "no-such-rev" to a commit`) {
+		t.Fatalf("error = %v", err)
+	}
+}
+
+func initRepo(t *testing.T) (repo, remote string) {
+	t.Helper()
+	repo, remote = t.TempDir(), filepath.Join(t.TempDir(), "origin.git")
+	git(t, repo, "init", "--bare", remote)
+	git(t, repo, "init")
+	git(t, repo, "config", "user.name", "Test User")
+	git(t, repo, "config", "user.email", "test@example.com")
+	writeFile(t, filepath.Join(repo, "README.md"), "test\n")
+	git(t, repo, "add", "README.md")
+	git(t, repo, "commit", "-m", "init")
+	git(t, repo, "remote", "add", "origin", remote)
+	git(t, repo, "push", "-u", "origin", "HEAD")
+	return repo, remote
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+)
+
+// annotationsEnabled reports whether to report problems as GitHub Actions
+// workflow commands, which the runner shows inline on the commit or pull
+// request: under Actions, unless MDRELEASE_ANNOTATIONS turns them off.
+func annotationsEnabled(getenv func(string) string) bool {
+	if getenv == nil || !envSet(getenv("GITHUB_ACTIONS")) {
+		return false
+	}
+	v := getenv("MDRELEASE_ANNOTATIONS")
+	return v == "" || envSet(v)
+}
+
+// annotation is one ::error, ::warning, or ::notice workflow command.
+type annotation struct {
+	level string
+	file  string
+	line  int
+	col   int
+	title string
+	msg   string
+}
+
+func (a annotation) String() string {
+	var props []string
+	if a.file != "" {
+		props = append(props, "file="+escapeAnnotationProperty(a.file))
+		if a.line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", a.line))
+		}
+		if a.col > 0 {
+			props = append(props, fmt.Sprintf("col=%d", a.col))
+		}
+	}
+	if a.title != "" {
+		props = append(props, "title="+escapeAnnotationProperty(a.title))
+	}
+	cmd := "::" + a.level
+	if len(props) > 0 {
+		cmd += " " + strings.Join(props, ",")
+	}
+	return cmd + "::" + escapeAnnotationData(a.msg)
+}
+
+var (
+	annotationData     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	annotationProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeAnnotationData(s string) string     { return annotationData.Replace(s) }
+func escapeAnnotationProperty(s string) string { return annotationProperty.Replace(s) }
+
+// errorAnnotation describes a failed command. Changelog parse errors point
+// at their line; running out of things to release is a notice, not an error.
+func errorAnnotation(err error, getenv func(string) string) annotation {
+	a := annotation{level: "error", msg: err.Error()}
+	var pe *changelog.ParseError
+	switch {
+	case errors.As(err, &pe):
+		a.title = "Changelog parse error"
+		a.file, a.line, a.col = workspacePath(pe.Path, getenv), pe.Line, pe.Column
+	case errors.As(err, new(*noOpError)):
+		a.level, a.title = "notice", "Nothing to release"
+	case errors.As(err, new(*preflightError)):
+		a.title = "Release preflight failed"
+	case errors.As(err, new(*gitutil.GitError)):
+		a.title = "Git command failed"
+	case errors.As(err, new(*forge.APIError)):
+		a.title = "Forge API error"
+	}
+	return a
+}
+
+// workspacePath makes path relative to $GITHUB_WORKSPACE, the checkout root
+// annotation file names are resolved against.
+func workspacePath(path string, getenv func(string) string) string {
+	if path == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	if ws := getenv("GITHUB_WORKSPACE"); ws != "" {
+		if rel, err := filepath.Rel(ws, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// annotationWriter rewrites "Warning: " lines as ::warning workflow commands
+// and passes everything else through as written.
+type annotationWriter struct {
+	w io.Writer
+
+	mu      sync.Mutex
+	buf     []byte
+	midLine bool
+}
+
+var warningPrefix = []byte("Warning: ")
+
+func (w *annotationWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for len(w.buf) > 0 {
+		line, complete := w.buf, false
+		if i := bytes.IndexByte(w.buf, '\n'); i >= 0 {
+			line, complete = w.buf[:i+1], true
+		}
+		if !w.midLine && !complete && (bytes.HasPrefix(line, warningPrefix) || bytes.HasPrefix(warningPrefix, line)) {
+			// Wait for the rest of what may be a warning.
+			break
+		}
+		out := line
+		if !w.midLine && complete && bytes.HasPrefix(line, warningPrefix) {
+			msg := strings.TrimSuffix(strings.TrimSuffix(string(line[len(warningPrefix):]), "\n"), "\r")
+			out = []byte(annotation{level: "warning", msg: msg}.String() + "\n")
+		}
+		if _, err := w.w.Write(out); err != nil {
+			return len(p), err
+		}
+		w.midLine = !complete
+		w.buf = w.buf[len(line):]
+	}
+	return len(p), nil
+}
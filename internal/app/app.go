@@ -1,15 +1,27 @@
 package app
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/jasonwillschiu/mdrelease/gitops"
 	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
 	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+	"github.com/jasonwillschiu/mdrelease/internal/notify"
+	"github.com/jasonwillschiu/mdrelease/internal/plugin"
 )
 
 const (
@@ -19,36 +31,69 @@ const (
 	ExitParse     = 3
 	ExitPreflight = 4
 	ExitGit       = 5
+	ExitForge     = 6
+	ExitNoOp      = 7
+
+	ExitInterrupted = 130
 
 	toolName = "mdrelease"
+
+	gitErrorTailLines = 10
+
+	syncFFOnly = "ff-only"
+	syncRebase = "rebase"
+	syncNone   = "none"
+
+	defaultReleaseBranchPattern = "release/{tag}"
 )
 
 var ToolVersion = "v0.0.0"
 
-type gitOps interface {
-	EnsureRepo() error
-	EnsureRemote(string) error
-	FetchTags() error
-	FetchRemote(string) error
-	PullFFOnly(string) error
-	EnsureTagAbsent(string) error
-	EnsureTagPresent(string) error
-	HasLocalTag(string) (bool, error)
-	HasRemoteTag(string, string) (bool, error)
-	DeleteLocalTag(string) error
-	DeleteRemoteTag(string, string) error
-	StageAll() error
-	HasStagedChanges() (bool, error)
-	Commit(string, string) error
-	CreateTag(string, string, string) error
-	PushHead(string) error
-	PushTag(string, string) error
-}
+// gitOps is the git backend the commands use; tests substitute fakes.
+type gitOps = gitops.Client
 
 type deps struct {
+	ctx    context.Context
 	getenv func(string) string
 	getwd  func() (string, error)
-	newGit func(io.Writer, io.Writer, bool) gitOps
+	newGit func(gitutil.Options) gitOps
+
+	newForge func(forgeConfig) (forge.Backend, error)
+	runTool  forge.RunFunc
+
+	newRepoWriter func(apiURL, repo, token string) (forge.FileWriter, error)
+	runHook       func(ctx context.Context, command string, env []string, stdout, stderr io.Writer) error
+	sendMail      notify.SendMailFunc
+	runPlugin     plugin.RunFunc
+
+	// logger, once a command sets it up for --log-format text or json,
+	// receives every line of its output as a structured record.
+	logger *slog.Logger
+
+	// plan, when set, turns a release into `mdrelease plan`: it runs the
+	// dry-run preflight and fills plan instead of releasing.
+	plan *releasePlan
+
+	// doctor, when set, turns a release into `mdrelease doctor`: it checks
+	// the environment the resolved flags need and stops there.
+	doctor bool
+
+	// promote, when set, turns a release into `mdrelease promote`: it first
+	// rewrites the latest prerelease entries as the final release.
+	promote bool
+
+	// component, when set, releases that configured component; it is how
+	// --all-components runs each one.
+	component string
+
+	// stdin answers the release confirmation, which is only asked when
+	// interactive is set (stdin and stdout are both terminals).
+	stdin       io.Reader
+	interactive bool
+
+	// inspectConfig, when set, receives a command's parsed flags in place of
+	// applying the config; its error stops the command before it does anything.
+	inspectConfig func(fs *flag.FlagSet, command, configPath string) error
 }
 
 type usageError struct{ msg string }
@@ -59,16 +104,51 @@ type preflightError struct{ msg string }
 
 func (e *preflightError) Error() string { return e.msg }
 
+// noOpError means there is nothing to release: the tag already exists or
+// there is nothing to commit. It gets its own exit code so scheduled jobs can
+// tell it apart from a failure.
+type noOpError struct{ msg string }
+
+func (e *noOpError) Error() string { return e.msg }
+
 func Run(args []string, stdout, stderr io.Writer) int {
+	return RunContext(context.Background(), args, stdout, stderr)
+}
+
+func RunContext(ctx context.Context, args []string, stdout, stderr io.Writer) (code int) {
 	d := deps{
+		ctx:    ctx,
 		getenv: os.Getenv,
 		getwd:  os.Getwd,
-		newGit: func(out, errOut io.Writer, dryRun bool) gitOps {
-			return gitutil.NewClient(out, errOut, dryRun)
+		newGit: func(opts gitutil.Options) gitOps {
+			return gitutil.New(opts)
 		},
+		stdin:       os.Stdin,
+		interactive: isTerminal(os.Stdin) && isTerminal(stdout),
+	}
+	updateCheck := startUpdateCheck(ctx, args, stderr, d.getenv)
+	defer func() {
+		if code != ExitInterrupted {
+			printUpdateNotice(errorOutput(stderr, args), updateCheck)
+		}
+	}()
+
+	// Structured logs stay structured; annotations replace plain output only.
+	structured := loggerOf(errorOutput(stderr, args)) != nil
+	annotate := !structured && annotationsEnabled(d.getenv)
+	if annotate {
+		stdout, stderr = &annotationWriter{w: stdout}, &annotationWriter{w: stderr}
 	}
 
 	if err := run(args, stdout, stderr, d); err != nil {
+		errOut := errorOutput(stderr, args)
+		printError := func() {
+			if annotate {
+				_, _ = fmt.Fprintln(errOut, errorAnnotation(err, d.getenv))
+				return
+			}
+			printErr(errOut, err)
+		}
 		if _, isUsage := err.(*usageError); isUsage {
 			_, _ = fmt.Fprintln(stderr, err.Error())
 			_, _ = fmt.Fprintln(stderr)
@@ -77,20 +157,39 @@ func Run(args []string, stdout, stderr io.Writer) int {
 		}
 
 		switch {
+		case errors.As(err, new(*interruptedError)):
+			ie := new(interruptedError)
+			errors.As(err, &ie)
+			printInterrupted(errOut, ie)
+			return ExitInterrupted
 		case errors.As(err, new(*changelog.ParseError)):
-			_, _ = fmt.Fprintln(stderr, "Error:", err)
+			printError()
 			if pe := new(changelog.ParseError); errors.As(err, &pe) {
-				_, _ = fmt.Fprintf(stderr, "Expected format example in %s: %s\n", pe.Path, changelog.ExpectedFormat)
+				printParseErrorSnippet(errOut, pe)
+				format := pe.Format
+				if format == "" {
+					format = changelog.ExpectedFormat
+				}
+				_, _ = fmt.Fprintf(errOut, "Expected format example in %s: %s\n", pe.Path, format)
 			}
 			return ExitParse
+		case errors.As(err, new(*noOpError)):
+			printError()
+			return ExitNoOp
 		case errors.As(err, new(*preflightError)):
-			_, _ = fmt.Fprintln(stderr, "Error:", err)
+			printError()
 			return ExitPreflight
 		case errors.As(err, new(*gitutil.GitError)):
-			_, _ = fmt.Fprintln(stderr, "Error:", err)
+			printError()
+			if ge := new(gitutil.GitError); errors.As(err, &ge) {
+				printGitErrorDetails(errOut, ge)
+			}
 			return ExitGit
+		case errors.As(err, new(*forge.APIError)):
+			printError()
+			return ExitForge
 		default:
-			_, _ = fmt.Fprintln(stderr, "Error:", err)
+			printError()
 			return ExitGeneral
 		}
 	}
@@ -105,6 +204,8 @@ func run(args []string, stdout, stderr io.Writer, d deps) error {
 			return nil
 		case "-version", "--version":
 			return runToolVersion(args[1:], stdout, stderr)
+		case completeCommand:
+			return runComplete(args[1:], stdout, d)
 		}
 	}
 
@@ -114,6 +215,42 @@ func run(args []string, stdout, stderr io.Writer, d deps) error {
 			return runRepoVersion(args[1:], stdout, stderr, d)
 		case "check":
 			return runCheck(args[1:], stdout, stderr, d)
+		case "tag-message":
+			return runTagMessage(args[1:], stdout, stderr, d)
+		case "export":
+			return runExport(args[1:], stdout, stderr, d)
+		case "site":
+			return runSite(args[1:], stdout, stderr, d)
+		case "import-tags":
+			return runImportTags(args[1:], stdout, stderr, d)
+		case "audit-tags":
+			return runAuditTags(args[1:], stdout, stderr, d)
+		case "backport":
+			return runBackport(args[1:], stdout, stderr, d)
+		case "resume":
+			return runResume(args[1:], stdout, stderr, d)
+		case "pr":
+			return runPR(args[1:], stdout, stderr, d)
+		case "config":
+			return runConfig(args[1:], stdout, stderr, d)
+		case "plan":
+			return runPlan(args[1:], stdout, stderr, d)
+		case "doctor":
+			return runDoctor(args[1:], stdout, stderr, d)
+		case "promote":
+			return runPromote(args[1:], stdout, stderr, d)
+		case "changed":
+			return runChanged(args[1:], stdout, stderr, d)
+		case "next":
+			return runNext(args[1:], stdout, stderr, d)
+		case "latest":
+			return runLatest(args[1:], stdout, stderr, d)
+		case "undo":
+			return runUndo(args[1:], stdout, stderr, d)
+		case "completion":
+			return runCompletion(args[1:], stdout, stderr)
+		case "help":
+			return runHelp(args[1:], stdout, stderr, d)
 		default:
 			return &usageError{msg: fmt.Sprintf("unknown command: %s", args[0])}
 		}
@@ -126,6 +263,205 @@ type commonConfig struct {
 	remote        string
 	tagPrefix     string
 	dryRun        bool
+	noUnshallow   bool
+	allowPrompt   bool
+	gitToken      string
+	gitTokenUser  string
+	breakLock     bool
+	commitDate    string
+	commitTime    time.Time
+	gitPath       string
+	userName      string
+	userEmail     string
+	quiet         bool
+	verbose       bool
+	noColor       bool
+	logFormat     string
+	ci            bool
+	interactive   bool
+	remoteLock    bool
+	// versionPattern and versionOrder are the --version-* flags, resolved
+	// into versions.
+	versionPattern   string
+	versionOrder     string
+	headingSeparator string
+	versions         versionScheme
+	// allowedRemotes are the URL globs the remote must match to be pushed.
+	allowedRemotes stringList
+}
+
+func addRemoteFlags(fs *flag.FlagSet, cfg *commonConfig) {
+	fs.BoolVar(&cfg.noUnshallow, "no-unshallow", false, "Fail instead of fetching full history when the repository is a shallow clone")
+	fs.BoolVar(&cfg.allowPrompt, "allow-git-prompt", false, "Allow git to prompt for credentials (prompts are disabled by default so CI never hangs)")
+	fs.StringVar(&cfg.gitToken, "git-token", "", "HTTPS token for fetch/push via a temporary credential helper (default: $MDRELEASE_GIT_TOKEN)")
+	fs.StringVar(&cfg.gitTokenUser, "git-token-user", "x-access-token", "Username sent with --git-token (for example oauth2 for GitLab)")
+	fs.StringVar(&cfg.userName, "git-user-name", "", "Committer name passed as `git -c user.name=...` (default: $MDRELEASE_GIT_USER_NAME)")
+	fs.StringVar(&cfg.userEmail, "git-user-email", "", "Committer email passed as `git -c user.email=...` (default: $MDRELEASE_GIT_USER_EMAIL)")
+	fs.StringVar(&cfg.gitPath, "git-path", "", "Path to the git executable (default: $MDRELEASE_GIT_PATH, then git on PATH)")
+	fs.BoolVar(&cfg.quiet, "quiet", false, "Print only the final result line; errors still go to stderr")
+	fs.BoolVar(&cfg.verbose, "verbose", false, "Trace every git command with its arguments, duration, exit status, and output to stderr")
+	fs.BoolVar(&cfg.ci, "ci", false, "Run as in CI: never prompt and never color output (default: on when $CI, $GITHUB_ACTIONS, or $GITLAB_CI is set)")
+	fs.BoolVar(&cfg.interactive, "interactive", false, "Run interactively even in CI or without a terminal: ask before releasing")
+	fs.Var(&cfg.allowedRemotes, "allowed-remote", "Refuse to push unless the remote URL matches this glob, such as git@github.com:myorg/* (repeatable; usually set once at the top of the config file)")
+	fs.StringVar(&cfg.logFormat, "log-format", logFormatPlain, "Output format: plain, or text (key=value) or json to log every line as a structured slog record")
+	fs.BoolVar(&cfg.noColor, "no-color", false, "Disable colored output (also disabled by $NO_COLOR or when stdout is not a terminal)")
+}
+
+// output is where a command's result line goes: stdout, colored when it is
+// a terminal outside CI, or d.logger with --log-format, which it sets up
+// unless an outer command such as resume already has.
+func (c commonConfig) output(stdout io.Writer, d *deps) (io.Writer, error) {
+	if c.ci && c.interactive {
+		return nil, &usageError{msg: "--ci and --interactive cannot be combined"}
+	}
+	if err := validLogFormat(c.logFormat); err != nil {
+		return nil, err
+	}
+	if d.logger == nil {
+		d.logger = newLogger(stdout, c.logFormat)
+	}
+	if d.logger != nil {
+		return &logWriter{logger: d.logger}, nil
+	}
+	return colorOutput(stdout, c.noColor || c.ciMode(d.getenv), d.getenv), nil
+}
+
+// diagnostics is where a command's stderr goes: unchanged, or a structured log
+// with --log-format.
+func (c commonConfig) diagnostics(stderr io.Writer) io.Writer {
+	if loggerOf(stderr) != nil {
+		return stderr
+	}
+	if logger := newLogger(stderr, c.logFormat); logger != nil {
+		return &logWriter{logger: logger}
+	}
+	return stderr
+}
+
+// narration is where progress output goes: stdout, or nowhere with --quiet,
+// which keeps only each command's final result line.
+func (c commonConfig) narration(stdout io.Writer) io.Writer {
+	if c.quiet {
+		return io.Discard
+	}
+	return stdout
+}
+
+func lockRelease(git gitOps, cfg commonConfig) (*releaseLock, error) {
+	if cfg.dryRun {
+		return nil, nil
+	}
+	gitDir, err := git.GitDir()
+	if err != nil {
+		return nil, err
+	}
+	return acquireReleaseLock(gitDir, cfg.breakLock)
+}
+
+func (c *commonConfig) resolveGitToken(getenv func(string) string) {
+	if strings.TrimSpace(c.gitToken) != "" || getenv == nil {
+		return
+	}
+	c.gitToken = strings.TrimSpace(getenv("MDRELEASE_GIT_TOKEN"))
+}
+
+func (c *commonConfig) resolveGitEnv(getenv func(string) string) {
+	if getenv == nil {
+		return
+	}
+	if strings.TrimSpace(c.gitPath) == "" {
+		c.gitPath = strings.TrimSpace(getenv("MDRELEASE_GIT_PATH"))
+	}
+	if strings.TrimSpace(c.userName) == "" {
+		c.userName = strings.TrimSpace(getenv("MDRELEASE_GIT_USER_NAME"))
+	}
+	if strings.TrimSpace(c.userEmail) == "" {
+		c.userEmail = strings.TrimSpace(getenv("MDRELEASE_GIT_USER_EMAIL"))
+	}
+}
+
+func ensureCommitterIdentity(git gitOps) error {
+	ok, err := git.HasCommitterIdentity()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &preflightError{msg: "git committer identity is not configured; pass --git-user-name and --git-user-email (or set MDRELEASE_GIT_USER_NAME/MDRELEASE_GIT_USER_EMAIL) to supply it for mdrelease's git commands only"}
+	}
+	return nil
+}
+
+func ensureGitVersion(git gitOps, cfg commonConfig) error {
+	gitPath := cfg.gitPath
+	if gitPath == "" {
+		gitPath = "git"
+	}
+	version, err := git.Version()
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+			return &preflightError{msg: fmt.Sprintf("git executable %q not found (install git or pass --git-path)", gitPath)}
+		}
+		return err
+	}
+	minimum, reason := gitutil.MinVersion, ""
+	if cfg.gitToken != "" {
+		minimum, reason = gitutil.MinTokenVersion, " with --git-token"
+	}
+	if !version.AtLeast(minimum) {
+		return &preflightError{msg: fmt.Sprintf("%s is git %s but mdrelease requires git %s or newer%s (upgrade git or pass --git-path to a newer binary)", gitPath, version, minimum, reason)}
+	}
+	return nil
+}
+
+func (c commonConfig) gitOptions(ctx context.Context, stdout, stderr io.Writer) gitutil.Options {
+	var trace io.Writer
+	if c.verbose {
+		trace = stderr
+	}
+	return gitutil.Options{
+		Context:     ctx,
+		Stdout:      stdout,
+		Stderr:      stderr,
+		DryRun:      c.dryRun,
+		AllowPrompt: c.allowPrompt,
+		Token:       c.gitToken,
+		TokenUser:   c.gitTokenUser,
+		CommitDate:  c.gitCommitDate(),
+		GitPath:     c.gitPath,
+		UserName:    c.userName,
+		UserEmail:   c.userEmail,
+		Trace:       trace,
+		// One listing of the remote's tags answers every tag a command
+		// checks there.
+		TagCache: gitutil.NewRemoteTagCache(),
+	}
+}
+
+func (c commonConfig) gitCommitDate() string {
+	if c.commitTime.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("@%d %s", c.commitTime.Unix(), c.commitTime.Format("-0700"))
+}
+
+func (c *commonConfig) resolveCommitDate(getenv func(string) string) error {
+	value, source := strings.TrimSpace(c.commitDate), "--commit-date"
+	if value == "" && getenv != nil {
+		value, source = strings.TrimSpace(getenv("SOURCE_DATE_EPOCH")), "SOURCE_DATE_EPOCH"
+	}
+	if value == "" {
+		return nil
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		c.commitTime = time.Unix(secs, 0).UTC()
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return &usageError{msg: fmt.Sprintf("invalid %s value %q (expected unix seconds or RFC 3339, for example 2024-01-02T15:04:05Z)", source, value)}
+	}
+	c.commitTime = parsed
+	return nil
 }
 
 type releaseActions struct {
@@ -146,24 +482,35 @@ func runToolVersion(args []string, stdout, stderr io.Writer) error {
 }
 
 func runRepoVersion(args []string, stdout, stderr io.Writer, d deps) error {
-	fs := flag.NewFlagSet("mdrelease version", flag.ContinueOnError)
-	fs.SetOutput(stderr)
+	fs := newFlagSet("mdrelease version", stderr)
 
+	var cfg commonConfig
 	var changelogFlag string
 	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
 
 	if err := fs.Parse(args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
 			return nil
 		}
 		return &usageError{msg: err.Error()}
 	}
+	if err := applyConfig(fs, "version", configPath, d); err != nil {
+		return err
+	}
 	if fs.NArg() != 0 {
 		return &usageError{msg: "version does not accept positional arguments"}
 	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
 
 	path := resolveChangelogPath(changelogFlag, d.getenv)
-	entry, err := changelog.ParseLatest(path)
+	entry, err := cfg.versions.changelog.ParseLatest(path)
 	if err != nil {
 		return err
 	}
@@ -172,77 +519,270 @@ func runRepoVersion(args []string, stdout, stderr io.Writer, d deps) error {
 }
 
 func runCheck(args []string, stdout, stderr io.Writer, d deps) error {
-	fs := flag.NewFlagSet("mdrelease check", flag.ContinueOnError)
-	fs.SetOutput(stderr)
+	fs := newFlagSet("mdrelease check", stderr)
 
 	var cfg commonConfig
 	var changelogFlag string
+	var componentName string
 	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
 	fs.StringVar(&cfg.remote, "remote", "origin", "Git remote name")
 	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix")
+	fs.StringVar(&componentName, "component", "", "Check this [components.<name>] config entry, including that a Go module component's tags match its module directory")
+	var branchPrefixes stringList
+	addBranchPrefixFlag(fs, &branchPrefixes)
+	var allowSkip bool
+	fs.BoolVar(&allowSkip, "allow-skip", false, "Accept a version that is not one patch, minor, or major step after the latest tag")
+	var goMod string
+	fs.StringVar(&goMod, "go-mod", "go.mod", "go.mod whose module path must match the changelog's major version, such as /v2 for 2.x.x (skipped when it does not exist)")
+	var allComponents bool
+	fs.BoolVar(&allComponents, "all-components", false, "Check every configured component and print a table of versions, tags, and tag availability")
+	var rf ruleFlags
+	addRuleFlags(fs, &rf)
 	fs.BoolVar(&cfg.dryRun, "dry-run", false, "Print planned checks without running mutating steps (skips fetch --tags)")
+	addRemoteFlags(fs, &cfg)
+	addVersionSchemeFlags(fs, &cfg)
+	var fc forgeConfig
+	addForgeTargetFlags(fs, &fc, forgeNone, "Also query this forge for an existing release of the tag: auto (detect from the remote URL), github, gitlab, gitea, bitbucket, or none")
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
 
 	if err := fs.Parse(args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
 			return nil
 		}
 		return &usageError{msg: err.Error()}
 	}
+	if err := applyConfig(fs, "check", configPath, d); err != nil {
+		return err
+	}
+	rules, err := newRulePolicy(rf, allowSkip)
+	if err != nil {
+		return err
+	}
 	if fs.NArg() != 0 {
 		return &usageError{msg: "check does not accept positional arguments"}
 	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	stdout = cfg.narration(result)
+	stderr = cfg.diagnostics(stderr)
 	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	if allComponents {
+		if componentName != "" {
+			return &usageError{msg: "--all-components cannot be combined with --component"}
+		}
+		if fc.kind != forgeNone {
+			return &usageError{msg: "check --all-components does not query forges (use --component with --forge)"}
+		}
+		cfg.resolveGitToken(d.getenv)
+		cfg.resolveGitEnv(d.getenv)
+		return checkComponents(cfg, configPath, rules, d, stdout, stderr, result)
+	}
+	var comp *component
+	if componentName != "" {
+		if comp, err = applyComponent(componentName, configPath, &cfg, d); err != nil {
+			return err
+		}
+	}
+	cfg.resolveGitToken(d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+	if err := fc.resolve(d.getenv); err != nil {
+		return err
+	}
 
-	entry, err := changelog.ParseLatest(cfg.changelogPath)
+	entry, err := cfg.versions.changelog.ParseLatest(cfg.changelogPath)
 	if err != nil {
 		return err
 	}
 
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if comp != nil {
+		// A component's own tag-prefix replaces the branch rules.
+		branchPrefixes = nil
+	}
+	if cfg.tagPrefix, err = resolveTagPrefix(git, cfg.tagPrefix, branchPrefixes, d.getenv); err != nil {
+		return err
+	}
 	tag := cfg.tagPrefix + entry.Version
 	_, _ = fmt.Fprintf(stdout, "Release check:\n")
+	if comp != nil {
+		_, _ = fmt.Fprintf(stdout, "  Component: %s\n", comp.name)
+	}
 	_, _ = fmt.Fprintf(stdout, "  Changelog: %s\n", cfg.changelogPath)
 	_, _ = fmt.Fprintf(stdout, "  Version: %s\n", entry.Version)
 	_, _ = fmt.Fprintf(stdout, "  Title: %s\n", entry.Summary)
 	_, _ = fmt.Fprintf(stdout, "  Tag: %s\n", tag)
+	if comp != nil && comp.goTagPrefix != "" {
+		if err := checkGoModuleTag(comp); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintln(stdout, "  Go module tag:", paint(stdout, colorGreen, "ok"))
+		goMod = filepath.Join(comp.path, "go.mod")
+	}
+	if checked, err := checkModuleMajor(goMod, entry.Version); err != nil {
+		return err
+	} else if checked {
+		_, _ = fmt.Fprintln(stdout, "  Go module major version:", paint(stdout, colorGreen, "ok"))
+	}
+
+	if err := checkGit(git, cfg, stdout); err != nil {
+		return err
+	}
+	if fc.enabled() {
+		if err := fc.detect(cfg.remote, detectRemote(git, cfg.remote), d.getenv); err != nil {
+			return err
+		}
+		if err := checkForgeRelease(d, git, fc, tag, stdout); err != nil {
+			return err
+		}
+	}
+	if err := git.EnsureTagAbsent(tag); err != nil {
+		return &noOpError{msg: fmt.Sprintf("no new changelog version to release: %s already exists (update %s)", tag, cfg.changelogPath)}
+	}
+	_, _ = fmt.Fprintln(stdout, "  Tag availability:", paint(stdout, colorGreen, "ok"))
+	in := ruleInput{entry: entry, changelog: cfg.changelogPath, tagPrefix: cfg.tagPrefix, tag: tag, rev: "HEAD", tags: true}
+	if comp != nil {
+		in.paths = []string{comp.path}
+	}
+	outcomes, err := rules.evaluate(git, d.getenv, in)
+	if err != nil {
+		return err
+	}
+	if err := reportRules(stdout, outcomes); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(result, paint(result, colorGreen, "Check passed."))
+	return nil
+}
 
-	git := d.newGit(stdout, stderr, cfg.dryRun)
+// checkGit verifies the repository, remote, and committer, and fetches tags
+// so tag availability reflects the remote.
+func checkGit(git gitOps, cfg commonConfig, stdout io.Writer) error {
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
 	if err := git.EnsureRepo(); err != nil {
 		return err
 	}
 	if err := git.EnsureRemote(cfg.remote); err != nil {
 		return err
 	}
+	if err := checkAllowedRemote(git, cfg); err != nil {
+		return err
+	}
+	if len(cfg.allowedRemotes) > 0 {
+		_, _ = fmt.Fprintln(stdout, "  Allowed remote:", paint(stdout, colorGreen, "ok"))
+	}
+	if err := ensureFullHistory(git, cfg, stdout); err != nil {
+		return err
+	}
+	if err := ensureCommitterIdentity(git); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(stdout, "  Committer identity:", paint(stdout, colorGreen, "ok"))
 	if cfg.dryRun {
-		_, _ = fmt.Fprintln(stdout, "  Fetch tags: skipped in --dry-run")
-	} else {
-		if err := git.FetchTags(); err != nil {
-			return err
-		}
-		_, _ = fmt.Fprintln(stdout, "  Fetch tags: ok")
+		_, _ = fmt.Fprintln(stdout, "  Fetch tags:", paint(stdout, colorYellow, "skipped"), "in --dry-run")
+		return nil
 	}
-	if err := git.EnsureTagAbsent(tag); err != nil {
-		return &preflightError{msg: fmt.Sprintf("no new changelog version to release: %s already exists (update %s)", tag, cfg.changelogPath)}
+	if err := git.FetchTags(); err != nil {
+		return err
 	}
-	_, _ = fmt.Fprintln(stdout, "  Tag availability: ok")
-	_, _ = fmt.Fprintln(stdout, "Check passed.")
+	_, _ = fmt.Fprintln(stdout, "  Fetch tags:", paint(stdout, colorGreen, "ok"))
 	return nil
 }
 
 func runRelease(args []string, stdout, stderr io.Writer, d deps) error {
-	fs := flag.NewFlagSet("mdrelease", flag.ContinueOnError)
-	fs.SetOutput(stderr)
+	return release(args, stdout, stderr, d, nil)
+}
+
+func release(args []string, stdout, stderr io.Writer, d deps, resume *releaseJournal) (err error) {
+	var steps stepLog
+	var rollbackOnFailure bool
+	var rollbackCommit bool
+	defer func() {
+		if err == nil {
+			_ = steps.journal.remove()
+			return
+		}
+		if d.ctx != nil && d.ctx.Err() != nil {
+			err = &interruptedError{steps: steps, err: err, resumable: steps.journal != nil}
+			return
+		}
+		if rollbackOnFailure {
+			if rbErr := steps.rollback(stdout); rbErr != nil {
+				err = fmt.Errorf("%w (rollback incomplete: %v)", err, rbErr)
+			}
+			_ = steps.journal.forgetRolledBack(steps)
+		}
+		if steps.journal != nil {
+			if len(steps.journal.state.Completed) == 0 {
+				_ = steps.journal.remove()
+				return
+			}
+			if remaining := steps.journal.state.remaining(); len(remaining) > 0 {
+				err = fmt.Errorf("%w (run `mdrelease resume` to retry the remaining steps: %s)", err, strings.Join(remaining, ", "))
+			}
+		}
+	}()
+
+	name := "mdrelease"
+	if d.plan != nil {
+		name = "mdrelease plan"
+	}
+	if d.doctor {
+		name = "mdrelease doctor"
+	}
+	if d.promote {
+		name = "mdrelease promote"
+	}
+	fs := newFlagSet(name, stderr)
 
 	var cfg commonConfig
 	var changelogFlag string
 	var all bool
 	var push bool
+	var ciActions string
 	var forceRetag bool
+	var syncMode string
+	var releaseBranch optionalString
+	var targetRef string
+	var branchPrefixes stringList
+	var notesOut stringList
+	var buildMetadata string
+	var buildMetadataIn string
+	var idempotent bool
+	var tagExistsOK bool
+	var allowNoOp bool
+	var sig signaturePolicy
+	var failOn, stableBranches stringList
+	var allowSkip bool
+	var componentName string
+	var allComponents bool
+	var bumpDeps bool
+	var skipLFS bool
+	var yes bool
 	var actions releaseActions
+	var fc forgeConfig
+	var pc packageConfig
+	var gc goProxyConfig
+	var ic imageConfig
+	var hc hookConfig
+	var nc notifyConfig
+	var vc versionFileConfig
 
 	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
 	fs.StringVar(&cfg.remote, "remote", "origin", "Git remote name")
 	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix")
 	fs.BoolVar(&cfg.dryRun, "dry-run", false, "Print planned actions without mutating git state")
+	addRemoteFlags(fs, &cfg)
+	addVersionSchemeFlags(fs, &cfg)
 	fs.BoolVar(&all, "all", false, "Run full release pipeline (default behavior)")
 	fs.BoolVar(&actions.stageAll, "stage-all", false, "Stage all changes (git add -A)")
 	fs.BoolVar(&actions.commit, "commit", false, "Commit staged changes using changelog title/body")
@@ -250,19 +790,131 @@ func runRelease(args []string, stdout, stderr io.Writer, d deps) error {
 	fs.BoolVar(&push, "push", false, "Push commit and tag (alias for --push-commit --push-tag)")
 	fs.BoolVar(&actions.pushCommit, "push-commit", false, "Push HEAD to remote")
 	fs.BoolVar(&actions.pushTag, "push-tag", false, "Push version tag to remote")
+	fs.StringVar(&ciActions, "ci-actions", defaultCIActions, "Actions a release runs in CI mode when no action flags are given: comma-separated stage-all, commit, tag, push, push-commit, push-tag")
 	fs.BoolVar(&forceRetag, "force-retag", false, "Overwrite an existing release tag by deleting and recreating it locally/remotely as needed")
+	fs.StringVar(&syncMode, "sync", syncFFOnly, "Sync strategy before push actions: ff-only, rebase, or none")
+	fs.StringVar(&targetRef, "target", "", "Tag this commit SHA or ref instead of HEAD (cannot be combined with --commit)")
+	fs.StringVar(&sig.mode, "require-signature", signatureNone, "Refuse to tag a commit without a verified signature: none, git (git verify-commit), or forge (the forge's verification status; requires --forge and tagging an existing commit)")
+	addBranchPrefixFlag(fs, &branchPrefixes)
+	fs.StringVar(&buildMetadata, "build-metadata", "", "Semver build metadata for the release, such as +build.$CI_RUN_ID or +g{shortsha} ($VARS come from the environment; {sha}/{shortsha} name the tagged commit)")
+	fs.StringVar(&buildMetadataIn, "build-metadata-in", buildMetadataInTag, "Where --build-metadata goes: tag (the tag name, v1.2.3+build.7) or message (a Build: line in the tag message of v1.2.3)")
+	fs.Var(&notesOut, "notes-out", "Write the release notes to this file: the tag message as plain text for .txt, otherwise the markdown release body with any generated notes (repeatable)")
+	fs.StringVar(&cfg.commitDate, "commit-date", "", "Author/committer date for the release commit and tag, as unix seconds or RFC 3339 (default: $SOURCE_DATE_EPOCH)")
+	fs.BoolVar(&tagExistsOK, "tag-exists-ok", false, "When the tag is already on the remote with the changelog message, skip staging, committing, tagging, and pushing, and run the remaining steps (forge release, hooks, notifications)")
+	fs.BoolVar(&idempotent, "idempotent", false, "Exit 0 with \"already released\" when the tag already exists locally (and remotely when pushing) at HEAD with the same message")
+	fs.BoolVar(&allowSkip, "allow-skip", false, "Release a version that is not one patch, minor, or major step after the latest tag")
+	fs.BoolVar(&allowNoOp, "allow-no-op", false, "Exit 0 instead of 7 when there is nothing to release (the tag already exists or there are no changes to commit)")
+	addFailOnFlags(fs, &failOn, &stableBranches)
+	var rf ruleFlags
+	addRuleFlags(fs, &rf)
+	fs.StringVar(&componentName, "component", "", "Release this [components.<name>] config entry: its changelog, tag prefix, and path replace --changelog, --tag-prefix, and staging all changes")
+	fs.BoolVar(&allComponents, "all-components", false, "Release every configured component that has a new changelog version, dependencies first")
+	fs.BoolVar(&bumpDeps, "bump-dependents", false, "With --all-components, update the go.mod requirement of each component that depends on a just-released Go module component before releasing it")
+	addLockFlags(fs, &cfg)
+	fs.BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "If a later step fails, delete the locally created tag/release branch to restore the pre-release state")
+	fs.BoolVar(&rollbackCommit, "rollback-commit", false, "With --rollback-on-failure, also soft-reset the release commit if it was not pushed")
+	fs.BoolVar(&skipLFS, "skip-lfs", false, "Do not run `git lfs push` before pushing the release commit/tag")
+	fs.BoolVar(&yes, "yes", false, "Do not ask for confirmation before releasing from a terminal")
+	addVersionFileFlags(fs, &vc)
+	addForgeFlags(fs, &fc)
+	addPackageFlags(fs, &pc)
+	addGoProxyFlags(fs, &gc)
+	addImageFlags(fs, &ic)
+	addHookFlags(fs, &hc)
+	addNotifyFlags(fs, &nc)
+	releaseBranch.defaultValue = defaultReleaseBranchPattern
+	fs.Var(&releaseBranch, "release-branch", "Also create (and push) a branch at the release commit; optional pattern with {version}/{tag} (default release/{tag})")
+
+	if d.plan != nil {
+		fs.BoolVar(&d.plan.json, "json", false, "Print the plan as JSON")
+	}
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
 
 	if err := fs.Parse(args); err != nil {
 		if errors.Is(err, flag.ErrHelp) {
-			printRootUsage(stdout)
+			printCommandHelp(stdout, fs)
 			return nil
 		}
 		return &usageError{msg: err.Error()}
 	}
+	// A resumed release replays the settings recorded in the journal; the
+	// secrets it does not record are read from the config again.
+	if resume == nil {
+		err = applyConfig(fs, "release", configPath, d)
+	} else {
+		err = applyConfigFlags(fs, "release", configPath, d, secretFlags...)
+	}
+	if err != nil {
+		return err
+	}
 	if fs.NArg() != 0 {
-		return &usageError{msg: "mdrelease does not accept positional arguments (use subcommands: check, version)"}
+		return &usageError{msg: "mdrelease does not accept positional arguments (use subcommands: check, version, backport, resume, pr)"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	policy, err := newFailPolicy(failOn, allowNoOp)
+	if err != nil {
+		return err
+	}
+	rules, err := newRulePolicy(rf, allowSkip)
+	if err != nil {
+		return err
+	}
+	if d.component != "" {
+		componentName = d.component
+	} else if allComponents {
+		switch {
+		case componentName != "":
+			return &usageError{msg: "--all-components cannot be combined with --component"}
+		case d.plan != nil || d.doctor || d.promote:
+			return &usageError{msg: fmt.Sprintf("%s does not support --all-components (use --component)", name)}
+		case resume != nil:
+			return &usageError{msg: "--all-components cannot be resumed"}
+		}
+		return releaseComponents(args, cfg, configPath, policy[conditionTagExists], bumpDeps, stdout, stderr, d)
+	}
+	if bumpDeps && d.component == "" {
+		return &usageError{msg: "--bump-dependents requires --all-components"}
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	stdout = cfg.narration(result)
+	stderr = cfg.diagnostics(stderr)
+	if d.plan != nil {
+		// A plan is a dry run that reports its result instead of narrating.
+		cfg.dryRun = true
+		stdout = io.Discard
 	}
 	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	// stagePaths limits staging to a component's files; empty stages all.
+	// componentPaths limits the commits preflight rules look at.
+	var stagePaths, componentPaths []string
+	switch {
+	case resume != nil:
+		stagePaths = resume.state.StagePaths
+	case componentName != "":
+		comp, err := applyComponent(componentName, configPath, &cfg, d)
+		if err != nil {
+			return err
+		}
+		stagePaths = []string{comp.path, comp.changelog}
+		componentPaths = []string{comp.path}
+	}
+	cfg.resolveGitToken(d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+	if err := cfg.resolveCommitDate(d.getenv); err != nil {
+		return err
+	}
+	switch syncMode {
+	case syncFFOnly, syncRebase, syncNone:
+	default:
+		return &usageError{msg: fmt.Sprintf("invalid --sync value %q (expected ff-only, rebase, or none)", syncMode)}
+	}
 
 	visited := visitedFlags(fs)
 	explicitMutation := visited["stage-all"] || visited["commit"] || visited["tag"] || visited["push"] || visited["push-commit"] || visited["push-tag"]
@@ -275,7 +927,11 @@ func runRelease(args []string, stdout, stderr io.Writer, d deps) error {
 		actions.pushTag = true
 	}
 
-	if all || !explicitMutation {
+	defaultCI, err := parseActions(ciActions)
+	if err != nil {
+		return err
+	}
+	if (all || !explicitMutation) && resume == nil {
 		actions = releaseActions{
 			stageAll:   true,
 			commit:     true,
@@ -283,42 +939,382 @@ func runRelease(args []string, stdout, stderr io.Writer, d deps) error {
 			pushCommit: true,
 			pushTag:    true,
 		}
+		if !all && cfg.ciMode(d.getenv) {
+			actions = defaultCI
+		}
 	}
 
-	entry, err := changelog.ParseLatest(cfg.changelogPath)
+	if tagExistsOK && forceRetag {
+		return &usageError{msg: "--tag-exists-ok cannot be combined with --force-retag"}
+	}
+	if rollbackCommit && !rollbackOnFailure {
+		return &usageError{msg: "--rollback-commit requires --rollback-on-failure"}
+	}
+
+	if err := fc.resolve(d.getenv); err != nil {
+		return err
+	}
+	if err := pc.resolve(fc, d.getenv); err != nil {
+		return err
+	}
+	if fc.enabled() && !actions.pushTag && !resume.done(stepPushTag) {
+		return &usageError{msg: "--forge requires pushing the tag (--push-tag, --push, or the default full release)"}
+	}
+	if gc.enabled && !actions.pushTag && !resume.done(stepPushTag) {
+		return &usageError{msg: "--go-proxy requires pushing the tag (--push-tag, --push, or the default full release)"}
+	}
+	if ic.enabled() && !actions.pushTag && !resume.done(stepPushTag) {
+		return &usageError{msg: "--image requires pushing the tag (--push-tag, --push, or the default full release)"}
+	}
+	if err := gc.prepare(cfg, d.getenv); err != nil {
+		return err
+	}
+	if err := nc.prepare(d.getenv); err != nil {
+		return err
+	}
+
+	if targetRef != "" && actions.commit {
+		return &usageError{msg: "--target cannot be combined with --commit (use --tag with --push-tag to tag an existing commit)"}
+	}
+	if err := sig.validate(fc, actions); err != nil {
+		return err
+	}
+	switch buildMetadataIn {
+	case buildMetadataInTag, buildMetadataInMessage:
+	default:
+		return &usageError{msg: fmt.Sprintf("invalid --build-metadata-in value %q (expected tag or message)", buildMetadataIn)}
+	}
+	if buildMetadata != "" && gc.enabled && buildMetadataIn == buildMetadataInTag {
+		return &usageError{msg: "--go-proxy cannot verify a tag with build metadata, which Go ignores (use --build-metadata-in message)"}
+	}
+
+	if d.doctor {
+		return diagnose(cfg, fc, d, stdout, stderr, result)
+	}
+
+	var entry *changelog.Entry
+	if d.promote {
+		if !actions.commit {
+			return &usageError{msg: "promote requires --commit (or the default full release) to commit the promoted changelog"}
+		}
+		entry, err = promoteChangelog(cfg.versions.changelog, cfg.changelogPath, cfg.dryRun, stdout)
+	} else {
+		entry, err = cfg.versions.changelog.ParseLatest(cfg.changelogPath)
+	}
 	if err != nil {
 		return err
 	}
+	if vc.enabled() && !actions.commit {
+		return &usageError{msg: "--version-file, --write-version, and --write-go-version require --commit (or the default full release)"}
+	}
 	tag := cfg.tagPrefix + entry.Version
+	// noOp reports that condition left nothing to release, with the exit
+	// behavior --fail-on gives it.
+	noOp := func(condition, msg string) error {
+		switch {
+		case d.component != "":
+			// --all-components decides for itself once every component has run.
+			return &noOpError{msg: msg}
+		case policy[condition] == outcomeError:
+			return &preflightError{msg: fmt.Sprintf("%s (--fail-on %s)", msg, condition)}
+		case policy[condition] == outcomeNoOp:
+			return &noOpError{msg: msg}
+		}
+		_, _ = fmt.Fprintf(result, "%s: %s\n", paint(result, colorYellow, "Nothing to release"), msg)
+		return writeActionsOutputs(d.getenv, newActionsResult(entry, tag, false))
+	}
+	versionUpdate, err := prepareVersionFiles(vc, entry.Version)
+	if err != nil {
+		return err
+	}
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	if componentName != "" {
+		// A component's own tag-prefix replaces the branch rules.
+		branchPrefixes = nil
+	}
+	if cfg.tagPrefix, err = resolveTagPrefix(git, cfg.tagPrefix, branchPrefixes, d.getenv); err != nil {
+		return err
+	}
+	tag = cfg.tagPrefix + entry.Version
+	if isPrerelease(entry.Version) && policy[conditionPrereleaseOnStable] != outcomeOK {
+		stable, err := onStableBranch(git, stableBranches, d.getenv)
+		if err != nil {
+			return err
+		}
+		if stable {
+			msg := fmt.Sprintf("%s is a prerelease and the release branch is stable", entry.Version)
+			if policy[conditionPrereleaseOnStable] == outcomeError {
+				return &preflightError{msg: fmt.Sprintf("%s (--fail-on %s)", msg, conditionPrereleaseOnStable)}
+			}
+			return &noOpError{msg: msg}
+		}
+	}
+	// tagEntry is entry as the tag message records it.
+	tagEntry := entry
+	if buildMetadata != "" {
+		if strings.Contains(entry.Version, "+") {
+			return &usageError{msg: fmt.Sprintf("--build-metadata cannot be added to %s, which already has build metadata in %s", entry.Version, cfg.changelogPath)}
+		}
+		meta, err := renderBuildMetadata(buildMetadata, d.getenv, func() (string, error) {
+			if actions.commit {
+				return "", &usageError{msg: "--build-metadata {sha} and {shortsha} name the tagged commit, which does not exist yet with --commit (tag an existing commit with --tag)"}
+			}
+			return git.ResolveCommit(cmp.Or(targetRef, "HEAD"))
+		})
+		if err != nil {
+			return err
+		}
+		if buildMetadataIn == buildMetadataInTag {
+			tag += "+" + meta
+		} else {
+			tagEntry = withBuildLine(entry, meta)
+		}
+		// A resumed release must tag with the metadata it started with.
+		buildMetadata = meta
+	}
+	branch := ""
+	if releaseBranch.set {
+		branch = renderReleaseBranch(releaseBranch.value, entry.Version, tag)
+		if branch == "" {
+			return &usageError{msg: "--release-branch pattern must not be empty"}
+		}
+	}
 
 	_, _ = fmt.Fprintln(stdout, "Release info:")
+	if componentName != "" {
+		_, _ = fmt.Fprintf(stdout, "  Component: %s\n", componentName)
+	}
 	_, _ = fmt.Fprintf(stdout, "  Changelog: %s\n", cfg.changelogPath)
 	_, _ = fmt.Fprintf(stdout, "  Version: %s\n", entry.Version)
 	_, _ = fmt.Fprintf(stdout, "  Title: %s\n", entry.Summary)
 	_, _ = fmt.Fprintf(stdout, "  Tag: %s\n", tag)
 	_, _ = fmt.Fprintf(stdout, "  Actions: %s\n", actions.String())
+	if branch != "" {
+		_, _ = fmt.Fprintf(stdout, "  Release branch: %s\n", branch)
+	}
+	if !cfg.commitTime.IsZero() {
+		_, _ = fmt.Fprintf(stdout, "  Commit date: %s\n", cfg.commitTime.Format(time.RFC3339))
+	}
+	remote := detectRemote(git, cfg.remote)
+
+	var forgeBackend forge.Backend
+	var assets []forge.Asset
+	var signer *forge.Signer
+	var packages *packagePublish
+	if fc.enabled() {
+		if err := fc.detect(cfg.remote, remote, d.getenv); err != nil {
+			return err
+		}
+		forgeBackend, assets, err = prepareForge(fc, cfg.dryRun, d)
+		if err != nil {
+			return err
+		}
+		if signer, err = prepareSigner(fc, assets, d); err != nil {
+			return err
+		}
+		if packages, err = preparePackages(pc, fc, remote, assets, cfg.dryRun, d); err != nil {
+			return err
+		}
+		if err := sig.prepare(fc, forgeBackend); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(stdout, "  Forge: %s (%s)\n", fc.kind, fc.repo)
+		if len(assets) > 0 {
+			_, _ = fmt.Fprintf(stdout, "  Assets: %s\n", describeAssets(assets))
+		}
+	}
+	if gc.enabled {
+		_, _ = fmt.Fprintf(stdout, "  Go module: %s (%s)\n", gc.module, gc.url)
+	}
+	var images []*forge.ImagePublisher
+	if ic.enabled() {
+		if images, err = prepareImages(ic, d); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(stdout, "  Images: %s\n", strings.Join(ic.images, ", "))
+	}
 
 	if cfg.dryRun {
 		_, _ = fmt.Fprintln(stdout, "  Mode: dry-run")
+	} else if cfg.prompts(d) && !yes {
+		if err := confirmRelease(d, stderr, tag, actions, cfg.remote); err != nil {
+			return err
+		}
 	}
 
-	git := d.newGit(stdout, stderr, cfg.dryRun)
-	if err := git.EnsureRepo(); err != nil {
+	lock, err := lockRelease(git, cfg)
+	if err != nil {
 		return err
 	}
-	needsRemote := actions.pushCommit || actions.pushTag
+	defer lock.release()
+
+	if !cfg.dryRun && resume == nil {
+		gitDir, err := git.GitDir()
+		if err != nil {
+			return err
+		}
+		pending, err := loadJournal(gitDir)
+		if err != nil {
+			return err
+		}
+		if pending != nil {
+			return &preflightError{msg: fmt.Sprintf("an unfinished release of %s is recorded in %s (completed: %s); run `mdrelease resume` to finish it or `mdrelease resume --discard` to start over", pending.state.Tag, pending.path, strings.Join(pending.state.Completed, ", "))}
+		}
+	}
+
+	if actions.commit || actions.tag {
+		if err := ensureCommitterIdentity(git); err != nil {
+			return err
+		}
+	}
+
+	// The verify gate runs before the remote lock, fetch, and pull, so a
+	// failure leaves both the repository and the remote untouched.
+	if resume == nil && (actions.commit || actions.tag) {
+		if err := verifyRelease(hc.verify, entry, tag, cfg.dryRun, stdout, stderr, d); err != nil {
+			return err
+		}
+	}
+
+	branchCreated := resume.done(stepReleaseBranch)
+	needsRemote := actions.pushCommit || actions.pushTag || resume.pending(stepPushReleaseBranch)
 	if needsRemote {
 		if err := git.EnsureRemote(cfg.remote); err != nil {
 			return err
 		}
+		if err := checkAllowedRemote(git, cfg); err != nil {
+			return err
+		}
+		remoteLock, err := lockRemote(git, cfg, tag)
+		if err != nil {
+			return err
+		}
+		defer remoteLock.release()
+		if err := ensureFullHistory(git, cfg, stdout); err != nil {
+			return err
+		}
 		if err := git.FetchRemote(cfg.remote); err != nil {
 			return err
 		}
-		if err := git.PullFFOnly(cfg.remote); err != nil {
+		if err := syncRemote(git, syncMode, cfg.remote, stdout); err != nil {
+			return err
+		}
+	}
+	// tagPublished is set when --tag-exists-ok finds the release already
+	// tagged on the remote, so only the steps after the push run.
+	tagPublished := false
+	if tagExistsOK && actions.pushTag {
+		if tagPublished, err = publishedTag(git, tag, tagEntry, cfg.remote); err != nil {
+			return err
+		}
+		if tagPublished {
+			_, _ = fmt.Fprintf(stdout, "Tag %s is already on %s; skipping %s\n", tag, cfg.remote, actions.String())
+			actions = releaseActions{}
+		}
+	}
+	pushLFS := false
+	if needsRemote && !skipLFS {
+		pushLFS, err = git.UsesLFS()
+		if err != nil {
+			return err
+		}
+		if pushLFS && !git.HasLFS() {
+			return &preflightError{msg: "repository tracks Git LFS files but git-lfs is not installed (install git-lfs or pass --skip-lfs)"}
+		}
+	}
+
+	if actions.pushCommit {
+		ahead, upstream, err := git.RemoteAhead(cfg.remote)
+		if err != nil {
 			return err
 		}
+		if ahead > 0 {
+			return &preflightError{msg: fmt.Sprintf("branch diverged: %s has %d commit(s) not in HEAD (rebase or pull before releasing)", upstream, ahead)}
+		}
 	}
 
+	target := ""
+	if targetRef != "" {
+		target, err = git.ResolveCommit(targetRef)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(stdout, "  Target: %s (%s)\n", targetRef, target)
+		if needsRemote {
+			upstream, err := git.RemoteBranchRef(cfg.remote)
+			if err != nil {
+				return err
+			}
+			if upstream == "" {
+				return &preflightError{msg: fmt.Sprintf("cannot verify --target: no remote branch found on %s for the current branch", cfg.remote)}
+			}
+			reachable, err := git.IsAncestor(target, upstream)
+			if err != nil {
+				return err
+			}
+			if !reachable {
+				return &preflightError{msg: fmt.Sprintf("target %s is not reachable from %s (push the commit before tagging it)", targetRef, strings.TrimPrefix(upstream, "refs/remotes/"))}
+			}
+		}
+	}
+	if sig.mode != signatureNone && actions.tag && !actions.commit {
+		commit := target
+		if commit == "" {
+			if commit, err = git.ResolveCommit("HEAD"); err != nil {
+				return err
+			}
+		}
+		if err := sig.check(d.ctx, git, commit, stdout); err != nil {
+			return err
+		}
+	}
+
+	if idempotent && actions.tag && !forceRetag {
+		released, err := alreadyReleased(git, tag, target, tagEntry, cfg.remote, actions.pushTag)
+		if err != nil {
+			return err
+		}
+		if released && d.plan != nil {
+			d.plan.describe(entry, tag, cfg.changelogPath)
+			d.plan.AlreadyReleased = true
+			return d.plan.print(result)
+		}
+		if released {
+			_, _ = fmt.Fprintf(result, "%s: %s (%s)\n", paint(result, colorGreen, "Already released"), entry.Summary, tag)
+			return writeActionsOutputs(d.getenv, newActionsResult(entry, tag, false))
+		}
+	}
+
+	if actions.tag && !forceRetag {
+		if err := git.EnsureTagAbsent(tag); err != nil {
+			return noOp(conditionTagExists, fmt.Sprintf("no new changelog version to release: %s already exists (update %s)", tag, cfg.changelogPath))
+		}
+	}
+	if resume == nil && (actions.stageAll || actions.commit || actions.tag || actions.pushCommit || actions.pushTag) {
+		outcomes, err := rules.evaluate(git, d.getenv, ruleInput{
+			entry:     entry,
+			changelog: cfg.changelogPath,
+			tagPrefix: cfg.tagPrefix,
+			tag:       tag,
+			rev:       cmp.Or(target, "HEAD"),
+			paths:     componentPaths,
+			commits:   actions.stageAll || actions.commit,
+			tags:      actions.tag && !forceRetag,
+		})
+		if err != nil {
+			return err
+		}
+		if err := enforceRules(stderr, outcomes); err != nil {
+			return err
+		}
+	}
+	steps.dryRun = cfg.dryRun
 	if actions.tag {
 		if forceRetag {
 			if actions.pushTag {
@@ -331,6 +1327,7 @@ func runRelease(args []string, stdout, stderr io.Writer, d deps) error {
 					if err := git.DeleteRemoteTag(cfg.remote, tag); err != nil {
 						return err
 					}
+					steps.record("", fmt.Sprintf("deleted remote tag %s from %s", tag, cfg.remote), fmt.Sprintf("re-push the tag with `mdrelease --tag --push-tag --force-retag` or `git push %s %s` if it still exists locally", cfg.remote, tag))
 				}
 			}
 			hasLocalTag, err := git.HasLocalTag(tag)
@@ -342,10 +1339,7 @@ func runRelease(args []string, stdout, stderr io.Writer, d deps) error {
 				if err := git.DeleteLocalTag(tag); err != nil {
 					return err
 				}
-			}
-		} else {
-			if err := git.EnsureTagAbsent(tag); err != nil {
-				return &preflightError{msg: fmt.Sprintf("no new changelog version to release: %s already exists (update %s)", tag, cfg.changelogPath)}
+				steps.record("", fmt.Sprintf("deleted local tag %s", tag), "")
 			}
 		}
 	}
@@ -360,6 +1354,7 @@ func runRelease(args []string, stdout, stderr io.Writer, d deps) error {
 			if err := git.DeleteRemoteTag(cfg.remote, tag); err != nil {
 				return err
 			}
+			steps.record("", fmt.Sprintf("deleted remote tag %s from %s", tag, cfg.remote), fmt.Sprintf("re-push the tag with `git push %s %s`", cfg.remote, tag))
 		}
 	}
 
@@ -369,71 +1364,461 @@ func runRelease(args []string, stdout, stderr io.Writer, d deps) error {
 		}
 	}
 
-	if actions.stageAll {
-		_, _ = fmt.Fprintln(stdout, "Staging changes...")
-		if err := git.StageAll(); err != nil {
+	if branch != "" && !branchCreated {
+		hasLocalBranch, err := git.HasLocalBranch(branch)
+		if err != nil {
 			return err
 		}
-	}
-
-	if actions.commit {
-		if cfg.dryRun && actions.stageAll {
-			_, _ = fmt.Fprintln(stdout, "Skipping staged-change verification in --dry-run after --stage-all.")
-		} else {
-			hasStaged, err := git.HasStagedChanges()
+		if hasLocalBranch {
+			return &preflightError{msg: fmt.Sprintf("release branch %s already exists locally", branch)}
+		}
+		if needsRemote {
+			hasRemoteBranch, err := git.HasRemoteBranch(cfg.remote, branch)
 			if err != nil {
 				return err
 			}
-			if !hasStaged {
-				msg := "no staged changes to commit"
-				if actions.stageAll {
-					msg = fmt.Sprintf("no changes to release after staging (update %s or make code changes)", cfg.changelogPath)
-				}
-				return &preflightError{msg: msg}
+			if hasRemoteBranch {
+				return &preflightError{msg: fmt.Sprintf("release branch %s already exists on %s", branch, cfg.remote)}
 			}
 		}
+	}
 
-		_, _ = fmt.Fprintln(stdout, "Committing changes...")
-		if err := git.Commit(entry.Summary, entry.Description); err != nil {
-			return err
+	postPushHook := hc.runsPostPush() && (actions.pushCommit || actions.pushTag || tagPublished || resume.pending(stepPostPushHook))
+	var hooks *hookRunner
+	r := &releaseRun{
+		ctx:               d.ctx,
+		git:               git,
+		cfg:               cfg,
+		stdout:            stdout,
+		steps:             &steps,
+		actions:           actions,
+		noOp:              noOp,
+		entry:             entry,
+		tagEntry:          tagEntry,
+		tag:               tag,
+		target:            target,
+		branch:            branch,
+		stagePaths:        stagePaths,
+		versionUpdate:     versionUpdate,
+		hc:                hc,
+		sig:               sig,
+		rollbackCommit:    rollbackCommit,
+		rollbackOnFailure: rollbackOnFailure,
+		fc:                fc,
+		backend:           forgeBackend,
+		signer:            signer,
+		assets:            assets,
+		packages:          packages,
+		recreate:          forceRetag || (resume != nil && resume.state.ForceRetag),
+		notes:             releaseNotes{markdown: entry.Description, text: tagMessage(tagEntry)},
+		notesOut:          notesOut,
+		ic:                ic,
+		images:            images,
+		gc:                gc,
+	}
+	pipeline := &releasePipeline{}
+	pipeline.use(journalSteps(&steps))
+	if cfg.verbose {
+		pipeline.use(traceSteps(stderr, time.Now))
+	}
+	pipeline.use(logSteps(stdout))
+	if cfg.dryRun {
+		pipeline.use(dryRunSteps)
+	}
+	if actions.commit && vc.enabled() {
+		pipeline.add(releaseStep{name: "version-files", run: r.writeVersionFiles})
+	}
+	if actions.commit && hc.runs(hc.preCommit) {
+		pipeline.add(releaseStep{name: "pre-commit-hooks", run: r.preCommitHooks})
+	}
+	if actions.stageAll {
+		staging := "Staging changes..."
+		if len(stagePaths) > 0 {
+			staging = fmt.Sprintf("Staging changes in %s...", strings.Join(stagePaths, ", "))
 		}
+		pipeline.add(releaseStep{name: stepStageAll, journal: stepStageAll, log: staging, run: r.stageAll})
+	}
+	if actions.commit {
+		pipeline.add(releaseStep{name: stepCommit, journal: stepCommit, log: "Committing changes...", run: r.commit, preview: r.previewCommit})
+	}
+	if actions.commit && actions.tag && sig.mode == signatureGit {
+		pipeline.add(releaseStep{name: "verify-signature", run: r.verifySignature, preview: r.previewSignature})
+	}
+	if actions.tag && hc.runs(hc.preTag) {
+		pipeline.add(releaseStep{name: "pre-tag-hooks", run: r.preTagHooks})
 	}
-
-	createdTag := false
 	if actions.tag {
-		_, _ = fmt.Fprintf(stdout, "Creating tag %s...\n", tag)
-		if err := git.CreateTag(tag, entry.Summary, entry.Description); err != nil {
+		pipeline.add(releaseStep{name: stepTag, journal: stepTag, log: fmt.Sprintf("Creating tag %s...", tag), run: r.createTag})
+	}
+	if branch != "" && !branchCreated {
+		pipeline.add(releaseStep{name: stepReleaseBranch, journal: stepReleaseBranch, log: fmt.Sprintf("Creating release branch %s...", branch), run: r.createBranch})
+	}
+	if pushLFS {
+		pipeline.add(releaseStep{name: "lfs-push", run: r.pushLFS})
+	}
+	if actions.pushCommit {
+		pipeline.add(releaseStep{name: stepPushCommit, journal: stepPushCommit, log: fmt.Sprintf("Pushing HEAD to %s...", cfg.remote), run: r.pushCommit})
+	}
+	if actions.pushTag {
+		pipeline.add(releaseStep{name: stepPushTag, journal: stepPushTag, log: fmt.Sprintf("Pushing tag %s to %s...", tag, cfg.remote), run: r.pushTag})
+	}
+	if branch != "" && needsRemote {
+		pipeline.add(releaseStep{name: stepPushReleaseBranch, journal: stepPushReleaseBranch, log: fmt.Sprintf("Pushing release branch %s to %s...", branch, cfg.remote), run: r.pushBranch})
+	}
+	if postPushHook {
+		pipeline.add(releaseStep{name: stepPostPushHook, journal: stepPostPushHook, run: r.postPushHooks})
+	}
+	if fc.enabled() {
+		pipeline.add(releaseStep{name: stepForgeRelease, journal: stepForgeRelease, run: r.forgeRelease, preview: r.previewForge})
+	}
+	if len(notesOut) > 0 {
+		pipeline.add(releaseStep{name: stepNotesOut, journal: stepNotesOut, run: r.writeNotes})
+	}
+	if ic.enabled() {
+		pipeline.add(releaseStep{name: stepImage, journal: stepImage, run: r.publishImages})
+	}
+	if gc.enabled {
+		pipeline.add(releaseStep{name: stepGoProxy, journal: stepGoProxy, run: r.warmGoProxy})
+	}
+
+	if d.plan != nil {
+		p := d.plan
+		p.describe(entry, tag, cfg.changelogPath)
+		if actions.commit {
+			p.addVersionFiles(versionUpdate)
+		}
+		if err := p.addGit(git, actions, entry, stagePaths, forceRetag, cfg.remote, target, branch, needsRemote); err != nil {
 			return err
 		}
-		createdTag = true
+		if fc.enabled() {
+			p.addForge(fc, assets, packages, forceRetag)
+		}
+		p.NotesOut = notesOut
+		if ic.enabled() {
+			p.Images = ic.images
+		}
+		if gc.enabled {
+			p.GoModule = gc.module
+		}
+		p.addHooks(hc, actions, postPushHook)
+		p.addNotify(nc)
+		p.Steps = pipeline.names()
+		return p.print(result)
+	}
+	hooks, err = newHookRunner(hc, entry, tag, cfg.dryRun, stdout, stderr, d)
+	if err != nil {
+		return err
 	}
+	r.hooks = hooks
+	notifier := newReleaseNotifier(nc, git, cfg, remote, fc, entry, tag, target, stdout, d)
+	defer func() {
+		if err != nil {
+			hooks.failed(hc.onFailure, err)
+			notifier.send(notify.StatusFailure, err)
+		}
+		hooks.close()
+	}()
 
-	if actions.pushCommit {
-		_, _ = fmt.Fprintf(stdout, "Pushing HEAD to %s...\n", cfg.remote)
-		if err := git.PushHead(cfg.remote); err != nil {
-			return err
+	if !cfg.dryRun {
+		if resume != nil {
+			steps.journal = resume
+		} else {
+			gitDir, err := git.GitDir()
+			if err != nil {
+				return err
+			}
+			steps.journal = &releaseJournal{path: journalPath(gitDir), state: journalState{
+				Version:          entry.Version,
+				Tag:              tag,
+				Changelog:        cfg.changelogPath,
+				Config:           configPath,
+				Remote:           cfg.remote,
+				TagPrefix:        cfg.tagPrefix,
+				Component:        componentName,
+				StagePaths:       stagePaths,
+				Target:           target,
+				BuildMetadata:    buildMetadata,
+				BuildIn:          buildMetadataIn,
+				ReleaseBranch:    branch,
+				Sync:             syncMode,
+				VersionPattern:   cfg.versionPattern,
+				VersionOrder:     cfg.versionOrder,
+				HeadingSeparator: cfg.headingSeparator,
+				SkipLFS:          skipLFS,
+				RequireSignature: sig.mode,
+				CommitDate:       formatOptionalTime(cfg.commitTime),
+				Forge:            fc.kind,
+				ForgeRepo:        fc.repo,
+				ForgeURL:         fc.apiURL,
+				ForgeBackend:     fc.backend,
+				ForgePlugin:      fc.plugin,
+				Assets:           fc.assets,
+				NotesOut:         notesOut,
+				ForceRetag:       forceRetag,
+				GenerateNotes:    fc.notes,
+				Sign:             fc.sign,
+				CosignKey:        fc.cosignKey,
+				SignPattern:      fc.signPattern,
+				PackageDesc:      pc.desc,
+				PackageLicense:   pc.license,
+				HomebrewTap:      pc.homebrew.repo,
+				HomebrewName:     pc.homebrew.name,
+				ScoopBucket:      pc.scoop.repo,
+				ScoopName:        pc.scoop.name,
+				WingetRepo:       pc.winget.repo,
+				WingetID:         pc.winget.name,
+				GoProxyURL:       gc.url,
+				GoMod:            gc.goMod,
+				Pkgsite:          gc.pkgsite,
+				Images:           ic.images,
+				ImageLatest:      ic.latest,
+				PreCommitHooks:   hc.preCommit,
+				PreTagHooks:      hc.preTag,
+				PostPushHooks:    hc.postPush,
+				OnFailureHooks:   hc.onFailure,
+				Plugins:          hc.plugins,
+				VersionFiles:     vc.rules,
+				WriteVersion:     vc.plain,
+				WriteGoVersion:   vc.goFiles,
+				WebhookOnFail:    nc.onFailure,
+				AnnounceTmpl:     nc.template,
+				EmailTo:          nc.emailTo,
+				EmailFrom:        nc.emailFrom,
+				SMTPAddr:         nc.smtpAddr,
+				SMTPUsername:     nc.smtpUser,
+				PkgsiteURL:       gc.pkgsiteURL,
+				Planned:          pipeline.journalKeys(),
+				Completed:        []string{},
+				StartedAt:        time.Now().UTC(),
+			}}
+			if err := steps.journal.save(); err != nil {
+				return err
+			}
 		}
 	}
 
+	if err := pipeline.run(); err != nil {
+		return err
+	}
 	if actions.pushTag {
-		_, _ = fmt.Fprintf(stdout, "Pushing tag %s to %s...\n", tag, cfg.remote)
-		if err := git.PushTag(cfg.remote, tag); err != nil {
-			if createdTag {
-				return fmt.Errorf("%w (tag %s was created locally and may need manual push/retry)", err, tag)
-			}
+		if err := printRemoteLinks(git, remote, cfg, tag, target, !fc.enabled(), stdout); err != nil {
 			return err
 		}
 	}
 
+	notifier.send(notify.StatusSuccess, nil)
+	if hooks != nil {
+		repo, releaseURL := cmp.Or(fc.repo, remote.Repo), ""
+		if remote.Kind != "" {
+			releaseURL = remote.ReleaseURL(tag)
+		}
+		hooks.released(repo, releaseURL)
+	}
+
 	if cfg.dryRun {
-		_, _ = fmt.Fprintln(stdout, "Dry-run complete.")
+		_, _ = fmt.Fprintln(result, paint(result, colorGreen, "Dry-run complete."))
+		return writeActionsOutputs(d.getenv, newActionsResult(entry, tag, false))
+	}
+
+	_, _ = fmt.Fprintf(result, "%s: %s (%s)\n", paint(result, colorGreen, "Release complete"), entry.Summary, tag)
+	return writeActionsOutputs(d.getenv, newActionsResult(entry, tag, actions.tag || actions.pushTag))
+}
+
+func ensureFullHistory(git gitOps, cfg commonConfig, stdout io.Writer) error {
+	shallow, err := git.IsShallow()
+	if err != nil {
+		return err
+	}
+	if !shallow {
 		return nil
 	}
+	if cfg.noUnshallow {
+		return &preflightError{msg: fmt.Sprintf("repository is a shallow clone; tag and ancestry checks need full history (run `git fetch --unshallow --tags %s`, use `fetch-depth: 0` with actions/checkout, or drop --no-unshallow)", cfg.remote)}
+	}
+	_, _ = fmt.Fprintf(stdout, "Fetching full history from %s (shallow clone detected)...\n", cfg.remote)
+	return git.Unshallow(cfg.remote)
+}
 
-	_, _ = fmt.Fprintf(stdout, "Release complete: %s (%s)\n", entry.Summary, tag)
+func syncRemote(git gitOps, mode, remote string, stdout io.Writer) error {
+	switch mode {
+	case syncRebase:
+		return git.PullRebase(remote)
+	case syncNone:
+		_, _ = fmt.Fprintln(stdout, "  Sync:", paint(stdout, colorYellow, "skipped"), "(--sync none)")
+		return nil
+	default:
+		return git.PullFFOnly(remote)
+	}
+}
+
+func alreadyReleased(git gitOps, tag, target string, entry *changelog.Entry, remote string, checkRemote bool) (bool, error) {
+	hasLocalTag, err := git.HasLocalTag(tag)
+	if err != nil || !hasLocalTag {
+		return false, err
+	}
+	want := target
+	if want == "" {
+		want, err = git.ResolveCommit("HEAD")
+		if err != nil {
+			return false, err
+		}
+	}
+	tagged, err := git.ResolveCommit(tag)
+	if err != nil {
+		return false, err
+	}
+	if tagged != want {
+		return false, nil
+	}
+	message, err := git.TagMessage(tag)
+	if err != nil {
+		return false, err
+	}
+	if normalizeMessage(message) != normalizeMessage(tagMessage(entry)) {
+		return false, nil
+	}
+	if checkRemote {
+		remoteCommit, err := git.RemoteTagCommit(remote, tag)
+		if err != nil {
+			return false, err
+		}
+		if remoteCommit != want {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// publishedTag reports whether tag is already on remote. A tag that is there
+// but is not the release of entry, the same commit locally with entry's
+// message, fails the preflight rather than being released over.
+func publishedTag(git gitOps, tag string, entry *changelog.Entry, remote string) (bool, error) {
+	remoteCommit, err := git.RemoteTagCommit(remote, tag)
+	if err != nil || remoteCommit == "" {
+		return false, err
+	}
+	hasLocalTag, err := git.HasLocalTag(tag)
+	if err != nil {
+		return false, err
+	}
+	if !hasLocalTag {
+		return false, &preflightError{msg: fmt.Sprintf("tag %s is on %s but not in this repository (run `git fetch %s tag %s`)", tag, remote, remote, tag)}
+	}
+	localCommit, err := git.ResolveCommit(tag)
+	if err != nil {
+		return false, err
+	}
+	if localCommit != remoteCommit {
+		return false, &preflightError{msg: fmt.Sprintf("local tag %s points at %s but %s has it at %s", tag, localCommit, remote, remoteCommit)}
+	}
+	message, err := git.TagMessage(tag)
+	if err != nil {
+		return false, err
+	}
+	if normalizeMessage(message) != normalizeMessage(tagMessage(entry)) {
+		return false, &preflightError{msg: fmt.Sprintf("tag %s on %s does not match the changelog entry for %s (pass --force-retag to replace it)", tag, remote, entry.Version)}
+	}
+	return true, nil
+}
+
+func tagMessage(entry *changelog.Entry) string {
+	if entry.Description == "" {
+		return entry.Summary
+	}
+	return entry.Summary + "\n\n" + entry.Description
+}
+
+func normalizeMessage(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+type optionalString struct {
+	value        string
+	defaultValue string
+	set          bool
+}
+
+func (o *optionalString) String() string { return o.value }
+
+func (o *optionalString) Set(v string) error {
+	if v == "false" {
+		o.set = false
+		o.value = ""
+		return nil
+	}
+	o.set = true
+	if v == "true" || v == "" {
+		o.value = o.defaultValue
+		return nil
+	}
+	o.value = v
+	return nil
+}
+
+func (o *optionalString) IsBoolFlag() bool { return true }
+
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
 	return nil
 }
 
+func renderReleaseBranch(pattern, version, tag string) string {
+	r := strings.NewReplacer("{version}", version, "{tag}", tag)
+	return strings.TrimSpace(r.Replace(pattern))
+}
+
+func printGitErrorDetails(w io.Writer, ge *gitutil.GitError) {
+	if len(ge.Args) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "  Command: %s\n", ge.Command())
+	if ge.ExitCode >= 0 {
+		_, _ = fmt.Fprintf(w, "  Exit code: %d\n", ge.ExitCode)
+	}
+	if tail := ge.OutputTail(gitErrorTailLines); len(tail) > 0 {
+		_, _ = fmt.Fprintln(w, "  Output (last lines):")
+		for _, line := range tail {
+			_, _ = fmt.Fprintf(w, "    %s\n", line)
+		}
+	}
+	if ge.IsAuthFailure() {
+		_, _ = fmt.Fprintln(w, "  Hint: git could not authenticate with the remote non-interactively.")
+		_, _ = fmt.Fprintln(w, "    Use an SSH key loaded in ssh-agent, or an HTTPS token via a credential helper (for example GITHUB_TOKEN in CI).")
+		_, _ = fmt.Fprintln(w, "    Pass --allow-git-prompt to let git prompt for credentials in an interactive terminal.")
+	}
+}
+
+// printParseErrorSnippet shows the offending changelog line with a caret
+// under the column that broke the format.
+func printParseErrorSnippet(w io.Writer, pe *changelog.ParseError) {
+	if pe.Line <= 0 {
+		return
+	}
+	gutter := strconv.Itoa(pe.Line)
+	_, _ = fmt.Fprintf(w, "  %s | %s\n", gutter, pe.Text)
+	if pe.Column <= 0 {
+		return
+	}
+	// Keep tabs so the caret lines up however the terminal expands them.
+	var pad strings.Builder
+	for i := 0; i < pe.Column-1; i++ {
+		if i < len(pe.Text) && pe.Text[i] == '\t' {
+			pad.WriteByte('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+	_, _ = fmt.Fprintf(w, "  %s | %s^\n", strings.Repeat(" ", len(gutter)), pad.String())
+}
+
 func resolveChangelogPath(flagValue string, getenv func(string) string) string {
 	if strings.TrimSpace(flagValue) != "" {
 		return flagValue
@@ -477,11 +1862,48 @@ func (a releaseActions) String() string {
 	return strings.Join(parts, ", ")
 }
 
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
 func printRootUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "Usage:")
 	_, _ = fmt.Fprintln(w, "  mdrelease [flags]        Run release (default is full release, equivalent to --all)")
 	_, _ = fmt.Fprintln(w, "  mdrelease check [flags]  Validate changelog and git preconditions")
+	_, _ = fmt.Fprintln(w, "  mdrelease plan [--json] [flags]")
+	_, _ = fmt.Fprintln(w, "                           Show exactly what a release with the same flags would change, without changing anything")
+	_, _ = fmt.Fprintln(w, "  mdrelease doctor [flags] Check git, identity, remote access, signing, forge token, and changelog for a release with the same flags")
+	_, _ = fmt.Fprintln(w, "  mdrelease promote [flags] Rewrite the latest prerelease entries (1.4.0-rc.2, ...) as 1.4.0 and release it")
 	_, _ = fmt.Fprintln(w, "  mdrelease version [flags] Print <latest-changelog-version>")
+	_, _ = fmt.Fprintln(w, "  mdrelease tag-message [flags] [version]")
+	_, _ = fmt.Fprintln(w, "                           Print the tag annotation a release of the latest (or given) changelog entry would get")
+	_, _ = fmt.Fprintln(w, "  mdrelease export --format html|email [flags]")
+	_, _ = fmt.Fprintln(w, "                           Render the changelog, or a version range of it, as a standalone HTML page or an email digest")
+	_, _ = fmt.Fprintln(w, "  mdrelease site [flags]   Write a static release-notes site: an index of versions and a page per release")
+	_, _ = fmt.Fprintln(w, "  mdrelease import-tags [flags]")
+	_, _ = fmt.Fprintln(w, "                           Backfill changelog entries from the messages of existing annotated tags")
+	_, _ = fmt.Fprintln(w, "  mdrelease audit-tags [flags]")
+	_, _ = fmt.Fprintln(w, "                           Report tags whose annotation differs from the changelog entry for their version")
+	_, _ = fmt.Fprintln(w, "  mdrelease changed [flags] Report components with commits since their last tag but no new changelog entry")
+	_, _ = fmt.Fprintln(w, "  mdrelease next [--write] Suggest the next version from the commits since the last tag")
+	_, _ = fmt.Fprintln(w, "  mdrelease latest [--compare] [flags]")
+	_, _ = fmt.Fprintln(w, "                           Print the highest semver tag, locally or on the remote, or compare it with the changelog")
+	_, _ = fmt.Fprintln(w, "  mdrelease backport --onto <branch> [flags]")
+	_, _ = fmt.Fprintln(w, "                           Cherry-pick the changelog entry commit onto a maintenance branch, tag, and push")
+	_, _ = fmt.Fprintln(w, "  mdrelease resume [flags] Finish the remaining steps of a failed release recorded in .git/mdrelease-state.json")
+	_, _ = fmt.Fprintln(w, "  mdrelease pr [flags]     Commit the changelog bump to a branch, push it, and open a release pull request")
+	_, _ = fmt.Fprintln(w, "  mdrelease undo [flags]   Delete the latest release's tag locally and on the remote, and optionally its commit and forge release")
+	_, _ = fmt.Fprintln(w, "  mdrelease config init [flags]")
+	_, _ = fmt.Fprintln(w, "                           Write a commented starter .mdrelease.toml from the detected repo state")
+	_, _ = fmt.Fprintln(w, "  mdrelease config show [--effective]")
+	_, _ = fmt.Fprintln(w, "                           Print config files, or every flag's effective value and its source")
+	_, _ = fmt.Fprintln(w, "  mdrelease config validate Check config files for unknown keys, invalid values, and conflicting settings")
+	_, _ = fmt.Fprintln(w, "  mdrelease completion {bash,zsh,fish,powershell}")
+	_, _ = fmt.Fprintln(w, "                           Print a shell completion script for subcommands, flags, remotes, and release tags")
+	_, _ = fmt.Fprintln(w, "  mdrelease help [command] Print a command's flags with their defaults, and examples (same as <command> -h)")
 	_, _ = fmt.Fprintln(w)
 	_, _ = fmt.Fprintf(w, "Installed mdrelease version: %s\n", ToolVersion)
 	_, _ = fmt.Fprintln(w)
@@ -495,6 +1917,18 @@ func printRootUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "  mdrelease --commit --tag --push")
 	_, _ = fmt.Fprintln(w, "  mdrelease --tag --push-tag")
 	_, _ = fmt.Fprintln(w, "  mdrelease --tag --push-tag --force-retag")
+	_, _ = fmt.Fprintln(w, "  mdrelease --sync rebase")
+	_, _ = fmt.Fprintln(w, "  mdrelease --tag --push-tag --target <sha>")
+	_, _ = fmt.Fprintln(w, "  mdrelease --idempotent")
+	_, _ = fmt.Fprintln(w, "  mdrelease --release-branch")
+	_, _ = fmt.Fprintln(w, "  mdrelease --release-branch=release/{version}")
+	_, _ = fmt.Fprintln(w, "  mdrelease backport --onto release-1.x --tag-prefix v")
+	_, _ = fmt.Fprintln(w, "  mdrelease resume")
+	_, _ = fmt.Fprintln(w, "  mdrelease pr --forge github")
+	_, _ = fmt.Fprintln(w, "  mdrelease doctor --forge auto")
+	_, _ = fmt.Fprintln(w, "  mdrelease config init --format yaml")
+	_, _ = fmt.Fprintln(w, "  mdrelease config show --effective --command check")
 	_, _ = fmt.Fprintln(w, "  mdrelease --version")
 	_, _ = fmt.Fprintln(w, "  mdrelease version")
+	_, _ = fmt.Fprintln(w, "  mdrelease help release")
 }
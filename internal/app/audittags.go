@@ -0,0 +1,167 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+)
+
+// runAuditTags compares the annotation of each tag with the changelog entry
+// for its version and reports the ones that differ, which were edited or
+// created outside mdrelease.
+func runAuditTags(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease audit-tags", stderr)
+
+	var cfg commonConfig
+	var changelogFlag string
+	var componentName string
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
+	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix")
+	fs.StringVar(&componentName, "component", "", "Use the changelog and tag prefix of this [components.<name>] config entry")
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "audit-tags", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "audit-tags does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+	if componentName != "" {
+		if _, err := applyComponent(componentName, configPath, &cfg, d); err != nil {
+			return err
+		}
+	}
+
+	versions, err := cfg.versions.changelog.Versions(cfg.changelogPath)
+	if err != nil {
+		return err
+	}
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	tags, err := git.TagDetails(cfg.tagPrefix)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]gitutil.Tag, len(tags))
+	for _, tag := range tags {
+		byName[tag.Name] = tag
+	}
+
+	var audited int
+	var differ []string
+	for _, version := range versions {
+		tag, ok := byName[cfg.tagPrefix+version]
+		if !ok {
+			continue
+		}
+		audited++
+		if !tag.Annotated {
+			differ = append(differ, tag.Name)
+			_, _ = fmt.Fprintf(stdout, "%s %s: lightweight tag, no annotation to compare\n", paint(stdout, colorRed, "Differs"), tag.Name)
+			continue
+		}
+		entry, err := cfg.versions.changelog.ParseVersion(cfg.changelogPath, version)
+		if err != nil {
+			return err
+		}
+		want := strings.Split(normalizeMessage(tagMessage(entry)), "\n")
+		got := strings.Split(normalizeMessage(withoutBuildLine(tagAnnotation(tag), version)), "\n")
+		if strings.Join(want, "\n") == strings.Join(got, "\n") {
+			_, _ = fmt.Fprintf(stdout, "%s %s\n", paint(stdout, colorGreen, "Matches"), tag.Name)
+			continue
+		}
+		differ = append(differ, tag.Name)
+		_, _ = fmt.Fprintf(stdout, "%s %s (- changelog, + tag):\n", paint(stdout, colorRed, "Differs"), tag.Name)
+		for _, line := range diffLines(want, got) {
+			_, _ = fmt.Fprintln(stdout, strings.TrimRight("    "+line, " "))
+		}
+	}
+	if audited == 0 {
+		_, _ = fmt.Fprintf(stdout, "No changelog versions in %s have a %s tag\n", cfg.changelogPath, cfg.tagPrefix+"<version>")
+		return nil
+	}
+	if len(differ) > 0 {
+		return &preflightError{msg: fmt.Sprintf("%d of %d tag annotation(s) differ from %s: %s", len(differ), audited, cfg.changelogPath, strings.Join(differ, ", "))}
+	}
+	_, _ = fmt.Fprintf(stdout, "%s: %d tag annotation(s) match %s\n", paint(stdout, colorGreen, "Audit passed"), audited, cfg.changelogPath)
+	return nil
+}
+
+// tagAnnotation is an annotated tag's message as mdrelease writes it: the
+// subject, then a blank line and the body.
+func tagAnnotation(tag gitutil.Tag) string {
+	if tag.Body == "" {
+		return tag.Subject
+	}
+	return tag.Subject + "\n\n" + tag.Body
+}
+
+// withoutBuildLine drops the "Build: <version>+<metadata>" line that
+// --build-metadata-in message appends, which the changelog does not hold.
+func withoutBuildLine(message, version string) string {
+	lines := strings.Split(strings.TrimSpace(message), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); strings.HasPrefix(last, "Build: "+version+"+") {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// diffLines is a line diff of a against b from their longest common
+// subsequence: shared lines are indented, lines only in a start with "-",
+// and lines only in b with "+".
+func diffLines(a, b []string) []string {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out = append(out, "  "+a[i])
+			i, j = i+1, j+1
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	return out
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func runBackport(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease backport", stderr)
+
+	var cfg commonConfig
+	var changelogFlag string
+	var onto string
+	var commitRef string
+	var noPush bool
+
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
+	fs.StringVar(&cfg.remote, "remote", "origin", "Git remote name")
+	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix for the backport release")
+	fs.BoolVar(&cfg.dryRun, "dry-run", false, "Print planned actions without mutating git state")
+	fs.StringVar(&onto, "onto", "", "Maintenance branch to cherry-pick onto (required)")
+	fs.StringVar(&commitRef, "commit", "HEAD", "Commit that adds the changelog entry to backport")
+	fs.StringVar(&cfg.commitDate, "commit-date", "", "Author/committer date for the backport tag, as unix seconds or RFC 3339 (default: $SOURCE_DATE_EPOCH)")
+	fs.BoolVar(&noPush, "no-push", false, "Cherry-pick and tag locally without pushing the branch or tag")
+	addRemoteFlags(fs, &cfg)
+	addLockFlags(fs, &cfg)
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "backport", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "backport does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	stdout = cfg.narration(result)
+	stderr = cfg.diagnostics(stderr)
+	if strings.TrimSpace(onto) == "" {
+		return &usageError{msg: "backport requires --onto <branch>"}
+	}
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	cfg.resolveGitToken(d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+	if err := cfg.resolveCommitDate(d.getenv); err != nil {
+		return err
+	}
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	lock, err := lockRelease(git, cfg)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	commit, err := git.ResolveCommit(commitRef)
+	if err != nil {
+		return err
+	}
+	content, err := git.ShowFile(commit, cfg.changelogPath)
+	if err != nil {
+		return err
+	}
+	entry, err := cfg.versions.changelog.ParseLatestContent(content, commit[:min(len(commit), 12)]+":"+cfg.changelogPath)
+	if err != nil {
+		return err
+	}
+	tag := cfg.tagPrefix + entry.Version
+
+	_, _ = fmt.Fprintln(stdout, "Backport info:")
+	_, _ = fmt.Fprintf(stdout, "  Commit: %s\n", commit)
+	_, _ = fmt.Fprintf(stdout, "  Onto: %s\n", onto)
+	_, _ = fmt.Fprintf(stdout, "  Version: %s\n", entry.Version)
+	_, _ = fmt.Fprintf(stdout, "  Title: %s\n", entry.Summary)
+	_, _ = fmt.Fprintf(stdout, "  Tag: %s\n", tag)
+	if cfg.dryRun {
+		_, _ = fmt.Fprintln(stdout, "  Mode: dry-run")
+	}
+
+	dirty, err := git.HasUncommittedChanges()
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return &preflightError{msg: "working tree has uncommitted changes; commit or stash them before backporting"}
+	}
+
+	if err := ensureCommitterIdentity(git); err != nil {
+		return err
+	}
+
+	original, err := git.CurrentBranch()
+	if err != nil {
+		return err
+	}
+	if original == onto {
+		return &preflightError{msg: fmt.Sprintf("already on %s; check out the branch that contains the changelog entry commit", onto)}
+	}
+
+	if !noPush {
+		if err := git.EnsureRemote(cfg.remote); err != nil {
+			return err
+		}
+		if err := checkAllowedRemote(git, cfg); err != nil {
+			return err
+		}
+		remoteLock, err := lockRemote(git, cfg, tag)
+		if err != nil {
+			return err
+		}
+		defer remoteLock.release()
+		if err := ensureFullHistory(git, cfg, stdout); err != nil {
+			return err
+		}
+		if err := git.FetchRemote(cfg.remote); err != nil {
+			return err
+		}
+		hasRemoteTag, err := git.HasRemoteTag(cfg.remote, tag)
+		if err != nil {
+			return err
+		}
+		if hasRemoteTag {
+			return &preflightError{msg: fmt.Sprintf("backport tag %s already exists on %s (update the changelog entry version for %s)", tag, cfg.remote, onto)}
+		}
+	}
+	if err := git.EnsureTagAbsent(tag); err != nil {
+		return &preflightError{msg: fmt.Sprintf("backport tag %s already exists (update the changelog entry version for %s)", tag, onto)}
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Checking out %s...\n", onto)
+	if err := git.Checkout(onto); err != nil {
+		return err
+	}
+	if !noPush {
+		if err := git.PullFFOnly(cfg.remote); err != nil {
+			return err
+		}
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Cherry-picking %s onto %s...\n", commit, onto)
+	if err := git.CherryPick(commit); err != nil {
+		return fmt.Errorf("%w (resolve conflicts, run `git cherry-pick --continue`, then `mdrelease --tag --push-tag` on %s)", err, onto)
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Creating tag %s...\n", tag)
+	if err := git.CreateTag(tag, "", entry.Summary, entry.Description); err != nil {
+		return err
+	}
+
+	if !noPush {
+		_, _ = fmt.Fprintf(stdout, "Pushing %s to %s...\n", onto, cfg.remote)
+		if err := git.PushHead(cfg.remote); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(stdout, "Pushing tag %s to %s...\n", tag, cfg.remote)
+		if err := git.PushTag(cfg.remote, tag); err != nil {
+			return fmt.Errorf("%w (tag %s was created locally on %s and may need manual push/retry)", err, tag, onto)
+		}
+	}
+
+	if original != "" {
+		_, _ = fmt.Fprintf(stdout, "Returning to %s...\n", original)
+		if err := git.Checkout(original); err != nil {
+			return err
+		}
+	}
+
+	if cfg.dryRun {
+		_, _ = fmt.Fprintln(result, paint(result, colorGreen, "Dry-run complete."))
+		return nil
+	}
+	_, _ = fmt.Fprintf(result, "%s: %s (%s on %s)\n", paint(result, colorGreen, "Backport complete"), entry.Summary, tag, onto)
+	return nil
+}
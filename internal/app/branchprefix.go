@@ -0,0 +1,67 @@
+package app
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+)
+
+const branchPlaceholder = "{branch}"
+
+func addBranchPrefixFlag(fs *flag.FlagSet, rules *stringList) {
+	fs.Var(rules, "branch-tag-prefix", "Use a tag prefix for releases from matching branches, as <branch-glob>=<prefix> such as release-1.x=v1. (repeatable; also the [branch-tag-prefixes] config table)")
+}
+
+// resolveTagPrefix picks the tag prefix for the current branch: an exact
+// --branch-tag-prefix match, else the longest matching glob, else prefix.
+// A {branch} placeholder in the result becomes the branch name, so
+// maintenance branches get their own tag namespace. The branch is only
+// looked up when a rule or placeholder needs it.
+func resolveTagPrefix(git gitOps, prefix string, rules []string, getenv func(string) string) (string, error) {
+	if len(rules) == 0 && !strings.Contains(prefix, branchPlaceholder) {
+		return prefix, nil
+	}
+	branch, err := releaseBranchName(git, getenv)
+	if err != nil {
+		return "", err
+	}
+	if branch == "" {
+		return "", &preflightError{msg: "cannot choose a tag prefix for the branch: HEAD is detached (check out the release branch)"}
+	}
+
+	matched, exact := "", false
+	for _, rule := range rules {
+		pattern, rulePrefix, ok := strings.Cut(rule, "=")
+		if !ok || pattern == "" {
+			return "", &usageError{msg: fmt.Sprintf("invalid --branch-tag-prefix %q (expected <branch-glob>=<prefix>)", rule)}
+		}
+		ok, err := path.Match(pattern, branch)
+		if err != nil {
+			return "", &usageError{msg: fmt.Sprintf("invalid --branch-tag-prefix pattern %q: %v", pattern, err)}
+		}
+		switch {
+		case exact:
+		case pattern == branch:
+			prefix, exact = rulePrefix, true
+		case ok && len(pattern) > len(matched):
+			prefix, matched = rulePrefix, pattern
+		}
+	}
+	return strings.ReplaceAll(prefix, branchPlaceholder, branch), nil
+}
+
+// releaseBranchName is the branch being released, or "" on a detached HEAD
+// outside a CI job that names its branch.
+func releaseBranchName(git gitOps, getenv func(string) string) (string, error) {
+	branch, err := git.CurrentBranch()
+	if err != nil || branch != "" {
+		return branch, err
+	}
+	// CI checks out a detached HEAD; its environment still names the branch.
+	if getenv("GITHUB_REF_TYPE") == "branch" {
+		branch = getenv("GITHUB_REF_NAME")
+	}
+	return strings.TrimSpace(cmp.Or(branch, getenv("CI_COMMIT_BRANCH"))), nil
+}
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/semver"
+)
+
+const (
+	buildMetadataInTag     = "tag"
+	buildMetadataInMessage = "message"
+)
+
+// renderBuildMetadata expands $VARS and the {sha}/{shortsha} placeholders in
+// a --build-metadata pattern and returns it without the leading "+". sha
+// resolves the tagged commit and is only called when a placeholder needs it.
+func renderBuildMetadata(pattern string, getenv func(string) string, sha func() (string, error)) (string, error) {
+	meta := os.Expand(strings.TrimPrefix(strings.TrimSpace(pattern), "+"), getenv)
+	if strings.Contains(meta, "{sha}") || strings.Contains(meta, "{shortsha}") {
+		full, err := sha()
+		if err != nil {
+			return "", err
+		}
+		meta = strings.NewReplacer("{sha}", full, "{shortsha}", full[:min(len(full), 7)]).Replace(meta)
+	}
+	if !semver.ValidBuild(meta) {
+		return "", &usageError{msg: fmt.Sprintf("invalid --build-metadata %q (expanded to %q): use dot-separated identifiers of letters, digits, and hyphens, and check that every $VAR is set", pattern, meta)}
+	}
+	return meta, nil
+}
+
+// withBuildLine is entry with a Build: line for --build-metadata-in message.
+func withBuildLine(entry *changelog.Entry, meta string) *changelog.Entry {
+	withBuild := *entry
+	withBuild.Description = strings.TrimSpace(entry.Description + "\n\nBuild: " + entry.Version + "+" + meta)
+	return &withBuild
+}
@@ -0,0 +1,140 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+)
+
+// runChanged reports, per component, whether commits since its last tag
+// touched its path without a new changelog entry to release them.
+func runChanged(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease changed", stderr)
+
+	var cfg commonConfig
+	var componentName string
+	fs.StringVar(&componentName, "component", "", "Report only this [components.<name>] config entry")
+	addRemoteFlags(fs, &cfg)
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "changed", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "changed does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	stdout = cfg.narration(result)
+	stderr = cfg.diagnostics(stderr)
+	cfg.resolveGitEnv(d.getenv)
+
+	components, err := loadComponents(configPath, d)
+	if err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		return &usageError{msg: "changed needs at least one [components.<name>] config section"}
+	}
+	if componentName != "" {
+		c, err := findComponent(components, componentName)
+		if err != nil {
+			return err
+		}
+		components = []component{*c}
+	}
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	if err := ensureFullHistory(git, cfg, stdout); err != nil {
+		return err
+	}
+
+	type unreleased struct {
+		name    string
+		since   string
+		commits []gitutil.Commit
+	}
+	var missing []unreleased
+	var failed []string
+	table := tabwriter.NewWriter(result, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "COMPONENT\tLAST TAG\tCOMMITS\tSTATUS")
+	for _, c := range components {
+		since, err := git.PreviousTag("HEAD", c.tagPrefix)
+		if err != nil {
+			return err
+		}
+		commits, err := git.CommitsSince(since, c.path)
+		if err != nil {
+			return err
+		}
+		status := "unchanged"
+		entry, err := cfg.versions.changelog.ParseLatest(c.changelog)
+		switch {
+		case err != nil:
+			status = "error: " + err.Error()
+			failed = append(failed, c.name)
+		case c.tagPrefix+entry.Version != since && git.EnsureTagAbsent(c.tagPrefix+entry.Version) == nil:
+			status = "ready to release " + entry.Version
+		case len(commits) > 0:
+			status = "needs changelog entry"
+			missing = append(missing, unreleased{c.name, since, commits})
+		}
+		lastTag := since
+		if lastTag == "" {
+			lastTag = "-"
+		}
+		_, _ = fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", c.name, lastTag, strconv.Itoa(len(commits)), status)
+	}
+	_ = table.Flush()
+
+	for _, m := range missing {
+		since := m.since
+		if since == "" {
+			since = "the first commit"
+		}
+		_, _ = fmt.Fprintf(stdout, "%s: %d commit(s) since %s:\n", m.name, len(m.commits), since)
+		for _, commit := range m.commits {
+			_, _ = fmt.Fprintf(stdout, "  %s %s\n", commit.Hash, commit.Subject)
+		}
+	}
+	switch {
+	case len(failed) > 0:
+		return &preflightError{msg: fmt.Sprintf("%d component(s) have a changelog that does not parse: %s", len(failed), strings.Join(failed, ", "))}
+	case len(missing) > 0:
+		names := make([]string, len(missing))
+		for i, m := range missing {
+			names[i] = m.name
+		}
+		return &preflightError{msg: fmt.Sprintf("%d component(s) have changes without a new changelog entry: %s", len(missing), strings.Join(names, ", "))}
+	}
+	_, _ = fmt.Fprintln(result, paint(result, colorGreen, "No component has unreleased changes without a changelog entry."))
+	return nil
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ciEnvVars are the variables CI services set on every job; any of them
+// switches mdrelease to non-interactive, uncolored output.
+var ciEnvVars = []string{"CI", "GITHUB_ACTIONS", "GITLAB_CI"}
+
+// defaultCIActions is what a release runs in CI mode without action flags:
+// everything but --stage-all, since `git add -A` in a CI workspace would
+// sweep build output into the release commit.
+const defaultCIActions = "commit,tag,push"
+
+// parseActions reads a --ci-actions list of release action flag names.
+func parseActions(list string) (releaseActions, error) {
+	var a releaseActions
+	for name := range strings.SplitSeq(list, ",") {
+		switch strings.TrimSpace(name) {
+		case "stage-all":
+			a.stageAll = true
+		case "commit":
+			a.commit = true
+		case "tag":
+			a.tag = true
+		case "push":
+			a.pushCommit, a.pushTag = true, true
+		case "push-commit":
+			a.pushCommit = true
+		case "push-tag":
+			a.pushTag = true
+		default:
+			return a, &usageError{msg: fmt.Sprintf("invalid --ci-actions entry %q (expected stage-all, commit, tag, push, push-commit, or push-tag)", strings.TrimSpace(name))}
+		}
+	}
+	return a, nil
+}
+
+func detectCI(getenv func(string) string) bool {
+	if getenv == nil {
+		return false
+	}
+	for _, name := range ciEnvVars {
+		if envSet(getenv(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// envSet reads a boolean switch variable: anything but empty, "0", or
+// "false" turns it on.
+func envSet(v string) bool {
+	return v != "" && v != "0" && !strings.EqualFold(v, "false")
+}
+
+// ciMode reports whether to run as in CI: forced by --ci, detected from the
+// environment, and overridden by --interactive.
+func (c commonConfig) ciMode(getenv func(string) string) bool {
+	if c.interactive {
+		return false
+	}
+	return c.ci || detectCI(getenv)
+}
+
+// prompts reports whether a release may ask for confirmation: on a terminal
+// outside CI, or always with --interactive.
+func (c commonConfig) prompts(d deps) bool {
+	if c.interactive {
+		return true
+	}
+	return d.interactive && !c.ciMode(d.getenv)
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	colorGreen  = "32"
+	colorRed    = "31"
+	colorYellow = "33"
+)
+
+// colorWriter marks an output that is a terminal accepting ANSI colors;
+// paint only colors text written to one.
+type colorWriter struct{ io.Writer }
+
+// colorOutput wraps w in a colorWriter when it is a terminal, NO_COLOR is
+// unset, and color was not turned off with --no-color.
+func colorOutput(w io.Writer, noColor bool, getenv func(string) string) io.Writer {
+	if noColor || (getenv != nil && getenv("NO_COLOR") != "") || !isTerminal(w) {
+		return w
+	}
+	return colorWriter{w}
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func paint(w io.Writer, color, s string) string {
+	if _, ok := w.(colorWriter); !ok {
+		return s
+	}
+	return "\x1b[" + color + "m" + s + "\x1b[0m"
+}
+
+// errorOutput is where Run reports a failed command: stderr with a red
+// "Error:" prefix, or a structured log with --log-format. It runs before any
+// command parses its flags, so it only sees flags given on the command line.
+func errorOutput(stderr io.Writer, args []string) io.Writer {
+	has := func(name string) bool {
+		return slices.Contains(args, "--"+name) || slices.Contains(args, "-"+name)
+	}
+	for i, arg := range args {
+		name, value, found := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "log-format" {
+			continue
+		}
+		if !found && i+1 < len(args) {
+			value = args[i+1]
+		}
+		if logger := newLogger(stderr, value); logger != nil {
+			return &logWriter{logger: logger}
+		}
+		return stderr
+	}
+	cfg := commonConfig{noColor: has("no-color"), ci: has("ci"), interactive: has("interactive")}
+	return colorOutput(stderr, cfg.noColor || cfg.ciMode(os.Getenv), os.Getenv)
+}
@@ -0,0 +1,282 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+)
+
+// completeCommand is the hidden command the completion scripts call with the
+// words typed so far; keeping the logic here keeps the scripts small.
+const completeCommand = "__complete"
+
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+var completionCommands = []string{"check", "plan", "doctor", "promote", "version", "tag-message", "export", "site", "import-tags", "audit-tags", "changed", "next", "latest", "backport", "resume", "pr", "undo", "config", "completion", "help"}
+
+var configSubcommands = []string{"init", "show", "validate"}
+
+func runCompletion(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("mdrelease completion", stderr)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() != 1 {
+		return &usageError{msg: fmt.Sprintf("completion requires a shell (%s)", strings.Join(completionShells, ", "))}
+	}
+	script, ok := map[string]string{
+		"bash":       bashCompletion,
+		"zsh":        zshCompletion,
+		"fish":       fishCompletion,
+		"powershell": powershellCompletion,
+	}[fs.Arg(0)]
+	if !ok {
+		return &usageError{msg: fmt.Sprintf("unsupported shell %q (expected %s)", fs.Arg(0), strings.Join(completionShells, ", "))}
+	}
+	_, _ = io.WriteString(stdout, script)
+	return nil
+}
+
+// runComplete prints one candidate per line for the last word in args. It
+// never fails: a shell completion has nowhere useful to show an error.
+func runComplete(args []string, stdout io.Writer, d deps) error {
+	for _, candidate := range completeWords(args, d) {
+		_, _ = fmt.Fprintln(stdout, candidate)
+	}
+	return nil
+}
+
+func completeWords(words []string, d deps) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	if cur == `""` {
+		// PowerShell before 7.3 drops empty arguments, so its script passes
+		// a quoted empty string instead.
+		cur = ""
+	}
+	words = words[:len(words)-1]
+
+	command := "release"
+	if len(words) == 0 {
+		if !strings.HasPrefix(cur, "-") {
+			return filterPrefix(completionCommands, cur)
+		}
+	} else if !strings.HasPrefix(words[0], "-") {
+		command = words[0]
+		words = words[1:]
+	}
+	switch command {
+	case "help":
+		if len(words) == 0 {
+			return filterPrefix(append([]string{"release"}, completionCommands[:len(completionCommands)-1]...), cur)
+		}
+		if len(words) == 1 && words[0] == "config" {
+			return filterPrefix(configSubcommands, cur)
+		}
+		return nil
+	case "completion":
+		if len(words) == 0 {
+			return filterPrefix(completionShells, cur)
+		}
+		return nil
+	case "config":
+		if len(words) == 0 {
+			return filterPrefix(configSubcommands, cur)
+		}
+		return nil
+	}
+	planning := command == "plan"
+	if planning || command == "doctor" || command == "promote" {
+		command = "release"
+	}
+	if _, ok := configCommands[command]; !ok {
+		return nil
+	}
+	fs, err := commandFlags(command, nil, d)
+	if err != nil {
+		return nil
+	}
+	if planning {
+		fs.Bool("json", false, "")
+	}
+
+	// bash splits "--flag=value" at "=", so the value arrives as its own word.
+	if len(words) >= 2 && words[len(words)-1] == "=" {
+		words = words[:len(words)-1]
+	} else if cur == "=" && len(words) > 0 {
+		cur = ""
+	} else if name, value, found := strings.Cut(strings.TrimLeft(cur, "-"), "="); found && strings.HasPrefix(cur, "-") {
+		prefix := cur[:len(cur)-len(value)]
+		var out []string
+		for _, candidate := range completeFlagValue(fs, command, words, name, value, d) {
+			out = append(out, prefix+candidate)
+		}
+		return out
+	}
+	if len(words) > 0 {
+		prev := words[len(words)-1]
+		if strings.HasPrefix(prev, "-") && !strings.Contains(prev, "=") {
+			if f := fs.Lookup(strings.TrimLeft(prev, "-")); f != nil && !isBoolFlag(f) {
+				return completeFlagValue(fs, command, words[:len(words)-1], f.Name, cur, d)
+			}
+		}
+	}
+	if cur != "" && !strings.HasPrefix(cur, "-") {
+		// No command takes positional arguments.
+		return nil
+	}
+	var flags []string
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, "--"+f.Name)
+	})
+	return filterPrefix(flags, cur)
+}
+
+// completeFlagValue completes a value for flag name; typed holds the
+// complete flags before it, which may change the changelog or tag prefix.
+func completeFlagValue(fs *flag.FlagSet, command string, typed []string, name, cur string, d deps) []string {
+	if choices := configSchema[name].choices; len(choices) > 0 {
+		return filterPrefix(choices, cur)
+	}
+	switch {
+	case name == "remote":
+		git := d.newGit(commonConfig{}.gitOptions(d.ctx, io.Discard, io.Discard))
+		remotes, err := git.Remotes()
+		if err != nil {
+			return nil
+		}
+		return filterPrefix(remotes, cur)
+	case name == "target" || (command == "backport" && name == "commit"):
+		return filterPrefix(changelogTags(command, typed, d), cur)
+	case name == configFlag || configPathFlags[name]:
+		return completeFiles(cur)
+	}
+	return nil
+}
+
+// changelogTags lists the release tag of every changelog version, using the
+// changelog and tag prefix the command would use.
+func changelogTags(command string, typed []string, d deps) []string {
+	fs, err := commandFlags(command, typed, d)
+	if err != nil {
+		return nil
+	}
+	configPath := ""
+	if f := fs.Lookup(configFlag); f != nil {
+		configPath = f.Value.String()
+	}
+	if _, _, err := mergeConfig(fs, command, configPath, d); err != nil {
+		return nil
+	}
+	prefix := "v"
+	if f := fs.Lookup("tag-prefix"); f != nil {
+		prefix = f.Value.String()
+	}
+	path := ""
+	if f := fs.Lookup("changelog"); f != nil {
+		path = f.Value.String()
+	}
+	versions, err := changelog.Versions(resolveChangelogPath(path, d.getenv))
+	if err != nil {
+		return nil
+	}
+	tags := make([]string, len(versions))
+	for i, version := range versions {
+		tags[i] = prefix + version
+	}
+	return tags
+}
+
+func completeFiles(cur string) []string {
+	matches, err := filepath.Glob(cur + "*")
+	if err != nil {
+		return nil
+	}
+	for i, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			matches[i] = match + string(filepath.Separator)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
+
+func filterPrefix(values []string, prefix string) []string {
+	var out []string
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+const bashCompletion = `# bash completion for mdrelease
+# Load it in the current shell with: source <(mdrelease completion bash)
+_mdrelease() {
+    local IFS=$'\n'
+    COMPREPLY=($(mdrelease __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+        compopt -o nospace
+    fi
+}
+complete -F _mdrelease mdrelease
+`
+
+const zshCompletion = `#compdef mdrelease
+# zsh completion for mdrelease
+# Load it in the current shell with: source <(mdrelease completion zsh)
+_mdrelease() {
+    local -a candidates
+    local candidate
+    candidates=("${(@f)$(mdrelease __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    for candidate in $candidates; do
+        [[ -z $candidate ]] && continue
+        if [[ $candidate == */ ]]; then
+            compadd -Q -S '' -- $candidate
+        else
+            compadd -Q -- $candidate
+        fi
+    done
+}
+compdef _mdrelease mdrelease
+`
+
+const fishCompletion = `# fish completion for mdrelease
+# Load it in the current shell with: mdrelease completion fish | source
+function __mdrelease_complete
+    set -l words (commandline -opc)
+    set -l current (commandline -ct)
+    mdrelease __complete $words[2..-1] "$current" 2>/dev/null
+end
+complete -c mdrelease -f -a '(__mdrelease_complete)'
+`
+
+const powershellCompletion = `# PowerShell completion for mdrelease
+# Load it in the current session with: mdrelease completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName mdrelease -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.StartOffset -lt $cursorPosition } |
+        Select-Object -Skip 1 |
+        ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '""' }
+    & mdrelease __complete @words 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
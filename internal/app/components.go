@@ -0,0 +1,430 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/config"
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+	"github.com/jasonwillschiu/mdrelease/internal/goproxy"
+)
+
+// componentSectionPrefix starts the config sections that define the
+// independently released parts of a monorepo, such as [components.api].
+const componentSectionPrefix = "components."
+
+var componentKeys = []string{"path", "changelog", "tag-prefix", "depends-on"}
+
+// component paths are absolute: they are resolved against the config file
+// that defines them, like other path settings.
+type component struct {
+	name      string
+	path      string
+	changelog string
+	tagPrefix string
+
+	// goTagPrefix is the prefix the Go toolchain requires when the component
+	// is a Go module: its directory relative to the repository root.
+	goTagPrefix string
+	goModule    string
+	dependsOn   []string
+}
+
+// loadComponents reads every [components.<name>] section, sorted by name. A
+// component defined in the project config wins over the global config.
+func loadComponents(configPath string, d deps) ([]component, error) {
+	paths, err := configFiles(configPath, d)
+	if err != nil {
+		return nil, err
+	}
+	var components []component
+	for _, path := range paths {
+		file, err := config.Load(path)
+		if err != nil {
+			return nil, &usageError{msg: fmt.Sprintf("config: %v", err)}
+		}
+		for section := range file.Sections {
+			name, ok := strings.CutPrefix(section, componentSectionPrefix)
+			if !ok || slices.ContainsFunc(components, func(c component) bool { return c.name == name }) {
+				continue
+			}
+			c, err := parseComponent(file, section, name)
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, c)
+		}
+	}
+	sort.Slice(components, func(i, j int) bool { return components[i].name < components[j].name })
+	return components, nil
+}
+
+func parseComponent(file *config.File, section, name string) (component, error) {
+	if name == "" || strings.ContainsAny(name, "/ \t") {
+		return component{}, &usageError{msg: fmt.Sprintf("config: %s: invalid component name %q in [%s]", file.Path, name, section)}
+	}
+	values := map[string]string{}
+	var dependsOn []string
+	for _, key := range file.Keys(section) {
+		v := file.Sections[section][key]
+		if msg := validateComponentEntry(key, v); msg != "" {
+			return component{}, &usageError{msg: fmt.Sprintf("config: %s:%d: %s", file.Path, v.Line, msg)}
+		}
+		name := strings.ReplaceAll(key, "_", "-")
+		if name == "depends-on" {
+			dependsOn = append(dependsOn, v.Items...)
+			continue
+		}
+		values[name] = v.Items[0]
+	}
+	dir := filepath.Dir(file.Path)
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	c := component{name: name, path: resolve(name), tagPrefix: name + "/v", dependsOn: dependsOn}
+	if p := values["path"]; p != "" {
+		c.path = resolve(p)
+	}
+	c.changelog = filepath.Join(c.path, changelog.DefaultPath)
+	if p := values["changelog"]; p != "" {
+		c.changelog = resolve(p)
+	}
+	prefix, err := goModuleTagPrefix(c.path)
+	if err != nil {
+		return component{}, err
+	}
+	c.goTagPrefix = prefix
+	if prefix != "" {
+		c.tagPrefix = prefix
+		if c.goModule, err = goproxy.ModulePath(filepath.Join(c.path, "go.mod")); err != nil {
+			return component{}, &usageError{msg: fmt.Sprintf("config: [%s]: %v", section, err)}
+		}
+	}
+	if prefix, ok := values["tag-prefix"]; ok {
+		c.tagPrefix = prefix
+	}
+	return c, nil
+}
+
+// goModuleTagPrefix returns the tag prefix for the Go module rooted at dir:
+// "sub/dir/v" for a nested module, "v" at the repository root, and "" when
+// dir has no go.mod.
+func goModuleTagPrefix(dir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	root := dir
+	for {
+		if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			// Outside a repository the module root is as good as any.
+			root = dir
+			break
+		}
+		root = parent
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", err
+	}
+	if rel == "." {
+		return "v", nil
+	}
+	return filepath.ToSlash(rel) + "/v", nil
+}
+
+// checkGoModuleTag reports a component tag that `go get` would not find.
+func checkGoModuleTag(c *component) error {
+	if c.goTagPrefix == "" || c.tagPrefix == c.goTagPrefix {
+		return nil
+	}
+	return &preflightError{msg: fmt.Sprintf("component %s is a Go module, so its tags must start with %q, not %q (remove tag-prefix from [components.%s] to use the module path)", c.name, c.goTagPrefix, c.tagPrefix, c.name)}
+}
+
+func validateComponentEntry(key string, v config.Value) string {
+	if !slices.Contains(componentKeys, strings.ReplaceAll(key, "_", "-")) {
+		return fmt.Sprintf("unknown component setting %q (expected %s)", key, strings.Join(componentKeys, ", "))
+	}
+	if v.List && strings.ReplaceAll(key, "_", "-") != "depends-on" {
+		return fmt.Sprintf("component setting %s takes a single value, not a list", key)
+	}
+	return ""
+}
+
+func findComponent(components []component, name string) (*component, error) {
+	for i := range components {
+		if components[i].name == name {
+			return &components[i], nil
+		}
+	}
+	if len(components) == 0 {
+		return nil, &usageError{msg: fmt.Sprintf("unknown component %q (define it in a [components.%s] config section)", name, name)}
+	}
+	names := make([]string, len(components))
+	for i, c := range components {
+		names[i] = c.name
+	}
+	return nil, &usageError{msg: fmt.Sprintf("unknown component %q (configured: %s)", name, strings.Join(names, ", "))}
+}
+
+// componentDeps returns the components c must be released after: those
+// named in depends-on and those whose Go module its go.mod requires.
+func componentDeps(c component, components []component) ([]string, error) {
+	deps := slices.Clone(c.dependsOn)
+	for _, name := range deps {
+		if !slices.ContainsFunc(components, func(o component) bool { return o.name == name }) {
+			return nil, &usageError{msg: fmt.Sprintf("component %s depends on unknown component %q", c.name, name)}
+		}
+	}
+	if c.goModule == "" {
+		return deps, nil
+	}
+	requires, err := goproxy.Requires(filepath.Join(c.path, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range components {
+		if _, ok := requires[o.goModule]; ok && o.goModule != "" && o.name != c.name && !slices.Contains(deps, o.name) {
+			deps = append(deps, o.name)
+		}
+	}
+	return deps, nil
+}
+
+// orderComponents sorts components so each comes after its dependencies,
+// keeping name order among independent components.
+func orderComponents(components []component) ([]component, error) {
+	deps := map[string][]string{}
+	for _, c := range components {
+		d, err := componentDeps(c, components)
+		if err != nil {
+			return nil, err
+		}
+		deps[c.name] = d
+	}
+	var ordered []component
+	done := map[string]bool{}
+	for len(ordered) < len(components) {
+		progressed := false
+		for _, c := range components {
+			if done[c.name] || slices.ContainsFunc(deps[c.name], func(name string) bool { return !done[name] }) {
+				continue
+			}
+			ordered = append(ordered, c)
+			done[c.name] = true
+			progressed = true
+			break
+		}
+		if !progressed {
+			var cycle []string
+			for _, c := range components {
+				if !done[c.name] {
+					cycle = append(cycle, c.name)
+				}
+			}
+			return nil, &usageError{msg: fmt.Sprintf("component dependencies form a cycle among: %s", strings.Join(cycle, ", "))}
+		}
+	}
+	return ordered, nil
+}
+
+// bumpDependents points the go.mod of every component that requires
+// released's module at the new version, so the dependent's own release
+// commits the update.
+func bumpDependents(released component, version string, components []component, dryRun bool, stdout io.Writer) error {
+	if released.goModule == "" {
+		return nil
+	}
+	for _, c := range components {
+		if c.goModule == "" || c.name == released.name {
+			continue
+		}
+		goMod := filepath.Join(c.path, "go.mod")
+		requires, err := goproxy.Requires(goMod)
+		if err != nil {
+			return err
+		}
+		if current, ok := requires[released.goModule]; !ok || current == version {
+			continue
+		}
+		if dryRun {
+			dryRunf(stdout, "require %s %s in %s", released.goModule, version, goMod)
+			continue
+		}
+		if _, err := goproxy.SetRequire(goMod, released.goModule, version); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(stdout, "Required %s %s in %s\n", released.goModule, version, goMod)
+	}
+	return nil
+}
+
+// applyComponent points cfg at the named component's changelog and tags.
+func applyComponent(name, configPath string, cfg *commonConfig, d deps) (*component, error) {
+	components, err := loadComponents(configPath, d)
+	if err != nil {
+		return nil, err
+	}
+	c, err := findComponent(components, name)
+	if err != nil {
+		return nil, err
+	}
+	cfg.changelogPath = c.changelog
+	cfg.tagPrefix = c.tagPrefix
+	return c, nil
+}
+
+// checkComponents prints one row per component with its changelog version,
+// tag, and whether the tag is still free. It fails when a component cannot
+// be released and reports nothing to release when every tag exists.
+func checkComponents(cfg commonConfig, configPath string, rules *rulePolicy, d deps, stdout, stderr, result io.Writer) error {
+	components, err := loadComponents(configPath, d)
+	if err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		return &usageError{msg: "--all-components needs at least one [components.<name>] config section"}
+	}
+	_, _ = fmt.Fprintln(stdout, "Release check:")
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := checkGit(git, cfg, stdout); err != nil {
+		return err
+	}
+
+	var pending, failed []string
+	table := tabwriter.NewWriter(result, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "COMPONENT\tVERSION\tTAG\tSTATUS")
+	for _, c := range components {
+		version, tag, status := "-", "-", "available"
+		entry, err := cfg.versions.changelog.ParseLatest(c.changelog)
+		switch {
+		case err != nil:
+			status = "error: " + err.Error()
+			failed = append(failed, c.name)
+		default:
+			version, tag = entry.Version, c.tagPrefix+entry.Version
+			released := git.EnsureTagAbsent(tag) != nil
+			if !released {
+				// The remote may have tags the fetch skipped, as in a dry run;
+				// its listing is shared by every component.
+				if released, err = git.HasRemoteTag(cfg.remote, tag); err != nil {
+					return err
+				}
+			}
+			if err := checkGoModuleTag(&c); err != nil {
+				status = "error: tag prefix must be " + strconv.Quote(c.goTagPrefix)
+				failed = append(failed, c.name)
+			} else if released {
+				status = "released"
+			} else if rules.modes[ruleTicket] != ruleOff && !hasTicket(rules.ticket, entry) {
+				if rules.modes[ruleTicket] == ruleFail {
+					status = "error: no ticket matching " + rules.ticket.String()
+					failed = append(failed, c.name)
+				} else {
+					status = "available, no ticket matching " + rules.ticket.String()
+					pending = append(pending, c.name)
+				}
+			} else {
+				pending = append(pending, c.name)
+			}
+		}
+		_, _ = fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", c.name, version, tag, status)
+	}
+	_ = table.Flush()
+
+	switch {
+	case len(failed) > 0:
+		return &preflightError{msg: fmt.Sprintf("%d component(s) cannot be released: %s", len(failed), strings.Join(failed, ", "))}
+	case len(pending) == 0:
+		return &noOpError{msg: "no component has a new changelog version to release"}
+	}
+	_, _ = fmt.Fprintln(result, paint(result, colorGreen, fmt.Sprintf("Check passed: %d component(s) ready to release: %s", len(pending), strings.Join(pending, ", "))))
+	return nil
+}
+
+// releaseComponents runs the release once per component with the same
+// flags, dependencies first. Components without a new changelog version are
+// skipped, and the first failure stops the components after it.
+func releaseComponents(args []string, cfg commonConfig, configPath string, nothingReleased string, bump bool, stdout, stderr io.Writer, d deps) error {
+	components, err := loadComponents(configPath, d)
+	if err != nil {
+		return err
+	}
+	if len(components) == 0 {
+		return &usageError{msg: "--all-components needs at least one [components.<name>] config section"}
+	}
+	if components, err = orderComponents(components); err != nil {
+		return err
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	narration := cfg.narration(result)
+
+	// Every component asks about its own tags on the same remote; list the
+	// remote's tags once for all of them.
+	tagCache := gitutil.NewRemoteTagCache()
+	var released []string
+	for _, c := range components {
+		_, _ = fmt.Fprintf(narration, "Component %s:\n", c.name)
+		cd := d
+		cd.component = c.name
+		cd.newGit = func(opts gitutil.Options) gitOps {
+			opts.TagCache = tagCache
+			return d.newGit(opts)
+		}
+		err := release(args, stdout, stderr, cd, nil)
+		if noOp := new(noOpError); errors.As(err, &noOp) {
+			_, _ = fmt.Fprintf(narration, "Skipping component %s: %v\n", c.name, noOp)
+			continue
+		}
+		if err != nil {
+			if len(released) > 0 {
+				return fmt.Errorf("component %s: %w (already released: %s)", c.name, err, strings.Join(released, ", "))
+			}
+			return fmt.Errorf("component %s: %w", c.name, err)
+		}
+		released = append(released, c.name)
+		if bump {
+			entry, err := cfg.versions.changelog.ParseLatest(c.changelog)
+			if err != nil {
+				return err
+			}
+			if err := bumpDependents(c, "v"+entry.Version, components, cfg.dryRun, narration); err != nil {
+				return fmt.Errorf("component %s: update dependents: %w", c.name, err)
+			}
+		}
+	}
+	if len(released) == 0 {
+		msg := "no component has a new changelog version to release"
+		switch nothingReleased {
+		case outcomeError:
+			return &preflightError{msg: fmt.Sprintf("%s (--fail-on %s)", msg, conditionTagExists)}
+		case outcomeNoOp:
+			return &noOpError{msg: msg}
+		}
+		_, _ = fmt.Fprintf(result, "%s: %s\n", paint(result, colorYellow, "Nothing to release"), msg)
+		return nil
+	}
+	_, _ = fmt.Fprintf(result, "Released %d of %d component(s): %s\n", len(released), len(components), strings.Join(released, ", "))
+	return nil
+}
@@ -0,0 +1,229 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/config"
+)
+
+const (
+	configFlag = "config"
+	configEnv  = "MDRELEASE_CONFIG"
+)
+
+var configSections = []string{"release", "check", "version", "tag-message", "export", "site", "import-tags", "audit-tags", "backport", "resume", "pr", "changed", "next", "latest", "undo"}
+
+// configTables are sections whose key = value pairs feed a repeatable flag
+// as "key=value", for settings that read better as a table than a list.
+var configTables = map[string]string{"version-files": "version-file", "branch-tag-prefixes": "branch-tag-prefix"}
+
+func addConfigFlag(fs *flag.FlagSet, path *string) {
+	fs.StringVar(path, configFlag, "", "Config file with default flag values (default: $MDRELEASE_CONFIG, then the nearest .mdrelease.toml, .mdrelease.yaml, or .mdrelease.yml up to the repository root; $XDG_CONFIG_HOME/mdrelease/config.toml is merged in below it)")
+}
+
+// configPathFlags take file paths, which are resolved relative to the config
+// file that sets them so a project config works from any subdirectory.
+var configPathFlags = map[string]bool{"changelog": true, "asset": true, "go-mod": true, "cosign-key": true, "version-file": true, "write-version": true, "write-go-version": true, "output": true}
+
+func resolveConfigPath(file, name, item string) string {
+	if !configPathFlags[name] || item == "" || filepath.IsAbs(item) || strings.Contains(item, "://") {
+		return item
+	}
+	if name == "version-file" {
+		// Only the path before "=" is a path; the pattern is kept as is.
+		path, pattern, ok := strings.Cut(item, "=")
+		if !ok || filepath.IsAbs(path) {
+			return filepath.Join(filepath.Dir(file), item)
+		}
+		return filepath.Join(filepath.Dir(file), path) + "=" + pattern
+	}
+	return filepath.Join(filepath.Dir(file), item)
+}
+
+func configFiles(path string, d deps) ([]string, error) {
+	getenv := d.getenv
+	if getenv == nil {
+		getenv = func(string) string { return "" }
+	}
+	if path == "" {
+		path = strings.TrimSpace(getenv(configEnv))
+	}
+	var files []string
+	if path == "" {
+		getwd := d.getwd
+		if getwd == nil {
+			getwd = os.Getwd
+		}
+		dir, err := getwd()
+		if err != nil {
+			return nil, err
+		}
+		if path, err = config.FindUp(dir); err != nil {
+			return nil, &usageError{msg: err.Error()}
+		}
+	}
+	if path != "" {
+		files = append(files, path)
+	}
+	global, err := config.FindGlobal(config.GlobalDir(getenv("XDG_CONFIG_HOME"), getenv("HOME")))
+	if err != nil {
+		return nil, &usageError{msg: err.Error()}
+	}
+	if global != "" {
+		files = append(files, global)
+	}
+	return files, nil
+}
+
+// applyConfig fills every flag that was not given on the command line from
+// the project config, then the global config: in each file the command's own
+// section wins over top-level keys.
+func applyConfig(fs *flag.FlagSet, command, path string, d deps) error {
+	if d.inspectConfig != nil {
+		return d.inspectConfig(fs, command, path)
+	}
+	_, _, err := mergeConfig(fs, command, path, d)
+	return err
+}
+
+// secretFlags are the flags whose values usually embed a credential, such
+// as webhook URLs; the release journal does not record them.
+var secretFlags = []string{"webhook", "slack-webhook", "discord-webhook"}
+
+// applyConfigFlags is applyConfig for only the flags in names that were not
+// given on the command line; every other key in the config is checked but
+// not applied.
+func applyConfigFlags(fs *flag.FlagSet, command, path string, d deps, names ...string) error {
+	explicit := visitedFlags(fs)
+	only := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	fs.VisitAll(func(f *flag.Flag) {
+		if slices.Contains(names, f.Name) && !explicit[f.Name] {
+			only.Var(f.Value, f.Name, f.Usage)
+			return
+		}
+		only.Var(new(stringList), f.Name, f.Usage)
+	})
+	return applyConfig(only, command, path, d)
+}
+
+// mergeConfig applies the config files to fs and reports which files it read
+// and the file:line each applied flag came from.
+func mergeConfig(fs *flag.FlagSet, command, path string, d deps) ([]string, map[string]string, error) {
+	paths, err := configFiles(path, d)
+	if err != nil {
+		return nil, nil, err
+	}
+	getenv := d.getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	explicit := visitedFlags(fs)
+	sources := map[string]string{}
+	for _, path := range paths {
+		file, err := config.Load(path)
+		if err != nil {
+			return nil, nil, &usageError{msg: fmt.Sprintf("config: %v", err)}
+		}
+		if err := applyConfigFile(fs, command, file, getenv, explicit, sources); err != nil {
+			return nil, nil, err
+		}
+	}
+	return paths, sources, nil
+}
+
+func applyConfigFile(fs *flag.FlagSet, command string, file *config.File, getenv func(string) string, explicit map[string]bool, sources map[string]string) error {
+	for section := range file.Sections {
+		if strings.HasPrefix(section, componentSectionPrefix) {
+			continue
+		}
+		if _, table := configTables[section]; section != "" && !table && !slices.Contains(configSections, section) {
+			return &usageError{msg: fmt.Sprintf("config: %s: unknown section %q (expected %s)", file.Path, section, strings.Join(configSections, ", "))}
+		}
+	}
+	for _, section := range slices.Sorted(maps.Keys(configTables)) {
+		if err := applyConfigTable(fs, file, section, getenv, explicit, sources); err != nil {
+			return err
+		}
+	}
+	for _, section := range []string{command, ""} {
+		for _, key := range file.Keys(section) {
+			v := file.Sections[section][key]
+			name := strings.ReplaceAll(key, "_", "-")
+			f := fs.Lookup(name)
+			if f == nil || name == configFlag {
+				if section == "" {
+					continue
+				}
+				return &usageError{msg: fmt.Sprintf("config: %s:%d: unknown %s setting %q", file.Path, v.Line, command, key)}
+			}
+			if explicit[name] || sources[name] != "" || envOverrides(fs, name, getenv) {
+				continue
+			}
+			sources[name] = fmt.Sprintf("%s:%d", file.Path, v.Line)
+			if _, repeatable := f.Value.(*stringList); v.List && !repeatable {
+				return &usageError{msg: fmt.Sprintf("config: %s:%d: %s takes a single value, not a list", file.Path, v.Line, key)}
+			}
+			for _, item := range v.Items {
+				item, err := config.Expand(item, getenv)
+				if err != nil {
+					return &usageError{msg: fmt.Sprintf("config: %s:%d: %s: %v", file.Path, v.Line, key, err)}
+				}
+				item = resolveConfigPath(file.Path, name, item)
+				if err := fs.Set(name, item); err != nil {
+					return &usageError{msg: fmt.Sprintf("config: %s:%d: invalid value %q for %s: %v", file.Path, v.Line, item, key, err)}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// envOverrides reports whether an environment variable such as
+// $MDRELEASE_CHANGELOG is set for flag name, which then takes precedence
+// over the config files; the flag stays unset so the command reads the
+// variable as usual. The variables a CI system sets for every job to name
+// its repository and token, such as $GITHUB_REPOSITORY or $CI_JOB_TOKEN,
+// only fill in what the config leaves empty.
+func envOverrides(fs *flag.FlagSet, name string, getenv func(string) string) bool {
+	for _, env := range flagEnvNames(fs, name) {
+		if strings.HasPrefix(name, "forge-") && !strings.HasPrefix(env, "MDRELEASE_") {
+			continue
+		}
+		if strings.TrimSpace(getenv(env)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func applyConfigTable(fs *flag.FlagSet, file *config.File, section string, getenv func(string) string, explicit map[string]bool, sources map[string]string) error {
+	name := configTables[section]
+	keys := file.Keys(section)
+	if fs.Lookup(name) == nil || len(keys) == 0 || explicit[name] || sources[name] != "" {
+		return nil
+	}
+	for _, key := range keys {
+		v := file.Sections[section][key]
+		if v.List {
+			return &usageError{msg: fmt.Sprintf("config: %s:%d: [%s] %s takes a single value, not a list", file.Path, v.Line, section, key)}
+		}
+		value, err := config.Expand(v.Items[0], getenv)
+		if err != nil {
+			return &usageError{msg: fmt.Sprintf("config: %s:%d: %s: %v", file.Path, v.Line, key, err)}
+		}
+		item := resolveConfigPath(file.Path, name, key+"="+value)
+		if err := fs.Set(name, item); err != nil {
+			return &usageError{msg: fmt.Sprintf("config: %s:%d: invalid value %q for [%s] %s: %v", file.Path, v.Line, value, section, key, err)}
+		}
+		if sources[name] == "" {
+			sources[name] = fmt.Sprintf("%s:%d", file.Path, v.Line)
+		}
+	}
+	return nil
+}
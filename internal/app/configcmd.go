@@ -0,0 +1,451 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/config"
+	"github.com/jasonwillschiu/mdrelease/internal/notify"
+)
+
+func runConfig(args []string, stdout, stderr io.Writer, d deps) error {
+	if len(args) == 0 {
+		printConfigUsage(stderr)
+		return &usageError{msg: "config requires a subcommand (init, show, or validate)"}
+	}
+	switch args[0] {
+	case "-h", "-help", "--help":
+		printConfigUsage(stdout)
+		return nil
+	case "init":
+		return runConfigInit(args[1:], stdout, stderr, d)
+	case "show":
+		return runConfigShow(args[1:], stdout, stderr, d)
+	case "validate":
+		return runConfigValidate(args[1:], stdout, stderr, d)
+	default:
+		return &usageError{msg: fmt.Sprintf("unknown config command: %s", args[0])}
+	}
+}
+
+func printConfigUsage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "Usage:")
+	_, _ = fmt.Fprintln(w, "  mdrelease config init [flags]  Write a commented starter .mdrelease.toml for this repository")
+	_, _ = fmt.Fprintln(w, "  mdrelease config show [flags]  Print the config files in effect and their settings")
+	_, _ = fmt.Fprintln(w, "  mdrelease config show --effective [--command <name>] [-- <command flags>]")
+	_, _ = fmt.Fprintln(w, "                                 Print every flag value a command would use and where it came from")
+	_, _ = fmt.Fprintln(w, "  mdrelease config validate      Check config files for unknown keys, invalid values, and conflicting settings")
+}
+
+type detectedRepo struct {
+	remote        string
+	defaultBranch string
+	changelog     string
+	changelogOK   bool
+	tagPrefix     string
+	latestTag     string
+	forge         string
+}
+
+func runConfigInit(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease config init", stderr)
+
+	var cfg commonConfig
+	var format, output string
+	var force, toStdout bool
+	fs.StringVar(&format, "format", config.FormatTOML, "Config format: toml or yaml")
+	fs.StringVar(&output, "output", "", "Path to write (default: .mdrelease.toml or .mdrelease.yaml at the repository root)")
+	fs.BoolVar(&force, "force", false, "Overwrite an existing config file")
+	fs.BoolVar(&toStdout, "stdout", false, "Print the config instead of writing a file")
+	fs.StringVar(&cfg.gitPath, "git-path", "", "Path to the git executable (default: $MDRELEASE_GIT_PATH, then git on PATH)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "config init does not accept positional arguments"}
+	}
+	if format != config.FormatTOML && format != config.FormatYAML {
+		return &usageError{msg: fmt.Sprintf("invalid --format value %q (expected toml or yaml)", format)}
+	}
+	cfg.resolveGitEnv(d.getenv)
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	top, err := git.TopLevel()
+	if err != nil {
+		return err
+	}
+	repo, err := detectRepo(git, top, d)
+	if err != nil {
+		return err
+	}
+	content, err := config.Render(format, configInitHeader, configInitSections(repo))
+	if err != nil {
+		return err
+	}
+	if toStdout {
+		_, _ = io.WriteString(stdout, content)
+		return nil
+	}
+
+	if output == "" {
+		output = filepath.Join(top, ".mdrelease."+format)
+	}
+	if !force {
+		existing, err := config.Find(filepath.Dir(output))
+		if err != nil {
+			return &preflightError{msg: err.Error()}
+		}
+		if existing == "" {
+			if _, err := os.Stat(output); err == nil {
+				existing = output
+			}
+		}
+		if existing != "" {
+			return &preflightError{msg: fmt.Sprintf("config already exists at %s (pass --force to overwrite or --stdout to print)", existing)}
+		}
+	}
+	if err := os.WriteFile(output, []byte(content), 0o644); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "Wrote %s\n", output)
+	_, _ = fmt.Fprintln(stdout, "Review the suggested settings, then run `mdrelease check`.")
+	return nil
+}
+
+func detectRepo(git gitOps, top string, d deps) (detectedRepo, error) {
+	repo := detectedRepo{remote: "origin", tagPrefix: "v"}
+	remotes, err := git.Remotes()
+	if err != nil {
+		return repo, err
+	}
+	if len(remotes) > 0 && !slices.Contains(remotes, "origin") {
+		repo.remote = remotes[0]
+	}
+	if len(remotes) > 0 {
+		if repo.defaultBranch, err = git.DefaultBranch(repo.remote); err != nil {
+			return repo, err
+		}
+		repo.forge = detectRemote(git, repo.remote).Kind
+	}
+	if repo.defaultBranch == "" {
+		if repo.defaultBranch, err = git.CurrentBranch(); err != nil {
+			return repo, err
+		}
+	}
+	if latest, err := git.PreviousTag("HEAD", ""); err == nil && latest != "" {
+		repo.latestTag = latest
+		if i := strings.IndexAny(latest, "0123456789"); i >= 0 {
+			repo.tagPrefix = latest[:i]
+		}
+	}
+
+	path := resolveChangelogPath("", d.getenv)
+	if !filepath.IsAbs(path) {
+		getwd := d.getwd
+		if getwd == nil {
+			getwd = os.Getwd
+		}
+		if wd, err := getwd(); err == nil {
+			path = filepath.Join(wd, path)
+		}
+	}
+	_, statErr := os.Stat(path)
+	repo.changelogOK = statErr == nil
+	if rel, err := filepath.Rel(top, path); err == nil && !strings.HasPrefix(rel, "..") {
+		path = filepath.ToSlash(rel)
+	}
+	repo.changelog = path
+	return repo, nil
+}
+
+const configInitHeader = `mdrelease configuration, generated by ` + "`mdrelease config init`" + `.
+Keys are flag names; flags on the command line override these values.
+Commented-out lines are suggestions: uncomment them to enable.`
+
+func configInitSections(repo detectedRepo) []config.Section {
+	changelogComment := "Changelog with the release entries"
+	if !repo.changelogOK {
+		changelogComment += " (not found yet; create it before releasing)"
+	}
+	tagComment := "Prefix of release tags"
+	if repo.latestTag != "" {
+		tagComment += fmt.Sprintf(" (detected from %s)", repo.latestTag)
+	}
+	forge, forgeComment := repo.forge, fmt.Sprintf("Publish a forge release after pushing the tag (detected from the %s remote URL)", repo.remote)
+	if forge == "" {
+		forge, forgeComment = forgeAuto, "Publish a forge release after pushing the tag"
+	}
+
+	sections := []config.Section{
+		{Settings: []config.Setting{
+			{Key: "changelog", Value: []string{repo.changelog}, Comment: changelogComment},
+			{Key: "remote", Value: []string{repo.remote}, Comment: "Git remote to fetch from and push to"},
+			{Key: "tag-prefix", Value: []string{repo.tagPrefix}, Comment: tagComment},
+		}},
+		{Name: "release", Comment: "Settings for the default `mdrelease` run", Settings: []config.Setting{
+			{Key: "forge", Value: []string{forge}, Comment: forgeComment, Disabled: true},
+			{Key: "asset", Value: []string{"dist/*"}, List: true, Comment: "Build artifacts to upload to the forge release", Disabled: true},
+			{Key: "rollback-on-failure", Value: []string{"true"}, Comment: "Delete the local tag if a later step fails", Disabled: true},
+		}},
+	}
+	if repo.defaultBranch != "" {
+		sections = append(sections, config.Section{Name: "pr", Comment: "Settings for `mdrelease pr`", Settings: []config.Setting{
+			{Key: "base", Value: []string{repo.defaultBranch}, Comment: fmt.Sprintf("Branch release pull requests target (%s default branch)", repo.remote)},
+		}})
+	}
+	return sections
+}
+
+const sourceCommandLine = "command line"
+
+var errConfigInspected = errors.New("config inspected")
+
+var configCommands = map[string]func([]string, io.Writer, io.Writer, deps) error{
+	"release":     runRelease,
+	"check":       runCheck,
+	"version":     runRepoVersion,
+	"tag-message": runTagMessage,
+	"export":      runExport,
+	"site":        runSite,
+	"import-tags": runImportTags,
+	"audit-tags":  runAuditTags,
+	"backport":    runBackport,
+	"resume":      runResume,
+	"pr":          runPR,
+	"changed":     runChanged,
+	"next":        runNext,
+	"latest":      runLatest,
+	"undo":        runUndo,
+}
+
+func runConfigShow(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease config show", stderr)
+
+	var configPath, command string
+	var effective bool
+	addConfigFlag(fs, &configPath)
+	fs.BoolVar(&effective, "effective", false, "Print the merged value and source of every flag of --command")
+	fs.StringVar(&command, "command", "release", "Command whose effective settings to print: "+strings.Join(configSections, ", "))
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if !effective {
+		if fs.NArg() != 0 {
+			return &usageError{msg: "config show accepts command flags only with --effective"}
+		}
+		return showConfigFiles(configPath, stdout, d)
+	}
+	runCommand, ok := configCommands[command]
+	if !ok {
+		return &usageError{msg: fmt.Sprintf("invalid --command value %q (expected %s)", command, strings.Join(configSections, ", "))}
+	}
+	getenv := d.getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	d.inspectConfig = func(cmdFlags *flag.FlagSet, command, configPath string) error {
+		explicit := visitedFlags(cmdFlags)
+		files, sources, err := mergeConfig(cmdFlags, command, configPath, d)
+		if err != nil {
+			return err
+		}
+		for name := range explicit {
+			sources[name] = sourceCommandLine
+		}
+		printEffectiveConfig(stdout, command, cmdFlags, files, sources, getenv)
+		return errConfigInspected
+	}
+	return inspectCommand(runCommand, configPath, fs.Args(), stdout, stderr, d)
+}
+
+// inspectCommand runs a command only as far as its config is applied; d must
+// have inspectConfig set.
+func inspectCommand(runCommand func([]string, io.Writer, io.Writer, deps) error, configPath string, args []string, stdout, stderr io.Writer, d deps) error {
+	if configPath != "" {
+		args = append([]string{"--" + configFlag, configPath}, args...)
+	}
+	err := runCommand(args, stdout, stderr, d)
+	if errors.Is(err, errConfigInspected) {
+		return nil
+	}
+	if err == nil {
+		return &usageError{msg: "could not inspect the command flags"}
+	}
+	return err
+}
+
+func showConfigFiles(configPath string, stdout io.Writer, d deps) error {
+	paths, err := configFiles(configPath, d)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		_, _ = fmt.Fprintln(stdout, "No config file found (run `mdrelease config init` to create one).")
+		return nil
+	}
+	for i, path := range paths {
+		file, err := config.Load(path)
+		if err != nil {
+			return &usageError{msg: fmt.Sprintf("config: %v", err)}
+		}
+		if i > 0 {
+			_, _ = fmt.Fprintln(stdout)
+		}
+		_, _ = fmt.Fprintf(stdout, "%s\n", path)
+		sections := []string{""}
+		for section := range file.Sections {
+			if section != "" {
+				sections = append(sections, section)
+			}
+		}
+		slices.Sort(sections[1:])
+		for _, section := range sections {
+			keys := file.Keys(section)
+			if len(keys) == 0 {
+				continue
+			}
+			indent := "  "
+			if section != "" {
+				_, _ = fmt.Fprintf(stdout, "  [%s]\n", section)
+				indent = "    "
+			}
+			for _, key := range keys {
+				v := file.Sections[section][key]
+				bare := !v.List && (v.Items[0] == "true" || v.Items[0] == "false")
+				_, _ = fmt.Fprintf(stdout, "%s%s = %s  (line %d)\n", indent, key, displayValue(key, v.Items, v.List, bare), v.Line)
+			}
+		}
+	}
+	return nil
+}
+
+func printEffectiveConfig(w io.Writer, command string, fs *flag.FlagSet, files []string, sources map[string]string, getenv func(string) string) {
+	_, _ = fmt.Fprintf(w, "Effective settings for %s (precedence: command line > environment > project config > global config > default)\n", commandLabel(command))
+	if len(files) == 0 {
+		_, _ = fmt.Fprintln(w, "Config files: none")
+	} else {
+		_, _ = fmt.Fprintf(w, "Config files: %s\n", strings.Join(files, ", "))
+	}
+	width := 0
+	fs.VisitAll(func(f *flag.Flag) { width = max(width, len(f.Name)) })
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == configFlag {
+			return
+		}
+		items, list := []string{f.Value.String()}, false
+		if l, ok := f.Value.(*stringList); ok {
+			items, list = *l, true
+		}
+		source := sources[f.Name]
+		if source == "" {
+			source = "default"
+			if f.Value.String() == "" {
+				for _, name := range flagEnvNames(fs, f.Name) {
+					if v := strings.TrimSpace(getenv(name)); v != "" {
+						items, source = []string{v}, "env "+name
+						break
+					}
+				}
+			}
+		}
+		_, _ = fmt.Fprintf(w, "  %-*s = %s  (%s)\n", width, f.Name, displayValue(f.Name, items, list, isBoolFlag(f)), source)
+	})
+}
+
+func commandLabel(command string) string {
+	if command == "release" {
+		return "`mdrelease`"
+	}
+	return "`mdrelease " + command + "`"
+}
+
+// flagEnvNames lists the environment variables a flag falls back to when it
+// is left empty, in the order the commands consult them.
+func flagEnvNames(fs *flag.FlagSet, name string) []string {
+	switch name {
+	case "changelog":
+		return []string{"MDRELEASE_CHANGELOG"}
+	case "git-token":
+		return []string{"MDRELEASE_GIT_TOKEN"}
+	case "git-path":
+		return []string{"MDRELEASE_GIT_PATH"}
+	case "git-user-name":
+		return []string{"MDRELEASE_GIT_USER_NAME"}
+	case "git-user-email":
+		return []string{"MDRELEASE_GIT_USER_EMAIL"}
+	case "commit-date":
+		return []string{"SOURCE_DATE_EPOCH"}
+	case "go-proxy-url":
+		return []string{"GOPROXY"}
+	case "smtp-password":
+		return []string{"MDRELEASE_SMTP_PASSWORD"}
+	case "forge-token", "forge-repo", "forge-url":
+		kind := fs.Lookup("forge")
+		if kind == nil {
+			return nil
+		}
+		env, ok := forgeEnvs[kind.Value.String()]
+		switch {
+		case !ok:
+			return nil
+		case name == "forge-token":
+			return env.tokens
+		case name == "forge-repo":
+			return []string{env.repo}
+		case env.apiURL != "":
+			return []string{env.apiURL}
+		}
+		return nil
+	}
+	if kind, ok := strings.CutSuffix(name, "-token"); ok && packageTokenEnvs[kind] != "" {
+		return []string{packageTokenEnvs[kind]}
+	}
+	return nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// displayValue formats a setting for config show; tokens and passwords are
+// never printed, and webhook URLs only up to the host.
+func displayValue(key string, items []string, list, bare bool) string {
+	shown := make([]string, len(items))
+	for i, item := range items {
+		switch {
+		case item != "" && (strings.HasSuffix(key, "token") || strings.HasSuffix(key, "password")):
+			shown[i] = "<redacted>"
+		case slices.Contains(secretFlags, strings.ReplaceAll(key, "_", "-")) && strings.Contains(item, "://"):
+			shown[i] = strconv.Quote(notify.Redact(item))
+		case bare && item != "":
+			shown[i] = item
+		default:
+			shown[i] = strconv.Quote(item)
+		}
+	}
+	if !list && len(shown) == 1 {
+		return shown[0]
+	}
+	return "[" + strings.Join(shown, ", ") + "]"
+}
@@ -0,0 +1,292 @@
+package app
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/config"
+	"github.com/jasonwillschiu/mdrelease/internal/versionfile"
+)
+
+type configRule struct {
+	choices   []string
+	requires  []string
+	conflicts []string
+}
+
+// configSchema mirrors the value and cross-flag checks the commands make after
+// parsing, so config validate can report them before a release runs.
+var configSchema = map[string]configRule{
+	"sync":               {choices: []string{syncFFOnly, syncRebase, syncNone}},
+	"forge":              {choices: []string{forgeAuto, forgeGitHub, forgeGitLab, forgeGitea, forgeBitbucket, forgeNone}},
+	"forge-backend":      {choices: []string{forgeBackendAPI, forgeBackendCLI}},
+	"log-format":         {choices: logFormats},
+	"version-order":      {choices: versionOrders},
+	"from":               {choices: []string{latestFromLocal, latestFromRemote, latestFromBoth}},
+	"require-signature":  {choices: []string{signatureNone, signatureGit, signatureForge}},
+	"build-metadata-in":  {choices: []string{buildMetadataInTag, buildMetadataInMessage}, requires: []string{"build-metadata"}},
+	"all":                {conflicts: []string{"stage-all", "commit", "tag", "push", "push-commit", "push-tag"}},
+	"target":             {conflicts: []string{"commit"}},
+	"rollback-commit":    {requires: []string{"rollback-on-failure"}},
+	"asset":              {requires: []string{"forge"}},
+	"generate-notes":     {requires: []string{"forge"}},
+	"forge-plugin":       {requires: []string{"forge"}},
+	"sign":               {requires: []string{"forge", "asset"}},
+	"cosign-key":         {requires: []string{"sign"}},
+	"homebrew-tap":       {requires: []string{"forge", "asset"}},
+	"scoop-bucket":       {requires: []string{"forge", "asset"}},
+	"winget-repo":        {requires: []string{"forge", "asset", "winget-id"}},
+	"pkgsite":            {requires: []string{"go-proxy"}},
+	"webhook-on-failure": {requires: []string{"webhook"}},
+	"email-to":           {requires: []string{"smtp-addr", "email-from"}},
+}
+
+func runConfigValidate(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease config validate", stderr)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "config validate does not accept positional arguments"}
+	}
+	paths, err := configFiles(configPath, d)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return &preflightError{msg: "no config file found (run `mdrelease config init` to create one)"}
+	}
+	getenv := d.getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+
+	var problems []string
+	for _, path := range paths {
+		file, err := config.Load(path)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		fileProblems, err := validateConfigFile(file, getenv, d)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, fileProblems...)
+	}
+	if len(problems) == 0 {
+		for _, command := range configSections {
+			commandProblems, err := validateConfigRules(command, configPath, d)
+			if err != nil {
+				return err
+			}
+			problems = append(problems, commandProblems...)
+		}
+	}
+
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			_, _ = fmt.Fprintf(stdout, "  %s\n", problem)
+		}
+		return &usageError{msg: fmt.Sprintf("config is invalid (%d problem(s))", len(problems))}
+	}
+	_, _ = fmt.Fprintf(stdout, "Config OK: %s\n", strings.Join(paths, ", "))
+	return nil
+}
+
+// commandFlags returns a command's flag set as parsed from args, before any
+// config is applied.
+func commandFlags(command string, args []string, d deps) (*flag.FlagSet, error) {
+	var fs *flag.FlagSet
+	d.inspectConfig = func(cmdFlags *flag.FlagSet, _, _ string) error {
+		fs = cmdFlags
+		return errConfigInspected
+	}
+	if err := inspectCommand(configCommands[command], "", args, io.Discard, io.Discard, d); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+func validateConfigFile(file *config.File, getenv func(string) string, d deps) ([]string, error) {
+	flagSets := map[string]*flag.FlagSet{}
+	for _, command := range configSections {
+		fs, err := commandFlags(command, nil, d)
+		if err != nil {
+			return nil, err
+		}
+		flagSets[command] = fs
+	}
+
+	type problem struct {
+		line int
+		msg  string
+	}
+	var found []problem
+	for section := range file.Sections {
+		commands := []string{section}
+		if strings.HasPrefix(section, componentSectionPrefix) {
+			if name := strings.TrimPrefix(section, componentSectionPrefix); name == "" || strings.ContainsAny(name, "/ \t") {
+				found = append(found, problem{msg: fmt.Sprintf("%s: invalid component name %q in [%s]", file.Path, name, section)})
+				continue
+			}
+			for _, key := range file.Keys(section) {
+				v := file.Sections[section][key]
+				if msg := validateComponentEntry(key, v); msg != "" {
+					found = append(found, problem{v.Line, fmt.Sprintf("%s:%d: %s", file.Path, v.Line, msg)})
+				}
+			}
+			continue
+		}
+		if _, table := configTables[section]; table {
+			for _, key := range file.Keys(section) {
+				v := file.Sections[section][key]
+				if msg := validateConfigTableEntry(section, key, v, getenv); msg != "" {
+					found = append(found, problem{v.Line, fmt.Sprintf("%s:%d: %s", file.Path, v.Line, msg)})
+				}
+			}
+			continue
+		}
+		if section == "" {
+			commands = configSections
+		} else if !slices.Contains(configSections, section) {
+			found = append(found, problem{msg: fmt.Sprintf("%s: unknown section %q (expected %s)", file.Path, section, strings.Join(configSections, ", "))})
+			continue
+		}
+		for _, key := range file.Keys(section) {
+			v := file.Sections[section][key]
+			where := fmt.Sprintf("%s:%d", file.Path, v.Line)
+			name := strings.ReplaceAll(key, "_", "-")
+			var flags []*flag.Flag
+			for _, command := range commands {
+				if f := flagSets[command].Lookup(name); f != nil && name != configFlag {
+					flags = append(flags, f)
+				}
+			}
+			switch {
+			case len(flags) == 0 && section == "":
+				found = append(found, problem{v.Line, fmt.Sprintf("%s: unknown setting %q (not a flag of any command)", where, key)})
+			case len(flags) == 0:
+				found = append(found, problem{v.Line, fmt.Sprintf("%s: unknown %s setting %q", where, section, key)})
+			default:
+				if msg := validateConfigValue(flags[0], key, v, getenv); msg != "" {
+					found = append(found, problem{v.Line, where + ": " + msg})
+				}
+			}
+		}
+	}
+	slices.SortFunc(found, func(a, b problem) int { return cmp.Or(a.line-b.line, strings.Compare(a.msg, b.msg)) })
+	problems := make([]string, len(found))
+	for i, p := range found {
+		problems[i] = p.msg
+	}
+	return problems, nil
+}
+
+func validateConfigValue(f *flag.Flag, key string, v config.Value, getenv func(string) string) string {
+	if _, repeatable := f.Value.(*stringList); v.List && !repeatable {
+		return fmt.Sprintf("%s takes a single value, not a list", key)
+	}
+	for _, raw := range v.Items {
+		item, err := config.Expand(raw, getenv)
+		if err != nil {
+			return fmt.Sprintf("%s: %v", key, err)
+		}
+		if item == "" && strings.Contains(raw, "${") {
+			// The variable is provided where the release runs.
+			continue
+		}
+		if choices := configSchema[f.Name].choices; len(choices) > 0 && !slices.Contains(choices, item) {
+			return fmt.Sprintf("invalid value %q for %s (expected %s)", item, key, strings.Join(choices, ", "))
+		}
+		if err := f.Value.Set(item); err != nil {
+			return fmt.Sprintf("invalid value %q for %s: %v", item, key, err)
+		}
+	}
+	return ""
+}
+
+func validateConfigTableEntry(section, key string, v config.Value, getenv func(string) string) string {
+	if v.List {
+		return fmt.Sprintf("[%s] %s takes a single value, not a list", section, key)
+	}
+	value, err := config.Expand(v.Items[0], getenv)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", key, err)
+	}
+	if _, err := versionfile.New(key, value); err != nil {
+		return fmt.Sprintf("[%s] %v", section, err)
+	}
+	return ""
+}
+
+// validateConfigRules merges the config into a command's flags and checks
+// the flag combinations the command would reject.
+func validateConfigRules(command, configPath string, d deps) ([]string, error) {
+	fs, err := commandFlags(command, nil, d)
+	if err != nil {
+		return nil, err
+	}
+	_, sources, err := mergeConfig(fs, command, configPath, d)
+	if err != nil {
+		var ue *usageError
+		if errors.As(err, &ue) {
+			return []string{fmt.Sprintf("%s: %s", command, strings.TrimPrefix(ue.msg, "config: "))}, nil
+		}
+		return nil, err
+	}
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(sources)) {
+		rule := configSchema[name]
+		if !flagActive(fs.Lookup(name)) {
+			continue
+		}
+		for _, other := range rule.conflicts {
+			if f := fs.Lookup(other); f != nil && flagActive(f) {
+				problems = append(problems, fmt.Sprintf("%s: [%s] %s cannot be combined with %s%s", sources[name], command, name, other, describeSource(sources[other])))
+			}
+		}
+		for _, needed := range rule.requires {
+			if f := fs.Lookup(needed); f != nil && !flagActive(f) {
+				problems = append(problems, fmt.Sprintf("%s: [%s] %s requires %s", sources[name], command, name, needed))
+			}
+		}
+	}
+	return problems, nil
+}
+
+func flagActive(f *flag.Flag) bool {
+	if f == nil {
+		return false
+	}
+	if l, ok := f.Value.(*stringList); ok {
+		return len(*l) > 0
+	}
+	if o, ok := f.Value.(*optionalString); ok {
+		return o.set
+	}
+	v := f.Value.String()
+	return v != "" && v != "false" && v != forgeNone
+}
+
+func describeSource(source string) string {
+	if source == "" {
+		return ""
+	}
+	return " (set at " + source + ")"
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// confirmRelease asks before a release run from a terminal changes anything.
+// The question repeats the version, actions, and remote so it still makes
+// sense under --quiet, where the release plan above it is not printed.
+func confirmRelease(d deps, stderr io.Writer, tag string, actions releaseActions, remote string) error {
+	question := fmt.Sprintf("Release %s (%s)", tag, actions.String())
+	if actions.pushCommit || actions.pushTag {
+		question += " to " + remote
+	}
+	return confirm(d, stderr, question, "release not confirmed; nothing was changed (pass --yes to skip the prompt)")
+}
+
+// confirm asks a yes/no question on stderr and returns a preflightError with
+// declined unless the answer is yes.
+func confirm(d deps, stderr io.Writer, question, declined string) error {
+	_, _ = fmt.Fprintf(stderr, "%s? [y/N] ", question)
+
+	answer, _ := bufio.NewReader(d.stdin).ReadString('\n')
+	if !strings.HasSuffix(answer, "\n") {
+		_, _ = fmt.Fprintln(stderr)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return &preflightError{msg: declined}
+}
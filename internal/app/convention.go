@@ -0,0 +1,62 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// conventionConventional is the --commit-convention value for Conventional
+// Commits subjects, the format `mdrelease next` reads.
+const conventionConventional = "conventional"
+
+// maxListedOffenders caps the commits a commit-convention failure names.
+const maxListedOffenders = 5
+
+// compileCommitConvention parses --commit-convention, a regular expression
+// for commit subjects or "conventional".
+func compileCommitConvention(pattern string) (*regexp.Regexp, error) {
+	switch pattern {
+	case "":
+		return nil, nil
+	case conventionConventional:
+		return conventionalSubject, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, &usageError{msg: fmt.Sprintf("invalid --commit-convention pattern %q: %v", pattern, err)}
+	}
+	return re, nil
+}
+
+// checkCommitConvention checks the subjects of the commits since the last
+// tag against re. Merge and revert commits git writes itself are exempt.
+func checkCommitConvention(git gitOps, re *regexp.Regexp, in ruleInput) (string, string, error) {
+	since, err := git.PreviousTag(in.rev, in.tagPrefix)
+	if err != nil {
+		return "", "", err
+	}
+	commits, err := git.CommitsSince(since, in.paths...)
+	if err != nil {
+		return "", "", err
+	}
+	scope := "since " + since
+	if since == "" {
+		scope = "in the history"
+	}
+	var offenders []string
+	for _, c := range commits {
+		if strings.HasPrefix(c.Subject, "Merge ") || strings.HasPrefix(c.Subject, `Revert "`) || re.MatchString(c.Subject) {
+			continue
+		}
+		offenders = append(offenders, fmt.Sprintf("%s %q", c.Hash, c.Subject))
+	}
+	if len(offenders) == 0 {
+		return fmt.Sprintf("%d commit(s) %s", len(commits), scope), "", nil
+	}
+	listed := offenders
+	if len(listed) > maxListedOffenders {
+		listed = append(listed[:maxListedOffenders:maxListedOffenders], fmt.Sprintf("and %d more", len(offenders)-maxListedOffenders))
+	}
+	return "", "", &preflightError{msg: fmt.Sprintf("%d of %d commit(s) %s do not match --commit-convention %s: %s (reword them before releasing)", len(offenders), len(commits), scope, re, strings.Join(listed, ", "))}
+}
@@ -0,0 +1,166 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+)
+
+// runDoctor takes the release flags and config so it diagnoses the setup the
+// release would actually use.
+func runDoctor(args []string, stdout, stderr io.Writer, d deps) error {
+	d.doctor = true
+	return release(args, stdout, stderr, d, nil)
+}
+
+// doctorReport prints one line per check and keeps going after a failure, so
+// a single run lists everything that needs fixing.
+type doctorReport struct {
+	w      io.Writer
+	failed int
+}
+
+func (r *doctorReport) pass(name, detail string) {
+	_, _ = fmt.Fprintf(r.w, "  %s: %s (%s)\n", name, paint(r.w, colorGreen, "ok"), detail)
+}
+
+func (r *doctorReport) skip(name, reason string) {
+	_, _ = fmt.Fprintf(r.w, "  %s: %s (%s)\n", name, paint(r.w, colorYellow, "skipped"), reason)
+}
+
+func (r *doctorReport) fail(name string, err error, hint string) {
+	r.failed++
+	_, _ = fmt.Fprintf(r.w, "  %s: %s\n    %v\n", name, paint(r.w, colorRed, "fail"), err)
+	if hint != "" {
+		_, _ = fmt.Fprintf(r.w, "    Hint: %s\n", hint)
+	}
+}
+
+func diagnose(cfg commonConfig, fc forgeConfig, d deps, stdout, stderr, result io.Writer) error {
+	r := &doctorReport{w: stdout}
+	_, _ = fmt.Fprintln(stdout, "Doctor:")
+
+	if entry, err := cfg.versions.changelog.ParseLatest(cfg.changelogPath); err != nil {
+		r.fail("Changelog", err, fmt.Sprintf("start %s with a `%s` heading, or pass --changelog", cfg.changelogPath, cfg.versions.changelog.Format()))
+	} else {
+		r.pass("Changelog", fmt.Sprintf("%s, latest %s - %s", cfg.changelogPath, entry.Version, entry.Summary))
+	}
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	gitOK := diagnoseGit(r, git, cfg)
+	diagnoseSigning(r, fc, d)
+	diagnoseForge(r, git, gitOK, cfg, fc, d)
+
+	if r.failed > 0 {
+		return &preflightError{msg: fmt.Sprintf("%d doctor check(s) failed", r.failed)}
+	}
+	_, _ = fmt.Fprintln(result, paint(result, colorGreen, "Doctor found no problems."))
+	return nil
+}
+
+// diagnoseGit reports whether git and the repository were usable, which the
+// forge check needs to read the remote URL.
+func diagnoseGit(r *doctorReport, git gitOps, cfg commonConfig) bool {
+	if err := ensureGitVersion(git, cfg); err != nil {
+		r.fail("Git", err, "")
+		for _, name := range []string{"Committer identity", "Repository", "Remote"} {
+			r.skip(name, "git is not usable")
+		}
+		return false
+	}
+	version, _ := git.Version()
+	r.pass("Git", "git "+version.String())
+
+	if err := ensureCommitterIdentity(git); err != nil {
+		r.fail("Committer identity", err, "or set user.name and user.email with `git config --global`")
+	} else {
+		r.pass("Committer identity", "configured")
+	}
+
+	if err := git.EnsureRepo(); err != nil {
+		r.fail("Repository", err, "run mdrelease from inside the repository to release")
+		r.skip("Remote", "not in a git repository")
+		return false
+	}
+	r.pass("Repository", "inside a git work tree")
+
+	if err := git.EnsureRemote(cfg.remote); err != nil {
+		r.fail("Remote", err, "")
+		return true
+	}
+	url, _ := git.RemoteURL(cfg.remote)
+	if err := checkAllowedRemote(git, cfg); err != nil {
+		r.fail("Remote", err, "point the remote at the release repository or update --allowed-remote")
+		return true
+	}
+	if err := git.EnsureRemoteReachable(cfg.remote); err != nil {
+		hint := fmt.Sprintf("check the URL (%s) and network access", url)
+		if ge := new(gitutil.GitError); errors.As(err, &ge) && ge.IsAuthFailure() {
+			hint = "git could not authenticate; load an SSH key into ssh-agent, use an HTTPS token via a credential helper or --git-token, or pass --allow-git-prompt"
+		}
+		r.fail("Remote", err, hint)
+		return true
+	}
+	r.pass("Remote", fmt.Sprintf("%s reachable at %s", cfg.remote, url))
+	return true
+}
+
+func diagnoseSigning(r *doctorReport, fc forgeConfig, d deps) {
+	if !fc.sign {
+		r.skip("Signing", "--sign not set")
+		return
+	}
+	signer, err := forge.NewSigner(fc.cosignKey, fc.signPattern, "", d.runTool)
+	if err != nil {
+		r.fail("Signing", err, "")
+		return
+	}
+	if !signer.Keyless() && !strings.Contains(signer.Key, "://") {
+		if _, err := os.Stat(signer.Key); err != nil {
+			r.fail("Signing", err, "pass --cosign-key a readable cosign key file or a KMS URI")
+			return
+		}
+	}
+	r.pass("Signing", "cosign with "+describeSigning(signer))
+}
+
+func diagnoseForge(r *doctorReport, git gitOps, gitOK bool, cfg commonConfig, fc forgeConfig, d deps) {
+	if !fc.enabled() {
+		r.skip("Forge", "--forge not set")
+		return
+	}
+	remote := forge.Remote{}
+	if gitOK {
+		remote = detectRemote(git, cfg.remote)
+	}
+	if err := fc.detect(cfg.remote, remote, d.getenv); err != nil {
+		r.fail("Forge", err, "")
+		return
+	}
+	env := forgeEnvs[fc.kind]
+	if fc.repo == "" {
+		r.fail("Forge", fmt.Errorf("no %s repository", fc.kind), fmt.Sprintf("pass --forge-repo or set %s", env.repo))
+		return
+	}
+	if fc.token == "" && fc.backend != forgeBackendCLI && fc.plugin == "" {
+		r.fail("Forge", fmt.Errorf("no %s API token", fc.kind), fmt.Sprintf("pass --forge-token, set %s, or use --forge-backend cli", strings.Join(env.tokens, " or ")))
+		return
+	}
+	if _, err := d.forgeBackend(fc); err != nil {
+		r.fail("Forge", err, "")
+		return
+	}
+	via := "API token set"
+	switch {
+	case fc.plugin != "":
+		via = "plugin mdrelease-" + fc.plugin
+	case fc.backend == forgeBackendCLI:
+		via = "authenticated CLI"
+	}
+	r.pass("Forge", fmt.Sprintf("%s %s, %s", fc.kind, fc.repo, via))
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/export"
+	"github.com/jasonwillschiu/mdrelease/internal/notify"
+)
+
+const (
+	exportFormatHTML      = "html"
+	exportFormatEmail     = "email"
+	exportFormatEmailHTML = "email-html"
+	exportFormatEmailText = "email-text"
+)
+
+// runExport renders the changelog, or the entries in a version range, as a
+// standalone document for publishing.
+func runExport(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease export", stderr)
+
+	var cfg commonConfig
+	var changelogFlag string
+	var componentName string
+	var format string
+	var from, to string
+	var output string
+	var title string
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
+	fs.StringVar(&componentName, "component", "", "Export the changelog of this [components.<name>] config entry")
+	fs.StringVar(&format, "format", exportFormatHTML, "Output format: html (a standalone page with an anchor per version), email (a multipart/alternative body with text and inline-styled HTML), email-html or email-text (one part of it)")
+	fs.StringVar(&from, "from", "", "Oldest version to include (default: the first entry)")
+	fs.StringVar(&to, "to", "", "Newest version to include (default: the latest entry)")
+	fs.StringVar(&output, "output", "", "Write to this file instead of stdout")
+	fs.StringVar(&title, "title", "Changelog", "Page title")
+	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix for the release headings of the email formats")
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "export", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "export does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	switch format {
+	case exportFormatHTML, exportFormatEmail, exportFormatEmailHTML, exportFormatEmailText:
+	default:
+		return &usageError{msg: fmt.Sprintf("invalid --format value %q (expected html, email, email-html or email-text)", format)}
+	}
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	if componentName != "" {
+		if _, err := applyComponent(componentName, configPath, &cfg, d); err != nil {
+			return err
+		}
+	}
+
+	entries, err := exportEntries(cfg.versions, cfg.changelogPath, from, to)
+	if err != nil {
+		return err
+	}
+	var page bytes.Buffer
+	if format == exportFormatHTML {
+		if err := export.HTML(&page, title, entries); err != nil {
+			return err
+		}
+	} else if err := writeEmailDigest(&page, format, cfg.tagPrefix, entries); err != nil {
+		return err
+	}
+	if output == "" {
+		_, err := stdout.Write(page.Bytes())
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		return fmt.Errorf("--output: %w", err)
+	}
+	if err := os.WriteFile(output, page.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("--output: %w", err)
+	}
+	_, _ = fmt.Fprintf(stdout, "Wrote %d changelog entries to %s\n", len(entries), output)
+	return nil
+}
+
+// exportEntries reads the changelog's entries, newest first, keeping those
+// from the --from version up to the --to version.
+func exportEntries(versions versionScheme, path, from, to string) ([]export.Entry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &changelog.ParseError{Path: path, Msg: "failed to open changelog", Err: err}
+	}
+	doc := versions.changelog.ParseDocument(string(content))
+	for _, bound := range []string{from, to} {
+		if bound != "" && doc.Index(bound) < 0 {
+			return nil, &changelog.ParseError{Path: path, Msg: fmt.Sprintf("no entry for version %s", bound)}
+		}
+	}
+	if from != "" && to != "" && versions.compare(from, to) > 0 {
+		return nil, &usageError{msg: fmt.Sprintf("--from %s is newer than --to %s", from, to)}
+	}
+	var entries []export.Entry
+	for _, block := range doc.Entries() {
+		if from != "" && versions.compare(block.Version, from) < 0 || to != "" && versions.compare(block.Version, to) > 0 {
+			continue
+		}
+		entries = append(entries, export.Entry{Version: block.Version, Summary: block.Summary, Bullets: block.Bullets()})
+	}
+	if len(entries) == 0 {
+		return nil, &changelog.ParseError{Path: path, Msg: fmt.Sprintf("no release entries to export (expected %s)", versions.changelog.Format()), Format: versions.changelog.Format()}
+	}
+	return entries, nil
+}
+
+// writeEmailDigest renders entries as the email body a release notification
+// would send, for handing to other mailers. The email format is a MIME
+// entity: its headers, then the multipart body.
+func writeEmailDigest(w io.Writer, format, tagPrefix string, entries []export.Entry) error {
+	releases := make([]notify.Release, 0, len(entries))
+	for _, entry := range entries {
+		var notes []string
+		for _, bullet := range entry.Bullets {
+			notes = append(notes, "- "+bullet)
+		}
+		releases = append(releases, notify.Release{
+			Status:  notify.StatusSuccess,
+			Version: entry.Version,
+			Tag:     tagPrefix + entry.Version,
+			Summary: entry.Summary,
+			Notes:   strings.Join(notes, "\n"),
+		})
+	}
+	text, html, err := notify.Digest(releases)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case exportFormatEmailText:
+		_, err = io.WriteString(w, text)
+		return err
+	case exportFormatEmailHTML:
+		_, err = io.WriteString(w, html)
+		return err
+	}
+	contentType, body, err := notify.Multipart(text, html)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "MIME-Version: 1.0\r\nContent-Type: %s\r\n\r\n", contentType); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
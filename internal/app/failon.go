@@ -0,0 +1,88 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Conditions --fail-on maps to an outcome.
+const (
+	conditionTagExists          = "tag-exists"
+	conditionNoChanges          = "no-changes"
+	conditionPrereleaseOnStable = "prerelease-on-stable"
+)
+
+// Outcomes of a --fail-on condition: error exits 4, no-op exits 7, and ok
+// exits 0 (skipping the release, or for prerelease-on-stable going ahead).
+const (
+	outcomeError = "error"
+	outcomeNoOp  = "no-op"
+	outcomeOK    = "ok"
+)
+
+var failOnOutcomes = []string{outcomeError, outcomeNoOp, outcomeOK}
+
+// defaultStableBranches are the branches prerelease-on-stable checks when
+// no --stable-branch is given.
+var defaultStableBranches = []string{"main", "master"}
+
+func addFailOnFlags(fs *flag.FlagSet, rules, stable *stringList) {
+	fs.Var(rules, "fail-on", "Exit behavior for a condition, as <condition>[=error|no-op|ok] with conditions tag-exists, no-changes, and prerelease-on-stable (default outcome error; repeatable)")
+	fs.Var(stable, "stable-branch", "Branch glob that counts as stable for --fail-on prerelease-on-stable (default: main and master; repeatable)")
+}
+
+// failPolicy is the outcome of each --fail-on condition.
+type failPolicy map[string]string
+
+// newFailPolicy starts from the defaults, a no-op (exit 7) for an existing
+// tag or nothing to commit and releasing prereleases anywhere, lets
+// --allow-no-op turn the no-ops into successes, and applies rules on top.
+func newFailPolicy(rules []string, allowNoOp bool) (failPolicy, error) {
+	p := failPolicy{
+		conditionTagExists:          outcomeNoOp,
+		conditionNoChanges:          outcomeNoOp,
+		conditionPrereleaseOnStable: outcomeOK,
+	}
+	if allowNoOp {
+		p[conditionTagExists], p[conditionNoChanges] = outcomeOK, outcomeOK
+	}
+	for _, rule := range rules {
+		condition, outcome, found := strings.Cut(rule, "=")
+		if !found {
+			outcome = outcomeError
+		}
+		if _, ok := p[condition]; !ok {
+			return nil, &usageError{msg: fmt.Sprintf("invalid --fail-on condition %q (expected tag-exists, no-changes, or prerelease-on-stable)", condition)}
+		}
+		if !slices.Contains(failOnOutcomes, outcome) {
+			return nil, &usageError{msg: fmt.Sprintf("invalid --fail-on outcome %q for %s (expected error, no-op, or ok)", outcome, condition)}
+		}
+		p[condition] = outcome
+	}
+	return p, nil
+}
+
+// onStableBranch reports whether the branch being released matches one of
+// the stable branch globs.
+func onStableBranch(git gitOps, patterns []string, getenv func(string) string) (bool, error) {
+	branch, err := releaseBranchName(git, getenv)
+	if err != nil || branch == "" {
+		return false, err
+	}
+	if len(patterns) == 0 {
+		patterns = defaultStableBranches
+	}
+	for _, pattern := range patterns {
+		ok, err := path.Match(pattern, branch)
+		if err != nil {
+			return false, &usageError{msg: fmt.Sprintf("invalid --stable-branch pattern %q: %v", pattern, err)}
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
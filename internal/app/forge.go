@@ -0,0 +1,457 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+	"github.com/jasonwillschiu/mdrelease/internal/plugin"
+)
+
+const (
+	forgeNone      = "none"
+	forgeGitHub    = "github"
+	forgeGitLab    = "gitlab"
+	forgeBitbucket = "bitbucket"
+	forgeGitea     = "gitea"
+	forgeAuto      = "auto"
+
+	forgeBackendAPI = "api"
+	forgeBackendCLI = "cli"
+)
+
+type forgeConfig struct {
+	kind     string
+	backend  string
+	repo     string
+	apiURL   string
+	token    string
+	jobToken bool
+	plugin   string
+	assets   stringList
+	notes    bool
+
+	sign        bool
+	cosignKey   string
+	signPattern string
+}
+
+type forgeEnv struct {
+	repo   string
+	apiURL string
+	tokens []string
+}
+
+var forgeEnvs = map[string]forgeEnv{
+	forgeGitHub:    {repo: "GITHUB_REPOSITORY", apiURL: "GITHUB_API_URL", tokens: []string{"MDRELEASE_FORGE_TOKEN", "GITHUB_TOKEN"}},
+	forgeGitLab:    {repo: "CI_PROJECT_PATH", apiURL: "CI_SERVER_URL", tokens: []string{"MDRELEASE_FORGE_TOKEN", "GITLAB_TOKEN", "CI_JOB_TOKEN"}},
+	forgeBitbucket: {repo: "BITBUCKET_REPO_FULL_NAME", tokens: []string{"MDRELEASE_FORGE_TOKEN", "BITBUCKET_ACCESS_TOKEN"}},
+	forgeGitea:     {repo: "GITHUB_REPOSITORY", apiURL: "GITHUB_SERVER_URL", tokens: []string{"MDRELEASE_FORGE_TOKEN", "GITEA_TOKEN"}},
+}
+
+func addForgeFlags(fs *flag.FlagSet, fc *forgeConfig) {
+	addForgeTargetFlags(fs, fc, forgeNone, "Create a forge release after pushing the tag: auto (detect from the remote URL), github, gitlab, gitea, bitbucket, or none")
+	fs.StringVar(&fc.plugin, "forge-plugin", "", "Create the forge release through the mdrelease-<name> plugin executable instead of a built-in client")
+	fs.Var(&fc.assets, "asset", "Upload files matching this glob to the forge release (repeatable)")
+	fs.BoolVar(&fc.sign, "sign", false, "Sign matching assets with cosign sign-blob and upload the .sig (and keyless .pem certificate) files alongside them")
+	fs.StringVar(&fc.cosignKey, "cosign-key", "", "Cosign private key path or KMS URI for --sign (default: keyless signing through the CI's OIDC identity)")
+	fs.StringVar(&fc.signPattern, "sign-pattern", "*", "Only sign assets whose file name matches this glob (for example checksums.txt)")
+	fs.BoolVar(&fc.notes, "generate-notes", false, "Append GitHub's generated \"What's Changed\" notes below the changelog entry in the release body")
+}
+
+func addForgeTargetFlags(fs *flag.FlagSet, fc *forgeConfig, kind, usage string) {
+	fs.StringVar(&fc.kind, "forge", kind, usage)
+	fs.StringVar(&fc.backend, "forge-backend", forgeBackendAPI, "How to talk to the forge: api (HTTP API with a token) or cli (shell out to an authenticated gh or glab)")
+	fs.StringVar(&fc.repo, "forge-repo", "", "Forge repository as owner/repo or group/project (default: $GITHUB_REPOSITORY, $CI_PROJECT_PATH, or $BITBUCKET_REPO_FULL_NAME, then the remote URL)")
+	fs.StringVar(&fc.apiURL, "forge-url", "", "Forge base URL for self-hosted instances (default: derived from the remote URL, else https://api.github.com, https://gitlab.com, or https://api.bitbucket.org/2.0)")
+	fs.StringVar(&fc.token, "forge-token", "", "Forge API token (default: $MDRELEASE_FORGE_TOKEN, then $GITHUB_TOKEN, $GITLAB_TOKEN/$CI_JOB_TOKEN, $GITEA_TOKEN, or $BITBUCKET_ACCESS_TOKEN)")
+}
+
+func (fc *forgeConfig) resolve(getenv func(string) string) error {
+	switch fc.backend {
+	case "", forgeBackendAPI:
+		fc.backend = forgeBackendAPI
+	case forgeBackendCLI:
+	default:
+		return &usageError{msg: fmt.Sprintf("invalid --forge-backend value %q (expected api or cli)", fc.backend)}
+	}
+	switch fc.kind {
+	case "", forgeNone:
+		fc.kind = ""
+		if len(fc.assets) > 0 {
+			return &usageError{msg: "--asset requires --forge (for example --forge github)"}
+		}
+		if fc.notes {
+			return &usageError{msg: "--generate-notes requires --forge github"}
+		}
+		if fc.sign {
+			return &usageError{msg: "--sign requires --forge and --asset"}
+		}
+		if fc.plugin != "" {
+			return &usageError{msg: "--forge-plugin requires --forge"}
+		}
+		return nil
+	case forgeAuto:
+	case forgeGitHub, forgeGitLab, forgeGitea, forgeBitbucket:
+	default:
+		return &usageError{msg: fmt.Sprintf("invalid --forge value %q (expected auto, github, gitlab, gitea, bitbucket, or none)", fc.kind)}
+	}
+	if fc.sign && len(fc.assets) == 0 {
+		return &usageError{msg: "--sign requires at least one --asset"}
+	}
+	if !fc.sign && fc.cosignKey != "" {
+		return &usageError{msg: "--cosign-key requires --sign"}
+	}
+	if fc.kind != forgeAuto {
+		fc.applyEnv(getenv)
+	}
+	return nil
+}
+
+func (fc *forgeConfig) applyEnv(getenv func(string) string) {
+	if getenv == nil {
+		return
+	}
+	env := forgeEnvs[fc.kind]
+	if strings.TrimSpace(fc.repo) == "" {
+		fc.repo = strings.TrimSpace(getenv(env.repo))
+	}
+	if strings.TrimSpace(fc.apiURL) == "" && env.apiURL != "" {
+		fc.apiURL = strings.TrimSpace(getenv(env.apiURL))
+	}
+	for _, name := range env.tokens {
+		if strings.TrimSpace(fc.token) != "" {
+			break
+		}
+		fc.token = strings.TrimSpace(getenv(name))
+		fc.jobToken = name == "CI_JOB_TOKEN"
+	}
+}
+
+func (fc *forgeConfig) detect(remoteName string, remote forge.Remote, getenv func(string) string) error {
+	if fc.kind == forgeAuto {
+		if remote.Kind == "" {
+			return &preflightError{msg: fmt.Sprintf("--forge auto could not recognize a forge from the %s remote URL (pass --forge github, gitlab, gitea, or bitbucket)", remoteName)}
+		}
+		fc.kind = remote.Kind
+		fc.applyEnv(getenv)
+	}
+	if remote.Kind != fc.kind {
+		return nil
+	}
+	if fc.repo == "" {
+		fc.repo = remote.Repo
+	}
+	if fc.apiURL == "" {
+		fc.apiURL = remote.APIURL()
+	}
+	return nil
+}
+
+func detectRemote(git gitOps, remoteName string) forge.Remote {
+	raw, err := git.RemoteURL(remoteName)
+	if err != nil || raw == "" {
+		return forge.Remote{}
+	}
+	remote, err := forge.ParseRemoteURL(raw)
+	if err != nil {
+		return forge.Remote{}
+	}
+	return remote
+}
+
+func previousTag(git gitOps, cfg commonConfig, tag, target string) (string, error) {
+	rev := tag + "^"
+	if cfg.dryRun {
+		rev = "HEAD"
+		if target != "" {
+			rev = target
+		}
+	}
+	previous, err := git.PreviousTag(rev, cfg.tagPrefix)
+	if err != nil || previous == tag {
+		return "", err
+	}
+	return previous, nil
+}
+
+func printRemoteLinks(git gitOps, remote forge.Remote, cfg commonConfig, tag, target string, releasePage bool, stdout io.Writer) error {
+	if remote.Kind == "" {
+		return nil
+	}
+	previous, err := previousTag(git, cfg, tag, target)
+	if err != nil {
+		return err
+	}
+	if previous != "" {
+		_, _ = fmt.Fprintf(stdout, "Compare: %s\n", remote.CompareURL(previous, tag))
+	}
+	if releasePage {
+		_, _ = fmt.Fprintf(stdout, "Release page: %s\n", remote.ReleaseURL(tag))
+	}
+	return nil
+}
+
+func (fc forgeConfig) enabled() bool { return fc.kind != "" }
+
+func newForgeBackend(fc forgeConfig) (forge.Backend, error) {
+	if fc.backend == forgeBackendCLI {
+		switch fc.kind {
+		case forgeGitHub:
+			return forge.NewGitHubCLI(fc.repo, nil)
+		case forgeGitLab:
+			return forge.NewGitLabCLI(fc.repo, nil)
+		default:
+			return nil, fmt.Errorf("--forge-backend cli supports github (gh) and gitlab (glab), not %s", fc.kind)
+		}
+	}
+	switch fc.kind {
+	case forgeGitHub:
+		return forge.NewGitHub(fc.apiURL, fc.token, fc.repo, nil)
+	case forgeGitLab:
+		return forge.NewGitLab(fc.apiURL, fc.token, fc.repo, fc.jobToken, nil)
+	case forgeGitea:
+		return forge.NewGitea(fc.apiURL, fc.token, fc.repo, nil)
+	case forgeBitbucket:
+		return forge.NewBitbucket(fc.apiURL, fc.token, fc.repo, nil)
+	default:
+		return nil, fmt.Errorf("unsupported forge %q", fc.kind)
+	}
+}
+
+func (d deps) forgeBackend(fc forgeConfig) (forge.Backend, error) {
+	if d.newForge != nil {
+		return d.newForge(fc)
+	}
+	if fc.plugin != "" {
+		p, err := plugin.Find(fc.plugin, d.runPlugin, os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		return &plugin.Forge{Plugin: p, Kind: fc.kind, Repo: fc.repo, URL: fc.apiURL}, nil
+	}
+	return newForgeBackend(fc)
+}
+
+func prepareForge(fc forgeConfig, dryRun bool, d deps) (forge.Backend, []forge.Asset, error) {
+	env := forgeEnvs[fc.kind]
+	if fc.repo == "" {
+		return nil, nil, &preflightError{msg: fmt.Sprintf("--forge %s requires --forge-repo (or %s)", fc.kind, env.repo)}
+	}
+	if fc.token == "" && fc.backend != forgeBackendCLI && fc.plugin == "" && !dryRun {
+		return nil, nil, &preflightError{msg: fmt.Sprintf("--forge %s requires an API token (--forge-token or %s, or --forge-backend cli)", fc.kind, strings.Join(env.tokens, ", "))}
+	}
+	backend, err := d.forgeBackend(fc)
+	if err != nil {
+		return nil, nil, &preflightError{msg: err.Error()}
+	}
+	if _, ok := backend.(forge.NotesGenerator); fc.notes && !ok {
+		return nil, nil, &preflightError{msg: fmt.Sprintf("--generate-notes is not supported by --forge %s (GitHub only)", fc.kind)}
+	}
+	assets, err := forge.ExpandAssets(fc.assets)
+	if err != nil {
+		return nil, nil, &preflightError{msg: err.Error()}
+	}
+	return backend, assets, nil
+}
+
+func checkForgeRelease(d deps, git gitOps, fc forgeConfig, tag string, stdout io.Writer) error {
+	if fc.repo == "" {
+		return &preflightError{msg: fmt.Sprintf("--forge %s requires --forge-repo (or %s)", fc.kind, forgeEnvs[fc.kind].repo)}
+	}
+	backend, err := d.forgeBackend(fc)
+	if err != nil {
+		return &preflightError{msg: err.Error()}
+	}
+	inspector, ok := backend.(forge.ReleaseInspector)
+	if !ok {
+		_, _ = fmt.Fprintf(stdout, "  Forge release: not checked (--forge %s --forge-backend %s cannot inspect releases)\n", fc.kind, fc.backend)
+		return nil
+	}
+	ctx := d.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	state, err := inspector.InspectRelease(ctx, tag)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		_, _ = fmt.Fprintf(stdout, "  Forge release: none for %s in %s\n", tag, fc.repo)
+		return nil
+	}
+	kind := "published"
+	if state.Draft {
+		kind = "draft"
+	}
+	_, _ = fmt.Fprintf(stdout, "  Forge release: %s %s release exists at %s\n", kind, fc.kind, state.URL)
+
+	hasLocalTag, err := git.HasLocalTag(tag)
+	if err != nil {
+		return err
+	}
+	if !hasLocalTag {
+		if state.Draft {
+			_, _ = fmt.Fprintln(stdout, "  Forge release target: draft without a local tag (releasing creates a new release; delete the draft if it is stale)")
+			return nil
+		}
+		return &preflightError{msg: fmt.Sprintf("a %s release for %s already exists at %s but the tag does not exist locally (bump the changelog version or delete the stale release)", fc.kind, tag, state.URL)}
+	}
+	local, err := git.ResolveCommit("refs/tags/" + tag)
+	if err != nil {
+		return err
+	}
+	switch state.Commit {
+	case "":
+		_, _ = fmt.Fprintf(stdout, "  Forge release target: tag %s not found on the forge\n", tag)
+	case local:
+		_, _ = fmt.Fprintf(stdout, "  Forge release target: %s (matches local tag)\n", local)
+	default:
+		return &preflightError{msg: fmt.Sprintf("the %s release for %s points at %s but the local tag is at %s (re-release with --force-retag or fix the tag)", fc.kind, tag, state.Commit, local)}
+	}
+	return nil
+}
+
+func describeAssets(assets []forge.Asset) string {
+	var total int64
+	for _, a := range assets {
+		total += a.Size
+	}
+	return fmt.Sprintf("%d file(s), %s", len(assets), forge.FormatSize(total))
+}
+
+type forgePublish struct {
+	backend     forge.Backend
+	fc          forgeConfig
+	signer      *forge.Signer
+	tag         string
+	previousTag string
+	commit      string
+	entry       *changelog.Entry
+	assets      []forge.Asset
+	recreate    bool
+	dryRun      bool
+	// body, when set, receives the release body as published.
+	body *string
+}
+
+func (p forgePublish) run(ctx context.Context, stdout io.Writer) (*forge.Published, error) {
+	backend, fc, tag := p.backend, p.fc, p.tag
+	rel := forge.Release{Tag: tag, Name: tag + " - " + p.entry.Summary, Body: p.entry.Description, Commit: p.commit, Recreate: p.recreate}
+	verb := "create"
+	if p.recreate {
+		verb = "recreate"
+	}
+	if p.dryRun {
+		if fc.notes {
+			dryRunf(stdout, "generate %s release notes since %s", backend.Name(), describePrevious(p.previousTag))
+		}
+		if p.signer != nil {
+			for _, a := range p.assets {
+				if p.signer.Selects(a) {
+					dryRunf(stdout, "cosign sign-blob %s (%s)", a.Path, describeSigning(p.signer))
+				}
+			}
+		}
+		dryRunf(stdout, "%s %s release %s in %s", verb, backend.Name(), tag, fc.repo)
+		for _, a := range p.assets {
+			dryRunf(stdout, "upload %s (%s)", a.Path, forge.FormatSize(a.Size))
+		}
+		return nil, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	assets := p.assets
+	if p.signer != nil {
+		dir, err := os.MkdirTemp("", "mdrelease-sign-")
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+		p.signer.Dir = dir
+		_, _ = fmt.Fprintf(stdout, "Signing assets with cosign (%s)...\n", describeSigning(p.signer))
+		signatures, err := p.signer.Sign(ctx, assets)
+		if err != nil {
+			return nil, err
+		}
+		assets = append(append([]forge.Asset{}, assets...), signatures...)
+	}
+	if generator, ok := backend.(forge.NotesGenerator); ok && fc.notes {
+		_, _ = fmt.Fprintf(stdout, "Generating %s release notes since %s...\n", backend.Name(), describePrevious(p.previousTag))
+		notes, err := generator.GenerateNotes(ctx, tag, p.previousTag, p.commit)
+		if err != nil {
+			return nil, err
+		}
+		rel.Body = mergeNotes(rel.Body, notes)
+	}
+	if p.body != nil {
+		*p.body = rel.Body
+	}
+	if p.recreate {
+		_, _ = fmt.Fprintf(stdout, "Recreating %s release %s in %s (tag was force-retagged)...\n", backend.Name(), tag, fc.repo)
+	} else {
+		_, _ = fmt.Fprintf(stdout, "Creating %s release %s in %s...\n", backend.Name(), tag, fc.repo)
+	}
+	pub, created, err := backend.EnsureRelease(ctx, rel)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		_, _ = fmt.Fprintf(stdout, "Using existing %s release %s\n", backend.Name(), tag)
+	}
+	uploader := forge.Uploader{Backend: backend, Out: stdout, Attempts: forge.DefaultAttempts, Backoff: forge.DefaultBackoff}
+	if err := uploader.Upload(ctx, pub, assets); err != nil {
+		return pub, err
+	}
+	if pub.URL != "" {
+		_, _ = fmt.Fprintf(stdout, "Release URL: %s\n", pub.URL)
+	}
+	return pub, nil
+}
+
+func prepareSigner(fc forgeConfig, assets []forge.Asset, d deps) (*forge.Signer, error) {
+	if !fc.sign {
+		return nil, nil
+	}
+	signer, err := forge.NewSigner(fc.cosignKey, fc.signPattern, "", d.runTool)
+	if err != nil {
+		return nil, &preflightError{msg: err.Error()}
+	}
+	for _, a := range assets {
+		if signer.Selects(a) {
+			return signer, nil
+		}
+	}
+	return nil, &preflightError{msg: fmt.Sprintf("--sign-pattern %q matches none of the --asset files", signer.Pattern)}
+}
+
+func describeSigning(signer *forge.Signer) string {
+	if signer.Keyless() {
+		return "keyless"
+	}
+	return "key " + signer.Key
+}
+
+func mergeNotes(body, generated string) string {
+	switch {
+	case generated == "":
+		return body
+	case body == "":
+		return generated
+	default:
+		return strings.TrimRight(body, "\n") + "\n\n" + generated
+	}
+}
+
+func describePrevious(previousTag string) string {
+	if previousTag == "" {
+		return "the first commit"
+	}
+	return previousTag
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+)
+
+type actionsResult struct {
+	version  string
+	tag      string
+	released bool
+	notes    string
+}
+
+func newActionsResult(entry *changelog.Entry, tag string, released bool) actionsResult {
+	return actionsResult{version: entry.Version, tag: tag, released: released, notes: entry.Description}
+}
+
+func writeActionsOutputs(getenv func(string) string, result actionsResult) error {
+	if getenv == nil {
+		return nil
+	}
+	outputPath := strings.TrimSpace(getenv("GITHUB_OUTPUT"))
+	envPath := strings.TrimSpace(getenv("GITHUB_ENV"))
+	if outputPath == "" && envPath == "" {
+		return nil
+	}
+
+	dir := strings.TrimSpace(getenv("RUNNER_TEMP"))
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	notesFile := filepath.Join(dir, "mdrelease-notes-"+strings.ReplaceAll(result.tag, "/", "-")+".md")
+	if err := os.WriteFile(notesFile, []byte(result.notes+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write release notes for GitHub Actions: %w", err)
+	}
+
+	released := strconv.FormatBool(result.released)
+	if outputPath != "" {
+		if err := appendLines(outputPath, "version="+result.version, "tag="+result.tag, "released="+released, "notes-file="+notesFile); err != nil {
+			return fmt.Errorf("write GITHUB_OUTPUT: %w", err)
+		}
+	}
+	if envPath != "" {
+		if err := appendLines(envPath, "MDRELEASE_VERSION="+result.version, "MDRELEASE_TAG="+result.tag, "MDRELEASE_RELEASED="+released, "MDRELEASE_NOTES_FILE="+notesFile); err != nil {
+			return fmt.Errorf("write GITHUB_ENV: %w", err)
+		}
+	}
+	return nil
+}
+
+func appendLines(path string, lines ...string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/goproxy"
+	"github.com/jasonwillschiu/mdrelease/internal/semver"
+)
+
+type goProxyConfig struct {
+	enabled bool
+	url     string
+	goMod   string
+	module  string
+
+	pkgsite    bool
+	pkgsiteURL string
+}
+
+func addGoProxyFlags(fs *flag.FlagSet, gc *goProxyConfig) {
+	fs.BoolVar(&gc.enabled, "go-proxy", false, "After pushing the tag, request the version from the Go module proxy until it is indexed so `go install module@version` works immediately")
+	fs.StringVar(&gc.url, "go-proxy-url", "", "Go module proxy to warm (default: the first URL in $GOPROXY, else https://proxy.golang.org)")
+	fs.StringVar(&gc.goMod, "go-mod", "go.mod", "go.mod file that names the module for --go-proxy")
+	fs.BoolVar(&gc.pkgsite, "pkgsite", false, "After --go-proxy, ask pkg.go.dev to fetch the new version and wait until its documentation is live")
+	fs.StringVar(&gc.pkgsiteURL, "pkgsite-url", goproxy.DefaultPkgsiteURL, "pkg.go.dev-compatible site refreshed by --pkgsite")
+}
+
+func (gc *goProxyConfig) prepare(cfg commonConfig, getenv func(string) string) error {
+	if !gc.enabled {
+		if gc.pkgsite {
+			return &usageError{msg: "--pkgsite requires --go-proxy"}
+		}
+		return nil
+	}
+	if !strings.HasSuffix(cfg.tagPrefix, "v") {
+		return &usageError{msg: fmt.Sprintf("--go-proxy requires a Go-style tag prefix ending in \"v\" (got --tag-prefix %q)", cfg.tagPrefix)}
+	}
+	module, err := goproxy.ModulePath(gc.goMod)
+	if err != nil {
+		return &preflightError{msg: fmt.Sprintf("--go-proxy could not read the module path: %v", err)}
+	}
+	gc.module = module
+	if gc.url == "" {
+		goproxyEnv := ""
+		if getenv != nil {
+			goproxyEnv = getenv("GOPROXY")
+		}
+		gc.url = goproxy.ProxyURL(goproxyEnv)
+	}
+	return nil
+}
+
+// checkModuleMajor fails when version's major release cannot be fetched as
+// the module goMod names: v2 and later need a matching "/vN" path suffix,
+// and a "/vN" path only accepts vN releases. A missing go.mod is not a Go
+// module, so there is nothing to check.
+func checkModuleMajor(goMod, version string) (bool, error) {
+	module, err := goproxy.ModulePath(goMod)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, &preflightError{msg: fmt.Sprintf("could not read the module path: %v", err)}
+	}
+	v, err := semver.Parse(version)
+	if err != nil {
+		return false, nil
+	}
+	want := goproxy.PathMajor(module)
+	switch {
+	case want >= 2 && v.Major != want:
+		return true, &preflightError{msg: fmt.Sprintf("%s names module %s, which only accepts v%d.x.x releases, but the changelog version is %s (change the version or the module path)", goMod, module, want, version)}
+	case want == 1 && v.Major >= 2:
+		return true, &preflightError{msg: fmt.Sprintf("changelog version %s is a major release, but %s names module %s without a /v%d suffix, so `go get` cannot use it (change the module path to %s/v%d)", version, goMod, module, v.Major, module, v.Major)}
+	}
+	return true, nil
+}
+
+func (gc goProxyConfig) warm(ctx context.Context, version string, dryRun bool, stdout io.Writer) error {
+	version = "v" + version
+	if dryRun {
+		dryRunf(stdout, "warm %s@%s on %s", gc.module, version, gc.url)
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, _ = fmt.Fprintf(stdout, "Warming %s@%s on %s...\n", gc.module, version, gc.url)
+	info, err := goproxy.Warmer{BaseURL: gc.url, Out: stdout}.Warm(ctx, gc.module, version)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "Go proxy serves %s@%s\n", gc.module, info.Version)
+	return nil
+}
+
+func (gc goProxyConfig) refreshDocs(ctx context.Context, version string, dryRun bool, stdout io.Writer) {
+	if !gc.pkgsite {
+		return
+	}
+	version = "v" + version
+	site := goproxy.Pkgsite{BaseURL: gc.pkgsiteURL, Out: stdout}
+	if dryRun {
+		dryRunf(stdout, "refresh %s", site.DocsURL(gc.module, version))
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, _ = fmt.Fprintf(stdout, "Waiting for %s@%s docs on %s...\n", gc.module, version, gc.pkgsiteURL)
+	docs, err := site.Refresh(ctx, gc.module, version)
+	if err != nil {
+		// The release itself is done; slow documentation is not a failure.
+		warnf(stdout, "docs are not live yet (%v); check %s later", err, docs)
+		return
+	}
+	_, _ = fmt.Fprintf(stdout, "Docs live: %s\n", docs)
+}
@@ -0,0 +1,263 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+type commandHelp struct {
+	usage    string
+	summary  string
+	examples []string
+}
+
+// helpTopics is keyed by the command's flag set name without "mdrelease ",
+// with the default release run as "release".
+var helpTopics = map[string]commandHelp{
+	"release": {
+		usage:   "mdrelease [flags]",
+		summary: "Release the latest changelog entry: stage all changes, commit, tag, and push (the default, same as --all). Individual action flags such as --commit or --tag run only those steps.",
+		examples: []string{
+			"mdrelease",
+			"mdrelease --commit --tag --push",
+			"mdrelease --tag --push-tag --target <sha>",
+			"mdrelease --tag --push-tag --force-retag",
+			"mdrelease --idempotent --allow-no-op",
+			"mdrelease --component api",
+			"mdrelease --forge github --asset 'dist/*'",
+		},
+	},
+	"check": {
+		usage:   "mdrelease check [flags]",
+		summary: "Validate the changelog and the git preconditions for a release without changing anything.",
+		examples: []string{
+			"mdrelease check",
+			"mdrelease check --dry-run",
+			"mdrelease check --forge auto",
+			"mdrelease check --all-components",
+		},
+	},
+	"plan": {
+		usage:   "mdrelease plan [--json] [release flags]",
+		summary: "Show exactly what a release with the same flags would change, without changing anything.",
+		examples: []string{
+			"mdrelease plan",
+			"mdrelease plan --json --release-branch",
+		},
+	},
+	"doctor": {
+		usage:   "mdrelease doctor [release flags]",
+		summary: "Check git, committer identity, remote access, signing, forge token, and changelog for a release with the same flags.",
+		examples: []string{
+			"mdrelease doctor",
+			"mdrelease doctor --forge auto --sign --asset 'dist/*'",
+		},
+	},
+	"promote": {
+		usage:   "mdrelease promote [release flags]",
+		summary: "Rewrite the latest prerelease changelog entries, such as 1.4.0-rc.2 and 1.4.0-rc.1, as one final 1.4.0 entry with all their bullets, then release it with the same flags as mdrelease.",
+		examples: []string{
+			"mdrelease promote",
+			"mdrelease promote --dry-run",
+			"mdrelease promote --forge github --asset 'dist/*'",
+		},
+	},
+	"version": {
+		usage:   "mdrelease version [flags]",
+		summary: "Print the latest changelog version.",
+		examples: []string{
+			"mdrelease version",
+			"mdrelease version --changelog docs/CHANGELOG.md",
+		},
+	},
+	"tag-message": {
+		usage:   "mdrelease tag-message [flags] [version]",
+		summary: "Print the tag annotation a release of the latest changelog entry, or of the version given, would get: the title, a blank line, and the bullets.",
+		examples: []string{
+			"mdrelease tag-message",
+			"mdrelease tag-message v1.2.3 > notes.txt",
+			"mdrelease tag-message --build-metadata +g{shortsha} --build-metadata-in message",
+		},
+	},
+	"export": {
+		usage:   "mdrelease export --format html|email|email-html|email-text [flags]",
+		summary: "Render the changelog, or the entries from --from to --to, as a standalone HTML page with an anchor per version, for publishing on docs sites, or as an email digest for other mailers.",
+		examples: []string{
+			"mdrelease export --format html > changelog.html",
+			"mdrelease export --from 1.0.0 --to 1.4.2 --output site/changelog.html --title \"Tool changelog\"",
+			"mdrelease export --format email --from 1.4.0 --output digest.eml",
+		},
+	},
+	"site": {
+		usage:   "mdrelease site [flags]",
+		summary: "Write a static release-notes site from the changelog: index.html listing every version and v<version>/index.html per release, ready to publish with GitHub Pages.",
+		examples: []string{
+			"mdrelease site",
+			"mdrelease site --output public --title \"Tool release notes\"",
+			"mdrelease site --dry-run",
+		},
+	},
+	"import-tags": {
+		usage:   "mdrelease import-tags [flags]",
+		summary: "Backfill changelog entries from existing annotated tags: each tag's subject becomes the title and its message the bullets, inserted in version order. Versions already in the changelog are left alone.",
+		examples: []string{
+			"mdrelease import-tags --dry-run",
+			"mdrelease import-tags --tag-prefix release-",
+		},
+	},
+	"audit-tags": {
+		usage:   "mdrelease audit-tags [flags]",
+		summary: "For each changelog version that has a tag, diff the entry against the tag annotation and report mismatches, catching tags edited or created outside mdrelease. Exits 4 when any differ.",
+		examples: []string{
+			"mdrelease audit-tags",
+			"mdrelease audit-tags --component api",
+		},
+	},
+	"changed": {
+		usage:   "mdrelease changed [flags]",
+		summary: "For each configured component, count the commits since its last tag that touch its path and report the ones with changes but no new changelog entry.",
+		examples: []string{
+			"mdrelease changed",
+			"mdrelease changed --component api",
+		},
+	},
+	"next": {
+		usage:   "mdrelease next [--write] [flags]",
+		summary: "Classify the commits since the last tag as Conventional Commits and print the suggested next version: a breaking change bumps major (minor before 1.0.0), feat bumps minor, anything else bumps patch.",
+		examples: []string{
+			"mdrelease next",
+			"mdrelease next --write",
+			"mdrelease next --component api --quiet",
+		},
+	},
+	"latest": {
+		usage:   "mdrelease latest [--from local|remote|both] [--compare] [flags]",
+		summary: "Print the highest semver tag with the tag prefix, from local tags, git ls-remote, or both. With --compare, report whether the changelog version is newer (exit 0), already tagged (exit 7), or behind (exit 4).",
+		examples: []string{
+			"mdrelease latest",
+			"mdrelease latest --from remote",
+			"mdrelease latest --from both --compare --quiet",
+		},
+	},
+	"backport": {
+		usage:   "mdrelease backport --onto <branch> [flags]",
+		summary: "Cherry-pick the commit that adds the changelog entry onto a maintenance branch, tag it, and push.",
+		examples: []string{
+			"mdrelease backport --onto release-1.x",
+			"mdrelease backport --onto release-1.x --commit <sha> --no-push",
+		},
+	},
+	"resume": {
+		usage:   "mdrelease resume [flags]",
+		summary: "Finish the remaining steps of a failed release recorded in .git/mdrelease-state.json.",
+		examples: []string{
+			"mdrelease resume",
+			"mdrelease resume --dry-run",
+			"mdrelease resume --discard",
+		},
+	},
+	"pr": {
+		usage:   "mdrelease pr [flags]",
+		summary: "Commit the changelog bump to a branch, push it, and open a release pull request.",
+		examples: []string{
+			"mdrelease pr --forge github",
+			"mdrelease pr --forge gitlab --base main --branch release/{version}",
+		},
+	},
+	"undo": {
+		usage:   "mdrelease undo [flags]",
+		summary: "Reverse the release of the latest changelog entry: delete its tag on the remote and locally after confirmation, optionally reset or revert the release commit while it is still HEAD, and with --forge delete the forge release left behind.",
+		examples: []string{
+			"mdrelease undo --dry-run",
+			"mdrelease undo --commit reset",
+			"mdrelease undo --commit revert --forge github --yes",
+		},
+	},
+	"config init": {
+		usage:   "mdrelease config init [flags]",
+		summary: "Write a commented starter .mdrelease.toml from the detected repository state.",
+		examples: []string{
+			"mdrelease config init",
+			"mdrelease config init --format yaml --stdout",
+		},
+	},
+	"config show": {
+		usage:   "mdrelease config show [--effective [--command <name>] [-- <command flags>]]",
+		summary: "Print the config files in effect, or every flag's effective value and its source.",
+		examples: []string{
+			"mdrelease config show",
+			"mdrelease config show --effective --command check",
+		},
+	},
+	"config validate": {
+		usage:   "mdrelease config validate [flags]",
+		summary: "Check config files for unknown keys, invalid values, and conflicting settings.",
+		examples: []string{
+			"mdrelease config validate",
+		},
+	},
+	"completion": {
+		usage:   "mdrelease completion {" + strings.Join(completionShells, ",") + "}",
+		summary: "Print a shell completion script for subcommands, flags, remotes, and release tags.",
+		examples: []string{
+			"source <(mdrelease completion bash)",
+			"mdrelease completion fish | source",
+		},
+	},
+}
+
+// newFlagSet creates a command's flag set. -h is answered by
+// printCommandHelp, so the flag package's own usage text is turned off.
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {}
+	return fs
+}
+
+func printCommandHelp(w io.Writer, fs *flag.FlagSet) {
+	topic := strings.TrimPrefix(fs.Name(), toolName+" ")
+	if topic == toolName {
+		topic = "release"
+	}
+	help := helpTopics[topic]
+	_, _ = fmt.Fprintf(w, "Usage: %s\n\n%s\n", help.usage, help.summary)
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		_, _ = fmt.Fprintln(w, "\nFlags:")
+		output := fs.Output()
+		fs.SetOutput(w)
+		fs.PrintDefaults()
+		fs.SetOutput(output)
+	}
+	if len(help.examples) > 0 {
+		_, _ = fmt.Fprintln(w, "\nExamples:")
+		for _, example := range help.examples {
+			_, _ = fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+}
+
+// runHelp answers `mdrelease help [command [subcommand]]` by running the
+// command with -h, so the flag listing always matches the real flag set.
+func runHelp(args []string, stdout, stderr io.Writer, d deps) error {
+	if len(args) == 0 {
+		printRootUsage(stdout)
+		return nil
+	}
+	topic := strings.Join(args, " ")
+	switch {
+	case topic == "release":
+		return runRelease([]string{"-h"}, stdout, stderr, d)
+	case topic == "config":
+		printConfigUsage(stdout)
+		return nil
+	case helpTopics[topic].usage != "":
+		return run(append(slices.Clone(args), "-h"), stdout, stderr, d)
+	}
+	return &usageError{msg: fmt.Sprintf("unknown help topic %q (run `mdrelease help` for the list of commands)", topic)}
+}
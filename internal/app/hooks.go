@@ -0,0 +1,240 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/notify"
+	"github.com/jasonwillschiu/mdrelease/internal/plugin"
+)
+
+const (
+	hookVerify    = "verify"
+	hookPreCommit = "pre-commit"
+	hookPreTag    = "pre-tag"
+	hookPostPush  = "post-push"
+	hookOnFailure = "on-failure"
+)
+
+type hookConfig struct {
+	verify    stringList
+	preCommit stringList
+	preTag    stringList
+	postPush  stringList
+	onFailure stringList
+	plugins   stringList
+}
+
+func addHookFlags(fs *flag.FlagSet, hc *hookConfig) {
+	fs.Var(&hc.verify, "verify-cmd", "Shell command that must pass before the release commits or tags anything, such as a build or test run (repeatable)")
+	fs.Var(&hc.preCommit, hookPreCommit+"-hook", "Shell command to run before staging and committing the release (repeatable)")
+	fs.Var(&hc.preTag, hookPreTag+"-hook", "Shell command to run before creating the release tag (repeatable)")
+	fs.Var(&hc.postPush, hookPostPush+"-hook", "Shell command to run after the release commit, tag, and branch are pushed (repeatable)")
+	fs.Var(&hc.onFailure, hookOnFailure+"-hook", "Shell command to run when the release fails; $MDRELEASE_ERROR holds the error (repeatable)")
+	fs.Var(&hc.plugins, "plugin", "Run the mdrelease-<name> executable from PATH at every hook point and after the release (repeatable)")
+}
+
+func (hc hookConfig) runsPostPush() bool { return hc.runs(hc.postPush) }
+
+// runs reports whether a hook point has commands or plugins to call.
+func (hc hookConfig) runs(commands []string) bool { return len(commands)+len(hc.plugins) > 0 }
+
+type hookError struct {
+	point   string
+	command string
+	err     error
+}
+
+func (e *hookError) Error() string {
+	return fmt.Sprintf("%s hook %q failed: %v", e.point, e.command, e.err)
+}
+
+func (e *hookError) Unwrap() error { return e.err }
+
+type hookRunner struct {
+	ctx       context.Context
+	run       func(ctx context.Context, command string, env []string, stdout, stderr io.Writer) error
+	plugins   []*plugin.Plugin
+	release   notify.Release
+	env       []string
+	notesFile string
+	dryRun    bool
+	stdout    io.Writer
+	stderr    io.Writer
+}
+
+// newHookRunner writes the release notes to a temporary file for
+// $MDRELEASE_NOTES_FILE; close removes it.
+func newHookRunner(hc hookConfig, entry *changelog.Entry, tag string, dryRun bool, stdout, stderr io.Writer, d deps) (*hookRunner, error) {
+	if len(hc.preCommit)+len(hc.preTag)+len(hc.postPush)+len(hc.onFailure)+len(hc.plugins) == 0 {
+		return nil, nil
+	}
+	h := &hookRunner{ctx: d.ctx, run: d.runHook, dryRun: dryRun, stdout: stdout, stderr: stderr}
+	if h.ctx == nil {
+		h.ctx = context.Background()
+	}
+	if h.run == nil {
+		h.run = runShell
+	}
+	for _, name := range hc.plugins {
+		p, err := plugin.Find(name, d.runPlugin, stderr)
+		if err != nil {
+			return nil, &preflightError{msg: err.Error()}
+		}
+		h.plugins = append(h.plugins, p)
+	}
+	h.release = notify.Release{Version: entry.Version, Tag: tag, Summary: entry.Summary, Notes: entry.Description}
+	if !dryRun {
+		f, err := os.CreateTemp("", "mdrelease-notes-*.md")
+		if err != nil {
+			return nil, err
+		}
+		h.notesFile = f.Name()
+		_, err = io.WriteString(f, entry.Description)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			h.close()
+			return nil, err
+		}
+	}
+	h.env = []string{
+		"MDRELEASE_VERSION=" + entry.Version,
+		"MDRELEASE_TAG=" + tag,
+		"MDRELEASE_TITLE=" + entry.Summary,
+		"MDRELEASE_NOTES_FILE=" + h.notesFile,
+	}
+	return h, nil
+}
+
+func (h *hookRunner) close() {
+	if h != nil && h.notesFile != "" {
+		_ = os.Remove(h.notesFile)
+	}
+}
+
+func (h *hookRunner) runPoint(point string, commands []string) error {
+	if err := h.runCommands(point, commands); err != nil {
+		return err
+	}
+	return h.callPlugins(point, "")
+}
+
+func (h *hookRunner) runCommands(point string, commands []string, extraEnv ...string) error {
+	if h == nil {
+		return nil
+	}
+	for _, command := range commands {
+		if h.dryRun {
+			dryRunf(h.stdout, "run %s hook: %s", point, command)
+			continue
+		}
+		_, _ = fmt.Fprintf(h.stdout, "Running %s hook: %s\n", point, command)
+		env := append(append([]string{"MDRELEASE_HOOK=" + point}, h.env...), extraEnv...)
+		if err := h.run(h.ctx, command, env, h.stdout, h.stderr); err != nil {
+			return &hookError{point: point, command: command, err: err}
+		}
+	}
+	return nil
+}
+
+// callPlugins sends event to each --plugin. Plugins see every event and
+// reply {} to the ones they do not handle.
+func (h *hookRunner) callPlugins(event, releaseErr string) error {
+	if h == nil {
+		return nil
+	}
+	for _, p := range h.plugins {
+		if h.dryRun {
+			dryRunf(h.stdout, "run %s plugin: %s", event, p.Name)
+			continue
+		}
+		r := h.release
+		r.Error = releaseErr
+		resp, err := p.Call(h.ctx, plugin.Request{Event: event, Release: r})
+		if msg := resp.Text(); msg != "" {
+			_, _ = fmt.Fprintf(h.stdout, "%s: %s\n", p.Name, msg)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// released tells plugins the release is published; like the webhooks this
+// can no longer fail the release.
+func (h *hookRunner) released(repo, releaseURL string) {
+	if h == nil || len(h.plugins) == 0 {
+		return
+	}
+	h.release.Status = notify.StatusSuccess
+	h.release.Repo = repo
+	h.release.ReleaseURL = releaseURL
+	if err := h.callPlugins(plugin.EventReleased, ""); err != nil {
+		warnf(h.stdout, "%v", err)
+	}
+}
+
+// failed runs the on-failure hooks; their own failures are only reported so
+// the release error stays the one returned.
+func (h *hookRunner) failed(commands []string, releaseErr error) {
+	if h == nil {
+		return
+	}
+	if err := h.runCommands(hookOnFailure, commands, "MDRELEASE_ERROR="+releaseErr.Error()); err != nil {
+		warnf(h.stderr, "%v", err)
+	}
+	if err := h.callPlugins(hookOnFailure, releaseErr.Error()); err != nil {
+		warnf(h.stderr, "%v", err)
+	}
+}
+
+// verifyRelease runs the --verify-cmd gate. It runs before the release
+// locks, fetches, deletes, commits, or tags anything, so a failure leaves
+// nothing to undo.
+func verifyRelease(commands []string, entry *changelog.Entry, tag string, dryRun bool, stdout, stderr io.Writer, d deps) error {
+	run := d.runHook
+	if run == nil {
+		run = runShell
+	}
+	ctx := d.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	env := []string{
+		"MDRELEASE_HOOK=" + hookVerify,
+		"MDRELEASE_VERSION=" + entry.Version,
+		"MDRELEASE_TAG=" + tag,
+		"MDRELEASE_TITLE=" + entry.Summary,
+	}
+	for _, command := range commands {
+		if dryRun {
+			dryRunf(stdout, "run verify command: %s", command)
+			continue
+		}
+		_, _ = fmt.Fprintf(stdout, "Verifying: %s\n", command)
+		if err := run(ctx, command, env, stdout, stderr); err != nil {
+			return &preflightError{msg: fmt.Sprintf("verify command %q failed: %v (nothing was committed, tagged, or pushed)", command, err)}
+		}
+	}
+	return nil
+}
+
+func runShell(ctx context.Context, command string, env []string, stdout, stderr io.Writer) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+)
+
+type imageConfig struct {
+	images stringList
+	latest bool
+}
+
+func addImageFlags(fs *flag.FlagSet, ic *imageConfig) {
+	fs.Var(&ic.images, "image", "After pushing the tag, retag this already-pushed container image (registry/name:tag) with the release version and push it (repeatable)")
+	fs.BoolVar(&ic.latest, "image-latest", true, "Also tag --image as latest (never for prerelease versions)")
+}
+
+func (ic imageConfig) enabled() bool { return len(ic.images) > 0 }
+
+func prepareImages(ic imageConfig, d deps) ([]*forge.ImagePublisher, error) {
+	var publishers []*forge.ImagePublisher
+	ctx := d.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, image := range ic.images {
+		p, err := forge.NewImagePublisher(image, d.runTool)
+		if err != nil {
+			return nil, &preflightError{msg: err.Error()}
+		}
+		if err := p.Inspect(ctx); err != nil {
+			return nil, &preflightError{msg: fmt.Sprintf("%v (push the image before releasing)", err)}
+		}
+		publishers = append(publishers, p)
+	}
+	return publishers, nil
+}
+
+func publishImages(ctx context.Context, publishers []*forge.ImagePublisher, ic imageConfig, version string, dryRun bool, stdout io.Writer) error {
+	latest := ic.latest && !isPrerelease(version)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, p := range publishers {
+		targets := p.Targets(version, latest)
+		if dryRun {
+			dryRunf(stdout, "tag image %s as %s", p.Source, strings.Join(targets, ", "))
+			continue
+		}
+		_, _ = fmt.Fprintf(stdout, "Tagging image %s as %s...\n", p.Source, strings.Join(targets, ", "))
+		if err := p.Publish(ctx, targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
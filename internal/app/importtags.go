@@ -0,0 +1,160 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+)
+
+// runImportTags backfills changelog entries from the messages of existing
+// annotated tags, for adopting mdrelease on a repository that has tags but
+// no changelog.
+func runImportTags(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease import-tags", stderr)
+
+	var cfg commonConfig
+	var changelogFlag string
+	var componentName string
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file to backfill (default: changelog.md; created when missing)")
+	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Import tags with this prefix; the rest of the name is the version")
+	fs.StringVar(&componentName, "component", "", "Use the changelog and tag prefix of this [components.<name>] config entry")
+	fs.BoolVar(&cfg.dryRun, "dry-run", false, "Print the entries that would be added without writing the changelog")
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "import-tags", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "import-tags does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+	if componentName != "" {
+		if _, err := applyComponent(componentName, configPath, &cfg, d); err != nil {
+			return err
+		}
+	}
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	tags, err := git.TagDetails(cfg.tagPrefix)
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(cfg.changelogPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return &changelog.ParseError{Path: cfg.changelogPath, Msg: "failed to open changelog", Err: err}
+	}
+	doc := cfg.versions.changelog.ParseDocument(string(existing))
+
+	var imports []gitutil.Tag
+	present := 0
+	for _, tag := range tags {
+		version := strings.TrimPrefix(tag.Name, cfg.tagPrefix)
+		switch {
+		case !cfg.versions.valid(version):
+			_, _ = fmt.Fprintf(stderr, "%s %s: not a version after the %q prefix\n", paint(stderr, colorYellow, "Skipped"), tag.Name, cfg.tagPrefix)
+		case doc.Index(version) >= 0:
+			present++
+		case !tag.Annotated:
+			_, _ = fmt.Fprintf(stderr, "%s %s: lightweight tag has no message\n", paint(stderr, colorYellow, "Skipped"), tag.Name)
+		default:
+			imports = append(imports, tag)
+		}
+	}
+	if len(imports) == 0 {
+		_, _ = fmt.Fprintf(stdout, "No tags to import (%d already in %s)\n", present, cfg.changelogPath)
+		return nil
+	}
+
+	// The changelog lists versions newest first whatever order they were
+	// tagged in, so each entry goes above the first older version.
+	slices.SortFunc(imports, func(a, b gitutil.Tag) int {
+		return cfg.versions.compare(strings.TrimPrefix(b.Name, cfg.tagPrefix), strings.TrimPrefix(a.Name, cfg.tagPrefix))
+	})
+	for _, tag := range imports {
+		version := strings.TrimPrefix(tag.Name, cfg.tagPrefix)
+		summary, bullets := tagNotes(tag, version)
+		entries := doc.Entries()
+		at := slices.IndexFunc(entries, func(b *changelog.Block) bool { return cfg.versions.compare(b.Version, version) < 0 })
+		if at < 0 {
+			at = len(entries)
+		}
+		if err := doc.Insert(at, version, summary, bullets); err != nil {
+			return &preflightError{msg: fmt.Sprintf("%s: %v", cfg.changelogPath, err)}
+		}
+		date := tag.Date.UTC().Format("2006-01-02")
+		if cfg.dryRun {
+			dryRunf(stdout, "would import %s (%s): %s", tag.Name, date, summary)
+			continue
+		}
+		_, _ = fmt.Fprintf(stdout, "Imported %s (%s): %s\n", tag.Name, date, summary)
+	}
+	if cfg.dryRun {
+		_, _ = fmt.Fprintln(stdout, paint(stdout, colorGreen, "Dry-run complete."))
+		return nil
+	}
+	if err := os.WriteFile(cfg.changelogPath, []byte(doc.String()), 0o644); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "%s %d entries into %s\n", paint(stdout, colorGreen, "Imported"), len(imports), cfg.changelogPath)
+	return nil
+}
+
+// tagNotes turns an annotated tag's message into an entry: the subject is
+// the summary, and each list item or paragraph of the body is a bullet, with
+// wrapped lines joined.
+func tagNotes(tag gitutil.Tag, version string) (string, []string) {
+	summary := strings.TrimSpace(strings.TrimLeft(tag.Subject, "#"))
+	if summary == "" {
+		summary = "Release " + version
+	}
+	var bullets []string
+	open := false
+	for _, line := range strings.Split(tag.Body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			open = false
+			continue
+		}
+		if len(line) > 1 && strings.ContainsRune("-*+", rune(line[0])) && line[1] == ' ' {
+			bullets = append(bullets, strings.TrimSpace(line[2:]))
+			open = true
+			continue
+		}
+		if open {
+			bullets[len(bullets)-1] += " " + line
+			continue
+		}
+		bullets = append(bullets, line)
+		open = true
+	}
+	return summary, bullets
+}
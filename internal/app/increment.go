@@ -0,0 +1,50 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/semver"
+)
+
+// checkIncrement enforces that version is one step after the latest tag
+// reachable from rev: the next patch, minor, or major release, or a
+// prerelease of one. It is skipped when there is no earlier tag or either
+// version is not a semantic version.
+func checkIncrement(git gitOps, prefix, version, rev string) error {
+	latest, err := git.PreviousTag(rev, prefix)
+	if err != nil || latest == "" {
+		return err
+	}
+	previous, err := semver.Parse(strings.TrimPrefix(latest, prefix))
+	if err != nil {
+		return nil
+	}
+	next, err := semver.Parse(version)
+	if err != nil {
+		return nil
+	}
+	core := previous.Core()
+	allowed := []string{
+		core.Increment(semver.Patch).String(),
+		core.Increment(semver.Minor).String(),
+		core.Increment(semver.Major).String(),
+	}
+	if previous.IsPrerelease() {
+		// A prerelease can be followed by another prerelease or the release.
+		allowed = append([]string{core.String()}, allowed...)
+	}
+	if next.Core().String() == core.String() && previous.IsPrerelease() {
+		// Prereleases of the same release must move forward.
+		if semver.Compare(next, previous) > 0 {
+			return nil
+		}
+		return &preflightError{msg: fmt.Sprintf("version %s does not follow %s: a prerelease or release of %s must be newer than it; pass --allow-skip to release it anyway", version, latest, core)}
+	}
+	for _, candidate := range allowed {
+		if next.Core().String() == candidate {
+			return nil
+		}
+	}
+	return &preflightError{msg: fmt.Sprintf("version %s does not follow %s: expected %s (or a prerelease of one); pass --allow-skip to release it anyway", version, latest, strings.Join(allowed, ", "))}
+}
@@ -0,0 +1,197 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	journalFileName = "mdrelease-state.json"
+
+	stepStageAll          = "stage-all"
+	stepCommit            = "commit"
+	stepTag               = "tag"
+	stepReleaseBranch     = "release-branch"
+	stepPushCommit        = "push-commit"
+	stepPushTag           = "push-tag"
+	stepPushReleaseBranch = "push-release-branch"
+	stepPostPushHook      = "post-push-hook"
+	stepForgeRelease      = "forge-release"
+	stepNotesOut          = "notes-out"
+	stepImage             = "image"
+	stepGoProxy           = "go-proxy"
+)
+
+type journalState struct {
+	Version          string    `json:"version"`
+	Tag              string    `json:"tag"`
+	Changelog        string    `json:"changelog"`
+	Config           string    `json:"config,omitempty"`
+	Remote           string    `json:"remote"`
+	TagPrefix        string    `json:"tagPrefix"`
+	Component        string    `json:"component,omitempty"`
+	StagePaths       []string  `json:"stagePaths,omitempty"`
+	Target           string    `json:"target,omitempty"`
+	BuildMetadata    string    `json:"buildMetadata,omitempty"`
+	BuildIn          string    `json:"buildMetadataIn,omitempty"`
+	ReleaseBranch    string    `json:"releaseBranch,omitempty"`
+	Sync             string    `json:"sync"`
+	VersionPattern   string    `json:"versionPattern,omitempty"`
+	VersionOrder     string    `json:"versionOrder,omitempty"`
+	HeadingSeparator string    `json:"headingSeparator,omitempty"`
+	SkipLFS          bool      `json:"skipLFS,omitempty"`
+	RequireSignature string    `json:"requireSignature,omitempty"`
+	CommitDate       string    `json:"commitDate,omitempty"`
+	Forge            string    `json:"forge,omitempty"`
+	ForgeRepo        string    `json:"forgeRepo,omitempty"`
+	ForgeURL         string    `json:"forgeURL,omitempty"`
+	ForgeBackend     string    `json:"forgeBackend,omitempty"`
+	ForgePlugin      string    `json:"forgePlugin,omitempty"`
+	Assets           []string  `json:"assets,omitempty"`
+	NotesOut         []string  `json:"notesOut,omitempty"`
+	ForceRetag       bool      `json:"forceRetag,omitempty"`
+	GenerateNotes    bool      `json:"generateNotes,omitempty"`
+	Sign             bool      `json:"sign,omitempty"`
+	CosignKey        string    `json:"cosignKey,omitempty"`
+	SignPattern      string    `json:"signPattern,omitempty"`
+	PackageDesc      string    `json:"packageDesc,omitempty"`
+	PackageLicense   string    `json:"packageLicense,omitempty"`
+	HomebrewTap      string    `json:"homebrewTap,omitempty"`
+	HomebrewName     string    `json:"homebrewFormula,omitempty"`
+	ScoopBucket      string    `json:"scoopBucket,omitempty"`
+	ScoopName        string    `json:"scoopManifest,omitempty"`
+	WingetRepo       string    `json:"wingetRepo,omitempty"`
+	WingetID         string    `json:"wingetID,omitempty"`
+	GoProxyURL       string    `json:"goProxyURL,omitempty"`
+	GoMod            string    `json:"goMod,omitempty"`
+	Pkgsite          bool      `json:"pkgsite,omitempty"`
+	PreCommitHooks   []string  `json:"preCommitHooks,omitempty"`
+	PreTagHooks      []string  `json:"preTagHooks,omitempty"`
+	PostPushHooks    []string  `json:"postPushHooks,omitempty"`
+	OnFailureHooks   []string  `json:"onFailureHooks,omitempty"`
+	Plugins          []string  `json:"plugins,omitempty"`
+	VersionFiles     []string  `json:"versionFiles,omitempty"`
+	WriteVersion     []string  `json:"writeVersion,omitempty"`
+	WriteGoVersion   []string  `json:"writeGoVersion,omitempty"`
+	WebhookOnFail    bool      `json:"webhookOnFailure,omitempty"`
+	AnnounceTmpl     string    `json:"announceTemplate,omitempty"`
+	EmailTo          []string  `json:"emailTo,omitempty"`
+	EmailFrom        string    `json:"emailFrom,omitempty"`
+	SMTPAddr         string    `json:"smtpAddr,omitempty"`
+	SMTPUsername     string    `json:"smtpUsername,omitempty"`
+	PkgsiteURL       string    `json:"pkgsiteURL,omitempty"`
+	Images           []string  `json:"images,omitempty"`
+	ImageLatest      bool      `json:"imageLatest,omitempty"`
+	Planned          []string  `json:"planned"`
+	Completed        []string  `json:"completed"`
+	StartedAt        time.Time `json:"startedAt"`
+}
+
+func (s journalState) done(step string) bool {
+	return slices.Contains(s.Completed, step)
+}
+
+func (s journalState) pending(step string) bool {
+	return slices.Contains(s.Planned, step) && !s.done(step)
+}
+
+func (s journalState) remaining() []string {
+	var steps []string
+	for _, step := range s.Planned {
+		if !s.done(step) {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+type releaseJournal struct {
+	path  string
+	state journalState
+}
+
+func (j *releaseJournal) done(step string) bool {
+	return j != nil && j.state.done(step)
+}
+
+func (j *releaseJournal) pending(step string) bool {
+	return j != nil && j.state.pending(step)
+}
+
+func journalPath(gitDir string) string {
+	return filepath.Join(gitDir, journalFileName)
+}
+
+func loadJournal(gitDir string) (*releaseJournal, error) {
+	path := journalPath(gitDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read release journal %s: %w", path, err)
+	}
+	j := &releaseJournal{path: path}
+	if err := json.Unmarshal(data, &j.state); err != nil {
+		return nil, fmt.Errorf("parse release journal %s: %w (remove it or run `mdrelease resume --discard`)", path, err)
+	}
+	return j, nil
+}
+
+func (j *releaseJournal) save() error {
+	if j == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(j.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("write release journal %s: %w", j.path, err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write release journal %s: %w", j.path, err)
+	}
+	return nil
+}
+
+func (j *releaseJournal) complete(step string) error {
+	if j == nil || j.state.done(step) {
+		return nil
+	}
+	j.state.Completed = append(j.state.Completed, step)
+	return j.save()
+}
+
+func (j *releaseJournal) forgetRolledBack(steps stepLog) error {
+	if j == nil {
+		return nil
+	}
+	for _, step := range steps.completed {
+		if step.key != "" && step.rolledBack {
+			j.state.Completed = slices.DeleteFunc(j.state.Completed, func(s string) bool { return s == step.key })
+		}
+	}
+	if len(j.state.Completed) == 0 {
+		return j.remove()
+	}
+	return j.save()
+}
+
+func (j *releaseJournal) remove() error {
+	if j == nil {
+		return nil
+	}
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove release journal %s: %w", j.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/semver"
+)
+
+const (
+	latestFromLocal  = "local"
+	latestFromRemote = "remote"
+	latestFromBoth   = "both"
+)
+
+// runLatest prints the highest version tag, and with --compare whether the
+// changelog version is still waiting to be released.
+func runLatest(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease latest", stderr)
+
+	var cfg commonConfig
+	var changelogFlag string
+	var componentName string
+	var from string
+	var compare bool
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file for --compare (default: changelog.md)")
+	fs.StringVar(&cfg.remote, "remote", "origin", "Git remote name")
+	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix")
+	fs.StringVar(&componentName, "component", "", "Use the changelog and tag prefix of this [components.<name>] config entry")
+	fs.StringVar(&from, "from", latestFromLocal, "Where to look for tags: local, remote (git ls-remote), or both")
+	fs.BoolVar(&compare, "compare", false, "Compare the latest tag with the changelog version: exit 0 when a release is pending, 7 when it is already tagged, 4 when the changelog is behind")
+	addRemoteFlags(fs, &cfg)
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "latest", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "latest does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	switch from {
+	case latestFromLocal, latestFromRemote, latestFromBoth:
+	default:
+		return &usageError{msg: fmt.Sprintf("invalid --from value %q (expected local, remote, or both)", from)}
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	stdout = cfg.narration(result)
+	stderr = cfg.diagnostics(stderr)
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	cfg.resolveGitToken(d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+	if componentName != "" {
+		if _, err := applyComponent(componentName, configPath, &cfg, d); err != nil {
+			return err
+		}
+	}
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	var tags []string
+	if from != latestFromRemote {
+		local, err := git.Tags(cfg.tagPrefix)
+		if err != nil {
+			return err
+		}
+		tags = append(tags, local...)
+	}
+	if from != latestFromLocal {
+		if err := git.EnsureRemote(cfg.remote); err != nil {
+			return err
+		}
+		remote, err := git.RemoteTags(cfg.remote, cfg.tagPrefix)
+		if err != nil {
+			return err
+		}
+		tags = append(tags, remote...)
+	}
+	latest := ""
+	for _, tag := range tags {
+		version := strings.TrimPrefix(tag, cfg.tagPrefix)
+		if !cfg.versions.valid(version) {
+			continue
+		}
+		if latest == "" || cfg.versions.compare(version, strings.TrimPrefix(latest, cfg.tagPrefix)) > 0 {
+			latest = tag
+		}
+	}
+
+	if !compare {
+		if latest == "" {
+			want := "<semver>"
+			if cfg.versions.pattern != nil {
+				want = cfg.versions.source
+			}
+			return &noOpError{msg: fmt.Sprintf("no %s tags matching %s%s", from, cfg.tagPrefix, want)}
+		}
+		_, _ = fmt.Fprintln(result, latest)
+		return nil
+	}
+
+	entry, err := cfg.versions.changelog.ParseLatest(cfg.changelogPath)
+	if err != nil {
+		return err
+	}
+	if err := cfg.versions.check(entry.Version); err != nil {
+		return &preflightError{msg: fmt.Sprintf("cannot compare %s from %s: %v", entry.Version, cfg.changelogPath, err)}
+	}
+	if latest == "" {
+		_, _ = fmt.Fprintf(result, "%s: %s has no %s tags yet\n", paint(result, colorGreen, "Release pending"), cfg.tagPrefix+entry.Version, from)
+		return nil
+	}
+	_, _ = fmt.Fprintf(stdout, "Latest tag: %s\n", latest)
+	_, _ = fmt.Fprintf(stdout, "Changelog: %s (%s)\n", entry.Version, cfg.changelogPath)
+	switch c := cfg.versions.compare(entry.Version, strings.TrimPrefix(latest, cfg.tagPrefix)); {
+	case c > 0:
+		_, _ = fmt.Fprintf(result, "%s: %s is newer than %s\n", paint(result, colorGreen, "Release pending"), cfg.tagPrefix+entry.Version, latest)
+		return nil
+	case c == 0:
+		return &noOpError{msg: fmt.Sprintf("changelog version %s is already released as %s", entry.Version, latest)}
+	default:
+		return &preflightError{msg: fmt.Sprintf("changelog version %s is behind the latest tag %s (update %s)", entry.Version, latest, cfg.changelogPath)}
+	}
+}
+
+// compareVersions orders two changelog or tag versions by semver
+// precedence. Versions that are not semantic versions, such as the 1.2
+// headings the changelog accepts, sort before every semantic version.
+func compareVersions(a, b string) int {
+	av, aErr := semver.Parse(a)
+	bv, bErr := semver.Parse(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return semver.Compare(av, bv)
+	case aErr == nil:
+		return 1
+	case bErr == nil:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// isPrerelease reports whether version is a semantic version with
+// prerelease identifiers, such as 1.4.0-rc.1.
+func isPrerelease(version string) bool {
+	v, err := semver.Parse(version)
+	return err == nil && v.IsPrerelease()
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+)
+
+const (
+	lockFileName  = "mdrelease.lock"
+	remoteLockRef = "refs/mdrelease/lock"
+)
+
+func addLockFlags(fs *flag.FlagSet, cfg *commonConfig) {
+	fs.BoolVar(&cfg.breakLock, "break-lock", false, "Remove a stale .git/mdrelease.lock, and with --remote-lock the remote lock ref, left by an interrupted release before starting")
+	fs.BoolVar(&cfg.remoteLock, "remote-lock", false, "Hold an advisory lock on the remote ("+remoteLockRef+") while pushing, so releases from different machines cannot race")
+}
+
+type releaseLock struct {
+	path string
+}
+
+func acquireReleaseLock(gitDir string, breakLock bool) (*releaseLock, error) {
+	path := filepath.Join(gitDir, lockFileName)
+	if breakLock {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("break release lock %s: %w", path, err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return nil, &preflightError{msg: describeHeldLock(path)}
+		}
+		return nil, fmt.Errorf("create release lock %s: %w", path, err)
+	}
+	host, _ := os.Hostname()
+	_, writeErr := fmt.Fprintf(file, "%d\n%s\n%s\n", os.Getpid(), host, time.Now().UTC().Format(time.RFC3339))
+	closeErr := file.Close()
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		_ = os.Remove(path)
+		return nil, fmt.Errorf("write release lock %s: %w", path, err)
+	}
+	return &releaseLock{path: path}, nil
+}
+
+func (l *releaseLock) release() {
+	if l == nil {
+		return
+	}
+	_ = os.Remove(l.path)
+}
+
+func describeHeldLock(path string) string {
+	msg := "another release is in progress"
+	data, err := os.ReadFile(path)
+	if err == nil {
+		fields := strings.Split(strings.TrimSpace(string(data)), "\n")
+		var details []string
+		if len(fields) > 0 {
+			if pid, err := strconv.Atoi(strings.TrimSpace(fields[0])); err == nil {
+				details = append(details, fmt.Sprintf("pid %d", pid))
+			}
+		}
+		if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+			details = append(details, "host "+strings.TrimSpace(fields[1]))
+		}
+		if len(fields) > 2 && strings.TrimSpace(fields[2]) != "" {
+			details = append(details, "started "+strings.TrimSpace(fields[2]))
+		}
+		if len(details) > 0 {
+			msg += " (" + strings.Join(details, ", ") + ")"
+		}
+	}
+	return fmt.Sprintf("%s; lock file %s (pass --break-lock if that run is no longer active)", msg, path)
+}
+
+// refLock is the --remote-lock ref this run pushed, which it deletes when
+// it finishes.
+type refLock struct {
+	git    gitOps
+	remote string
+	commit string
+}
+
+// lockRemote takes the remote lock before a run changes the remote. The lock
+// commit's message says who holds it, for the error another run reports.
+// Dry runs do not lock.
+func lockRemote(git gitOps, cfg commonConfig, tag string) (*refLock, error) {
+	if !cfg.remoteLock || cfg.dryRun {
+		return nil, nil
+	}
+	host, _ := os.Hostname()
+	msg := fmt.Sprintf("mdrelease lock for %s\n\npid %d, host %s, started %s\n", tag, os.Getpid(), host, time.Now().UTC().Format(time.RFC3339))
+	commit, err := git.AcquireRemoteLock(cfg.remote, remoteLockRef, msg, cfg.breakLock)
+	if err != nil {
+		var held *gitutil.RemoteLockHeldError
+		if errors.As(err, &held) {
+			return nil, &preflightError{msg: fmt.Sprintf("another release is in progress: %v (pass --break-lock if that run is no longer active)", err)}
+		}
+		return nil, err
+	}
+	return &refLock{git: git, remote: cfg.remote, commit: commit}, nil
+}
+
+func (l *refLock) release() {
+	if l == nil {
+		return
+	}
+	_ = l.git.ReleaseRemoteLock(l.remote, remoteLockRef, l.commit)
+}
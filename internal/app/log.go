@@ -0,0 +1,89 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+const (
+	logFormatPlain = "plain"
+	logFormatText  = "text"
+	logFormatJSON  = "json"
+)
+
+var logFormats = []string{logFormatPlain, logFormatText, logFormatJSON}
+
+// newLogger returns the logger for --log-format text or json, or nil for
+// plain output.
+func newLogger(w io.Writer, format string) *slog.Logger {
+	switch format {
+	case logFormatText:
+		return slog.New(slog.NewTextHandler(w, nil))
+	case logFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, nil))
+	}
+	return nil
+}
+
+// logWriter logs each line written to it as an info record, so the
+// narration every command prints becomes a structured log with --log-format
+// text or json. Warnings, errors, and skipped dry-run steps are logged at
+// their own level by warnf, printErr, and dryRunf.
+type logWriter struct{ logger *slog.Logger }
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	for line := range strings.Lines(string(p)) {
+		if msg := strings.TrimSpace(line); msg != "" {
+			w.logger.Info(msg)
+		}
+	}
+	return len(p), nil
+}
+
+// loggerOf returns the logger w writes to, or nil when w prints plain text.
+func loggerOf(w io.Writer) *slog.Logger {
+	if lw, ok := w.(*logWriter); ok {
+		return lw.logger
+	}
+	return nil
+}
+
+// warnf prints a warning after a yellow "Warning:", or logs it at warn level.
+func warnf(w io.Writer, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if logger := loggerOf(w); logger != nil {
+		logger.Warn(msg)
+		return
+	}
+	_, _ = fmt.Fprintln(w, paint(w, colorYellow, "Warning:"), msg)
+}
+
+// printErr prints err after a red "Error:", or logs it at error level.
+func printErr(w io.Writer, err error) {
+	if logger := loggerOf(w); logger != nil {
+		logger.Error(err.Error())
+		return
+	}
+	_, _ = fmt.Fprintln(w, paint(w, colorRed, "Error:"), err)
+}
+
+// dryRunf prints what --dry-run skipped after "[dry-run]", or logs it with
+// dry_run=true.
+func dryRunf(w io.Writer, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if logger := loggerOf(w); logger != nil {
+		logger.Info(msg, slog.Bool("dry_run", true))
+		return
+	}
+	_, _ = fmt.Fprintln(w, "[dry-run]", msg)
+}
+
+func validLogFormat(format string) error {
+	if !slices.Contains(logFormats, format) {
+		return &usageError{msg: fmt.Sprintf("invalid --log-format value %q (expected %s)", format, strings.Join(logFormats, ", "))}
+	}
+	return nil
+}
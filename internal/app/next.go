@@ -0,0 +1,196 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+	"github.com/jasonwillschiu/mdrelease/internal/semver"
+)
+
+var conventionalSubject = regexp.MustCompile(`^([A-Za-z]+)(\([^)]*\))?(!)?:\s*(.+)$`)
+
+// classifyCommit reads a Conventional Commits subject: a "!" or a
+// BREAKING CHANGE footer is major, feat is minor, and anything else,
+// including a subject without a type, is a patch. It also returns the
+// subject without its type prefix.
+func classifyCommit(c gitutil.Commit) (semver.Level, string) {
+	level, text := semver.Patch, c.Subject
+	if m := conventionalSubject.FindStringSubmatch(c.Subject); m != nil {
+		text = m[4]
+		if strings.EqualFold(m[1], "feat") {
+			level = semver.Minor
+		}
+		if m[3] == "!" {
+			level = semver.Major
+		}
+	}
+	for _, line := range strings.Split(c.Body, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			level = semver.Major
+		}
+	}
+	return level, text
+}
+
+// nextVersion applies level to version. Before 1.0.0 a breaking change
+// bumps the minor version, as semver allows anything to change in 0.x.
+func nextVersion(version semver.Version, level semver.Level) semver.Version {
+	if version.Major == 0 && level == semver.Major {
+		level = semver.Minor
+	}
+	return version.Increment(level)
+}
+
+func runNext(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease next", stderr)
+
+	var cfg commonConfig
+	var changelogFlag string
+	var componentName string
+	var write bool
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
+	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix")
+	var branchPrefixes stringList
+	addBranchPrefixFlag(fs, &branchPrefixes)
+	fs.StringVar(&componentName, "component", "", "Suggest the next version of this [components.<name>] config entry, from the commits that touch its path")
+	fs.BoolVar(&write, "write", false, "Add a stub entry for the suggested version to the top of the changelog")
+	fs.BoolVar(&cfg.dryRun, "dry-run", false, "With --write, print the entry that would be added without changing the changelog")
+	addRemoteFlags(fs, &cfg)
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "next", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "next does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	stdout = cfg.narration(result)
+	stderr = cfg.diagnostics(stderr)
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+	var paths []string
+	if componentName != "" {
+		c, err := applyComponent(componentName, configPath, &cfg, d)
+		if err != nil {
+			return err
+		}
+		paths = []string{c.path}
+		// A component's own tag-prefix replaces the branch rules.
+		branchPrefixes = nil
+	}
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	if cfg.tagPrefix, err = resolveTagPrefix(git, cfg.tagPrefix, branchPrefixes, d.getenv); err != nil {
+		return err
+	}
+	if err := ensureFullHistory(git, cfg, stdout); err != nil {
+		return err
+	}
+	since, err := git.PreviousTag("HEAD", cfg.tagPrefix)
+	if err != nil {
+		return err
+	}
+	var current semver.Version
+	if since != "" {
+		if current, err = semver.Parse(strings.TrimPrefix(since, cfg.tagPrefix)); err != nil {
+			return &preflightError{msg: fmt.Sprintf("cannot suggest a version after %s: %v (next only suggests semantic versions)", since, err)}
+		}
+	}
+	commits, err := git.CommitsSince(since, paths...)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return &noOpError{msg: fmt.Sprintf("no commits since %s to release", since)}
+	}
+
+	level := semver.Patch
+	var levels []semver.Level
+	var notes []string
+	described := since
+	if described == "" {
+		described = "the first commit"
+	}
+	_, _ = fmt.Fprintf(stdout, "Commits since %s:\n", described)
+	for _, c := range commits {
+		l, text := classifyCommit(c)
+		level = max(level, l)
+		levels = append(levels, l)
+		notes = append(notes, text)
+		_, _ = fmt.Fprintf(stdout, "  %-5s  %s %s\n", l, c.Hash, c.Subject)
+	}
+	next := nextVersion(current, level).String()
+	if err := cfg.versions.check(next); err != nil {
+		return &preflightError{msg: fmt.Sprintf("cannot suggest a version: %v", err)}
+	}
+	_, _ = fmt.Fprintf(stdout, "Suggested %s bump: %s\n", level, next)
+
+	if write {
+		// The most significant change makes the summary.
+		summary := notes[slices.Index(levels, level)]
+		if err := writeChangelogStub(cfg.versions, cfg.changelogPath, next, summary, notes, cfg.dryRun, stdout); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintln(result, next)
+	return nil
+}
+
+// writeChangelogStub adds "# <version> - <summary>" with every note as a
+// bullet above the existing entries, for the author to edit before release.
+func writeChangelogStub(versions versionScheme, path, version, summary string, notes []string, dryRun bool, stdout io.Writer) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	doc := versions.changelog.ParseDocument(string(existing))
+	if entries := doc.Entries(); len(entries) > 0 {
+		if versions.valid(entries[0].Version) && versions.compare(version, entries[0].Version) <= 0 {
+			return &preflightError{msg: fmt.Sprintf("%s already starts at %s; edit that entry instead of adding %s", path, entries[0].Version, version)}
+		}
+	}
+	if err := doc.Insert(0, version, summary, notes); err != nil {
+		return &preflightError{msg: fmt.Sprintf("%s: %v", path, err)}
+	}
+	if dryRun {
+		dryRunf(stdout, "would add to %s:", path)
+		_, _ = fmt.Fprint(stdout, doc.Entries()[0])
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(doc.String()), 0o644); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "Added %s to %s\n", version, path)
+	return nil
+}
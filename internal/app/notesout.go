@@ -0,0 +1,46 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// releaseNotes are the notes a release used: markdown is the forge release
+// body (generated notes included), text is the annotated tag message.
+type releaseNotes struct {
+	markdown string
+	text     string
+}
+
+// notesFormat is how --notes-out renders path: plain text for .txt files,
+// markdown otherwise.
+func notesFormat(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".txt") {
+		return "text"
+	}
+	return "markdown"
+}
+
+func writeReleaseNotes(paths []string, notes releaseNotes, dryRun bool, stdout io.Writer) error {
+	for _, path := range paths {
+		format, content := notesFormat(path), notes.markdown
+		if format == "text" {
+			content = notes.text
+		}
+		if dryRun {
+			dryRunf(stdout, "write %s release notes to %s", format, path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("--notes-out: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(strings.TrimRight(content, "\n")+"\n"), 0o644); err != nil {
+			return fmt.Errorf("--notes-out: %w", err)
+		}
+		_, _ = fmt.Fprintf(stdout, "Wrote %s release notes to %s\n", format, path)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+	"github.com/jasonwillschiu/mdrelease/internal/notify"
+)
+
+type notifyConfig struct {
+	webhooks  stringList
+	onFailure bool
+	slack     stringList
+	discord   stringList
+	template  string
+	announce  *template.Template
+	emailTo   stringList
+	emailFrom string
+	smtpAddr  string
+	smtpUser  string
+	smtpPass  string
+}
+
+func addNotifyFlags(fs *flag.FlagSet, nc *notifyConfig) {
+	fs.Var(&nc.webhooks, "webhook", "POST a JSON payload (version, tag, summary, notes, repo, compare URL) to this URL after a successful release (repeatable)")
+	fs.BoolVar(&nc.onFailure, "webhook-on-failure", false, "Also POST to --webhook when the release fails, with status \"failure\" and the error")
+	fs.Var(&nc.slack, "slack-webhook", "Announce a successful release as a Slack Block Kit message to this incoming webhook URL (repeatable)")
+	fs.Var(&nc.discord, "discord-webhook", "Announce a successful release as a Discord embed to this webhook URL (repeatable)")
+	fs.Var(&nc.emailTo, "email-to", "Email the release notes to this address after a successful release (repeatable; requires --smtp-addr and --email-from)")
+	fs.StringVar(&nc.emailFrom, "email-from", "", "Sender address for --email-to")
+	fs.StringVar(&nc.smtpAddr, "smtp-addr", "", "SMTP relay host:port for --email-to (STARTTLS is used when offered)")
+	fs.StringVar(&nc.smtpUser, "smtp-username", "", "SMTP username; no authentication when empty")
+	fs.StringVar(&nc.smtpPass, "smtp-password", "", "SMTP password (or set MDRELEASE_SMTP_PASSWORD)")
+	fs.StringVar(&nc.template, "announce-template", "", "Go template for the Slack/Discord announcement line (fields: .Version, .Tag, .Summary, .Notes, .Repo, .ReleaseURL, .CompareURL)")
+}
+
+func (nc notifyConfig) enabled() bool {
+	return len(nc.webhooks)+len(nc.slack)+len(nc.discord)+len(nc.emailTo) > 0
+}
+
+func (nc *notifyConfig) prepare(getenv func(string) string) error {
+	if len(nc.emailTo) > 0 {
+		if strings.TrimSpace(nc.smtpAddr) == "" || strings.TrimSpace(nc.emailFrom) == "" {
+			return &usageError{msg: "--email-to requires --smtp-addr and --email-from"}
+		}
+		if nc.smtpPass == "" && getenv != nil {
+			nc.smtpPass = getenv("MDRELEASE_SMTP_PASSWORD")
+		}
+	}
+	if nc.template != "" && len(nc.slack)+len(nc.discord) == 0 {
+		return &usageError{msg: "--announce-template requires --slack-webhook or --discord-webhook"}
+	}
+	tmpl, err := notify.ParseAnnouncement(nc.template)
+	if err != nil {
+		return &usageError{msg: fmt.Sprintf("invalid --announce-template: %v", err)}
+	}
+	nc.announce = tmpl
+	return nil
+}
+
+type releaseNotifier struct {
+	nc       notifyConfig
+	ctx      context.Context
+	sendMail notify.SendMailFunc
+	git      gitOps
+	cfg      commonConfig
+	remote   forge.Remote
+	repo     string
+	entry    *changelog.Entry
+	tag      string
+	target   string
+	stdout   io.Writer
+}
+
+func newReleaseNotifier(nc notifyConfig, git gitOps, cfg commonConfig, remote forge.Remote, fc forgeConfig, entry *changelog.Entry, tag, target string, stdout io.Writer, d deps) *releaseNotifier {
+	if !nc.enabled() {
+		return nil
+	}
+	n := &releaseNotifier{nc: nc, ctx: d.ctx, sendMail: d.sendMail, git: git, cfg: cfg, remote: remote, repo: fc.repo, entry: entry, tag: tag, target: target, stdout: stdout}
+	if n.ctx == nil {
+		n.ctx = context.Background()
+	}
+	if n.repo == "" {
+		n.repo = remote.Repo
+	}
+	return n
+}
+
+func (n *releaseNotifier) payload(status string) notify.Release {
+	r := notify.Release{
+		Status:  status,
+		Version: n.entry.Version,
+		Tag:     n.tag,
+		Summary: n.entry.Summary,
+		Notes:   n.entry.Description,
+		Repo:    n.repo,
+	}
+	if n.remote.Kind == "" {
+		return r
+	}
+	if status == notify.StatusSuccess {
+		r.ReleaseURL = n.remote.ReleaseURL(n.tag)
+	}
+	if previous, err := previousTag(n.git, n.cfg, n.tag, n.target); err == nil && previous != "" {
+		r.CompareURL = n.remote.CompareURL(previous, n.tag)
+	}
+	return r
+}
+
+// send never fails the release: by the time it runs the release is either
+// published or already failing, so delivery problems are only warnings.
+func (n *releaseNotifier) send(status string, releaseErr error) {
+	if n == nil || (status == notify.StatusFailure && !n.nc.onFailure) {
+		return
+	}
+	if n.cfg.dryRun {
+		if len(n.nc.webhooks) > 0 {
+			dryRunf(n.stdout, "notify %d webhook(s) of %s %s", len(n.nc.webhooks), status, n.tag)
+		}
+		if chats := len(n.nc.slack) + len(n.nc.discord); chats > 0 && status == notify.StatusSuccess {
+			dryRunf(n.stdout, "announce %s to %d Slack/Discord webhook(s)", n.tag, chats)
+		}
+		if len(n.nc.emailTo) > 0 && status == notify.StatusSuccess {
+			dryRunf(n.stdout, "email %s release notes to %s", n.tag, strings.Join(n.nc.emailTo, ", "))
+		}
+		return
+	}
+	r := n.payload(status)
+	if releaseErr != nil {
+		r.Error = releaseErr.Error()
+	}
+	for _, url := range n.nc.webhooks {
+		if err := (notify.Webhook{URL: url}).Send(n.ctx, r); err != nil {
+			warnf(n.stdout, "webhook notification failed: %v", err)
+			continue
+		}
+		_, _ = fmt.Fprintf(n.stdout, "Notified webhook of %s %s\n", status, n.tag)
+	}
+	if status == notify.StatusSuccess {
+		n.announce(r)
+		n.email(r)
+	}
+}
+
+func (n *releaseNotifier) email(r notify.Release) {
+	if len(n.nc.emailTo) == 0 {
+		return
+	}
+	e := notify.Email{
+		Addr:     n.nc.smtpAddr,
+		Username: n.nc.smtpUser,
+		Password: n.nc.smtpPass,
+		From:     n.nc.emailFrom,
+		To:       n.nc.emailTo,
+		SendMail: n.sendMail,
+	}
+	if err := e.Send(r, time.Now()); err != nil {
+		warnf(n.stdout, "email notification failed: %v", err)
+		return
+	}
+	_, _ = fmt.Fprintf(n.stdout, "Emailed %s release notes to %s\n", n.tag, strings.Join(n.nc.emailTo, ", "))
+}
+
+// announce posts the release to the chat webhooks; failed releases are
+// only reported through --webhook.
+func (n *releaseNotifier) announce(r notify.Release) {
+	if len(n.nc.slack)+len(n.nc.discord) == 0 {
+		return
+	}
+	tmpl := n.nc.announce
+	if tmpl == nil {
+		tmpl, _ = notify.ParseAnnouncement("")
+	}
+	text, err := notify.RenderAnnouncement(tmpl, r)
+	if err != nil {
+		warnf(n.stdout, "announcement template failed: %v", err)
+		return
+	}
+	chats := []struct {
+		name   string
+		urls   []string
+		format func(notify.Release, string) ([]byte, error)
+	}{
+		{"Slack", n.nc.slack, notify.SlackPayload},
+		{"Discord", n.nc.discord, notify.DiscordPayload},
+	}
+	for _, chat := range chats {
+		if len(chat.urls) == 0 {
+			continue
+		}
+		body, err := chat.format(r, text)
+		if err != nil {
+			warnf(n.stdout, "%s announcement failed: %v", chat.name, err)
+			continue
+		}
+		for _, url := range chat.urls {
+			if err := (notify.Webhook{URL: url}).Post(n.ctx, "application/json", body); err != nil {
+				warnf(n.stdout, "%s announcement failed: %v", chat.name, err)
+				continue
+			}
+			_, _ = fmt.Fprintf(n.stdout, "Announced %s on %s\n", n.tag, chat.name)
+		}
+	}
+}
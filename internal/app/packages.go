@@ -0,0 +1,227 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+	"github.com/jasonwillschiu/mdrelease/internal/packaging"
+)
+
+const (
+	packageHomebrew = "homebrew"
+	packageScoop    = "scoop"
+	packageWinget   = "winget"
+)
+
+type packageTarget struct {
+	repo  string
+	name  string
+	token string
+}
+
+type packageConfig struct {
+	desc     string
+	license  string
+	homebrew packageTarget
+	scoop    packageTarget
+	winget   packageTarget
+}
+
+var packageTokenEnvs = map[string]string{
+	packageHomebrew: "HOMEBREW_TAP_TOKEN",
+	packageScoop:    "SCOOP_BUCKET_TOKEN",
+	packageWinget:   "WINGET_TOKEN",
+}
+
+func addPackageFlags(fs *flag.FlagSet, pc *packageConfig) {
+	fs.StringVar(&pc.desc, "package-desc", "", "Description for Homebrew, Scoop, and winget manifests")
+	fs.StringVar(&pc.desc, "homebrew-desc", "", "Alias for --package-desc")
+	fs.StringVar(&pc.license, "package-license", "", "SPDX license identifier for Homebrew, Scoop, and winget manifests")
+	fs.StringVar(&pc.homebrew.repo, "homebrew-tap", "", "After the forge release, commit an updated Homebrew formula to this GitHub tap repository (owner/homebrew-tap)")
+	fs.StringVar(&pc.homebrew.name, "homebrew-formula", "", "Homebrew formula name, written to Formula/<name>.rb (default: the forge repository name)")
+	fs.StringVar(&pc.homebrew.token, "homebrew-token", "", "GitHub token with write access to the tap (default: $HOMEBREW_TAP_TOKEN, then the forge token)")
+	fs.StringVar(&pc.scoop.repo, "scoop-bucket", "", "After the forge release, commit an updated Scoop manifest to this GitHub bucket repository (owner/scoop-bucket)")
+	fs.StringVar(&pc.scoop.name, "scoop-manifest", "", "Scoop manifest name, written to bucket/<name>.json (default: the forge repository name)")
+	fs.StringVar(&pc.scoop.token, "scoop-token", "", "GitHub token with write access to the bucket (default: $SCOOP_BUCKET_TOKEN, then the forge token)")
+	fs.StringVar(&pc.winget.repo, "winget-repo", "", "After the forge release, commit winget manifests to this GitHub repository (for example your fork of microsoft/winget-pkgs)")
+	fs.StringVar(&pc.winget.name, "winget-id", "", "winget package identifier (Publisher.Package); required with --winget-repo")
+	fs.StringVar(&pc.winget.token, "winget-token", "", "GitHub token with write access to the winget repository (default: $WINGET_TOKEN, then the forge token)")
+}
+
+func (pc packageConfig) targets() []string {
+	var kinds []string
+	for _, kind := range []string{packageHomebrew, packageScoop, packageWinget} {
+		if pc.target(kind).repo != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+func (pc *packageConfig) target(kind string) *packageTarget {
+	switch kind {
+	case packageHomebrew:
+		return &pc.homebrew
+	case packageScoop:
+		return &pc.scoop
+	default:
+		return &pc.winget
+	}
+}
+
+func (pc *packageConfig) resolve(fc forgeConfig, getenv func(string) string) error {
+	repoFlags := map[string]string{packageHomebrew: "--homebrew-tap", packageScoop: "--scoop-bucket", packageWinget: "--winget-repo"}
+	nameFlags := map[string]string{packageHomebrew: "--homebrew-formula", packageScoop: "--scoop-manifest", packageWinget: "--winget-id"}
+	for _, kind := range []string{packageHomebrew, packageScoop, packageWinget} {
+		t := pc.target(kind)
+		if t.repo == "" {
+			if t.name != "" {
+				return &usageError{msg: fmt.Sprintf("%s requires %s", nameFlags[kind], repoFlags[kind])}
+			}
+			continue
+		}
+		if !fc.enabled() || len(fc.assets) == 0 {
+			return &usageError{msg: fmt.Sprintf("%s requires --forge and at least one --asset", repoFlags[kind])}
+		}
+		owner, repo, ok := strings.Cut(strings.Trim(t.repo, "/"), "/")
+		if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return &usageError{msg: fmt.Sprintf("invalid %s value %q (expected owner/repo)", repoFlags[kind], t.repo)}
+		}
+		if kind == packageWinget && !packaging.ValidWingetID(t.name) {
+			return &usageError{msg: fmt.Sprintf("--winget-repo requires --winget-id Publisher.Package (got %q)", t.name)}
+		}
+		if t.token == "" && getenv != nil {
+			t.token = strings.TrimSpace(getenv(packageTokenEnvs[kind]))
+		}
+	}
+	return nil
+}
+
+func (d deps) repoWriter(apiURL, repo, token string) (forge.FileWriter, error) {
+	if d.newRepoWriter != nil {
+		return d.newRepoWriter(apiURL, repo, token)
+	}
+	return forge.NewGitHub(apiURL, token, repo, nil)
+}
+
+type packagePublisher struct {
+	kind   string
+	target packageTarget
+	writer forge.FileWriter
+}
+
+type packagePublish struct {
+	pc         packageConfig
+	publishers []packagePublisher
+	download   forge.Remote
+	assets     []forge.Asset
+	dryRun     bool
+}
+
+func preparePackages(pc packageConfig, fc forgeConfig, remote forge.Remote, assets []forge.Asset, dryRun bool, d deps) (*packagePublish, error) {
+	kinds := pc.targets()
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	download := forge.RemoteFor(fc.kind, fc.apiURL, fc.repo)
+	if remote.Kind == fc.kind && remote.Repo == fc.repo {
+		download = remote
+	}
+	if download.Host == "" {
+		return nil, &preflightError{msg: fmt.Sprintf("cannot derive asset download URLs for package manifests with --forge %s (pass --forge-url)", fc.kind)}
+	}
+	platforms := map[string]bool{}
+	for _, a := range assets {
+		if goos, _, ok := packaging.DetectPlatform(a.Name); ok {
+			platforms[goos] = true
+		}
+	}
+	apiURL := forge.DefaultGitHubAPIURL
+	if fc.kind == forgeGitHub && fc.apiURL != "" {
+		apiURL = fc.apiURL
+	}
+	p := &packagePublish{pc: pc, download: download, assets: assets, dryRun: dryRun}
+	for _, kind := range kinds {
+		t := *pc.target(kind)
+		if t.name == "" {
+			t.name = path.Base(fc.repo)
+		}
+		switch {
+		case kind == packageHomebrew && !platforms["darwin"] && !platforms["linux"]:
+			return nil, &preflightError{msg: "--homebrew-tap needs at least one darwin or linux --asset (name archives like tool_darwin_arm64.tar.gz)"}
+		case kind != packageHomebrew && !platforms["windows"]:
+			return nil, &preflightError{msg: fmt.Sprintf("--%s needs at least one windows --asset (name archives like tool_windows_amd64.zip)", map[string]string{packageScoop: "scoop-bucket", packageWinget: "winget-repo"}[kind])}
+		}
+		if t.token == "" {
+			t.token = fc.token
+		}
+		if t.token == "" && !dryRun {
+			return nil, &preflightError{msg: fmt.Sprintf("publishing the %s manifest to %s requires a GitHub token with write access (or %s)", kind, t.repo, packageTokenEnvs[kind])}
+		}
+		writer, err := d.repoWriter(apiURL, t.repo, t.token)
+		if err != nil {
+			return nil, &preflightError{msg: err.Error()}
+		}
+		p.publishers = append(p.publishers, packagePublisher{kind: kind, target: t, writer: writer})
+	}
+	return p, nil
+}
+
+func (p packagePublish) render(pub packagePublisher, version string, artifacts []packaging.Artifact) ([]packaging.File, string, error) {
+	homepage := p.download.WebURL()
+	switch pub.kind {
+	case packageHomebrew:
+		f := packaging.Formula{Name: pub.target.name, Desc: p.pc.desc, Homepage: homepage, Version: version, License: p.pc.license, Artifacts: artifacts}
+		content, err := packaging.RenderFormula(f)
+		return []packaging.File{{Path: f.Path(), Content: content}}, "Homebrew formula", err
+	case packageScoop:
+		m := packaging.ScoopManifest{Name: pub.target.name, Desc: p.pc.desc, Homepage: homepage, Version: version, License: p.pc.license, Artifacts: artifacts}
+		content, err := packaging.RenderScoop(m)
+		return []packaging.File{{Path: m.Path(), Content: content}}, "Scoop manifest", err
+	default:
+		files, err := packaging.RenderWinget(packaging.WingetManifest{ID: pub.target.name, Desc: p.pc.desc, Homepage: homepage, Version: version, License: p.pc.license, Artifacts: artifacts})
+		return files, "winget manifest", err
+	}
+}
+
+func (p packagePublish) run(ctx context.Context, tag, version string, stdout io.Writer) error {
+	var artifacts []packaging.Artifact
+	for _, a := range p.assets {
+		artifact, ok, err := packaging.NewArtifact(a.Path, a.Name, p.download.AssetURL(tag, a.Name))
+		if err != nil {
+			return err
+		}
+		if ok {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for _, pub := range p.publishers {
+		files, label, err := p.render(pub, version, artifacts)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if p.dryRun {
+				dryRunf(stdout, "update %s %s in %s to %s", label, f.Path, pub.target.repo, version)
+				continue
+			}
+			_, _ = fmt.Fprintf(stdout, "Updating %s %s in %s...\n", label, f.Path, pub.target.repo)
+			changed, err := pub.writer.WriteFile(ctx, f.Path, []byte(f.Content), fmt.Sprintf("%s %s", pub.target.name, version))
+			if err != nil {
+				return err
+			}
+			if !changed {
+				_, _ = fmt.Fprintf(stdout, "%s %s is already up to date\n", label, f.Path)
+			}
+		}
+	}
+	return nil
+}
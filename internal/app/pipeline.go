@@ -0,0 +1,121 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// releaseStep is one named stage of a release. journal is the step's key in
+// .git/mdrelease-state.json, or empty for steps that resume does not track.
+type releaseStep struct {
+	name    string
+	journal string
+	// log, when set, is printed as the step starts, such as "Creating tag
+	// v1.2.3...".
+	log string
+	run func() error
+	// preview, when set, runs instead of run in a dry run, for steps whose
+	// work the dry-run git client and publishers cannot stand in for.
+	preview func() error
+}
+
+// stepMiddleware wraps every step's run with a concern shared by all of
+// them, such as timing or journaling. It calls next to run the step.
+type stepMiddleware func(step releaseStep, next func() error) error
+
+// releasePipeline runs its steps in order through its middleware, the first
+// added outermost, and stops at the first error.
+type releasePipeline struct {
+	steps      []releaseStep
+	middleware []stepMiddleware
+}
+
+func (p *releasePipeline) add(step releaseStep) {
+	p.steps = append(p.steps, step)
+}
+
+func (p *releasePipeline) use(mw ...stepMiddleware) {
+	p.middleware = append(p.middleware, mw...)
+}
+
+func (p *releasePipeline) names() []string {
+	names := make([]string, len(p.steps))
+	for i, step := range p.steps {
+		names[i] = step.name
+	}
+	return names
+}
+
+// journalKeys lists the steps the resume journal plans to complete.
+func (p *releasePipeline) journalKeys() []string {
+	var keys []string
+	for _, step := range p.steps {
+		if step.journal != "" {
+			keys = append(keys, step.journal)
+		}
+	}
+	return keys
+}
+
+func (p *releasePipeline) run() error {
+	for _, step := range p.steps {
+		next := step.run
+		for i := len(p.middleware) - 1; i >= 0; i-- {
+			mw, inner := p.middleware[i], next
+			next = func() error { return mw(step, inner) }
+		}
+		if err := next(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// traceSteps reports each step's outcome and duration, for --verbose.
+func traceSteps(w io.Writer, now func() time.Time) stepMiddleware {
+	return func(step releaseStep, next func() error) error {
+		start := now()
+		err := next()
+		status := "ok"
+		if err != nil {
+			status = "failed"
+		}
+		_, _ = fmt.Fprintf(w, "step %s: %s in %s\n", step.name, status, now().Sub(start).Round(time.Millisecond))
+		return err
+	}
+}
+
+// journalSteps records each completed step so `mdrelease resume` skips it.
+// Dry runs have no journal.
+func journalSteps(steps *stepLog) stepMiddleware {
+	return func(step releaseStep, next func() error) error {
+		if err := next(); err != nil {
+			return err
+		}
+		if step.journal != "" {
+			_ = steps.journal.complete(step.journal)
+		}
+		return nil
+	}
+}
+
+// logSteps prints each step's log line as it starts.
+func logSteps(w io.Writer) stepMiddleware {
+	return func(step releaseStep, next func() error) error {
+		if step.log != "" {
+			_, _ = fmt.Fprintln(w, step.log)
+		}
+		return next()
+	}
+}
+
+// dryRunSteps runs each step's preview instead of the step, for --dry-run.
+// Steps without a preview run as usual: the git client and publishers they
+// call print what they would do instead of doing it.
+func dryRunSteps(step releaseStep, next func() error) error {
+	if step.preview != nil {
+		return step.preview()
+	}
+	return next()
+}
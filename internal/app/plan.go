@@ -0,0 +1,324 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+)
+
+// releasePlan is what `mdrelease plan` reports: every change a release with
+// the same flags would make, resolved against the repository as it is now.
+// The exported fields are the --json output.
+type releasePlan struct {
+	json bool
+
+	Version         string              `json:"version"`
+	Title           string              `json:"title"`
+	Tag             string              `json:"tag"`
+	Changelog       string              `json:"changelog"`
+	AlreadyReleased bool                `json:"alreadyReleased,omitempty"`
+	VersionFiles    []string            `json:"versionFiles,omitempty"`
+	Stage           []string            `json:"stage,omitempty"`
+	Commit          *planCommit         `json:"commit,omitempty"`
+	DeleteTag       bool                `json:"deleteTag,omitempty"`
+	CreateTag       *planTag            `json:"createTag,omitempty"`
+	ReleaseBranch   string              `json:"releaseBranch,omitempty"`
+	Remote          string              `json:"remote,omitempty"`
+	Push            []string            `json:"push,omitempty"`
+	Forge           *planForge          `json:"forge,omitempty"`
+	NotesOut        []string            `json:"notesOut,omitempty"`
+	Images          []string            `json:"images,omitempty"`
+	GoModule        string              `json:"goModule,omitempty"`
+	Hooks           map[string][]string `json:"hooks,omitempty"`
+	Notify          []string            `json:"notify,omitempty"`
+	Steps           []string            `json:"steps,omitempty"`
+}
+
+type planCommit struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body,omitempty"`
+}
+
+// planTag.Commit is empty when the tag goes on the release commit, which
+// does not exist yet.
+type planTag struct {
+	Name   string `json:"name"`
+	Commit string `json:"commit,omitempty"`
+}
+
+type planForge struct {
+	Kind          string   `json:"kind"`
+	Repo          string   `json:"repo"`
+	Recreate      bool     `json:"recreate,omitempty"`
+	GenerateNotes bool     `json:"generateNotes,omitempty"`
+	Assets        []string `json:"assets,omitempty"`
+	Sign          bool     `json:"sign,omitempty"`
+	Packages      []string `json:"packages,omitempty"`
+}
+
+func runPlan(args []string, stdout, stderr io.Writer, d deps) error {
+	d.plan = &releasePlan{}
+	return release(args, stdout, stderr, d, nil)
+}
+
+func (p *releasePlan) describe(entry *changelog.Entry, tag, changelogPath string) {
+	p.Version = entry.Version
+	p.Title = entry.Summary
+	p.Tag = tag
+	p.Changelog = changelogPath
+}
+
+// addGit records the commit, tag, branch, and pushes, looking up the files
+// that would be committed and the commits that would be tagged and pushed.
+func (p *releasePlan) addGit(git gitOps, actions releaseActions, entry *changelog.Entry, stagePaths []string, forceRetag bool, remote, target, branch string, pushBranch bool) error {
+	if actions.commit {
+		files, err := git.ChangedFiles(actions.stageAll && len(stagePaths) == 0)
+		if err != nil {
+			return err
+		}
+		if actions.stageAll && len(stagePaths) > 0 {
+			changed, err := git.ChangedFiles(true)
+			if err != nil {
+				return err
+			}
+			within, err := pathsWithin(git, changed, stagePaths)
+			if err != nil {
+				return err
+			}
+			files = append(files, within...)
+		}
+		for _, file := range files {
+			if !slices.Contains(p.Stage, file) {
+				p.Stage = append(p.Stage, file)
+			}
+		}
+		p.Commit = &planCommit{Subject: entry.Summary, Body: entry.Description}
+	}
+	if actions.tag && forceRetag {
+		local, err := git.HasLocalTag(p.Tag)
+		if err != nil {
+			return err
+		}
+		remoteTag := false
+		if actions.pushTag {
+			if remoteTag, err = git.HasRemoteTag(remote, p.Tag); err != nil {
+				return err
+			}
+		}
+		p.DeleteTag = local || remoteTag
+	}
+	if actions.tag {
+		p.CreateTag = &planTag{Name: p.Tag, Commit: target}
+		if target == "" && !actions.commit {
+			head, err := git.ResolveCommit("HEAD")
+			if err != nil {
+				return err
+			}
+			p.CreateTag.Commit = head
+		}
+	}
+	p.ReleaseBranch = branch
+	if actions.pushCommit {
+		current, err := git.CurrentBranch()
+		if err != nil {
+			return err
+		}
+		ref := "HEAD"
+		if current != "" {
+			ref = "refs/heads/" + current
+		}
+		p.Push = append(p.Push, ref)
+	}
+	if actions.pushTag {
+		p.Push = append(p.Push, "refs/tags/"+p.Tag)
+	}
+	if branch != "" && pushBranch {
+		p.Push = append(p.Push, "refs/heads/"+branch)
+	}
+	if len(p.Push) > 0 {
+		p.Remote = remote
+	}
+	return nil
+}
+
+func (p *releasePlan) addVersionFiles(u *versionUpdate) {
+	for _, rule := range u.rules {
+		p.VersionFiles = append(p.VersionFiles, rule.Path)
+	}
+	p.VersionFiles = append(p.VersionFiles, u.plain...)
+	for _, f := range u.goFiles {
+		p.VersionFiles = append(p.VersionFiles, f.path)
+	}
+	// They are staged with the release commit even when unchanged today.
+	p.Stage = append(p.Stage, p.VersionFiles...)
+}
+
+func (p *releasePlan) addForge(fc forgeConfig, assets []forge.Asset, packages *packagePublish, recreate bool) {
+	p.Forge = &planForge{Kind: fc.kind, Repo: fc.repo, Recreate: recreate, GenerateNotes: fc.notes, Sign: fc.sign}
+	for _, a := range assets {
+		p.Forge.Assets = append(p.Forge.Assets, a.Name)
+	}
+	if packages != nil {
+		for _, pub := range packages.publishers {
+			p.Forge.Packages = append(p.Forge.Packages, fmt.Sprintf("%s %s in %s", pub.kind, pub.target.name, pub.target.repo))
+		}
+	}
+}
+
+func (p *releasePlan) addHooks(hc hookConfig, actions releaseActions, postPush bool) {
+	p.Hooks = map[string][]string{}
+	add := func(point string, commands []string, runs bool) {
+		if runs && len(commands) > 0 {
+			p.Hooks[point] = commands
+		}
+	}
+	add("verify", hc.verify, actions.commit || actions.tag)
+	add(hookPreCommit, hc.preCommit, actions.commit)
+	add(hookPreTag, hc.preTag, actions.tag)
+	add(hookPostPush, hc.postPush, postPush)
+	add(hookOnFailure, hc.onFailure, true)
+	add("plugin", hc.plugins, true)
+	if len(p.Hooks) == 0 {
+		p.Hooks = nil
+	}
+}
+
+// addNotify lists the notification channels by kind only; their URLs and
+// addresses can carry secrets.
+func (p *releasePlan) addNotify(nc notifyConfig) {
+	for _, channel := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"webhook", len(nc.webhooks) > 0},
+		{"slack", len(nc.slack) > 0},
+		{"discord", len(nc.discord) > 0},
+		{"email", len(nc.emailTo) > 0},
+	} {
+		if channel.enabled {
+			p.Notify = append(p.Notify, channel.name)
+		}
+	}
+}
+
+func (p *releasePlan) print(w io.Writer) error {
+	if p.json {
+		data, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "Release plan for %s (%s) from %s:\n", p.Tag, p.Title, p.Changelog)
+	if p.AlreadyReleased {
+		_, _ = fmt.Fprintf(w, "  %s is already released; nothing to do.\n", p.Tag)
+		return nil
+	}
+	changes := 0
+	change := func(format string, args ...any) {
+		changes++
+		_, _ = fmt.Fprintf(w, "  "+format+"\n", args...)
+	}
+	for _, path := range p.VersionFiles {
+		change("+ set version %s in %s", p.Version, path)
+	}
+	if p.Commit != nil {
+		change("+ commit %q with %d file(s)", p.Commit.Subject, len(p.Stage))
+		for _, path := range p.Stage {
+			_, _ = fmt.Fprintf(w, "      %s\n", path)
+		}
+	}
+	if p.DeleteTag {
+		change("- delete existing tag %s", p.Tag)
+	}
+	if p.CreateTag != nil {
+		at := "the release commit"
+		if p.CreateTag.Commit != "" {
+			at = p.CreateTag.Commit
+		}
+		change("+ tag %s at %s", p.CreateTag.Name, at)
+	}
+	if p.ReleaseBranch != "" {
+		change("+ branch %s", p.ReleaseBranch)
+	}
+	for _, ref := range p.Push {
+		change("+ push %s to %s", ref, p.Remote)
+	}
+	if f := p.Forge; f != nil {
+		verb := "create"
+		if f.Recreate {
+			verb = "recreate"
+		}
+		change("+ %s %s release %s on %s", verb, f.Kind, p.Tag, f.Repo)
+		for _, asset := range f.Assets {
+			_, _ = fmt.Fprintf(w, "      %s\n", asset)
+		}
+		if f.Sign {
+			change("+ sign release assets")
+		}
+		for _, pkg := range f.Packages {
+			change("+ update %s", pkg)
+		}
+	}
+	for _, path := range p.NotesOut {
+		change("+ write %s release notes to %s", notesFormat(path), path)
+	}
+	for _, image := range p.Images {
+		change("+ tag image %s as %s", image, p.Version)
+	}
+	if p.GoModule != "" {
+		change("+ fetch %s@v%s through the Go module proxy", p.GoModule, p.Version)
+	}
+	for _, point := range []string{"verify", hookPreCommit, hookPreTag, hookPostPush, hookOnFailure, "plugin"} {
+		for _, command := range p.Hooks[point] {
+			_, _ = fmt.Fprintf(w, "  run %s: %s\n", point, command)
+		}
+	}
+	if len(p.Notify) > 0 {
+		_, _ = fmt.Fprintf(w, "  notify: %s\n", strings.Join(p.Notify, ", "))
+	}
+	if len(p.Steps) > 0 {
+		_, _ = fmt.Fprintf(w, "  steps: %s\n", strings.Join(p.Steps, " -> "))
+	}
+	_, _ = fmt.Fprintf(w, "Plan: %d change(s). Run `mdrelease` with the same flags to apply it.\n", changes)
+	return nil
+}
+
+// pathsWithin keeps the repository-relative files that are, or are under,
+// one of paths, which are relative to the working directory or absolute.
+func pathsWithin(git gitOps, files, paths []string) ([]string, error) {
+	top, err := git.TopLevel()
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []string
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(top, abs)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, filepath.ToSlash(rel))
+	}
+	var within []string
+	for _, file := range files {
+		for _, prefix := range prefixes {
+			if prefix == "." || file == prefix || strings.HasPrefix(file, prefix+"/") {
+				within = append(within, file)
+				break
+			}
+		}
+	}
+	return within, nil
+}
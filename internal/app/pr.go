@@ -0,0 +1,257 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+)
+
+const defaultPRBranchPattern = "release-pr/{tag}"
+
+func runPR(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease pr", stderr)
+
+	var cfg commonConfig
+	var fc forgeConfig
+	var changelogFlag string
+	var branchPattern string
+	var base string
+
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
+	fs.StringVar(&cfg.remote, "remote", "origin", "Git remote name")
+	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix the release will use once the pull request is merged")
+	fs.BoolVar(&cfg.dryRun, "dry-run", false, "Print planned actions without mutating git state or opening the pull request")
+	fs.StringVar(&branchPattern, "branch", defaultPRBranchPattern, "Branch to push the changelog bump to; {version} and {tag} are replaced")
+	fs.StringVar(&base, "base", "", "Branch the pull request targets (default: the current branch)")
+	fs.StringVar(&cfg.commitDate, "commit-date", "", "Author/committer date for the release commit, as unix seconds or RFC 3339 (default: $SOURCE_DATE_EPOCH)")
+	addRemoteFlags(fs, &cfg)
+	addForgeTargetFlags(fs, &fc, forgeAuto, "Forge to open the pull request on: auto (detect from the remote URL), github, gitlab, gitea, bitbucket, or none (push the branch only)")
+	addLockFlags(fs, &cfg)
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "pr", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "pr does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	stdout = cfg.narration(result)
+	stderr = cfg.diagnostics(stderr)
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	cfg.resolveGitToken(d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+	if err := cfg.resolveCommitDate(d.getenv); err != nil {
+		return err
+	}
+	if err := fc.resolve(d.getenv); err != nil {
+		return err
+	}
+
+	entry, err := cfg.versions.changelog.ParseLatest(cfg.changelogPath)
+	if err != nil {
+		return err
+	}
+	tag := cfg.tagPrefix + entry.Version
+	branch := renderReleaseBranch(branchPattern, entry.Version, tag)
+	if branch == "" {
+		return &usageError{msg: "--branch pattern must not be empty"}
+	}
+
+	_, _ = fmt.Fprintln(stdout, "Release PR info:")
+	_, _ = fmt.Fprintf(stdout, "  Changelog: %s\n", cfg.changelogPath)
+	_, _ = fmt.Fprintf(stdout, "  Version: %s\n", entry.Version)
+	_, _ = fmt.Fprintf(stdout, "  Title: %s\n", entry.Summary)
+	_, _ = fmt.Fprintf(stdout, "  Tag: %s\n", tag)
+	_, _ = fmt.Fprintf(stdout, "  Branch: %s\n", branch)
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	if base == "" {
+		if base, err = git.CurrentBranch(); err != nil {
+			return err
+		}
+		if base == "" {
+			return &preflightError{msg: "HEAD is detached; check out the branch the release pull request should target or pass --base"}
+		}
+	}
+	if base == branch {
+		return &usageError{msg: fmt.Sprintf("--branch %s must differ from the pull request base", branch)}
+	}
+	_, _ = fmt.Fprintf(stdout, "  Base: %s\n", base)
+
+	remote := detectRemote(git, cfg.remote)
+	var requester forge.PullRequester
+	if fc.enabled() {
+		if err := fc.detect(cfg.remote, remote, d.getenv); err != nil {
+			return err
+		}
+		backend, _, err := prepareForge(fc, cfg.dryRun, d)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if requester, ok = backend.(forge.PullRequester); !ok {
+			return &preflightError{msg: fmt.Sprintf("--forge %s cannot open pull requests", fc.kind)}
+		}
+		_, _ = fmt.Fprintf(stdout, "  Forge: %s (%s)\n", fc.kind, fc.repo)
+	}
+	if cfg.dryRun {
+		_, _ = fmt.Fprintln(stdout, "  Mode: dry-run")
+	}
+
+	lock, err := lockRelease(git, cfg)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+
+	if err := ensureCommitterIdentity(git); err != nil {
+		return err
+	}
+	if err := git.EnsureRemote(cfg.remote); err != nil {
+		return err
+	}
+	if err := checkAllowedRemote(git, cfg); err != nil {
+		return err
+	}
+	remoteLock, err := lockRemote(git, cfg, tag)
+	if err != nil {
+		return err
+	}
+	defer remoteLock.release()
+	if err := ensureFullHistory(git, cfg, stdout); err != nil {
+		return err
+	}
+	if err := git.FetchRemote(cfg.remote); err != nil {
+		return err
+	}
+	if err := git.EnsureTagAbsent(tag); err != nil {
+		return &noOpError{msg: fmt.Sprintf("no new changelog version to release: %s already exists (update %s)", tag, cfg.changelogPath)}
+	}
+	hasRemoteTag, err := git.HasRemoteTag(cfg.remote, tag)
+	if err != nil {
+		return err
+	}
+	if hasRemoteTag {
+		return &noOpError{msg: fmt.Sprintf("no new changelog version to release: %s already exists on %s (update %s)", tag, cfg.remote, cfg.changelogPath)}
+	}
+	hasLocalBranch, err := git.HasLocalBranch(branch)
+	if err != nil {
+		return err
+	}
+	hasRemoteBranch, err := git.HasRemoteBranch(cfg.remote, branch)
+	if err != nil {
+		return err
+	}
+	if hasLocalBranch || hasRemoteBranch {
+		return &preflightError{msg: fmt.Sprintf("release PR branch %s already exists (merge or delete the open release pull request, or pass --branch)", branch)}
+	}
+
+	_, _ = fmt.Fprintln(stdout, "Staging changes...")
+	if err := git.StageAll(); err != nil {
+		return err
+	}
+	if cfg.dryRun {
+		_, _ = fmt.Fprintln(stdout, "Skipping staged-change verification in --dry-run after --stage-all.")
+	} else {
+		hasStaged, err := git.HasStagedChanges()
+		if err != nil {
+			return err
+		}
+		if !hasStaged {
+			return &noOpError{msg: fmt.Sprintf("no changes to release after staging (update %s or make code changes)", cfg.changelogPath)}
+		}
+	}
+
+	_, _ = fmt.Fprintf(stdout, "Creating branch %s...\n", branch)
+	if err := git.CreateBranch(branch, ""); err != nil {
+		return err
+	}
+	if err := git.Checkout(branch); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(stdout, "Committing changes...")
+	if err := git.Commit(entry.Summary, entry.Description); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "Pushing %s to %s...\n", branch, cfg.remote)
+	if err := git.PushBranch(cfg.remote, branch); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "Returning to %s...\n", base)
+	if err := git.Checkout(base); err != nil {
+		return err
+	}
+
+	pr := forge.PullRequest{
+		Head:  branch,
+		Base:  base,
+		Title: "Release " + tag + " - " + entry.Summary,
+		Body:  releasePRBody(entry, tag, base),
+	}
+	switch {
+	case requester == nil:
+		if url := remote.CompareURL(base, branch); url != "" {
+			_, _ = fmt.Fprintf(stdout, "Open the pull request: %s\n", url)
+		}
+	case cfg.dryRun:
+		dryRunf(stdout, "open %s pull request %s -> %s in %s: %s", fc.kind, branch, base, fc.repo, pr.Title)
+	default:
+		ctx := d.ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		_, _ = fmt.Fprintf(stdout, "Opening %s pull request %s -> %s...\n", fc.kind, branch, base)
+		opened, err := requester.OpenPullRequest(ctx, pr)
+		if err != nil {
+			return fmt.Errorf("%w (branch %s was pushed; open the pull request manually)", err, branch)
+		}
+		_, _ = fmt.Fprintf(stdout, "Pull request #%d: %s\n", opened.Number, opened.URL)
+	}
+
+	if cfg.dryRun {
+		_, _ = fmt.Fprintln(result, paint(result, colorGreen, "Dry-run complete."))
+		return nil
+	}
+	_, _ = fmt.Fprintf(result, "Release PR ready: %s (%s). After it is merged, run `mdrelease --tag --push-tag` on %s to publish the tag.\n", entry.Summary, tag, base)
+	return nil
+}
+
+func releasePRBody(entry *changelog.Entry, tag, base string) string {
+	var b strings.Builder
+	if entry.Description != "" {
+		b.WriteString(entry.Description)
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "---\nMerging this pull request prepares %s. Run `mdrelease --tag --push-tag` on %s after the merge to tag and publish it.\n", tag, base)
+	return b.String()
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+)
+
+func runPromote(args []string, stdout, stderr io.Writer, d deps) error {
+	d.promote = true
+	return release(args, stdout, stderr, d, nil)
+}
+
+// promoteChangelog rewrites the prerelease entries at the top of the
+// changelog as the final release and returns that entry. In a dry run the
+// file is left alone and the release goes on with the promoted entry.
+func promoteChangelog(scheme *changelog.Scheme, path string, dryRun bool, stdout io.Writer) (*changelog.Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &changelog.ParseError{Path: path, Msg: "failed to open changelog", Err: err}
+	}
+	content, promoted, err := scheme.Promote(string(data), path)
+	if pe := new(changelog.PromoteError); errors.As(err, &pe) {
+		return nil, &preflightError{msg: pe.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry, err := scheme.ParseLatestContent(content, path)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		dryRunf(stdout, "promote %s to %s in %s", strings.Join(promoted, ", "), entry.Version, path)
+		return entry, nil
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return nil, err
+	}
+	_, _ = fmt.Fprintf(stdout, "Promoted %s to %s in %s\n", strings.Join(promoted, ", "), entry.Version, path)
+	return entry, nil
+}
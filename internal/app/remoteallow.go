@@ -0,0 +1,31 @@
+package app
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// checkAllowedRemote fails preflight when --allowed-remote patterns are set
+// and the remote's URL matches none of them, so a release meant for one
+// repository is never pushed to a fork or mirror. Patterns are path globs
+// matched against the URL as configured, such as git@github.com:myorg/*.
+func checkAllowedRemote(git gitOps, cfg commonConfig) error {
+	if len(cfg.allowedRemotes) == 0 {
+		return nil
+	}
+	url, err := git.RemoteURL(cfg.remote)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range cfg.allowedRemotes {
+		matched, err := path.Match(pattern, url)
+		if err != nil {
+			return &usageError{msg: fmt.Sprintf("invalid --allowed-remote pattern %q: %v", pattern, err)}
+		}
+		if matched {
+			return nil
+		}
+	}
+	return &preflightError{msg: fmt.Sprintf("remote %s points at %s, which matches no --allowed-remote pattern (%s); check that you are not releasing to a fork or mirror", cfg.remote, url, strings.Join(cfg.allowedRemotes, ", "))}
+}
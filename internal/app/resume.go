@@ -0,0 +1,288 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+func runResume(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease resume", stderr)
+
+	var cfg commonConfig
+	var discard bool
+	var forgeToken string
+	var yes bool
+	var webhooks, slack, discord stringList
+	fs.BoolVar(&cfg.dryRun, "dry-run", false, "Print the remaining actions without mutating git state")
+	fs.BoolVar(&discard, "discard", false, "Delete the recorded release state instead of resuming it")
+	addRemoteFlags(fs, &cfg)
+	fs.StringVar(&forgeToken, "forge-token", "", "Forge API token for a pending forge release (default: $MDRELEASE_FORGE_TOKEN, then $GITHUB_TOKEN)")
+	fs.BoolVar(&yes, "yes", false, "Do not ask for confirmation before resuming from a terminal")
+	addLockFlags(fs, &cfg)
+	fs.Var(&webhooks, "webhook", "Webhook URL of the unfinished release, which is not recorded (repeatable; default: the release config's)")
+	fs.Var(&slack, "slack-webhook", "Slack webhook URL of the unfinished release (repeatable; default: the release config's)")
+	fs.Var(&discord, "discord-webhook", "Discord webhook URL of the unfinished release (repeatable; default: the release config's)")
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	explicit := visitedFlags(fs)
+	if err := applyConfig(fs, "resume", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "resume does not accept positional arguments"}
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	stdout = cfg.narration(result)
+	stderr = cfg.diagnostics(stderr)
+	cfg.resolveGitToken(d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	gitDir, err := git.GitDir()
+	if err != nil {
+		return err
+	}
+	journal, err := loadJournal(gitDir)
+	if err != nil {
+		return err
+	}
+	if journal == nil {
+		return &preflightError{msg: fmt.Sprintf("no unfinished release to resume (%s not found)", journalPath(gitDir))}
+	}
+	state := journal.state
+
+	if discard {
+		if cfg.dryRun {
+			dryRunf(result, "would discard release state for %s (%s)", state.Tag, journal.path)
+			return nil
+		}
+		if err := journal.remove(); err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(result, "Discarded release state for %s (%s)\n", state.Tag, journal.path)
+		return nil
+	}
+
+	cfg.versionPattern, cfg.versionOrder, cfg.headingSeparator = state.VersionPattern, state.VersionOrder, state.HeadingSeparator
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	entry, err := cfg.versions.changelog.ParseLatest(state.Changelog)
+	if err != nil {
+		return err
+	}
+	if entry.Version != state.Version {
+		return &preflightError{msg: fmt.Sprintf("%s now starts at %s but the unfinished release is %s (restore the changelog or run `mdrelease resume --discard`)", state.Changelog, entry.Version, state.Version)}
+	}
+
+	remaining := state.remaining()
+	if len(remaining) == 0 {
+		if !cfg.dryRun {
+			if err := journal.remove(); err != nil {
+				return err
+			}
+		}
+		_, _ = fmt.Fprintf(result, "Nothing to resume: all steps for %s already completed.\n", state.Tag)
+		return nil
+	}
+
+	completed := "(none)"
+	if len(state.Completed) > 0 {
+		completed = strings.Join(state.Completed, ", ")
+	}
+	_, _ = fmt.Fprintf(stdout, "Resuming release %s (started %s)\n", state.Tag, state.StartedAt.Format("2006-01-02 15:04:05 MST"))
+	_, _ = fmt.Fprintf(stdout, "  Completed: %s\n", completed)
+	_, _ = fmt.Fprintf(stdout, "  Remaining: %s\n", strings.Join(remaining, ", "))
+
+	releaseArgs := []string{
+		"--changelog", state.Changelog,
+		"--remote", state.Remote,
+		"--tag-prefix", state.TagPrefix,
+	}
+	if state.Config != "" && configPath == "" {
+		// Secrets such as webhook URLs are read from the config again.
+		releaseArgs = append(releaseArgs, "--config", state.Config)
+	}
+	if state.Sync != "" {
+		releaseArgs = append(releaseArgs, "--sync", state.Sync)
+	}
+	if state.VersionPattern != "" {
+		releaseArgs = append(releaseArgs, "--version-pattern", state.VersionPattern)
+	}
+	if state.VersionOrder != "" {
+		releaseArgs = append(releaseArgs, "--version-order", state.VersionOrder)
+	}
+	if state.HeadingSeparator != "" {
+		releaseArgs = append(releaseArgs, "--heading-separator", state.HeadingSeparator)
+	}
+	if state.SkipLFS {
+		releaseArgs = append(releaseArgs, "--skip-lfs")
+	}
+	if state.RequireSignature != "" && state.pending(stepTag) {
+		releaseArgs = append(releaseArgs, "--require-signature", state.RequireSignature)
+	}
+	if state.CommitDate != "" {
+		releaseArgs = append(releaseArgs, "--commit-date", state.CommitDate)
+	}
+	if state.Target != "" {
+		releaseArgs = append(releaseArgs, "--target", state.Target)
+	}
+	if state.BuildMetadata != "" {
+		releaseArgs = append(releaseArgs, "--build-metadata", state.BuildMetadata, "--build-metadata-in", state.BuildIn)
+	}
+	for _, step := range remaining {
+		switch step {
+		case stepStageAll, stepCommit, stepTag, stepPushCommit, stepPushTag:
+			releaseArgs = append(releaseArgs, "--"+step)
+		}
+	}
+	if state.pending(stepForgeRelease) {
+		releaseArgs = append(releaseArgs, "--forge", state.Forge, "--forge-repo", state.ForgeRepo)
+		if state.ForgeURL != "" {
+			releaseArgs = append(releaseArgs, "--forge-url", state.ForgeURL)
+		}
+		if state.ForgePlugin != "" {
+			releaseArgs = append(releaseArgs, "--forge-plugin", state.ForgePlugin)
+		}
+		if state.ForgeBackend != "" {
+			releaseArgs = append(releaseArgs, "--forge-backend", state.ForgeBackend)
+		}
+		for _, asset := range state.Assets {
+			releaseArgs = append(releaseArgs, "--asset", asset)
+		}
+		if state.GenerateNotes {
+			releaseArgs = append(releaseArgs, "--generate-notes")
+		}
+		if state.Sign {
+			releaseArgs = append(releaseArgs, "--sign", "--sign-pattern", state.SignPattern)
+			if state.CosignKey != "" {
+				releaseArgs = append(releaseArgs, "--cosign-key", state.CosignKey)
+			}
+		}
+		if state.HomebrewTap != "" || state.ScoopBucket != "" || state.WingetRepo != "" {
+			releaseArgs = append(releaseArgs, "--package-desc", state.PackageDesc, "--package-license", state.PackageLicense)
+		}
+		if state.HomebrewTap != "" {
+			releaseArgs = append(releaseArgs, "--homebrew-tap", state.HomebrewTap, "--homebrew-formula", state.HomebrewName)
+		}
+		if state.ScoopBucket != "" {
+			releaseArgs = append(releaseArgs, "--scoop-bucket", state.ScoopBucket, "--scoop-manifest", state.ScoopName)
+		}
+		if state.WingetRepo != "" {
+			releaseArgs = append(releaseArgs, "--winget-repo", state.WingetRepo, "--winget-id", state.WingetID)
+		}
+	}
+	if state.pending(stepNotesOut) {
+		for _, path := range state.NotesOut {
+			releaseArgs = append(releaseArgs, "--notes-out", path)
+		}
+	}
+	if state.pending(stepImage) {
+		for _, image := range state.Images {
+			releaseArgs = append(releaseArgs, "--image", image)
+		}
+		releaseArgs = append(releaseArgs, fmt.Sprintf("--image-latest=%t", state.ImageLatest))
+	}
+	if state.pending(stepGoProxy) {
+		releaseArgs = append(releaseArgs, "--go-proxy", "--go-proxy-url", state.GoProxyURL, "--go-mod", state.GoMod)
+		if state.Pkgsite {
+			releaseArgs = append(releaseArgs, "--pkgsite", "--pkgsite-url", state.PkgsiteURL)
+		}
+	}
+	hooks := []struct {
+		flag     string
+		commands []string
+		pending  bool
+	}{
+		{hookPreCommit, state.PreCommitHooks, state.pending(stepCommit)},
+		{hookPreTag, state.PreTagHooks, state.pending(stepTag)},
+		{hookPostPush, state.PostPushHooks, state.pending(stepPostPushHook)},
+		{hookOnFailure, state.OnFailureHooks, true},
+	}
+	for _, hook := range hooks {
+		if hook.pending {
+			for _, command := range hook.commands {
+				releaseArgs = append(releaseArgs, "--"+hook.flag+"-hook", command)
+			}
+		}
+	}
+	if state.pending(stepCommit) {
+		for _, spec := range state.VersionFiles {
+			releaseArgs = append(releaseArgs, "--version-file", spec)
+		}
+		for _, path := range state.WriteVersion {
+			releaseArgs = append(releaseArgs, "--write-version", path)
+		}
+		for _, path := range state.WriteGoVersion {
+			releaseArgs = append(releaseArgs, "--write-go-version", path)
+		}
+	}
+	for _, name := range state.Plugins {
+		releaseArgs = append(releaseArgs, "--plugin", name)
+	}
+	if state.WebhookOnFail {
+		releaseArgs = append(releaseArgs, "--webhook-on-failure")
+	}
+	if state.AnnounceTmpl != "" {
+		releaseArgs = append(releaseArgs, "--announce-template", state.AnnounceTmpl)
+	}
+	for _, to := range state.EmailTo {
+		releaseArgs = append(releaseArgs, "--email-to", to)
+	}
+	if len(state.EmailTo) > 0 {
+		// The SMTP password is never journaled; resume reads it from
+		// MDRELEASE_SMTP_PASSWORD again.
+		releaseArgs = append(releaseArgs, "--email-from", state.EmailFrom, "--smtp-addr", state.SMTPAddr, "--smtp-username", state.SMTPUsername)
+	}
+	if state.ReleaseBranch != "" && (state.pending(stepReleaseBranch) || state.pending(stepPushReleaseBranch)) {
+		releaseArgs = append(releaseArgs, "--release-branch="+state.ReleaseBranch)
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if slices.Contains(secretFlags, f.Name) && !explicit[f.Name] {
+			// The release reads its own config for these.
+			return
+		}
+		switch v := f.Value.(type) {
+		case *stringList:
+			for _, item := range *v {
+				releaseArgs = append(releaseArgs, "--"+f.Name+"="+item)
+			}
+		default:
+			if f.Name != "discard" {
+				releaseArgs = append(releaseArgs, "--"+f.Name+"="+f.Value.String())
+			}
+		}
+	})
+	if cfg.quiet {
+		releaseArgs = append(releaseArgs, "--quiet")
+	}
+	if cfg.verbose {
+		releaseArgs = append(releaseArgs, "--verbose")
+	}
+	if cfg.noColor {
+		releaseArgs = append(releaseArgs, "--no-color")
+	}
+	return release(releaseArgs, result, stderr, d, journal)
+}
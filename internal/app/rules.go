@@ -0,0 +1,308 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+)
+
+// Preflight rules check reports and a release enforces.
+const (
+	ruleCleanTree        = "clean-tree"
+	ruleBranch           = "branch"
+	ruleVersionIncrement = "version-increment"
+	ruleSignedHead       = "signed-head"
+	ruleTicket           = "ticket"
+	ruleEntryAge         = "entry-age"
+	ruleCommitConvention = "commit-convention"
+)
+
+// ruleNames is the order rules run and are reported in.
+var ruleNames = []string{ruleCleanTree, ruleBranch, ruleVersionIncrement, ruleSignedHead, ruleTicket, ruleEntryAge, ruleCommitConvention}
+
+// Modes of a rule: off skips it, warn prints a broken rule and goes on, and
+// fail stops the release with exit code 4.
+const (
+	ruleOff  = "off"
+	ruleWarn = "warn"
+	ruleFail = "fail"
+)
+
+var ruleModes = []string{ruleOff, ruleWarn, ruleFail}
+
+// ruleFlags are the flags that configure the preflight rules.
+type ruleFlags struct {
+	rules         stringList
+	branches      stringList
+	requireTicket string
+	maxEntryAge   time.Duration
+	convention    string
+}
+
+func addRuleFlags(fs *flag.FlagSet, rf *ruleFlags) {
+	fs.Var(&rf.rules, "rule", "Mode of a preflight rule, as <rule>[=off|warn|fail] with rules clean-tree, branch, version-increment, signed-head, ticket, entry-age, and commit-convention (default mode fail; repeatable)")
+	fs.Var(&rf.branches, "allowed-branch", "Branch glob releases may run from, such as main or release/*; turns on the branch rule (repeatable)")
+	fs.StringVar(&rf.requireTicket, "require-ticket", "", "Regular expression, such as PROJ-\\d+, that the entry's title or bullets must match; turns on the ticket rule")
+	fs.DurationVar(&rf.maxEntryAge, "max-entry-age", 0, "Oldest a changelog entry may be, counted from the commit that added its heading, such as 336h; turns on the entry-age rule")
+	fs.StringVar(&rf.convention, "commit-convention", "", "Regular expression every commit subject since the last tag must match, or conventional for Conventional Commits; turns on the commit-convention rule")
+}
+
+// rulePolicy is the mode of each rule and the settings the rules check
+// against.
+type rulePolicy struct {
+	modes    map[string]string
+	branches []string
+	ticket   *regexp.Regexp
+	maxAge   time.Duration
+	subject  *regexp.Regexp
+}
+
+// newRulePolicy starts from the defaults, where version-increment fails
+// (off with --allow-skip) and branch, ticket, entry-age, and
+// commit-convention fail once their setting is given, and applies --rule on
+// top.
+func newRulePolicy(rf ruleFlags, allowSkip bool) (*rulePolicy, error) {
+	ticket, err := compileTicketPattern(rf.requireTicket)
+	if err != nil {
+		return nil, err
+	}
+	for _, pattern := range rf.branches {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, &usageError{msg: fmt.Sprintf("invalid --allowed-branch pattern %q: %v", pattern, err)}
+		}
+	}
+	if rf.maxEntryAge < 0 {
+		return nil, &usageError{msg: fmt.Sprintf("--max-entry-age must not be negative, got %s", rf.maxEntryAge)}
+	}
+	subject, err := compileCommitConvention(rf.convention)
+	if err != nil {
+		return nil, err
+	}
+	p := &rulePolicy{modes: map[string]string{}, branches: rf.branches, ticket: ticket, maxAge: rf.maxEntryAge, subject: subject}
+	for _, name := range ruleNames {
+		p.modes[name] = ruleOff
+	}
+	if !allowSkip {
+		p.modes[ruleVersionIncrement] = ruleFail
+	}
+	if len(p.branches) > 0 {
+		p.modes[ruleBranch] = ruleFail
+	}
+	if p.ticket != nil {
+		p.modes[ruleTicket] = ruleFail
+	}
+	if p.maxAge > 0 {
+		p.modes[ruleEntryAge] = ruleFail
+	}
+	if p.subject != nil {
+		p.modes[ruleCommitConvention] = ruleFail
+	}
+	for _, rule := range rf.rules {
+		name, mode, found := strings.Cut(rule, "=")
+		if !found {
+			mode = ruleFail
+		}
+		if _, ok := p.modes[name]; !ok {
+			return nil, &usageError{msg: fmt.Sprintf("invalid --rule %q (expected %s)", name, strings.Join(ruleNames, ", "))}
+		}
+		if !slices.Contains(ruleModes, mode) {
+			return nil, &usageError{msg: fmt.Sprintf("invalid --rule mode %q for %s (expected off, warn, or fail)", mode, name)}
+		}
+		p.modes[name] = mode
+	}
+	for _, needs := range []struct {
+		rule, flag string
+		set        bool
+	}{
+		{ruleBranch, "--allowed-branch", len(p.branches) > 0},
+		{ruleTicket, "--require-ticket", p.ticket != nil},
+		{ruleEntryAge, "--max-entry-age", p.maxAge > 0},
+		{ruleCommitConvention, "--commit-convention", p.subject != nil},
+	} {
+		if p.modes[needs.rule] != ruleOff && !needs.set {
+			return nil, &usageError{msg: fmt.Sprintf("--rule %s needs %s", needs.rule, needs.flag)}
+		}
+	}
+	return p, nil
+}
+
+// ruleInput is the release the rules look at.
+type ruleInput struct {
+	entry     *changelog.Entry
+	changelog string
+	tagPrefix string
+	tag       string
+	// rev is the commit the release builds on: HEAD or --target.
+	rev string
+	// paths limits the commits the commit-convention rule checks to a
+	// component's files; empty checks every commit.
+	paths []string
+	// commits is set when the release stages or commits the working tree.
+	commits bool
+	// tags is set when the release creates the tag.
+	tags bool
+}
+
+// ruleOutcome is one rule's result. A broken rule has err set; detail
+// describes a passed rule and reason why a rule did not apply.
+type ruleOutcome struct {
+	name   string
+	mode   string
+	detail string
+	reason string
+	err    error
+}
+
+// evaluate runs every rule that is not off. A broken rule is recorded in
+// its outcome; errors that keep a rule from running, such as a failed git
+// command, are returned.
+func (p *rulePolicy) evaluate(git gitOps, getenv func(string) string, in ruleInput) ([]ruleOutcome, error) {
+	outcomes := make([]ruleOutcome, 0, len(ruleNames))
+	for _, name := range ruleNames {
+		o := ruleOutcome{name: name, mode: p.modes[name]}
+		if o.mode != ruleOff {
+			var err error
+			o.detail, o.reason, err = p.run(name, git, getenv, in)
+			if pe := new(preflightError); errors.As(err, &pe) {
+				o.err = err
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, nil
+}
+
+// run checks one rule, returning a detail when it passes, a reason when it
+// does not apply, or a preflightError when it is broken.
+func (p *rulePolicy) run(name string, git gitOps, getenv func(string) string, in ruleInput) (string, string, error) {
+	switch name {
+	case ruleCleanTree:
+		if in.commits {
+			return "", "the release commits the working tree", nil
+		}
+		dirty, err := git.HasUncommittedChanges()
+		if err != nil {
+			return "", "", err
+		}
+		if dirty {
+			return "", "", &preflightError{msg: "working tree has uncommitted changes; commit or stash them before releasing"}
+		}
+		return "", "", nil
+	case ruleBranch:
+		branch, err := releaseBranchName(git, getenv)
+		if err != nil {
+			return "", "", err
+		}
+		if branch == "" {
+			return "", "", &preflightError{msg: "HEAD is detached, so the release branch cannot be checked against --allowed-branch (check out the release branch)"}
+		}
+		for _, pattern := range p.branches {
+			if ok, _ := path.Match(pattern, branch); ok {
+				return branch, "", nil
+			}
+		}
+		return "", "", &preflightError{msg: fmt.Sprintf("branch %s matches no --allowed-branch pattern (%s)", branch, strings.Join(p.branches, ", "))}
+	case ruleVersionIncrement:
+		if !in.tags {
+			return "", "the release does not create a tag", nil
+		}
+		return "", "", checkIncrement(git, in.tagPrefix, in.entry.Version, in.rev)
+	case ruleSignedHead:
+		commit, err := git.ResolveCommit(in.rev)
+		if err != nil {
+			return "", "", err
+		}
+		verified, err := git.VerifyCommit(commit)
+		if err != nil {
+			return "", "", err
+		}
+		if !verified {
+			return "", "", &preflightError{msg: fmt.Sprintf("%s (%s) has no valid signature according to git verify-commit", in.rev, commit)}
+		}
+		return commit, "", nil
+	case ruleTicket:
+		refs, err := ticketReferences(p.ticket, in.entry, in.changelog)
+		return strings.Join(refs, ", "), "", err
+	case ruleEntryAge:
+		heading := `^#[[:space:]]*` + regexp.QuoteMeta(in.entry.Version) + `([^0-9A-Za-z.+-]|$)`
+		added, err := git.LineAddedAt(in.changelog, heading)
+		if err != nil {
+			return "", "", err
+		}
+		if added.IsZero() {
+			return "", "the entry is not committed yet", nil
+		}
+		age := time.Since(added)
+		if age > p.maxAge {
+			return "", "", &preflightError{msg: fmt.Sprintf("changelog entry %s was added %s ago, more than --max-entry-age %s (refresh the entry or release it sooner)", in.entry.Version, formatAge(age), formatAge(p.maxAge))}
+		}
+		return "added " + formatAge(age) + " ago", "", nil
+	case ruleCommitConvention:
+		return checkCommitConvention(git, p.subject, in)
+	}
+	return "", "", nil
+}
+
+// formatAge prints whole days for long durations and minutes otherwise.
+func formatAge(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+	}
+	return d.Round(time.Minute).String()
+}
+
+// reportRules prints every rule's outcome for check, and fails when a rule set
+// to fail is broken.
+func reportRules(w io.Writer, outcomes []ruleOutcome) error {
+	var failed []string
+	var first error
+	for _, o := range outcomes {
+		switch {
+		case o.mode == ruleOff:
+			_, _ = fmt.Fprintf(w, "  Rule %s: off\n", o.name)
+		case o.reason != "":
+			_, _ = fmt.Fprintf(w, "  Rule %s: %s (%s)\n", o.name, paint(w, colorYellow, "skipped"), o.reason)
+		case o.err == nil && o.detail != "":
+			_, _ = fmt.Fprintf(w, "  Rule %s: %s (%s)\n", o.name, paint(w, colorGreen, "ok"), o.detail)
+		case o.err == nil:
+			_, _ = fmt.Fprintf(w, "  Rule %s: %s\n", o.name, paint(w, colorGreen, "ok"))
+		case o.mode == ruleWarn:
+			_, _ = fmt.Fprintf(w, "  Rule %s: %s (%v)\n", o.name, paint(w, colorYellow, "warning"), o.err)
+		default:
+			_, _ = fmt.Fprintf(w, "  Rule %s: %s (%v)\n", o.name, paint(w, colorRed, "failed"), o.err)
+			failed = append(failed, o.name)
+			if first == nil {
+				first = o.err
+			}
+		}
+	}
+	if len(failed) > 1 {
+		return &preflightError{msg: fmt.Sprintf("%d preflight rules failed: %s", len(failed), strings.Join(failed, ", "))}
+	}
+	return first
+}
+
+// enforceRules prints a warning for each broken rule set to warn and
+// returns the first broken rule set to fail.
+func enforceRules(stderr io.Writer, outcomes []ruleOutcome) error {
+	for _, o := range outcomes {
+		if o.err == nil {
+			continue
+		}
+		if o.mode == ruleFail {
+			return o.err
+		}
+		warnf(stderr, "rule %s: %v", o.name, o.err)
+	}
+	return nil
+}
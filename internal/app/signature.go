@@ -0,0 +1,85 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+)
+
+// Sources --require-signature can trust for a commit's signature.
+const (
+	signatureNone  = "none"
+	signatureGit   = "git"
+	signatureForge = "forge"
+)
+
+// signaturePolicy refuses to tag a commit whose signature is not verified,
+// either by git verify-commit or by the forge.
+type signaturePolicy struct {
+	mode     string
+	verifier forge.CommitVerifier
+	forge    string
+}
+
+// validate checks --require-signature against the release's other flags.
+// The forge can only vouch for a commit it already has, so forge mode tags
+// an existing commit instead of creating one.
+func (p signaturePolicy) validate(fc forgeConfig, actions releaseActions) error {
+	switch p.mode {
+	case signatureNone, signatureGit:
+		return nil
+	case signatureForge:
+	default:
+		return &usageError{msg: fmt.Sprintf("invalid --require-signature value %q (expected none, git, or forge)", p.mode)}
+	}
+	if !fc.enabled() {
+		return &usageError{msg: "--require-signature forge requires --forge"}
+	}
+	if actions.commit {
+		return &usageError{msg: "--require-signature forge checks a commit the forge already has and cannot be combined with --commit (use --require-signature git, or tag a pushed commit with --tag --push-tag)"}
+	}
+	return nil
+}
+
+// prepare finds the forge's commit verification for forge mode.
+func (p *signaturePolicy) prepare(fc forgeConfig, backend forge.Backend) error {
+	if p.mode != signatureForge {
+		return nil
+	}
+	verifier, ok := backend.(forge.CommitVerifier)
+	if !ok {
+		return &preflightError{msg: fmt.Sprintf("--require-signature forge is not supported by --forge %s --forge-backend %s (use --require-signature git)", fc.kind, fc.backend)}
+	}
+	p.verifier, p.forge = verifier, fc.kind
+	return nil
+}
+
+func (p signaturePolicy) check(ctx context.Context, git gitOps, commit string, stdout io.Writer) error {
+	switch p.mode {
+	case signatureGit:
+		verified, err := git.VerifyCommit(commit)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			return &preflightError{msg: fmt.Sprintf("commit %s does not have a valid signature (git verify-commit failed; sign it, or trust the signer's key, before releasing)", commit)}
+		}
+		_, _ = fmt.Fprintf(stdout, "  Signature: %s verified by git\n", commit)
+	case signatureForge:
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		verified, reason, err := p.verifier.VerifyCommit(ctx, commit)
+		if err != nil {
+			return err
+		}
+		if !verified {
+			return &preflightError{msg: fmt.Sprintf("%s does not show commit %s as verified (%s)", p.forge, commit, cmp.Or(reason, "unverified"))}
+		}
+		_, _ = fmt.Fprintf(stdout, "  Signature: %s verified by %s\n", commit, p.forge)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/jasonwillschiu/mdrelease/internal/export"
+)
+
+// runSite writes a static release-notes site generated from the changelog:
+// an index of versions and a page per release.
+func runSite(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease site", stderr)
+
+	var cfg commonConfig
+	var changelogFlag string
+	var componentName string
+	var from, to string
+	var output string
+	var title string
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
+	fs.StringVar(&componentName, "component", "", "Build the site from the changelog of this [components.<name>] config entry")
+	fs.StringVar(&output, "output", "site", "Directory to write the site to; files mdrelease does not generate are left alone")
+	fs.StringVar(&from, "from", "", "Oldest version to include (default: the first entry)")
+	fs.StringVar(&to, "to", "", "Newest version to include (default: the latest entry)")
+	fs.StringVar(&title, "title", "Release notes", "Site title")
+	fs.BoolVar(&cfg.dryRun, "dry-run", false, "List the files without writing them")
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "site", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "site does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	if output == "" {
+		return &usageError{msg: "--output must not be empty"}
+	}
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	if componentName != "" {
+		if _, err := applyComponent(componentName, configPath, &cfg, d); err != nil {
+			return err
+		}
+	}
+
+	entries, err := exportEntries(cfg.versions, cfg.changelogPath, from, to)
+	if err != nil {
+		return err
+	}
+	pages, err := export.Site(title, entries)
+	if err != nil {
+		return err
+	}
+	for _, page := range pages {
+		path := filepath.Join(output, filepath.FromSlash(page.Path))
+		if cfg.dryRun {
+			dryRunf(stdout, "write %s", path)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("write site: %w", err)
+		}
+		if err := os.WriteFile(path, page.Content, 0o644); err != nil {
+			return fmt.Errorf("write site: %w", err)
+		}
+	}
+	if cfg.dryRun {
+		_, _ = fmt.Fprintln(stdout, paint(stdout, colorGreen, "Dry-run complete."))
+		return nil
+	}
+	_, _ = fmt.Fprintf(stdout, "%s: %d release page(s) in %s\n", paint(stdout, colorGreen, "Site written"), len(entries), output)
+	return nil
+}
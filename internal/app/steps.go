@@ -0,0 +1,355 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+)
+
+type completedStep struct {
+	key        string
+	name       string
+	cleanup    string
+	undo       func() error
+	published  bool
+	rolledBack bool
+}
+
+type stepLog struct {
+	completed []*completedStep
+	journal   *releaseJournal
+	// dryRun keeps record from listing anything, since a dry run leaves
+	// nothing to roll back or clean up.
+	dryRun bool
+}
+
+func (l *stepLog) record(key, name, cleanup string) *completedStep {
+	step := &completedStep{key: key, name: name, cleanup: cleanup}
+	if !l.dryRun {
+		l.completed = append(l.completed, step)
+	}
+	return step
+}
+
+func (l *stepLog) rollback(w io.Writer) error {
+	var errs []error
+	for i := len(l.completed) - 1; i >= 0; i-- {
+		step := l.completed[i]
+		if step.undo == nil || step.published {
+			continue
+		}
+		if err := step.undo(); err != nil {
+			errs = append(errs, fmt.Errorf("undo %s: %w", step.name, err))
+			continue
+		}
+		step.undo = nil
+		step.rolledBack = true
+		_, _ = fmt.Fprintf(w, "Rolled back: %s\n", step.name)
+	}
+	return errors.Join(errs...)
+}
+
+func markPublished(step *completedStep) {
+	if step != nil {
+		step.published = true
+	}
+}
+
+type interruptedError struct {
+	steps     stepLog
+	err       error
+	resumable bool
+}
+
+func (e *interruptedError) Error() string {
+	return fmt.Sprintf("release interrupted: %v", e.err)
+}
+
+func (e *interruptedError) Unwrap() error { return e.err }
+
+func printInterrupted(w io.Writer, e *interruptedError) {
+	printErr(w, e)
+	if len(e.steps.completed) == 0 {
+		_, _ = fmt.Fprintln(w, "No release steps completed; git state was not changed.")
+		return
+	}
+	_, _ = fmt.Fprintln(w, "Completed steps:")
+	for _, step := range e.steps.completed {
+		_, _ = fmt.Fprintf(w, "  - %s\n", step.name)
+	}
+	var cleanup []string
+	for i := len(e.steps.completed) - 1; i >= 0; i-- {
+		if c := e.steps.completed[i].cleanup; c != "" {
+			cleanup = append(cleanup, c)
+		}
+	}
+	if len(cleanup) > 0 {
+		_, _ = fmt.Fprintln(w, "Manual cleanup (most recent first):")
+		for _, c := range cleanup {
+			_, _ = fmt.Fprintf(w, "  - %s\n", c)
+		}
+	}
+	if e.resumable {
+		_, _ = fmt.Fprintln(w, "Or run `mdrelease resume` to finish the remaining steps.")
+	}
+}
+
+// releaseRun is what the steps of one release share: the resolved flags and
+// prepared publishers release() hands them, and what earlier steps leave for
+// later ones, such as the local tag a push marks as published.
+type releaseRun struct {
+	ctx     context.Context
+	git     gitOps
+	cfg     commonConfig
+	stdout  io.Writer
+	steps   *stepLog
+	actions releaseActions
+	// noOp reports that a step found nothing to release.
+	noOp func(condition, msg string) error
+
+	entry      *changelog.Entry
+	tagEntry   *changelog.Entry
+	tag        string
+	target     string
+	branch     string
+	stagePaths []string
+
+	versionUpdate     *versionUpdate
+	hc                hookConfig
+	hooks             *hookRunner
+	sig               signaturePolicy
+	rollbackCommit    bool
+	rollbackOnFailure bool
+
+	fc       forgeConfig
+	backend  forge.Backend
+	signer   *forge.Signer
+	assets   []forge.Asset
+	packages *packagePublish
+	recreate bool
+	notes    releaseNotes
+	notesOut []string
+	ic       imageConfig
+	images   []*forge.ImagePublisher
+	gc       goProxyConfig
+
+	commitStep, tagStep, branchStep *completedStep
+	createdTag                      bool
+}
+
+func (r *releaseRun) writeVersionFiles() error {
+	return r.versionUpdate.apply(r.git, r.entry.Version, r.tag, r.actions.stageAll && len(r.stagePaths) == 0, r.cfg.dryRun, r.stdout)
+}
+
+func (r *releaseRun) preCommitHooks() error { return r.hooks.runPoint(hookPreCommit, r.hc.preCommit) }
+
+func (r *releaseRun) preTagHooks() error { return r.hooks.runPoint(hookPreTag, r.hc.preTag) }
+
+func (r *releaseRun) stageAll() error {
+	var err error
+	if len(r.stagePaths) > 0 {
+		err = r.git.StagePaths(r.stagePaths...)
+	} else {
+		err = r.git.StageAll()
+	}
+	if err != nil {
+		return err
+	}
+	r.steps.record(stepStageAll, "staged changes", "run `git reset` to unstage if you do not want to release")
+	return nil
+}
+
+func (r *releaseRun) commit() error {
+	hasStaged, err := r.git.HasStagedChanges()
+	if err != nil {
+		return err
+	}
+	if !hasStaged {
+		msg := "no staged changes to commit"
+		if r.actions.stageAll {
+			msg = fmt.Sprintf("no changes to release after staging (update %s or make code changes)", r.cfg.changelogPath)
+		}
+		return r.noOp(conditionNoChanges, msg)
+	}
+	return r.createCommit()
+}
+
+// previewCommit skips the staged-change check after --stage-all, which a dry
+// run did not actually stage.
+func (r *releaseRun) previewCommit() error {
+	if !r.actions.stageAll {
+		return r.commit()
+	}
+	_, _ = fmt.Fprintln(r.stdout, "Skipping staged-change verification in --dry-run after --stage-all.")
+	return r.createCommit()
+}
+
+func (r *releaseRun) createCommit() error {
+	if err := r.git.Commit(r.entry.Summary, r.entry.Description); err != nil {
+		return err
+	}
+	r.commitStep = r.steps.record(stepCommit, "created release commit", "run `git reset --soft HEAD~1` to undo the release commit")
+	if r.rollbackCommit {
+		r.commitStep.undo = func() error { return r.git.ResetSoft("HEAD~1") }
+	}
+	return nil
+}
+
+func (r *releaseRun) verifySignature() error {
+	head, err := r.git.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	return r.sig.check(r.ctx, r.git, head, r.stdout)
+}
+
+func (r *releaseRun) previewSignature() error {
+	dryRunf(r.stdout, "git verify-commit HEAD")
+	return nil
+}
+
+func (r *releaseRun) createTag() error {
+	if err := r.git.CreateTag(r.tag, r.target, r.tagEntry.Summary, r.tagEntry.Description); err != nil {
+		return err
+	}
+	r.createdTag = true
+	r.tagStep = r.steps.record(stepTag, fmt.Sprintf("created local tag %s", r.tag), fmt.Sprintf("run `git tag -d %s` to remove the local tag", r.tag))
+	r.tagStep.undo = func() error { return r.git.DeleteLocalTag(r.tag) }
+	return nil
+}
+
+func (r *releaseRun) createBranch() error {
+	if err := r.git.CreateBranch(r.branch, r.target); err != nil {
+		return err
+	}
+	r.branchStep = r.steps.record(stepReleaseBranch, fmt.Sprintf("created release branch %s", r.branch), fmt.Sprintf("run `git branch -D %s` to remove the local branch", r.branch))
+	r.branchStep.undo = func() error { return r.git.DeleteLocalBranch(r.branch) }
+	return nil
+}
+
+func (r *releaseRun) pushLFS() error {
+	var refs []string
+	if r.actions.pushCommit {
+		refs = append(refs, "HEAD")
+	}
+	if r.actions.pushTag && (!r.actions.pushCommit || r.target != "") {
+		refs = append(refs, r.tag)
+	}
+	for _, ref := range refs {
+		_, _ = fmt.Fprintf(r.stdout, "Pushing LFS objects for %s to %s...\n", ref, r.cfg.remote)
+		if err := r.git.LFSPush(r.cfg.remote, ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *releaseRun) pushCommit() error {
+	if err := r.git.PushHead(r.cfg.remote); err != nil {
+		return err
+	}
+	r.steps.record(stepPushCommit, fmt.Sprintf("pushed HEAD to %s", r.cfg.remote), "")
+	markPublished(r.commitStep)
+	return nil
+}
+
+func (r *releaseRun) pushTag() error {
+	if err := r.git.PushTag(r.cfg.remote, r.tag); err != nil {
+		if r.createdTag && !r.rollbackOnFailure {
+			return fmt.Errorf("%w (tag %s was created locally and may need manual push/retry)", err, r.tag)
+		}
+		return err
+	}
+	r.steps.record(stepPushTag, fmt.Sprintf("pushed tag %s to %s", r.tag, r.cfg.remote), fmt.Sprintf("run `git push %s :refs/tags/%s` to retract the published tag", r.cfg.remote, r.tag))
+	markPublished(r.tagStep)
+	return nil
+}
+
+func (r *releaseRun) pushBranch() error {
+	if err := r.git.PushBranch(r.cfg.remote, r.branch); err != nil {
+		return err
+	}
+	r.steps.record(stepPushReleaseBranch, fmt.Sprintf("pushed release branch %s to %s", r.branch, r.cfg.remote), fmt.Sprintf("run `git push %s :refs/heads/%s` to remove the remote branch", r.cfg.remote, r.branch))
+	markPublished(r.branchStep)
+	return nil
+}
+
+func (r *releaseRun) postPushHooks() error {
+	if err := r.hooks.runPoint(hookPostPush, r.hc.postPush); err != nil {
+		return err
+	}
+	r.steps.record(stepPostPushHook, "ran post-push hooks", "")
+	return nil
+}
+
+func (r *releaseRun) forgeRelease() error {
+	commit, err := r.git.ResolveCommit("refs/tags/" + r.tag)
+	if err != nil {
+		return err
+	}
+	return r.publishForge(commit)
+}
+
+// previewForge publishes without the tag's commit, since a dry run did not
+// create the tag.
+func (r *releaseRun) previewForge() error { return r.publishForge("") }
+
+func (r *releaseRun) publishForge(commit string) error {
+	previous := ""
+	if r.fc.notes {
+		var err error
+		if previous, err = previousTag(r.git, r.cfg, r.tag, r.target); err != nil {
+			return err
+		}
+	}
+	pub, err := forgePublish{
+		backend:     r.backend,
+		fc:          r.fc,
+		signer:      r.signer,
+		tag:         r.tag,
+		previousTag: previous,
+		commit:      commit,
+		entry:       r.entry,
+		assets:      r.assets,
+		recreate:    r.recreate,
+		dryRun:      r.cfg.dryRun,
+		body:        &r.notes.markdown,
+	}.run(r.ctx, r.stdout)
+	if err != nil {
+		return err
+	}
+	if r.packages != nil {
+		if err := r.packages.run(r.ctx, r.tag, r.entry.Version, r.stdout); err != nil {
+			return err
+		}
+	}
+	if pub != nil {
+		r.steps.record(stepForgeRelease, fmt.Sprintf("published %s release %s", r.fc.kind, r.tag), fmt.Sprintf("delete the %s release at %s", r.fc.kind, pub.URL))
+	}
+	return nil
+}
+
+func (r *releaseRun) writeNotes() error {
+	return writeReleaseNotes(r.notesOut, r.notes, r.cfg.dryRun, r.stdout)
+}
+
+func (r *releaseRun) publishImages() error {
+	if err := publishImages(r.ctx, r.images, r.ic, r.entry.Version, r.cfg.dryRun, r.stdout); err != nil {
+		return err
+	}
+	r.steps.record(stepImage, fmt.Sprintf("tagged %d container image(s) as %s", len(r.images), r.entry.Version), "")
+	return nil
+}
+
+func (r *releaseRun) warmGoProxy() error {
+	if err := r.gc.warm(r.ctx, r.entry.Version, r.cfg.dryRun, r.stdout); err != nil {
+		return err
+	}
+	r.steps.record(stepGoProxy, fmt.Sprintf("warmed %s@v%s on the Go module proxy", r.gc.module, r.entry.Version), "")
+	r.gc.refreshDocs(r.ctx, r.entry.Version, r.cfg.dryRun, r.stdout)
+	return nil
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+)
+
+// runTagMessage prints the annotation a release would give its tag, for the
+// latest changelog entry or the version given, so other tools can reuse it
+// verbatim.
+func runTagMessage(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease tag-message", stderr)
+
+	var cfg commonConfig
+	var changelogFlag string
+	var componentName string
+	var buildMetadata string
+	var buildMetadataIn string
+	var targetRef string
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
+	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix, stripped from a version given as a tag such as v1.2.3")
+	fs.StringVar(&componentName, "component", "", "Use the changelog and tag prefix of this [components.<name>] config entry")
+	fs.StringVar(&buildMetadata, "build-metadata", "", "Semver build metadata the release adds, as for mdrelease --build-metadata")
+	fs.StringVar(&buildMetadataIn, "build-metadata-in", buildMetadataInTag, "Where --build-metadata goes: tag (the message is unchanged) or message (a Build: line)")
+	fs.StringVar(&targetRef, "target", "HEAD", "Commit that {sha} and {shortsha} in --build-metadata name")
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "tag-message", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return &usageError{msg: "tag-message accepts at most one version"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	switch buildMetadataIn {
+	case buildMetadataInTag, buildMetadataInMessage:
+	default:
+		return &usageError{msg: fmt.Sprintf("invalid --build-metadata-in value %q (expected tag or message)", buildMetadataIn)}
+	}
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	if componentName != "" {
+		if _, err := applyComponent(componentName, configPath, &cfg, d); err != nil {
+			return err
+		}
+	}
+
+	var entry *changelog.Entry
+	var err error
+	if version := fs.Arg(0); version != "" {
+		entry, err = cfg.versions.changelog.ParseVersion(cfg.changelogPath, strings.TrimPrefix(version, cfg.tagPrefix))
+	} else {
+		entry, err = cfg.versions.changelog.ParseLatest(cfg.changelogPath)
+	}
+	if err != nil {
+		return err
+	}
+	if buildMetadata != "" && buildMetadataIn == buildMetadataInMessage {
+		if strings.Contains(entry.Version, "+") {
+			return &usageError{msg: fmt.Sprintf("--build-metadata cannot be added to %s, which already has build metadata in %s", entry.Version, cfg.changelogPath)}
+		}
+		meta, err := renderBuildMetadata(buildMetadata, d.getenv, func() (string, error) {
+			cfg.resolveGitEnv(d.getenv)
+			git := d.newGit(cfg.gitOptions(d.ctx, stderr, stderr))
+			if err := git.EnsureRepo(); err != nil {
+				return "", err
+			}
+			return git.ResolveCommit(targetRef)
+		})
+		if err != nil {
+			return err
+		}
+		entry = withBuildLine(entry, meta)
+	}
+	_, _ = fmt.Fprintln(stdout, tagMessage(entry))
+	return nil
+}
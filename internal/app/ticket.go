@@ -0,0 +1,43 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+)
+
+// compileTicketPattern parses --require-ticket, a regular expression such as
+// PROJ-\d+ for the ticket references an entry must mention.
+func compileTicketPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, &usageError{msg: fmt.Sprintf("invalid --require-ticket pattern %q: %v", pattern, err)}
+	}
+	return re, nil
+}
+
+// ticketReferences returns the distinct ticket references in the entry's
+// title and bullets, in the order they first appear, and fails when there
+// are none.
+func ticketReferences(re *regexp.Regexp, entry *changelog.Entry, path string) ([]string, error) {
+	var refs []string
+	for _, ref := range re.FindAllString(entry.Summary+"\n"+entry.Description, -1) {
+		if !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	if len(refs) == 0 {
+		return nil, &preflightError{msg: fmt.Sprintf("changelog entry %s in %s references no ticket matching %s (mention one in the title or a bullet)", entry.Version, path, re)}
+	}
+	return refs, nil
+}
+
+// hasTicket reports whether the entry references a ticket matching re.
+func hasTicket(re *regexp.Regexp, entry *changelog.Entry) bool {
+	return re.MatchString(entry.Summary + "\n" + entry.Description)
+}
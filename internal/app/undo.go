@@ -0,0 +1,307 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+)
+
+const (
+	undoCommitKeep   = "keep"
+	undoCommitReset  = "reset"
+	undoCommitRevert = "revert"
+)
+
+// runUndo reverses the latest changelog entry's release: it deletes the forge
+// release and the tag on the remote and locally, and with --commit resets or
+// reverts the release commit while it is still HEAD.
+func runUndo(args []string, stdout, stderr io.Writer, d deps) error {
+	fs := newFlagSet("mdrelease undo", stderr)
+
+	var cfg commonConfig
+	var fc forgeConfig
+	var changelogFlag string
+	var componentName string
+	var commitMode string
+	var keepRemote bool
+	var deletePublished bool
+	var yes bool
+	fs.StringVar(&changelogFlag, "changelog", "", "Path to changelog file (default: changelog.md)")
+	fs.StringVar(&cfg.remote, "remote", "origin", "Git remote name")
+	fs.StringVar(&cfg.tagPrefix, "tag-prefix", "v", "Tag prefix")
+	fs.StringVar(&componentName, "component", "", "Use the changelog and tag prefix of this [components.<name>] config entry")
+	fs.StringVar(&commitMode, "commit", undoCommitKeep, "What to do with the release commit when it is still HEAD: keep, reset (git reset --soft HEAD~1, only before it is pushed), or revert (commit a revert)")
+	fs.BoolVar(&keepRemote, "keep-remote", false, "Only delete the local tag; leave the tag on the remote")
+	fs.BoolVar(&deletePublished, "delete-published", false, "With --forge, also delete a published release; by default undo only deletes a draft and refuses to run when the release is published")
+	fs.BoolVar(&cfg.dryRun, "dry-run", false, "Print what would be undone without changing anything")
+	fs.BoolVar(&yes, "yes", false, "Do not ask for confirmation (required outside a terminal)")
+	addLockFlags(fs, &cfg)
+	addRemoteFlags(fs, &cfg)
+	addForgeTargetFlags(fs, &fc, forgeNone, "Also delete the release, or the draft left behind by deleting its tag, on this forge: auto (detect from the remote URL), github, gitlab, gitea, or none")
+
+	addVersionSchemeFlags(fs, &cfg)
+
+	var configPath string
+	addConfigFlag(fs, &configPath)
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printCommandHelp(stdout, fs)
+			return nil
+		}
+		return &usageError{msg: err.Error()}
+	}
+	if err := applyConfig(fs, "undo", configPath, d); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return &usageError{msg: "undo does not accept positional arguments"}
+	}
+	if err := cfg.resolveVersionScheme(); err != nil {
+		return err
+	}
+	switch commitMode {
+	case undoCommitKeep, undoCommitReset, undoCommitRevert:
+	default:
+		return &usageError{msg: fmt.Sprintf("invalid --commit value %q (expected keep, reset, or revert)", commitMode)}
+	}
+	result, err := cfg.output(stdout, &d)
+	if err != nil {
+		return err
+	}
+	stdout = cfg.narration(result)
+	stderr = cfg.diagnostics(stderr)
+	cfg.changelogPath = resolveChangelogPath(changelogFlag, d.getenv)
+	cfg.resolveGitToken(d.getenv)
+	cfg.resolveGitEnv(d.getenv)
+	if componentName != "" {
+		if _, err := applyComponent(componentName, configPath, &cfg, d); err != nil {
+			return err
+		}
+	}
+	if err := fc.resolve(d.getenv); err != nil {
+		return err
+	}
+
+	entry, err := cfg.versions.changelog.ParseLatest(cfg.changelogPath)
+	if err != nil {
+		return err
+	}
+	tag := cfg.tagPrefix + entry.Version
+
+	git := d.newGit(cfg.gitOptions(d.ctx, stdout, stderr))
+	if err := ensureGitVersion(git, cfg); err != nil {
+		return err
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return err
+	}
+	hasLocalTag, err := git.HasLocalTag(tag)
+	if err != nil {
+		return err
+	}
+	hasRemoteTag := false
+	if !keepRemote {
+		if err := git.EnsureRemote(cfg.remote); err != nil {
+			return err
+		}
+		if err := checkAllowedRemote(git, cfg); err != nil {
+			return err
+		}
+		if hasRemoteTag, err = git.HasRemoteTag(cfg.remote, tag); err != nil {
+			return err
+		}
+	}
+	tagCommit := ""
+	switch {
+	case hasLocalTag:
+		tagCommit, err = git.ResolveCommit("refs/tags/" + tag)
+	case hasRemoteTag:
+		tagCommit, err = git.RemoteTagCommit(cfg.remote, tag)
+	}
+	if err != nil {
+		return err
+	}
+
+	var deleter forge.ReleaseDeleter
+	if fc.enabled() {
+		if err := fc.detect(cfg.remote, detectRemote(git, cfg.remote), d.getenv); err != nil {
+			return err
+		}
+		backend, _, err := prepareForge(fc, cfg.dryRun, d)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if deleter, ok = backend.(forge.ReleaseDeleter); !ok {
+			return &preflightError{msg: fmt.Sprintf("--forge %s cannot delete releases", fc.kind)}
+		}
+		if !deletePublished {
+			if err := refusePublished(d, backend, fc, tag); err != nil {
+				return err
+			}
+		}
+	} else if !hasLocalTag && !hasRemoteTag {
+		where := "locally or on " + cfg.remote
+		if keepRemote {
+			where = "locally"
+		}
+		return &noOpError{msg: fmt.Sprintf("tag %s does not exist %s; nothing to undo", tag, where)}
+	}
+
+	if commitMode != undoCommitKeep {
+		if tagCommit == "" {
+			return &preflightError{msg: fmt.Sprintf("cannot %s the release commit: tag %s does not exist", commitMode, tag)}
+		}
+		head, err := git.ResolveCommit("HEAD")
+		if err != nil {
+			return err
+		}
+		if head != tagCommit {
+			return &preflightError{msg: fmt.Sprintf("the release commit %s is no longer HEAD (%s); undo it by hand or pass --commit keep", tagCommit, head)}
+		}
+		if commitMode == undoCommitReset {
+			upstream, err := git.RemoteBranchRef(cfg.remote)
+			if err != nil {
+				return err
+			}
+			if upstream != "" {
+				pushed, err := git.IsAncestor(head, upstream)
+				if err != nil {
+					return err
+				}
+				if pushed {
+					return &preflightError{msg: fmt.Sprintf("the release commit is already on %s; pass --commit revert to undo it with a new commit", strings.TrimPrefix(upstream, "refs/remotes/"))}
+				}
+			}
+		}
+	}
+
+	var steps []string
+	if deleter != nil {
+		steps = append(steps, "delete "+fc.kind+" release")
+	}
+	if hasRemoteTag {
+		steps = append(steps, "delete tag on "+cfg.remote)
+	}
+	if hasLocalTag {
+		steps = append(steps, "delete local tag")
+	}
+	if commitMode != undoCommitKeep {
+		steps = append(steps, commitMode+" release commit")
+	}
+
+	_, _ = fmt.Fprintln(stdout, "Undo info:")
+	_, _ = fmt.Fprintf(stdout, "  Changelog: %s\n", cfg.changelogPath)
+	_, _ = fmt.Fprintf(stdout, "  Version: %s\n", entry.Version)
+	_, _ = fmt.Fprintf(stdout, "  Tag: %s\n", tag)
+	if tagCommit != "" {
+		_, _ = fmt.Fprintf(stdout, "  Commit: %s\n", tagCommit)
+	}
+	if deleter != nil {
+		_, _ = fmt.Fprintf(stdout, "  Forge: %s (%s)\n", fc.kind, fc.repo)
+	}
+	_, _ = fmt.Fprintf(stdout, "  Steps: %s\n", strings.Join(steps, ", "))
+	if cfg.dryRun {
+		_, _ = fmt.Fprintln(stdout, "  Mode: dry-run")
+	}
+
+	if !cfg.dryRun && !yes {
+		if !cfg.prompts(d) {
+			return &preflightError{msg: fmt.Sprintf("undo deletes %s; pass --yes to confirm outside a terminal", tag)}
+		}
+		if err := confirm(d, stderr, fmt.Sprintf("Undo %s (%s)", tag, strings.Join(steps, ", ")), "undo not confirmed; nothing was changed (pass --yes to skip the prompt)"); err != nil {
+			return err
+		}
+	}
+
+	lock, err := lockRelease(git, cfg)
+	if err != nil {
+		return err
+	}
+	defer lock.release()
+	if hasRemoteTag {
+		remoteLock, err := lockRemote(git, cfg, tag)
+		if err != nil {
+			return err
+		}
+		defer remoteLock.release()
+	}
+
+	if deleter != nil {
+		if cfg.dryRun {
+			dryRunf(stdout, "delete %s release %s in %s", fc.kind, tag, fc.repo)
+		} else {
+			ctx := d.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			_, _ = fmt.Fprintf(stdout, "Deleting %s release %s...\n", fc.kind, tag)
+			deleted, err := deleter.DeleteRelease(ctx, tag)
+			if err != nil {
+				return err
+			}
+			if !deleted {
+				_, _ = fmt.Fprintf(stdout, "No %s release for %s in %s\n", fc.kind, tag, fc.repo)
+			}
+		}
+	}
+	if hasRemoteTag {
+		_, _ = fmt.Fprintf(stdout, "Deleting tag %s on %s...\n", tag, cfg.remote)
+		if err := git.DeleteRemoteTag(cfg.remote, tag); err != nil {
+			return err
+		}
+	}
+	if hasLocalTag {
+		_, _ = fmt.Fprintf(stdout, "Deleting local tag %s...\n", tag)
+		if err := git.DeleteLocalTag(tag); err != nil {
+			return err
+		}
+	}
+	switch commitMode {
+	case undoCommitReset:
+		_, _ = fmt.Fprintln(stdout, "Resetting the release commit (changes stay staged)...")
+		if err := git.ResetSoft("HEAD~1"); err != nil {
+			return err
+		}
+	case undoCommitRevert:
+		_, _ = fmt.Fprintln(stdout, "Reverting the release commit...")
+		if err := git.Revert(tagCommit); err != nil {
+			return err
+		}
+	}
+
+	if cfg.dryRun {
+		_, _ = fmt.Fprintln(result, paint(result, colorGreen, "Dry-run complete."))
+		return nil
+	}
+	_, _ = fmt.Fprintf(result, "%s: %s (%s)\n", paint(result, colorGreen, "Release undone"), tag, strings.Join(steps, ", "))
+	return nil
+}
+
+// refusePublished fails unless the forge release for tag is a draft or
+// missing: users may already have downloaded a published release, so undo
+// only deletes one with --delete-published.
+func refusePublished(d deps, backend forge.Backend, fc forgeConfig, tag string) error {
+	inspector, ok := backend.(forge.ReleaseInspector)
+	if !ok {
+		return &preflightError{msg: fmt.Sprintf("--forge %s cannot tell a draft release from a published one; pass --delete-published to delete the %s release anyway", fc.kind, tag)}
+	}
+	ctx := d.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	state, err := inspector.InspectRelease(ctx, tag)
+	if err != nil {
+		return err
+	}
+	if state != nil && !state.Draft {
+		return &preflightError{msg: fmt.Sprintf("the %s release %s is published at %s; undo only deletes drafts (pass --delete-published to delete it anyway)", fc.kind, tag, state.URL)}
+	}
+	return nil
+}
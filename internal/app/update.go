@@ -0,0 +1,119 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jasonwillschiu/mdrelease/internal/config"
+	"github.com/jasonwillschiu/mdrelease/internal/goproxy"
+	"github.com/jasonwillschiu/mdrelease/internal/semver"
+)
+
+const (
+	toolModule = "github.com/jasonwillschiu/mdrelease"
+
+	noUpdateCheckEnv   = "MDRELEASE_NO_UPDATE_CHECK"
+	updateCheckFile    = "update-check.json"
+	updateCheckEvery   = 24 * time.Hour
+	updateCheckTimeout = 2 * time.Second
+)
+
+// updateCache is the update-check.json file in the user config dir; it keeps
+// mdrelease from asking the module proxy more than once a day.
+type updateCache struct {
+	CheckedAt time.Time `json:"checkedAt"`
+	Latest    string    `json:"latest"`
+}
+
+type updateChecker struct {
+	dir    string
+	now    func() time.Time
+	latest func(context.Context) (string, error)
+}
+
+// startUpdateCheck looks up the latest mdrelease release in the background
+// while the command runs. It returns nil when the check is off: for
+// development builds, in CI, when stderr is not a terminal, for shell
+// completion, and when MDRELEASE_NO_UPDATE_CHECK is set.
+func startUpdateCheck(ctx context.Context, args []string, stderr io.Writer, getenv func(string) string) <-chan string {
+	if ToolVersion == "v0.0.0" || envSet(getenv(noUpdateCheckEnv)) || detectCI(getenv) || !isTerminal(stderr) {
+		return nil
+	}
+	if len(args) > 0 && (args[0] == completeCommand || args[0] == "completion") {
+		return nil
+	}
+	dir := config.GlobalDir(getenv("XDG_CONFIG_HOME"), getenv("HOME"))
+	if dir == "" {
+		return nil
+	}
+	checker := updateChecker{
+		dir: dir,
+		now: time.Now,
+		latest: func(ctx context.Context) (string, error) {
+			info, err := goproxy.Latest(ctx, goproxy.ProxyURL(getenv("GOPROXY")), &http.Client{Timeout: updateCheckTimeout}, toolModule)
+			if err != nil {
+				return "", err
+			}
+			return info.Version, nil
+		},
+	}
+	result := make(chan string, 1)
+	go func() {
+		result <- checker.latestVersion(ctx)
+	}()
+	return result
+}
+
+// printUpdateNotice waits for the background check, which is bounded by
+// updateCheckTimeout, and prints a notice when a newer release exists.
+func printUpdateNotice(w io.Writer, check <-chan string) {
+	if check == nil {
+		return
+	}
+	if latest := <-check; newerVersion(latest, ToolVersion) {
+		_, _ = fmt.Fprintf(w, "%s mdrelease %s is available (installed %s): go install %s@%s\n", paint(w, colorYellow, "Update:"), latest, ToolVersion, toolModule, latest)
+	}
+}
+
+// latestVersion returns the cached latest version, asking the proxy only
+// when the cache is older than updateCheckEvery. Failures are silent: a
+// missed notice is better than a noisy or failed release.
+func (c updateChecker) latestVersion(ctx context.Context) string {
+	path := filepath.Join(c.dir, updateCheckFile)
+	var cache updateCache
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cache) == nil {
+		if age := c.now().Sub(cache.CheckedAt); age >= 0 && age < updateCheckEvery {
+			return cache.Latest
+		}
+	}
+	latest, err := c.latest(ctx)
+	if err != nil {
+		latest = cache.Latest
+	}
+	// Record the attempt even when it failed, so an offline machine does not
+	// wait on the proxy for every command.
+	data, err := json.Marshal(updateCache{CheckedAt: c.now(), Latest: latest})
+	if err == nil && os.MkdirAll(c.dir, 0o755) == nil {
+		_ = os.WriteFile(path, data, 0o644)
+	}
+	return latest
+}
+
+// newerVersion reports whether latest is a vMAJOR.MINOR.PATCH release with
+// higher precedence than current; prereleases and anything it cannot parse
+// are never newer.
+func newerVersion(latest, current string) bool {
+	l, err := semver.Parse(strings.TrimPrefix(latest, "v"))
+	if err != nil || l.IsPrerelease() {
+		return false
+	}
+	c, err := semver.Parse(strings.TrimPrefix(current, "v"))
+	return err == nil && semver.Compare(l, c) > 0
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/jasonwillschiu/mdrelease/internal/versionfile"
+)
+
+type versionFileConfig struct {
+	rules   stringList
+	plain   stringList
+	goFiles stringList
+}
+
+func addVersionFileFlags(fs *flag.FlagSet, vc *versionFileConfig) {
+	fs.Var(&vc.rules, "version-file", "Set the changelog version in this file before the release commit, as path or path=<regexp>|marker:<text> (repeatable; also the [version-files] config table)")
+	fs.Var(&vc.plain, "write-version", "Write the changelog version to this plain file (such as VERSION) before the release commit (repeatable)")
+	fs.Var(&vc.goFiles, "write-go-version", "Write a generated Go file declaring const Version = \"<tag>\" before the release commit (repeatable)")
+}
+
+func (vc versionFileConfig) enabled() bool {
+	return len(vc.rules)+len(vc.plain)+len(vc.goFiles) > 0
+}
+
+type goVersionFile struct {
+	path string
+	pkg  string
+}
+
+type versionUpdate struct {
+	rules   []versionfile.Rule
+	plain   []string
+	goFiles []goVersionFile
+}
+
+// prepareVersionFiles parses the --version-file rules and checks that each
+// still matches its file, so a stale pattern fails before anything changes.
+func prepareVersionFiles(vc versionFileConfig, version string) (*versionUpdate, error) {
+	u := &versionUpdate{plain: vc.plain}
+	for _, spec := range vc.rules {
+		rule, err := versionfile.Parse(spec)
+		if err != nil {
+			return nil, &usageError{msg: fmt.Sprintf("invalid --version-file %q: %v", spec, err)}
+		}
+		if _, err := rule.Update(version, false); err != nil {
+			return nil, &preflightError{msg: fmt.Sprintf("--version-file: %v", err)}
+		}
+		u.rules = append(u.rules, rule)
+	}
+	for _, path := range vc.goFiles {
+		pkg, err := versionfile.GoPackage(path)
+		if err != nil {
+			return nil, &preflightError{msg: fmt.Sprintf("--write-go-version: %v", err)}
+		}
+		u.goFiles = append(u.goFiles, goVersionFile{path: path, pkg: pkg})
+	}
+	return u, nil
+}
+
+// apply writes the changelog version into the version files. Without
+// --stage-all the edited files are staged on their own so they are part of
+// the release commit.
+func (u *versionUpdate) apply(git gitOps, version, tag string, stageAll, dryRun bool, stdout io.Writer) error {
+	var changed []string
+	report := func(path string, updated bool) {
+		if updated {
+			_, _ = fmt.Fprintf(stdout, "Set version %s in %s\n", version, path)
+			changed = append(changed, path)
+		}
+	}
+	for _, rule := range u.rules {
+		if dryRun {
+			dryRunf(stdout, "set version %s in %s", version, rule.Path)
+			continue
+		}
+		updated, err := rule.Update(version, true)
+		if err != nil {
+			return err
+		}
+		report(rule.Path, updated)
+	}
+	for _, path := range u.plain {
+		if dryRun {
+			dryRunf(stdout, "write version %s to %s", version, path)
+			continue
+		}
+		updated, err := versionfile.Write(path, versionfile.Plain(version))
+		if err != nil {
+			return err
+		}
+		report(path, updated)
+	}
+	for _, f := range u.goFiles {
+		if dryRun {
+			dryRunf(stdout, "write package %s const Version = %q to %s", f.pkg, tag, f.path)
+			continue
+		}
+		src, err := versionfile.GoSource(f.pkg, tag)
+		if err != nil {
+			return err
+		}
+		updated, err := versionfile.Write(f.path, src)
+		if err != nil {
+			return err
+		}
+		report(f.path, updated)
+	}
+	if stageAll || len(changed) == 0 {
+		return nil
+	}
+	return git.StagePaths(changed...)
+}
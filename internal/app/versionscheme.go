@@ -0,0 +1,158 @@
+package app
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/semver"
+)
+
+const (
+	versionOrderSemver  = "semver"
+	versionOrderNumeric = "numeric"
+	versionOrderLexical = "lexical"
+	versionOrderDate    = "date"
+)
+
+var versionOrders = []string{versionOrderSemver, versionOrderNumeric, versionOrderLexical, versionOrderDate}
+
+// versionScheme decides which versions are valid and how they are ordered:
+// semantic versions by default, or a --version-pattern and --version-order
+// for products with other schemes, such as R2024-06 or 1.2.3.4. Its
+// changelog scheme also carries the --heading-separator. The zero value is
+// the default.
+type versionScheme struct {
+	changelog *changelog.Scheme
+	pattern   *regexp.Regexp
+	// source is the pattern as given, for messages.
+	source string
+	order  string
+}
+
+func addVersionSchemeFlags(fs *flag.FlagSet, cfg *commonConfig) {
+	fs.StringVar(&cfg.versionPattern, "version-pattern", "", "Regexp every version must match instead of being a semantic version, such as R[0-9]{4}-[0-9]{2} (usually set once at the top of the config file)")
+	fs.StringVar(&cfg.versionOrder, "version-order", versionOrderSemver, "How versions are ordered: semver, numeric (runs of digits compared as numbers, for 1.2.3.4), lexical, or date (by the YYYY-MM or YYYY-MM-DD date in the version)")
+	fs.StringVar(&cfg.headingSeparator, "heading-separator", "-", "What separates the version from the summary in changelog headings, such as - (# 1.2.3 - Summary), — or :, or () for # 1.2.3 (Summary)")
+}
+
+func (c *commonConfig) resolveVersionScheme() error {
+	if c.versionOrder != "" && !slices.Contains(versionOrders, c.versionOrder) {
+		return &usageError{msg: fmt.Sprintf("invalid --version-order value %q (expected semver, numeric, lexical, or date)", c.versionOrder)}
+	}
+	scheme, err := changelog.NewScheme(c.versionPattern, c.headingSeparator)
+	if err != nil {
+		return &usageError{msg: err.Error()}
+	}
+	c.versions = versionScheme{changelog: scheme, source: c.versionPattern, order: c.versionOrder}
+	if pattern := scheme.Pattern(); pattern != "" {
+		c.versions.pattern = regexp.MustCompile(`^(?:` + pattern + `)$`)
+	}
+	return nil
+}
+
+// check reports why version is not valid in the scheme.
+func (s versionScheme) check(version string) error {
+	if s.pattern != nil && !s.pattern.MatchString(version) {
+		return fmt.Errorf("%q does not match --version-pattern %s", version, s.source)
+	}
+	switch {
+	case s.order == versionOrderDate:
+		if _, _, ok := versionDate(version); !ok {
+			return fmt.Errorf("%q has no YYYY-MM or YYYY-MM-DD date for --version-order date", version)
+		}
+	case s.pattern == nil:
+		if _, err := semver.Parse(version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s versionScheme) valid(version string) bool { return s.check(version) == nil }
+
+// compare orders two versions, oldest first.
+func (s versionScheme) compare(a, b string) int {
+	switch s.order {
+	case versionOrderNumeric:
+		return compareNumeric(a, b)
+	case versionOrderLexical:
+		return strings.Compare(a, b)
+	case versionOrderDate:
+		ad, arest, aok := versionDate(a)
+		bd, brest, bok := versionDate(b)
+		switch {
+		case aok && bok:
+			return cmp.Or(ad.Compare(bd), compareNumeric(arest, brest))
+		case aok:
+			return 1
+		case bok:
+			return -1
+		}
+		return strings.Compare(a, b)
+	}
+	return compareVersions(a, b)
+}
+
+var versionDatePattern = regexp.MustCompile(`([0-9]{4})([-.]?)([0-9]{2})(?:([-.]?)([0-9]{2}))?`)
+
+// versionDate finds the first date in version, such as 2024-06 in R2024-06.1
+// or 20240615 in build-20240615, and returns what follows it to break ties.
+// A day counts only when it is separated like the month, so R2024-06.12 is
+// release 12 of June 2024.
+func versionDate(version string) (time.Time, string, bool) {
+	for _, m := range versionDatePattern.FindAllStringSubmatchIndex(version, -1) {
+		year, _ := strconv.Atoi(version[m[2]:m[3]])
+		month, _ := strconv.Atoi(version[m[6]:m[7]])
+		if m[10] >= 0 && version[m[4]:m[5]] == version[m[8]:m[9]] {
+			day, _ := strconv.Atoi(version[m[10]:m[11]])
+			if date, ok := validDate(year, month, day); ok {
+				return date, version[m[11]:], true
+			}
+		}
+		if date, ok := validDate(year, month, 1); ok {
+			return date, version[m[7]:], true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+func validDate(year, month, day int) (time.Time, bool) {
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return date, date.Year() == year && int(date.Month()) == month && date.Day() == day
+}
+
+// compareNumeric compares runs of digits as numbers and everything else as
+// text, so 1.2.10.0 comes after 1.2.9.1.
+func compareNumeric(a, b string) int {
+	for a != "" && b != "" {
+		ra, rb := leadingRun(a), leadingRun(b)
+		c := strings.Compare(ra, rb)
+		an, aErr := strconv.ParseUint(ra, 10, 64)
+		bn, bErr := strconv.ParseUint(rb, 10, 64)
+		if aErr == nil && bErr == nil {
+			c = cmp.Compare(an, bn)
+		}
+		if c != 0 {
+			return c
+		}
+		a, b = a[len(ra):], b[len(rb):]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// leadingRun returns the digits, or the non-digits, at the start of s.
+func leadingRun(s string) string {
+	digit := s[0] >= '0' && s[0] <= '9'
+	i := 1
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9') == digit {
+		i++
+	}
+	return s[:i]
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"regexp"
 	"strings"
 )
 
@@ -20,22 +19,48 @@ type Entry struct {
 	Description string
 }
 
+// ParseError points at the offending heading when there is one: Line and
+// Column are 1-based (0 when unknown) and Text is the line as written.
 type ParseError struct {
-	Path string
-	Msg  string
-	Err  error
+	Path   string
+	Line   int
+	Column int
+	Text   string
+	Msg    string
+	Err    error
+	// Format is the heading format the changelog was read with; empty means
+	// ExpectedFormat.
+	Format string
 }
 
 func (e *ParseError) Error() string {
+	where := e.Path
+	if e.Line > 0 {
+		where = fmt.Sprintf("%s:%d", e.Path, e.Line)
+	}
 	if e.Err != nil {
-		return fmt.Sprintf("%s: %s: %v", e.Path, e.Msg, e.Err)
+		return fmt.Sprintf("%s: %s: %v", where, e.Msg, e.Err)
 	}
-	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+	return fmt.Sprintf("%s: %s", where, e.Msg)
 }
 
 func (e *ParseError) Unwrap() error { return e.Err }
 
-func ParseLatest(path string) (*Entry, error) {
+func ParseLatest(path string) (*Entry, error) { return defaultScheme.ParseLatest(path) }
+
+func ParseLatestContent(content, path string) (*Entry, error) {
+	return defaultScheme.ParseLatestContent(content, path)
+}
+
+// ParseVersion returns the entry for version, which need not be the latest.
+func ParseVersion(path, version string) (*Entry, error) {
+	return defaultScheme.ParseVersion(path, version)
+}
+
+// Versions lists the version of every entry in the changelog, newest first.
+func Versions(path string) ([]string, error) { return defaultScheme.Versions(path) }
+
+func (s *Scheme) ParseLatest(path string) (*Entry, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, &ParseError{
@@ -48,33 +73,78 @@ func ParseLatest(path string) (*Entry, error) {
 		_ = file.Close()
 	}()
 
-	return parseLatestFromReader(file, path)
+	return s.parseEntryFromReader(file, path, "")
 }
 
-func ParseLatestContent(content, path string) (*Entry, error) {
-	return parseLatestFromReader(strings.NewReader(content), path)
+func (s *Scheme) ParseLatestContent(content, path string) (*Entry, error) {
+	return s.parseEntryFromReader(strings.NewReader(content), path, "")
 }
 
-func parseLatestFromReader(r io.Reader, path string) (*Entry, error) {
-	headerRegex := regexp.MustCompile(`^#\s*([0-9]+(?:\.[0-9]+){1,2}(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)\s*-\s*(.+)$`)
+func (s *Scheme) ParseVersion(path, version string) (*Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, &ParseError{Path: path, Msg: "failed to open changelog", Err: err}
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return s.parseEntryFromReader(file, path, version)
+}
 
+func (s *Scheme) Versions(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, &ParseError{Path: path, Msg: "failed to open changelog", Err: err}
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var versions []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if version, _, ok := s.heading(scanner.Text()); ok {
+			versions = append(versions, version)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, &ParseError{Path: path, Msg: "failed while reading changelog", Err: err}
+	}
+	return versions, nil
+}
+
+// parseEntryFromReader parses the entry for version, or the latest entry
+// when version is empty.
+func (s *Scheme) parseEntryFromReader(r io.Reader, path, version string) (*Entry, error) {
 	scanner := bufio.NewScanner(r)
 	var entry Entry
 	collecting := false
 	var bulletLines []string
+	var invalid *ParseError
+	var headerText string
+	lineNo, headerLine := 0, 0
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNo++
 
 		if strings.HasPrefix(line, "#") {
-			matches := headerRegex.FindStringSubmatch(line)
-			if matches == nil {
+			headingVersion, summary, ok := s.heading(line)
+			if !ok {
+				if invalid == nil && !collecting && version == "" {
+					invalid = s.diagnoseHeading(line, lineNo, path)
+				}
 				continue
 			}
 
 			if !collecting {
-				entry.Version = strings.TrimSpace(matches[1])
-				entry.Summary = strings.TrimSpace(matches[2])
+				if version != "" && headingVersion != version {
+					continue
+				}
+				entry.Version = headingVersion
+				entry.Summary = summary
+				headerLine, headerText = lineNo, line
 				collecting = true
 				continue
 			}
@@ -100,10 +170,28 @@ func parseLatestFromReader(r io.Reader, path string) (*Entry, error) {
 		}
 	}
 
-	if !collecting || entry.Summary == "" {
+	if !collecting && version != "" {
+		return nil, &ParseError{Path: path, Msg: fmt.Sprintf("no entry for version %s", version)}
+	}
+	if !collecting && invalid != nil {
+		return nil, invalid
+	}
+	if !collecting {
 		return nil, &ParseError{
-			Path: path,
-			Msg:  fmt.Sprintf("unable to parse latest release entry (expected %s)", ExpectedFormat),
+			Path:   path,
+			Msg:    fmt.Sprintf("unable to parse latest release entry: no release heading found (expected %s)", s.Format()),
+			Format: s.Format(),
+		}
+	}
+	if entry.Summary == "" {
+		// The summary was only whitespace, which the header pattern accepts.
+		return nil, &ParseError{
+			Path:   path,
+			Line:   headerLine,
+			Column: len(strings.TrimRight(headerText, " \t")) + 2,
+			Text:   headerText,
+			Msg:    fmt.Sprintf("unable to parse latest release entry: missing summary after %q (expected %s)", s.orDefault().separator, s.Format()),
+			Format: s.Format(),
 		}
 	}
 
@@ -113,3 +201,57 @@ func parseLatestFromReader(r io.Reader, path string) (*Entry, error) {
 
 	return &entry, nil
 }
+
+// diagnoseHeading explains why a heading that looks like a release heading
+// (its text starts with a version) does not parse, pointing at the first
+// character that breaks the format. Other headings, such as "# Changelog",
+// return nil.
+func (s *Scheme) diagnoseHeading(line string, lineNo int, path string) *ParseError {
+	s = s.orDefault()
+	text := strings.TrimLeft(line, "# \t")
+	if len(text) > 1 && (text[0] == 'v' || text[0] == 'V') && !s.version.MatchString(text) {
+		text = text[1:]
+	}
+	if !s.start.MatchString(text) {
+		return nil
+	}
+	fail := func(pos int, reason string) *ParseError {
+		return &ParseError{
+			Path:   path,
+			Line:   lineNo,
+			Column: pos + 1,
+			Text:   line,
+			Msg:    fmt.Sprintf("unable to parse latest release entry: %s (expected %s)", reason, s.Format()),
+			Format: s.Format(),
+		}
+	}
+
+	if len(line) > 1 && line[1] == '#' {
+		return fail(1, "release headings use a single \"#\"")
+	}
+	pos := len(line) - len(strings.TrimLeft(line[1:], " \t"))
+	version := s.version.FindString(line[pos:])
+	if version == "" {
+		if line[pos] == 'v' || line[pos] == 'V' {
+			return fail(pos, "drop the \"v\" before the version (--tag-prefix adds it to the tag)")
+		}
+		return fail(pos, "expected a version such as "+s.example)
+	}
+	pos += len(version)
+	pos = len(line) - len(strings.TrimLeft(line[pos:], " \t"))
+	if !strings.HasPrefix(line[pos:], s.separator) {
+		reason := fmt.Sprintf("expected %q between the version and the summary", s.between)
+		for _, other := range []string{"-", "—", "–", ":", "|", "("} {
+			if other != s.separator && strings.HasPrefix(line[pos:], other) {
+				sep := other
+				if sep == "(" {
+					sep = ParenSeparator
+				}
+				reason += fmt.Sprintf("; pass --heading-separator %q to read %q headings", sep, other)
+				break
+			}
+		}
+		return fail(pos, reason)
+	}
+	return fail(len(line), fmt.Sprintf("missing summary after %q", s.separator))
+}
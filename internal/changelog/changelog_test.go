@@ -1,8 +1,10 @@
 package changelog
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -85,6 +87,41 @@ func TestParseLatest_InvalidFormat(t *testing.T) {
 	}
 }
 
+func TestParseLatest_ErrorPointsAtHeading(t *testing.T) {
+	tests := []struct {
+		name    string
+		heading string
+		column  int
+		reason  string
+	}{
+		{"nested heading", "## 1.2.3 - Title", 2, `release headings use a single "#"`},
+		{"v prefix", "# v1.2.3 - Title", 3, `drop the "v" before the version`},
+		{"missing separator", "# 1.2.3 Title", 9, `expected " - " between the version and the summary`},
+		{"missing summary", "# 1.2.3 -", 10, `missing summary after "-"`},
+		{"blank summary", "# 1.2.3 -  ", 11, `missing summary after "-"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLatestContent("# Changelog\n\n"+tt.heading+"\n- Change\n", "changelog.md")
+			pe, ok := err.(*ParseError)
+			if !ok {
+				t.Fatalf("error = %v, want *ParseError", err)
+			}
+			if pe.Line != 3 || pe.Column != tt.column || pe.Text != tt.heading {
+				t.Fatalf("position = %d:%d %q, want 3:%d %q", pe.Line, pe.Column, pe.Text, tt.column, tt.heading)
+			}
+			if !strings.HasPrefix(err.Error(), "changelog.md:3: ") || !strings.Contains(err.Error(), tt.reason) {
+				t.Fatalf("error = %q, want line 3 and %q", err, tt.reason)
+			}
+		})
+	}
+
+	_, err := ParseLatestContent("# Changelog\n\nNothing yet.\n", "changelog.md")
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 0 || !strings.Contains(err.Error(), "no release heading found") {
+		t.Fatalf("error = %v, want a ParseError without a line", err)
+	}
+}
+
 func writeFile(t *testing.T, contents string) string {
 	t.Helper()
 	dir := t.TempDir()
@@ -94,3 +131,239 @@ func writeFile(t *testing.T, contents string) string {
 	}
 	return path
 }
+
+func TestParseVersion(t *testing.T) {
+	path := writeFile(t, `# Changelog
+
+# 1.2.3 - Add release flow
+- Added parser
+
+# 1.2.2 - Previous
+- Old
+- Older
+
+# 1.2.1 - First
+`)
+
+	entry, err := ParseVersion(path, "1.2.2")
+	if err != nil {
+		t.Fatalf("ParseVersion returned error: %v", err)
+	}
+	if entry.Summary != "Previous" || entry.Description != "- Old\n- Older" {
+		t.Fatalf("entry = %+v", entry)
+	}
+	if entry, err := ParseVersion(path, "1.2.1"); err != nil || entry.Summary != "First" || entry.Description != "" {
+		t.Fatalf("last entry = %+v, %v", entry, err)
+	}
+	if _, err := ParseVersion(path, "1.2"); err == nil || !strings.Contains(err.Error(), "no entry for version 1.2") {
+		t.Fatalf("error = %v, want missing version", err)
+	}
+}
+
+func TestVersions(t *testing.T) {
+	path := writeFile(t, `# Changelog
+
+# 1.2.3 - Add release flow
+- Added parser
+
+## Notes
+# 1.2.2 - Previous
+# 1.0.0-rc.1 - First
+`)
+
+	versions, err := Versions(path)
+	if err != nil {
+		t.Fatalf("Versions returned error: %v", err)
+	}
+	if got := strings.Join(versions, ","); got != "1.2.3,1.2.2,1.0.0-rc.1" {
+		t.Fatalf("versions = %q", got)
+	}
+}
+
+func TestSchemeReadsCustomVersions(t *testing.T) {
+	scheme, err := NewScheme(`R([0-9]{4})-([0-9]{2})`, "")
+	if err != nil {
+		t.Fatalf("NewScheme: %v", err)
+	}
+	path := writeFile(t, `# Changelog
+
+# R2024-11 - November release
+- Faster sync
+
+# 1.2.3 - Not a release in this scheme
+# R2024-06 - June release
+`)
+
+	entry, err := scheme.ParseLatest(path)
+	if err != nil {
+		t.Fatalf("ParseLatest returned error: %v", err)
+	}
+	if entry.Version != "R2024-11" || entry.Summary != "November release" || entry.Description != "- Faster sync" {
+		t.Fatalf("entry = %+v", entry)
+	}
+	versions, err := scheme.Versions(path)
+	if err != nil || strings.Join(versions, ",") != "R2024-11,R2024-06" {
+		t.Fatalf("Versions = %v, %v", versions, err)
+	}
+	content, _ := os.ReadFile(path)
+	if doc := scheme.ParseDocument(string(content)); doc.Index("R2024-06") != 1 || doc.Index("1.2.3") >= 0 {
+		t.Fatalf("document entries = %d", len(doc.Entries()))
+	}
+
+	_, err = scheme.ParseLatest(writeFile(t, "# R2024-11 November release\n"))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Line != 1 || !strings.Contains(pe.Msg, `expected " - " between the version and the summary`) {
+		t.Fatalf("error = %v", err)
+	}
+	if _, err := NewScheme("R[0-9", ""); err == nil {
+		t.Fatal("NewScheme accepted an invalid pattern")
+	}
+
+	// Anchors at the ends are dropped, so the pattern still matches inside
+	// a heading; anchors elsewhere, or a pattern of nothing but anchors, are
+	// rejected.
+	anchored, err := NewScheme(`^R[0-9]{4}-[0-9]{2}$`, "")
+	if err != nil {
+		t.Fatalf("NewScheme with anchors: %v", err)
+	}
+	if entry, err := anchored.ParseLatest(path); err != nil || entry.Version != "R2024-11" || anchored.Pattern() != `R[0-9]{4}-[0-9]{2}` {
+		t.Fatalf("anchored ParseLatest = %+v, %v (pattern %q)", entry, err, anchored.Pattern())
+	}
+	if s, err := NewScheme(`R[0-9]+\$`, ""); err != nil || s.Pattern() != `R[0-9]+\$` {
+		t.Fatalf("escaped $: pattern = %v, %v", s, err)
+	}
+	for _, bad := range []string{`R(^[0-9]+)`, `R[0-9]+$|S`, `(?m)R$\n`, `^$`} {
+		if _, err := NewScheme(bad, ""); err == nil {
+			t.Errorf("NewScheme(%q) accepted an inner anchor", bad)
+		}
+	}
+}
+
+func TestSchemeSeparators(t *testing.T) {
+	for _, tt := range []struct {
+		separator string
+		heading   string
+	}{
+		{":", "# 1.2.3: Add release flow"},
+		{"—", "# 1.2.3 — Add release flow"},
+		{ParenSeparator, "# 1.2.3 (Add release flow)"},
+	} {
+		scheme, err := NewScheme("", tt.separator)
+		if err != nil {
+			t.Fatalf("NewScheme(%q): %v", tt.separator, err)
+		}
+		entry, err := scheme.ParseLatestContent("# Changelog\n\n"+tt.heading+"\n- Added parser\n", "changelog.md")
+		if err != nil || entry.Version != "1.2.3" || entry.Summary != "Add release flow" || entry.Description != "- Added parser" {
+			t.Fatalf("%q: entry = %+v, %v", tt.separator, entry, err)
+		}
+		// New entries are written with the same separator.
+		doc := scheme.ParseDocument(tt.heading + "\n")
+		if err := doc.Insert(0, "1.2.4", "Add release flow", nil); err != nil {
+			t.Fatal(err)
+		}
+		if want := strings.Replace(tt.heading, "1.2.3", "1.2.4", 1) + "\n"; doc.Entries()[0].String() != want {
+			t.Fatalf("%q: inserted %q, want %q", tt.separator, doc.Entries()[0].String(), want)
+		}
+	}
+
+	// The default scheme names the separator it found.
+	_, err := ParseLatestContent("# 1.2.3: Add release flow\n", "changelog.md")
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Column != 8 || !strings.Contains(pe.Msg, `pass --heading-separator ":" to read ":" headings`) {
+		t.Fatalf("error = %v", err)
+	}
+	scheme, _ := NewScheme("", ParenSeparator)
+	if _, err := scheme.ParseLatestContent("# 1.2.3 ()\n", "changelog.md"); !errors.As(err, &pe) || pe.Format != "# <version> (<summary>)" || !strings.Contains(pe.Msg, `missing summary after "("`) {
+		t.Fatalf("empty summary error = %v", err)
+	}
+	for _, bad := range []string{"to", " - ", "1"} {
+		if _, err := NewScheme("", bad); err == nil {
+			t.Errorf("NewScheme accepted separator %q", bad)
+		}
+	}
+}
+
+func TestDocumentEditsKeepUnrelatedContent(t *testing.T) {
+	content := "<!-- keep this -->\r\n# 1.1.0 - Second\r\nIntro paragraph.\r\n- Old bullet\r\n* not a bullet\r\n\r\n# 1.0.0 - First\r\n  - indented start\r\n"
+	doc := ParseDocument(content)
+	if got := doc.String(); got != content {
+		t.Fatalf("round trip changed the file:\n%q", got)
+	}
+	if err := doc.Update("1.1.0", "Second, revised", []string{"New bullet", "Another"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Insert(0, "1.2.0", "Third", []string{"Add thing"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Insert(0, "1.0.0", "Dup", nil); err == nil {
+		t.Fatal("Insert should refuse an existing version")
+	}
+	want := "<!-- keep this -->\r\n# 1.2.0 - Third\r\n- Add thing\r\n\r\n# 1.1.0 - Second, revised\r\nIntro paragraph.\r\n- New bullet\r\n- Another\r\n* not a bullet\r\n\r\n# 1.0.0 - First\r\n  - indented start\r\n"
+	if got := doc.String(); got != want {
+		t.Fatalf("after edits:\n%q\nwant\n%q", got, want)
+	}
+
+	if err := doc.Move("1.0.0", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Remove("1.2.0"); err != nil {
+		t.Fatal(err)
+	}
+	var versions []string
+	for _, b := range doc.Entries() {
+		versions = append(versions, b.Version)
+	}
+	if strings.Join(versions, ",") != "1.0.0,1.1.0" {
+		t.Fatalf("versions = %v", versions)
+	}
+	if got := doc.String(); !strings.HasPrefix(got, "<!-- keep this -->\r\n# 1.0.0 - First\r\n  - indented start\r\n\r\n# 1.1.0") {
+		t.Fatalf("moved entry should be separated by a blank line:\n%q", got)
+	}
+	if got := doc.Entries()[1].Bullets(); strings.Join(got, "|") != "New bullet|Another" {
+		t.Fatalf("Bullets = %v", got)
+	}
+}
+
+func TestPromote(t *testing.T) {
+	content := `# Changelog
+
+# 1.4.0-rc.2 - Add export
+- Fix export crash
+- Add CSV export
+
+# 1.4.0-rc.1 - Add export
+- Add CSV export
+- Add JSON export
+
+# 1.3.0 - Previous
+- Old
+`
+	got, promoted, err := Promote(content, "changelog.md")
+	if err != nil {
+		t.Fatalf("Promote: %v", err)
+	}
+	want := `# Changelog
+
+# 1.4.0 - Add export
+- Fix export crash
+- Add CSV export
+- Add JSON export
+
+# 1.3.0 - Previous
+- Old
+`
+	if got != want {
+		t.Fatalf("Promote =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Join(promoted, ",") != "1.4.0-rc.2,1.4.0-rc.1" {
+		t.Fatalf("promoted = %v", promoted)
+	}
+
+	var pe *PromoteError
+	if _, _, err := Promote("# 1.3.0 - Final\n- Done\n", "changelog.md"); !errors.As(err, &pe) || err.Error() != "changelog.md:1: unable to promote: the latest entry 1.3.0 is not a prerelease such as 1.3.0-rc.1" {
+		t.Fatalf("final entry: error = %v", err)
+	}
+	if _, _, err := Promote("# 1.3.0-rc.1 - Again\n\n# 1.3.0 - Final\n", "changelog.md"); !errors.As(err, &pe) || !strings.Contains(err.Error(), "already has a 1.3.0 entry") {
+		t.Fatalf("released version: error = %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package changelog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Document is a changelog held for editing. It keeps every line as written,
+// split into the text before the first entry and one block per entry, so
+// inserting, updating, removing, or reordering entries leaves the rest of
+// the file byte for byte as it was.
+type Document struct {
+	preamble []string
+	entries  []*Block
+	newline  string
+	scheme   *Scheme
+}
+
+// Block is one entry: its heading and every line up to the next heading,
+// including blank lines and prose between bullets.
+type Block struct {
+	Version string
+	Summary string
+	// Line is the heading's 1-based line number as parsed, or 0 for an
+	// inserted entry.
+	Line  int
+	lines []string
+}
+
+// ParseDocument splits content into entries at each "# <version> - <summary>"
+// heading. Line endings are kept, and new lines use the file's own.
+func ParseDocument(content string) *Document { return defaultScheme.ParseDocument(content) }
+
+func (s *Scheme) ParseDocument(content string) *Document {
+	doc := &Document{newline: "\n", scheme: s}
+	if strings.Contains(content, "\r\n") {
+		doc.newline = "\r\n"
+	}
+	var current *Block
+	for i, line := range strings.SplitAfter(content, "\n") {
+		if line == "" {
+			continue
+		}
+		if version, summary, ok := s.heading(strings.TrimRight(line, "\r\n")); ok {
+			current = &Block{Version: version, Summary: summary, Line: i + 1}
+			doc.entries = append(doc.entries, current)
+		}
+		if current == nil {
+			doc.preamble = append(doc.preamble, line)
+		} else {
+			current.lines = append(current.lines, line)
+		}
+	}
+	return doc
+}
+
+// Entries returns the entries in file order, newest first.
+func (d *Document) Entries() []*Block { return d.entries }
+
+// Index returns the position of version's entry, or -1.
+func (d *Document) Index(version string) int {
+	return slices.IndexFunc(d.entries, func(b *Block) bool { return b.Version == version })
+}
+
+// Insert adds a new entry at position i (0 is the top).
+func (d *Document) Insert(i int, version, summary string, bullets []string) error {
+	if d.Index(version) >= 0 {
+		return fmt.Errorf("changelog already has a %s entry", version)
+	}
+	if i < 0 || i > len(d.entries) {
+		return fmt.Errorf("cannot insert %s at position %d of %d entries", version, i, len(d.entries))
+	}
+	b := &Block{Version: version, Summary: summary, lines: []string{d.heading(version, summary)}}
+	b.setBullets(bullets, d.newline)
+	d.entries = slices.Insert(d.entries, i, b)
+	return nil
+}
+
+// Update replaces the summary and bullets of version's entry and keeps its
+// other lines.
+func (d *Document) Update(version, summary string, bullets []string) error {
+	i := d.Index(version)
+	if i < 0 {
+		return fmt.Errorf("changelog has no %s entry", version)
+	}
+	b := d.entries[i]
+	b.Summary = summary
+	b.lines[0] = d.heading(version, summary)
+	b.setBullets(bullets, d.newline)
+	return nil
+}
+
+// Remove deletes version's entry.
+func (d *Document) Remove(version string) error {
+	i := d.Index(version)
+	if i < 0 {
+		return fmt.Errorf("changelog has no %s entry", version)
+	}
+	d.entries = slices.Delete(d.entries, i, i+1)
+	return nil
+}
+
+// Move puts version's entry at position to.
+func (d *Document) Move(version string, to int) error {
+	i := d.Index(version)
+	if i < 0 {
+		return fmt.Errorf("changelog has no %s entry", version)
+	}
+	if to < 0 || to >= len(d.entries) {
+		return fmt.Errorf("cannot move %s to position %d of %d entries", version, to, len(d.entries))
+	}
+	b := d.entries[i]
+	d.entries = slices.Insert(slices.Delete(d.entries, i, i+1), to, b)
+	return nil
+}
+
+// String renders the document. Entries that moved are separated by a blank
+// line; everything else is written as it was read.
+func (d *Document) String() string {
+	var sb strings.Builder
+	for _, line := range d.preamble {
+		sb.WriteString(line)
+	}
+	for i, b := range d.entries {
+		lines := b.lines
+		if last := lines[len(lines)-1]; !strings.HasSuffix(last, "\n") && i < len(d.entries)-1 {
+			lines = append(slices.Clone(lines[:len(lines)-1]), last+d.newline)
+		}
+		for _, line := range lines {
+			sb.WriteString(line)
+		}
+		if i < len(d.entries)-1 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			sb.WriteString(d.newline)
+		}
+	}
+	return sb.String()
+}
+
+func (d *Document) heading(version, summary string) string {
+	return d.scheme.render(version, summary) + d.newline
+}
+
+// String returns the entry's lines as they will be written.
+func (b *Block) String() string { return strings.Join(b.lines, "") }
+
+// Bullets returns the entry's "- " lines without the marker.
+func (b *Block) Bullets() []string {
+	var bullets []string
+	for _, line := range b.lines[1:] {
+		if after, found := strings.CutPrefix(strings.TrimSpace(line), "-"); found && strings.TrimSpace(after) != "" {
+			bullets = append(bullets, strings.TrimSpace(after))
+		}
+	}
+	return bullets
+}
+
+// setBullets replaces the run of bullet lines, from the first bullet to the
+// last, with bullets; lines before and after it are kept. Without existing
+// bullets they go right after the heading.
+func (b *Block) setBullets(bullets []string, newline string) {
+	first, last := -1, 0
+	for i, line := range b.lines[1:] {
+		if strings.HasPrefix(strings.TrimSpace(line), "-") {
+			if first < 0 {
+				first = i + 1
+			}
+			last = i + 1
+		}
+	}
+	if first < 0 {
+		first, last = 1, 0
+	}
+	rendered := make([]string, len(bullets))
+	for i, bullet := range bullets {
+		rendered[i] = "- " + bullet + newline
+	}
+	if first == 1 && last == 0 && !strings.HasSuffix(b.lines[0], "\n") {
+		b.lines[0] += newline
+	}
+	b.lines = slices.Concat(b.lines[:first], rendered, b.lines[last+1:])
+}
@@ -0,0 +1,73 @@
+package changelog
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/jasonwillschiu/mdrelease/internal/semver"
+)
+
+// PromoteError reports a changelog that reads fine but cannot be promoted,
+// such as one whose latest entry is not a prerelease.
+type PromoteError struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+func (e *PromoteError) Error() string {
+	return fmt.Sprintf("%s:%d: unable to promote: %s", e.Path, e.Line, e.Msg)
+}
+
+// Promote rewrites the prerelease entries at the top of a changelog, such as
+// 1.4.0-rc.2 and 1.4.0-rc.1, as a single final 1.4.0 entry. The entry keeps
+// the newest prerelease's summary and the bullets of every prerelease,
+// newest first and without duplicates. It returns the new content and the
+// prerelease versions it replaced.
+func Promote(content, path string) (string, []string, error) {
+	return defaultScheme.Promote(content, path)
+}
+
+func (s *Scheme) Promote(content, path string) (string, []string, error) {
+	doc := s.ParseDocument(content)
+	entries := doc.Entries()
+	if len(entries) == 0 {
+		return "", nil, &ParseError{Path: path, Msg: fmt.Sprintf("unable to promote: no release heading found (expected %s)", s.Format()), Format: s.Format()}
+	}
+	latest := entries[0]
+	v, err := semver.Parse(latest.Version)
+	if err != nil {
+		return "", nil, &PromoteError{Path: path, Line: latest.Line, Msg: err.Error()}
+	}
+	final := v.Core().String()
+	if !v.IsPrerelease() {
+		return "", nil, &PromoteError{Path: path, Line: latest.Line, Msg: fmt.Sprintf("the latest entry %s is not a prerelease such as %s-rc.1", latest.Version, final)}
+	}
+
+	var promoted []string
+	var bullets []string
+	for _, b := range entries {
+		if v, err := semver.Parse(b.Version); err != nil || !v.IsPrerelease() || v.Core().String() != final {
+			break
+		}
+		promoted = append(promoted, b.Version)
+		for _, bullet := range b.Bullets() {
+			if !slices.Contains(bullets, bullet) {
+				bullets = append(bullets, bullet)
+			}
+		}
+	}
+	if i := doc.Index(final); i >= 0 {
+		return "", nil, &PromoteError{Path: path, Line: entries[i].Line, Msg: fmt.Sprintf("%s already has a %s entry", path, final)}
+	}
+
+	for _, version := range promoted {
+		if err := doc.Remove(version); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := doc.Insert(0, final, latest.Summary, bullets); err != nil {
+		return "", nil, err
+	}
+	return doc.String(), promoted, nil
+}
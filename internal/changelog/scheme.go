@@ -0,0 +1,169 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// Scheme is the shape of release headings: the version format and the
+// separator before the summary. The default reads numeric versions such as
+// 1.2.3 and 1.4.0-rc.1 separated by " - "; NewScheme reads others, such as
+// "# R2024-06 - Summary" or "# 1.2.3: Summary". A nil *Scheme is the default.
+type Scheme struct {
+	header *regexp.Regexp
+	// version matches a version at the start of a heading's text, and start
+	// decides whether a heading that does not parse was meant as a release
+	// heading, so it is reported instead of skipped.
+	version, start *regexp.Regexp
+	// pattern is the custom version pattern without the anchors at its
+	// ends; "" for the default.
+	pattern string
+	example string
+	// separator is what follows the version, as typed; between and close
+	// surround the summary in headings the scheme writes.
+	separator, between, close string
+}
+
+const (
+	defaultVersion   = `[0-9]+(?:\.[0-9]+){1,2}(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`
+	defaultSeparator = "-"
+	// ParenSeparator puts the summary in parentheses: "# 1.2.3 (Summary)".
+	ParenSeparator = "()"
+)
+
+var defaultScheme = mustScheme("", defaultSeparator)
+
+// NewScheme returns the scheme whose versions match pattern as a whole (the
+// default numeric versions when it is empty) and whose summary follows
+// separator, such as "-", "—", or ":", or is in parentheses with "()". A ^
+// at the start of pattern and a $ at its end are dropped, as versions are
+// anchored anyway; anchors anywhere else are an error.
+func NewScheme(pattern, separator string) (*Scheme, error) {
+	if pattern == "" && (separator == "" || separator == defaultSeparator) {
+		return defaultScheme, nil
+	}
+	if pattern != "" {
+		var err error
+		if pattern, err = unanchor(pattern); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(separator) != separator || strings.ContainsFunc(separator, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r)
+	}) {
+		return nil, fmt.Errorf("invalid heading separator %q: use punctuation such as -, —, or :, or () for parentheses", separator)
+	}
+	return mustScheme(pattern, separator), nil
+}
+
+// unanchor strips the ^ and $ that anchor pattern at its ends, since the
+// pattern is spliced into the heading pattern, where they could never match.
+func unanchor(pattern string) (string, error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", fmt.Errorf("invalid version pattern: %w", err)
+	}
+	bare := strings.TrimPrefix(pattern, "^")
+	if end := len(bare) - 1; end >= 0 && bare[end] == '$' {
+		escapes := len(bare[:end]) - len(strings.TrimRight(bare[:end], `\`))
+		if escapes%2 == 0 {
+			bare = bare[:end]
+		}
+	}
+	if bare == "" {
+		return "", fmt.Errorf("invalid version pattern %q: it matches only an empty version", pattern)
+	}
+	re, err := syntax.Parse(bare, syntax.Perl)
+	if err != nil {
+		return "", fmt.Errorf("invalid version pattern: %w", err)
+	}
+	if anchored(re) {
+		return "", fmt.Errorf("invalid version pattern %q: ^ and $ are only allowed at its start and end", pattern)
+	}
+	return bare, nil
+}
+
+func anchored(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText:
+		return true
+	}
+	for _, sub := range re.Sub {
+		if anchored(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustScheme(pattern, separator string) *Scheme {
+	if separator == "" {
+		separator = defaultSeparator
+	}
+	s := &Scheme{separator: separator, between: " " + separator + " ", example: "1.2.3"}
+	version := defaultVersion
+	s.start = regexp.MustCompile(`^[0-9]`)
+	if pattern != "" {
+		version = pattern
+		s.pattern = pattern
+		s.example = "one matching " + pattern
+	}
+	s.version = regexp.MustCompile(`^(?:` + version + `)`)
+	if pattern != "" {
+		s.start = s.version
+	}
+
+	// A custom version may contain the separator itself, so it needs the
+	// space before a "-" that the default numeric versions can do without.
+	before := `\s*`
+	if pattern != "" && separator == defaultSeparator {
+		before = `\s+`
+	}
+	summary := before + regexp.QuoteMeta(separator) + `\s*(.+)$`
+	switch separator {
+	case ParenSeparator:
+		s.separator, s.between, s.close = "(", " (", ")"
+		summary = `\s*\((.*)\)\s*$`
+	case ":":
+		s.between = ": "
+	}
+	s.header = regexp.MustCompile(`^#\s*(` + version + `)` + summary)
+	return s
+}
+
+func (s *Scheme) orDefault() *Scheme {
+	if s == nil {
+		return defaultScheme
+	}
+	return s
+}
+
+// Pattern is the custom version pattern without anchors, or "" for the
+// default numeric versions.
+func (s *Scheme) Pattern() string {
+	return s.orDefault().pattern
+}
+
+// Format is the heading the scheme expects, such as "# <version> - <summary>".
+func (s *Scheme) Format() string {
+	s = s.orDefault()
+	return "# <version>" + s.between + "<summary>" + s.close
+}
+
+// heading reads a release heading. The pattern's own groups come between
+// the version and the summary, which is always the last group.
+func (s *Scheme) heading(line string) (version, summary string, ok bool) {
+	m := s.orDefault().header.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	return strings.TrimSpace(m[1]), strings.TrimSpace(m[len(m)-1]), true
+}
+
+// render writes a release heading without its line ending.
+func (s *Scheme) render(version, summary string) string {
+	s = s.orDefault()
+	return "# " + version + s.between + summary + s.close
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	FileNames       = []string{".mdrelease.toml", ".mdrelease.yaml", ".mdrelease.yml"}
+	GlobalFileNames = []string{"config.toml", "config.yaml", "config.yml"}
+)
+
+type Value struct {
+	Items []string
+	List  bool
+	Line  int
+}
+
+type File struct {
+	Path     string
+	Sections map[string]map[string]Value
+}
+
+type ParseError struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+func Find(dir string) (string, error) {
+	return findOne(dir, FileNames, "config files (%s); keep one or pass --config")
+}
+
+func findOne(dir string, names []string, several string) (string, error) {
+	var found []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	default:
+		return "", fmt.Errorf("found several "+several, strings.Join(found, ", "))
+	}
+}
+
+// FindUp looks for a project config in dir and its parents, stopping at the
+// repository root (the first directory containing .git).
+func FindUp(dir string) (string, error) {
+	for {
+		path, err := Find(dir)
+		if err != nil || path != "" {
+			return path, err
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func GlobalDir(xdgConfigHome, home string) string {
+	switch {
+	case xdgConfigHome != "":
+		return filepath.Join(xdgConfigHome, "mdrelease")
+	case home != "":
+		return filepath.Join(home, ".config", "mdrelease")
+	default:
+		return ""
+	}
+}
+
+func FindGlobal(dir string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	return findOne(dir, GlobalFileNames, "global config files (%s); keep one")
+}
+
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, data)
+}
+
+func Parse(path string, data []byte) (*File, error) {
+	f := &File{Path: path, Sections: map[string]map[string]Value{}}
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = parseTOML(f, string(data))
+	case ".yaml", ".yml":
+		err = parseYAML(f, string(data))
+	default:
+		return nil, &ParseError{Path: path, Msg: "unsupported config format (use .toml, .yaml, or .yml)"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) set(section, key string, v Value) error {
+	values := f.Sections[section]
+	if values == nil {
+		values = map[string]Value{}
+		f.Sections[section] = values
+	}
+	if prev, ok := values[key]; ok {
+		return f.errorf(v.Line, "duplicate key %q (first set on line %d)", key, prev.Line)
+	}
+	values[key] = v
+	return nil
+}
+
+func (f *File) errorf(line int, format string, args ...any) error {
+	return &ParseError{Path: f.Path, Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (f *File) Keys(section string) []string {
+	keys := make([]string, 0, len(f.Sections[section]))
+	for key := range f.Sections[section] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	data := `# shared settings
+remote = "upstream"
+tag-prefix = 'v'
+dry_run = false # trailing comment
+
+[release]
+forge = "github"
+asset = [
+  "dist/*.tar.gz", # archives
+  "dist/checksums.txt",
+]
+sign = true
+
+[check]
+forge-url = "https://ghe.example.com/#api"
+
+[components.web]
+`
+	f, err := Parse(".mdrelease.toml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Sections[""]["remote"]; got.Items[0] != "upstream" || got.Line != 2 {
+		t.Fatalf("remote = %+v", got)
+	}
+	if got := f.Sections[""]["dry_run"].Items[0]; got != "false" {
+		t.Fatalf("dry_run = %q", got)
+	}
+	if _, ok := f.Sections["components.web"]; !ok {
+		t.Fatalf("empty table missing from sections: %v", f.Sections)
+	}
+	asset := f.Sections["release"]["asset"]
+	if !asset.List || !slices.Equal(asset.Items, []string{"dist/*.tar.gz", "dist/checksums.txt"}) {
+		t.Fatalf("asset = %+v", asset)
+	}
+	if got := f.Sections["check"]["forge-url"].Items[0]; got != "https://ghe.example.com/#api" {
+		t.Fatalf("forge-url = %q", got)
+	}
+	if got := f.Keys("release"); !slices.Equal(got, []string{"asset", "forge", "sign"}) {
+		t.Fatalf("Keys(release) = %v", got)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	data := `---
+remote: upstream
+tag-prefix: "v"
+
+release:
+  forge: github   # comment
+  asset:
+    - dist/*.tar.gz
+    - 'dist/checksums.txt'
+  image: [ghcr.io/acme/app:sha, "ghcr.io/acme/other:sha"]
+check:
+  forge: auto
+`
+	f, err := Parse(".mdrelease.yml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Sections[""]["remote"].Items[0]; got != "upstream" {
+		t.Fatalf("remote = %q", got)
+	}
+	asset := f.Sections["release"]["asset"]
+	if !asset.List || !slices.Equal(asset.Items, []string{"dist/*.tar.gz", "dist/checksums.txt"}) || asset.Line != 7 {
+		t.Fatalf("asset = %+v", asset)
+	}
+	if got := f.Sections["release"]["image"].Items; !slices.Equal(got, []string{"ghcr.io/acme/app:sha", "ghcr.io/acme/other:sha"}) {
+		t.Fatalf("image = %v", got)
+	}
+	if got := f.Sections["check"]["forge"].Items[0]; got != "auto" {
+		t.Fatalf("check.forge = %q", got)
+	}
+}
+
+func TestParseErrorsReportLines(t *testing.T) {
+	tests := []struct {
+		name, data, want string
+	}{
+		{".mdrelease.toml", "remote = upstream\n", ".mdrelease.toml:1: remote: bare value upstream must be quoted"},
+		{".mdrelease.toml", "a = 1\n[x\n", ".mdrelease.toml:2: invalid table header"},
+		{".mdrelease.toml", "a = 1\na = 2\n", ".mdrelease.toml:2: duplicate key \"a\" (first set on line 1)"},
+		{".mdrelease.yaml", "remote upstream\n", ".mdrelease.yaml:1: expected key: value"},
+		{".mdrelease.yaml", "release:\n  forge:\n    x: y\n", ".mdrelease.yaml:3: settings nest only one level deep"},
+		{".mdrelease.yaml", "remote:\n", ".mdrelease.yaml:1: remote: missing value"},
+		{".mdrelease.json", "{}", "unsupported config format"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.name, []byte(tt.data))
+		var pe *ParseError
+		if !errors.As(err, &pe) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%s, %q) error = %v, want %q", tt.name, tt.data, err, tt.want)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	if got, err := Find(dir); err != nil || got != "" {
+		t.Fatalf("Find(empty) = %q, %v", got, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".mdrelease.yaml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Find(dir); err != nil || got != filepath.Join(dir, ".mdrelease.yaml") {
+		t.Fatalf("Find = %q, %v", got, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".mdrelease.toml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Find(dir); err == nil {
+		t.Fatal("Find with two config files: want error")
+	}
+}
+
+func TestFindUpStopsAtRepositoryRoot(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "repo")
+	sub := filepath.Join(repo, "cmd", "tool")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".mdrelease.toml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := FindUp(sub); err != nil || got != "" {
+		t.Fatalf("FindUp above the repository root = %q, %v", got, err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".mdrelease.yaml"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := FindUp(sub); err != nil || got != filepath.Join(repo, ".mdrelease.yaml") {
+		t.Fatalf("FindUp = %q, %v", got, err)
+	}
+}
+
+func TestGlobalDir(t *testing.T) {
+	if got := GlobalDir("/xdg", "/home/u"); got != filepath.Join("/xdg", "mdrelease") {
+		t.Fatalf("GlobalDir(xdg) = %s", got)
+	}
+	if got := GlobalDir("", "/home/u"); got != filepath.Join("/home/u", ".config", "mdrelease") {
+		t.Fatalf("GlobalDir(home) = %s", got)
+	}
+	if got := GlobalDir("", ""); got != "" {
+		t.Fatalf("GlobalDir() = %s", got)
+	}
+}
+
+func TestRenderRoundTrips(t *testing.T) {
+	sections := []Section{
+		{Settings: []Setting{
+			{Key: "remote", Value: []string{"origin"}, Comment: "Remote"},
+			{Key: "tag-prefix", Value: []string{"v"}},
+		}},
+		{Name: "release", Comment: "Release settings", Settings: []Setting{
+			{Key: "asset", Value: []string{"dist/*"}, List: true, Disabled: true},
+		}},
+		{Name: "pr", Settings: []Setting{
+			{Key: "base", Value: []string{"main"}},
+			{Key: "draft", Value: []string{"true"}, Disabled: true},
+		}},
+	}
+	for _, format := range []string{FormatTOML, FormatYAML} {
+		out, err := Render(format, "Starter config", sections)
+		if err != nil {
+			t.Fatalf("Render(%s): %v", format, err)
+		}
+		if !strings.HasPrefix(out, "# Starter config\n") || !strings.Contains(out, "# Release settings\n") {
+			t.Fatalf("Render(%s) = %s", format, out)
+		}
+		f, err := Parse("config."+format, []byte(out))
+		if err != nil {
+			t.Fatalf("Parse(%s): %v\n%s", format, err, out)
+		}
+		if got := f.Sections[""]["remote"].Items; len(got) != 1 || got[0] != "origin" {
+			t.Fatalf("%s remote = %v", format, got)
+		}
+		if got := f.Sections["pr"]["base"].Items; len(got) != 1 || got[0] != "main" {
+			t.Fatalf("%s pr.base = %v", format, got)
+		}
+		if _, ok := f.Sections["release"]; ok {
+			t.Fatalf("%s: disabled section was parsed: %v", format, f.Sections["release"])
+		}
+		if _, ok := f.Sections["pr"]["draft"]; ok {
+			t.Fatalf("%s: disabled setting was parsed", format)
+		}
+	}
+	if _, err := Render("json", "", sections); err == nil {
+		t.Fatal("Render(json) succeeded")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	env := map[string]string{"TOKEN": "s3cret", "EMPTY": ""}
+	getenv := func(key string) string { return env[key] }
+	for in, want := range map[string]string{
+		"plain":                    "plain",
+		"${TOKEN}":                 "s3cret",
+		"Bearer ${TOKEN}!":         "Bearer s3cret!",
+		"${MISSING}":               "",
+		"${EMPTY:-fallback}":       "fallback",
+		"${TOKEN:-fallback}":       "s3cret",
+		"$${TOKEN}":                "${TOKEN}",
+		"^v[0-9]+$":                "^v[0-9]+$",
+		"cost $5 and $$":           "cost $5 and $",
+		"${MISSING:-a-b}/${TOKEN}": "a-b/s3cret",
+	} {
+		got, err := Expand(in, getenv)
+		if err != nil || got != want {
+			t.Fatalf("Expand(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"${TOKEN", "${}", "${1X}", "${A B}"} {
+		if _, err := Expand(in, getenv); err == nil {
+			t.Fatalf("Expand(%q) succeeded", in)
+		}
+	}
+}
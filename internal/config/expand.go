@@ -0,0 +1,59 @@
+package config
+
+import (
+	"errors"
+	"strings"
+)
+
+// Expand replaces ${NAME} with the value of the environment variable NAME and
+// ${NAME:-default} with default when NAME is unset or empty. $$ is a literal $;
+// any other $ is kept as is, so values such as regexes need no escaping.
+func Expand(s string, getenv func(string) string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			s = s[i+2:]
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", errors.New("unterminated ${ in value")
+			}
+			name, def, hasDefault := strings.Cut(s[i+2:i+end], ":-")
+			if !validEnvName(name) {
+				return "", errors.New("invalid variable name in ${" + s[i+2:i+end] + "}")
+			}
+			v := getenv(name)
+			if v == "" && hasDefault {
+				v = def
+			}
+			b.WriteString(v)
+			s = s[i+end+1:]
+		default:
+			b.WriteByte('$')
+			s = s[i+1:]
+		}
+	}
+}
+
+func validEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatTOML = "toml"
+	FormatYAML = "yaml"
+)
+
+type Setting struct {
+	Key     string
+	Value   []string
+	List    bool
+	Comment string
+	// Disabled settings are written commented out, as a suggestion.
+	Disabled bool
+}
+
+type Section struct {
+	Name     string
+	Comment  string
+	Settings []Setting
+}
+
+func Render(format, header string, sections []Section) (string, error) {
+	if format != FormatTOML && format != FormatYAML {
+		return "", fmt.Errorf("unsupported config format %q (expected toml or yaml)", format)
+	}
+	var b strings.Builder
+	if header != "" {
+		for _, line := range strings.Split(strings.TrimRight(header, "\n"), "\n") {
+			b.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+		}
+	}
+	for i, section := range sections {
+		if i > 0 || header != "" {
+			b.WriteString("\n")
+		}
+		if section.Comment != "" {
+			fmt.Fprintf(&b, "# %s\n", section.Comment)
+		}
+		indent := ""
+		if section.Name != "" {
+			// A section with only suggestions is itself a suggestion; an
+			// empty YAML mapping would not parse.
+			header := ""
+			if section.disabled() {
+				header = "# "
+			}
+			switch format {
+			case FormatTOML:
+				fmt.Fprintf(&b, "%s[%s]\n", header, section.Name)
+			case FormatYAML:
+				fmt.Fprintf(&b, "%s%s:\n", header, section.Name)
+				indent = "  "
+			}
+		}
+		for _, s := range section.Settings {
+			if s.Comment != "" {
+				fmt.Fprintf(&b, "%s# %s\n", indent, s.Comment)
+			}
+			prefix := indent
+			if s.Disabled {
+				prefix += "# "
+			}
+			switch format {
+			case FormatTOML:
+				fmt.Fprintf(&b, "%s%s = %s\n", prefix, s.Key, tomlValue(s))
+			case FormatYAML:
+				if s.List {
+					fmt.Fprintf(&b, "%s%s:\n", prefix, s.Key)
+					for _, item := range s.Value {
+						fmt.Fprintf(&b, "%s  - %s\n", prefix, yamlScalar(item))
+					}
+					continue
+				}
+				fmt.Fprintf(&b, "%s%s: %s\n", prefix, s.Key, yamlScalar(first(s.Value)))
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+func (s Section) disabled() bool {
+	for _, setting := range s.Settings {
+		if !setting.Disabled {
+			return false
+		}
+	}
+	return true
+}
+
+func tomlValue(s Setting) string {
+	if s.List {
+		items := make([]string, len(s.Value))
+		for i, item := range s.Value {
+			items[i] = strconv.Quote(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	v := first(s.Value)
+	if v == "true" || v == "false" {
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	return strconv.Quote(v)
+}
+
+func yamlScalar(v string) string {
+	if v == "" || strings.ContainsAny(v, ":#[]{},&*!|>'\"%@`") || strings.TrimSpace(v) != v || strings.HasPrefix(v, "-") {
+		return strconv.Quote(v)
+	}
+	return v
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
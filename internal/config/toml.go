@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML reads the subset of TOML that mdrelease settings need: [table]
+// headers, key = value pairs, strings, booleans, numbers, and string arrays
+// (which may span several lines).
+func parseTOML(f *File, data string) error {
+	section := ""
+	lines := strings.Split(data, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return f.errorf(lineNo, "invalid table header %q", line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return f.errorf(lineNo, "empty table name")
+			}
+			if f.Sections[section] == nil {
+				// An empty table still exists, like [components.web].
+				f.Sections[section] = map[string]Value{}
+			}
+			continue
+		}
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return f.errorf(lineNo, "expected key = value, got %q", line)
+		}
+		key = unquoteKey(strings.TrimSpace(key))
+		raw = strings.TrimSpace(raw)
+		if key == "" {
+			return f.errorf(lineNo, "missing key before =")
+		}
+		if strings.HasPrefix(raw, "[") {
+			for !strings.HasSuffix(raw, "]") && i+1 < len(lines) {
+				i++
+				raw += " " + strings.TrimSpace(stripComment(lines[i]))
+			}
+			items, err := parseArray(raw, true)
+			if err != nil {
+				return f.errorf(lineNo, "%s: %v", key, err)
+			}
+			if err := f.set(section, key, Value{Items: items, List: true, Line: lineNo}); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := parseScalar(raw, true)
+		if err != nil {
+			return f.errorf(lineNo, "%s: %v", key, err)
+		}
+		if err := f.set(section, key, Value{Items: []string{value}, Line: lineNo}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unquoteKey(key string) string {
+	if s, err := strconv.Unquote(key); err == nil {
+		return s
+	}
+	return strings.Trim(key, "'")
+}
+
+func parseArray(raw string, strict bool) ([]string, error) {
+	if !strings.HasSuffix(raw, "]") {
+		return nil, errors.New("unterminated array")
+	}
+	inner := strings.TrimSpace(raw[1 : len(raw)-1])
+	var items []string
+	for inner != "" {
+		var item string
+		var err error
+		item, inner, err = nextItem(inner)
+		if err != nil {
+			return nil, err
+		}
+		value, err := parseScalar(item, strict)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+func nextItem(s string) (item, rest string, err error) {
+	s = strings.TrimSpace(s)
+	end := -1
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		end = closingQuote(s)
+		if end < 0 {
+			return "", "", errors.New("unterminated string")
+		}
+		end++
+	}
+	comma := strings.Index(s[max(end, 0):], ",")
+	if comma < 0 {
+		return strings.TrimSpace(s), "", nil
+	}
+	comma += max(end, 0)
+	return strings.TrimSpace(s[:comma]), strings.TrimSpace(s[comma+1:]), nil
+}
+
+func closingQuote(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// parseScalar decodes a quoted string or a bare boolean/number/word. TOML
+// only allows bare booleans and numbers, so strict rejects other bare words.
+func parseScalar(raw string, strict bool) (string, error) {
+	switch {
+	case raw == "":
+		return "", errors.New("missing value")
+	case raw[0] == '"':
+		if closingQuote(raw) != len(raw)-1 {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		s, err := strconv.Unquote(raw)
+		if err != nil {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return s, nil
+	case raw[0] == '\'':
+		if closingQuote(raw) != len(raw)-1 {
+			return "", fmt.Errorf("invalid string %s", raw)
+		}
+		return strings.ReplaceAll(raw[1:len(raw)-1], "''", "'"), nil
+	case raw == "true" || raw == "false":
+		return raw, nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(raw, "_", ""), 64); err == nil {
+		return strings.ReplaceAll(raw, "_", ""), nil
+	}
+	if strict {
+		return "", fmt.Errorf("bare value %s must be quoted", raw)
+	}
+	return raw, nil
+}
+
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
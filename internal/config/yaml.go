@@ -0,0 +1,110 @@
+package config
+
+import "strings"
+
+type yamlLine struct {
+	no     int
+	indent int
+	text   string
+}
+
+// parseYAML reads the subset of YAML that mdrelease settings need: top-level
+// key: value pairs, one level of command sections, and string lists written
+// as "- item" lines or inline [a, b] flows.
+func parseYAML(f *File, data string) error {
+	var lines []yamlLine
+	for i, raw := range strings.Split(data, "\n") {
+		if strings.HasPrefix(strings.TrimLeft(raw, " "), "\t") && strings.TrimSpace(raw) != "" {
+			return f.errorf(i+1, "tabs are not allowed for indentation")
+		}
+		text := strings.TrimRight(stripComment(raw), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		lines = append(lines, yamlLine{no: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+
+	section, sectionIndent := "", -1
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		if section != "" && l.indent <= sectionIndent {
+			section, sectionIndent = "", -1
+		}
+		if strings.HasPrefix(l.text, "- ") || l.text == "-" {
+			return f.errorf(l.no, "unexpected list item")
+		}
+		if section == "" && l.indent != 0 {
+			return f.errorf(l.no, "unexpected indentation")
+		}
+		key, raw, ok := cutYAMLKey(l.text)
+		if !ok {
+			return f.errorf(l.no, "expected key: value, got %q", l.text)
+		}
+		if raw != "" {
+			v, err := yamlValue(raw, l.no)
+			if err != nil {
+				return f.errorf(l.no, "%s: %v", key, err)
+			}
+			if err := f.set(section, key, v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// An empty value opens either a list of "- item" lines or, at the
+		// top level, a command section of indented keys.
+		if i+1 >= len(lines) || lines[i+1].indent <= l.indent {
+			return f.errorf(l.no, "%s: missing value", key)
+		}
+		next := lines[i+1]
+		if strings.HasPrefix(next.text, "- ") || next.text == "-" {
+			var items []string
+			for i+1 < len(lines) && lines[i+1].indent == next.indent && strings.HasPrefix(lines[i+1].text, "-") {
+				i++
+				item, err := parseScalar(strings.TrimSpace(strings.TrimPrefix(lines[i].text, "-")), false)
+				if err != nil {
+					return f.errorf(lines[i].no, "%s: %v", key, err)
+				}
+				items = append(items, item)
+			}
+			if err := f.set(section, key, Value{Items: items, List: true, Line: l.no}); err != nil {
+				return err
+			}
+			continue
+		}
+		if section != "" {
+			return f.errorf(next.no, "settings nest only one level deep (under a command section)")
+		}
+		section, sectionIndent = key, l.indent
+		if f.Sections[section] == nil {
+			f.Sections[section] = map[string]Value{}
+		}
+	}
+	return nil
+}
+
+func cutYAMLKey(text string) (key, value string, ok bool) {
+	if i := strings.Index(text, ": "); i > 0 {
+		return unquoteKey(strings.TrimSpace(text[:i])), strings.TrimSpace(text[i+2:]), true
+	}
+	if strings.HasSuffix(text, ":") && len(text) > 1 {
+		return unquoteKey(strings.TrimSpace(text[:len(text)-1])), "", true
+	}
+	return "", "", false
+}
+
+func yamlValue(raw string, line int) (Value, error) {
+	if strings.HasPrefix(raw, "[") {
+		items, err := parseArray(raw, false)
+		if err != nil {
+			return Value{}, err
+		}
+		return Value{Items: items, List: true, Line: line}, nil
+	}
+	item, err := parseScalar(raw, false)
+	if err != nil {
+		return Value{}, err
+	}
+	return Value{Items: []string{item}, Line: line}, nil
+}
@@ -0,0 +1,160 @@
+// Package export renders changelog entries as documents for publishing.
+package export
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Entry is one changelog entry to render.
+type Entry struct {
+	Version string
+	Summary string
+	Bullets []string
+}
+
+// Anchor is the fragment id of version's section, such as v1.2.3, so links
+// to a release stay stable as entries are added.
+func Anchor(version string) string {
+	return "v" + version
+}
+
+var codeSpan = regexp.MustCompile("`([^`]+)`")
+
+// inline escapes text and renders its `code` spans, the only inline markdown
+// changelog bullets commonly use.
+func inline(text string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range codeSpan.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:m[0]]))
+		b.WriteString("<code>" + template.HTMLEscapeString(text[m[2]:m[3]]) + "</code>")
+		last = m[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+	return template.HTML(b.String())
+}
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{"anchor": Anchor, "inline": inline}).Parse(`
+{{- define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.}}</title>
+<style>
+body { font-family: system-ui, sans-serif; line-height: 1.5; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #1f2328; }
+nav ul { list-style: none; padding: 0; display: flex; flex-wrap: wrap; gap: 0.25rem 1rem; }
+h2 a { color: inherit; text-decoration: none; }
+h2 a:hover { text-decoration: underline; }
+code { font-size: 0.9em; background: #f0f2f4; padding: 0.1em 0.3em; border-radius: 4px; }
+</style>
+</head>
+<body>
+{{- end}}
+{{- define "bullets"}}
+{{- if .}}
+<ul>
+{{- range .}}
+<li>{{inline .}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+{{- define "page"}}{{template "head" .Title}}
+<h1>{{.Title}}</h1>
+<nav>
+<ul>
+{{- range .Entries}}
+<li><a href="#{{anchor .Version}}">{{.Version}}</a></li>
+{{- end}}
+</ul>
+</nav>
+{{- range .Entries}}
+<section id="{{anchor .Version}}">
+<h2><a href="#{{anchor .Version}}">{{.Version}}</a> - {{inline .Summary}}</h2>
+{{- template "bullets" .Bullets}}
+</section>
+{{- end}}
+</body>
+</html>
+{{end}}
+{{- define "index"}}{{template "head" .Title}}
+<h1>{{.Title}}</h1>
+<ul>
+{{- range .Entries}}
+<li><a href="{{anchor .Version}}/index.html">{{.Version}}</a> - {{inline .Summary}}</li>
+{{- end}}
+</ul>
+</body>
+</html>
+{{end}}
+{{- define "release"}}{{template "head" (printf "%s %s" .Title .Entry.Version)}}
+<nav><a href="../index.html">{{.Title}}</a></nav>
+<h1>{{.Entry.Version}} - {{inline .Entry.Summary}}</h1>
+{{- template "bullets" .Entry.Bullets}}
+<nav>
+<ul>
+{{- with .Newer}}
+<li><a href="../{{anchor .Version}}/index.html">Newer: {{.Version}}</a></li>
+{{- end}}
+{{- with .Older}}
+<li><a href="../{{anchor .Version}}/index.html">Older: {{.Version}}</a></li>
+{{- end}}
+</ul>
+</nav>
+</body>
+</html>
+{{end}}`))
+
+// HTML writes a standalone HTML page with a section per entry, in the order
+// given, and a list of versions linking to them.
+func HTML(w io.Writer, title string, entries []Entry) error {
+	return templates.ExecuteTemplate(w, "page", struct {
+		Title   string
+		Entries []Entry
+	}{title, entries})
+}
+
+// Page is one file of a generated site, with a slash-separated path
+// relative to the site root.
+type Page struct {
+	Path    string
+	Content []byte
+}
+
+// Site renders a static site: index.html listing every entry, a
+// <anchor>/index.html page per entry that links to its neighbours, and an
+// empty .nojekyll so GitHub Pages serves the files as they are.
+func Site(title string, entries []Entry) ([]Page, error) {
+	var index bytes.Buffer
+	if err := templates.ExecuteTemplate(&index, "index", struct {
+		Title   string
+		Entries []Entry
+	}{title, entries}); err != nil {
+		return nil, err
+	}
+	pages := []Page{{Path: "index.html", Content: index.Bytes()}}
+	for i, entry := range entries {
+		var newer, older *Entry
+		if i > 0 {
+			newer = &entries[i-1]
+		}
+		if i+1 < len(entries) {
+			older = &entries[i+1]
+		}
+		var page bytes.Buffer
+		if err := templates.ExecuteTemplate(&page, "release", struct {
+			Title        string
+			Entry        Entry
+			Newer, Older *Entry
+		}{title, entry, newer, older}); err != nil {
+			return nil, err
+		}
+		pages = append(pages, Page{Path: Anchor(entry.Version) + "/index.html", Content: page.Bytes()})
+	}
+	return append(pages, Page{Path: ".nojekyll"}), nil
+}
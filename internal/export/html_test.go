@@ -0,0 +1,72 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTMLRendersAnchoredSections(t *testing.T) {
+	var b bytes.Buffer
+	err := HTML(&b, "Tool <changelog>", []Entry{
+		{Version: "1.3.0", Summary: "Add `--flag`", Bullets: []string{"Escape <b>tags</b> & `a<b>`"}},
+		{Version: "1.2.3-rc.1", Summary: "First"},
+	})
+	if err != nil {
+		t.Fatalf("HTML: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>Tool &lt;changelog&gt;</title>",
+		`<li><a href="#v1.3.0">1.3.0</a></li>`,
+		`<section id="v1.3.0">`,
+		`<h2><a href="#v1.3.0">1.3.0</a> - Add <code>--flag</code></h2>`,
+		"<li>Escape &lt;b&gt;tags&lt;/b&gt; &amp; <code>a&lt;b&gt;</code></li>",
+		`<section id="v1.2.3-rc.1">`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "<ul>") != 2 {
+		t.Fatalf("expected a bullet list only for the entry with bullets:\n%s", out)
+	}
+}
+
+func TestSiteLinksReleasePages(t *testing.T) {
+	pages, err := Site("Tool", []Entry{
+		{Version: "1.3.0", Summary: "Third", Bullets: []string{"Add `x`"}},
+		{Version: "1.2.0", Summary: "Second"},
+		{Version: "1.1.0", Summary: "First"},
+	})
+	if err != nil {
+		t.Fatalf("Site: %v", err)
+	}
+	byPath := map[string]string{}
+	var paths []string
+	for _, p := range pages {
+		byPath[p.Path] = string(p.Content)
+		paths = append(paths, p.Path)
+	}
+	if got := strings.Join(paths, ","); got != "index.html,v1.3.0/index.html,v1.2.0/index.html,v1.1.0/index.html,.nojekyll" {
+		t.Fatalf("paths = %s", got)
+	}
+	if !strings.Contains(byPath["index.html"], `<li><a href="v1.2.0/index.html">1.2.0</a> - Second</li>`) {
+		t.Fatalf("index:\n%s", byPath["index.html"])
+	}
+	middle := byPath["v1.2.0/index.html"]
+	for _, want := range []string{
+		"<title>Tool 1.2.0</title>",
+		`<a href="../index.html">Tool</a>`,
+		`<a href="../v1.3.0/index.html">Newer: 1.3.0</a>`,
+		`<a href="../v1.1.0/index.html">Older: 1.1.0</a>`,
+	} {
+		if !strings.Contains(middle, want) {
+			t.Fatalf("release page missing %q:\n%s", want, middle)
+		}
+	}
+	if strings.Contains(byPath["v1.3.0/index.html"], "Newer:") || !strings.Contains(byPath["v1.3.0/index.html"], "<li>Add <code>x</code></li>") {
+		t.Fatalf("latest page:\n%s", byPath["v1.3.0/index.html"])
+	}
+}
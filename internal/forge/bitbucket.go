@@ -0,0 +1,195 @@
+package forge
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const DefaultBitbucketAPIURL = "https://api.bitbucket.org/2.0"
+
+type Bitbucket struct {
+	BaseURL   string
+	Workspace string
+	Repo      string
+
+	api apiClient
+}
+
+func NewBitbucket(baseURL, token, repository string, client *http.Client) (*Bitbucket, error) {
+	workspace, repo, ok := strings.Cut(strings.Trim(repository, "/"), "/")
+	if !ok || workspace == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("invalid Bitbucket repository %q (expected workspace/repo)", repository)
+	}
+	if baseURL == "" {
+		baseURL = DefaultBitbucketAPIURL
+	}
+	headers := map[string]string{"Accept": "application/json"}
+	switch {
+	case token == "":
+	case strings.Contains(token, ":"):
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(token))
+	default:
+		headers["Authorization"] = "Bearer " + token
+	}
+	return &Bitbucket{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		Workspace: workspace,
+		Repo:      repo,
+		api:       apiClient{client: client, headers: headers},
+	}, nil
+}
+
+func (b *Bitbucket) Name() string { return "bitbucket" }
+
+func (b *Bitbucket) EnsureRelease(ctx context.Context, rel Release) (*Published, bool, error) {
+	var tag struct {
+		Target struct {
+			Hash string `json:"hash"`
+		} `json:"target"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	status, err := b.api.do(ctx, http.MethodGet, b.repoURL("refs/tags/"+url.PathEscape(rel.Tag)), nil, &tag)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return nil, false, &APIError{Op: "verify Bitbucket tag " + rel.Tag, StatusCode: status, Message: "tag not found on Bitbucket after push"}
+		}
+		return nil, false, wrapAPIError("verify Bitbucket tag "+rel.Tag, err)
+	}
+	if rel.Commit != "" && tag.Target.Hash != "" && tag.Target.Hash != rel.Commit {
+		return nil, false, &APIError{Op: "verify Bitbucket tag " + rel.Tag, StatusCode: status, Message: fmt.Sprintf("tag points at %s, expected %s", tag.Target.Hash, rel.Commit)}
+	}
+
+	downloads, err := b.downloads(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	pub := &Published{Tag: rel.Tag, URL: tag.Links.HTML.Href, Assets: downloads}
+	notes := notesFileName(rel.Tag)
+	if rel.Recreate {
+		// Uploading a download with an existing name replaces it, so
+		// forget the old files and let every asset upload again.
+		pub.Assets = nil
+	}
+	for _, name := range pub.Assets {
+		if name == notes {
+			return pub, false, nil
+		}
+	}
+	content := rel.Name + "\n"
+	if rel.Body != "" {
+		content += "\n" + rel.Body + "\n"
+	}
+	if err := b.upload(ctx, notes, strings.NewReader(content)); err != nil {
+		return nil, false, wrapAPIError("create Bitbucket download "+notes, err)
+	}
+	pub.Assets = append(pub.Assets, notes)
+	return pub, true, nil
+}
+
+func (b *Bitbucket) InspectRelease(ctx context.Context, tag string) (*ReleaseState, error) {
+	var ref struct {
+		Target struct {
+			Hash string `json:"hash"`
+		} `json:"target"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	status, err := b.api.do(ctx, http.MethodGet, b.repoURL("refs/tags/"+url.PathEscape(tag)), nil, &ref)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, wrapAPIError("get Bitbucket tag "+tag, err)
+	}
+	downloads, err := b.downloads(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range downloads {
+		if name == notesFileName(tag) {
+			return &ReleaseState{URL: ref.Links.HTML.Href, Commit: ref.Target.Hash}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *Bitbucket) UploadAsset(ctx context.Context, _ *Published, asset Asset) error {
+	file, err := os.Open(asset.Path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	if err := b.upload(ctx, asset.Name, file); err != nil {
+		return wrapAPIError("upload asset "+asset.Name, err)
+	}
+	return nil
+}
+
+func (b *Bitbucket) downloads(ctx context.Context) ([]string, error) {
+	var names []string
+	next := b.repoURL("downloads?pagelen=100")
+	for next != "" {
+		var page struct {
+			Values []struct {
+				Name string `json:"name"`
+			} `json:"values"`
+			Next string `json:"next"`
+		}
+		if _, err := b.api.do(ctx, http.MethodGet, next, nil, &page); err != nil {
+			return nil, wrapAPIError("list Bitbucket downloads", err)
+		}
+		for _, v := range page.Values {
+			names = append(names, v.Name)
+		}
+		next = page.Next
+	}
+	return names, nil
+}
+
+func (b *Bitbucket) upload(ctx context.Context, name string, content io.Reader) error {
+	_, err := b.api.uploadForm(ctx, b.repoURL("downloads"), "files", name, content)
+	return err
+}
+
+func (b *Bitbucket) OpenPullRequest(ctx context.Context, pr PullRequest) (*OpenedPullRequest, error) {
+	payload := map[string]any{
+		"title":               pr.Title,
+		"description":         pr.Body,
+		"source":              map[string]any{"branch": map[string]string{"name": pr.Head}},
+		"destination":         map[string]any{"branch": map[string]string{"name": pr.Base}},
+		"close_source_branch": true,
+	}
+	var created struct {
+		ID    int64 `json:"id"`
+		Links struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	if _, err := b.api.do(ctx, http.MethodPost, b.repoURL("pullrequests"), payload, &created); err != nil {
+		return nil, wrapAPIError("open Bitbucket pull request for "+pr.Head, err)
+	}
+	return &OpenedPullRequest{Number: created.ID, URL: created.Links.HTML.Href}, nil
+}
+
+func (b *Bitbucket) repoURL(path string) string {
+	return fmt.Sprintf("%s/repositories/%s/%s/%s", b.BaseURL, url.PathEscape(b.Workspace), url.PathEscape(b.Repo), path)
+}
+
+func notesFileName(tag string) string {
+	return tag + "-release-notes.md"
+}
@@ -0,0 +1,243 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type RunFunc func(ctx context.Context, name string, args ...string) (string, error)
+
+type cliRunner struct {
+	program string
+	run     RunFunc
+}
+
+func newCLIRunner(program string, run RunFunc) (cliRunner, error) {
+	if run == nil {
+		if _, err := exec.LookPath(program); err != nil {
+			return cliRunner{}, fmt.Errorf("%s not found on PATH (install it and log in, or use --forge-backend api)", program)
+		}
+		run = runCLI
+	}
+	return cliRunner{program: program, run: run}, nil
+}
+
+func runCLI(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return string(out), errors.New(msg)
+		}
+		return string(out), err
+	}
+	return string(out), nil
+}
+
+func (c cliRunner) call(ctx context.Context, op string, args ...string) (string, error) {
+	out, err := c.run(ctx, c.program, args...)
+	if err != nil {
+		return out, &APIError{Op: op, Err: fmt.Errorf("%s %s: %w", c.program, args[0], err)}
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func isCLINotFound(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "not found") || strings.Contains(msg, "404")
+}
+
+type GitHubCLI struct {
+	Repo string
+
+	cli cliRunner
+}
+
+func NewGitHubCLI(repository string, run RunFunc) (*GitHubCLI, error) {
+	cli, err := newCLIRunner("gh", run)
+	if err != nil {
+		return nil, err
+	}
+	return &GitHubCLI{Repo: strings.Trim(repository, "/"), cli: cli}, nil
+}
+
+func (g *GitHubCLI) Name() string { return "github" }
+
+func (g *GitHubCLI) EnsureRelease(ctx context.Context, rel Release) (*Published, bool, error) {
+	pub, err := g.view(ctx, rel.Tag)
+	switch {
+	case err == nil && !rel.Recreate:
+		return pub, false, nil
+	case err == nil:
+		if _, err := g.cli.call(ctx, "delete GitHub release "+rel.Tag, "release", "delete", rel.Tag, "--repo", g.Repo, "--yes"); err != nil {
+			return nil, false, err
+		}
+	case !isCLINotFound(err):
+		return nil, false, err
+	}
+	out, err := g.cli.call(ctx, "create GitHub release "+rel.Tag, "release", "create", rel.Tag, "--repo", g.Repo, "--verify-tag", "--title", rel.Name, "--notes", rel.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	return &Published{Tag: rel.Tag, URL: lastLine(out)}, true, nil
+}
+
+func (g *GitHubCLI) view(ctx context.Context, tag string) (*Published, error) {
+	out, err := g.cli.call(ctx, "get GitHub release "+tag, "release", "view", tag, "--repo", g.Repo, "--json", "url,assets")
+	if err != nil {
+		return nil, err
+	}
+	var view struct {
+		URL    string `json:"url"`
+		Assets []struct {
+			Name string `json:"name"`
+		} `json:"assets"`
+	}
+	if err := json.Unmarshal([]byte(out), &view); err != nil {
+		return nil, &APIError{Op: "get GitHub release " + tag, Err: fmt.Errorf("decode gh output: %w", err)}
+	}
+	pub := &Published{Tag: tag, URL: view.URL}
+	for _, a := range view.Assets {
+		pub.Assets = append(pub.Assets, a.Name)
+	}
+	return pub, nil
+}
+
+func (g *GitHubCLI) DeleteRelease(ctx context.Context, tag string) (bool, error) {
+	if _, err := g.view(ctx, tag); err != nil {
+		if isCLINotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if _, err := g.cli.call(ctx, "delete GitHub release "+tag, "release", "delete", tag, "--repo", g.Repo, "--yes"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (g *GitHubCLI) UploadAsset(ctx context.Context, pub *Published, asset Asset) error {
+	_, err := g.cli.call(ctx, "upload asset "+asset.Name, "release", "upload", pub.Tag, asset.Path, "--repo", g.Repo)
+	return err
+}
+
+func (g *GitHubCLI) OpenPullRequest(ctx context.Context, pr PullRequest) (*OpenedPullRequest, error) {
+	out, err := g.cli.call(ctx, "open GitHub pull request for "+pr.Head, "pr", "create", "--repo", g.Repo, "--head", pr.Head, "--base", pr.Base, "--title", pr.Title, "--body", pr.Body)
+	if err != nil {
+		return nil, err
+	}
+	return openedFromURL(lastLine(out)), nil
+}
+
+func (g *GitHubCLI) GenerateNotes(ctx context.Context, tag, previousTag, commit string) (string, error) {
+	args := []string{"api", "--method", "POST", "repos/" + g.Repo + "/releases/generate-notes", "-f", "tag_name=" + tag}
+	if previousTag != "" {
+		args = append(args, "-f", "previous_tag_name="+previousTag)
+	}
+	if commit != "" {
+		args = append(args, "-f", "target_commitish="+commit)
+	}
+	args = append(args, "--jq", ".body")
+	return g.cli.call(ctx, "generate GitHub release notes for "+tag, args...)
+}
+
+type GitLabCLI struct {
+	Project string
+
+	cli cliRunner
+}
+
+func NewGitLabCLI(project string, run RunFunc) (*GitLabCLI, error) {
+	cli, err := newCLIRunner("glab", run)
+	if err != nil {
+		return nil, err
+	}
+	return &GitLabCLI{Project: strings.Trim(project, "/"), cli: cli}, nil
+}
+
+func (g *GitLabCLI) Name() string { return "gitlab" }
+
+func (g *GitLabCLI) EnsureRelease(ctx context.Context, rel Release) (*Published, bool, error) {
+	pub, err := g.view(ctx, rel.Tag)
+	switch {
+	case err == nil && !rel.Recreate:
+		return pub, false, nil
+	case err == nil:
+		if _, err := g.cli.call(ctx, "delete GitLab release "+rel.Tag, "release", "delete", rel.Tag, "--repo", g.Project, "--yes"); err != nil {
+			return nil, false, err
+		}
+	case !isCLINotFound(err):
+		return nil, false, err
+	}
+	if _, err := g.cli.call(ctx, "create GitLab release "+rel.Tag, "release", "create", rel.Tag, "--repo", g.Project, "--name", rel.Name, "--notes", rel.Body); err != nil {
+		return nil, false, err
+	}
+	pub, err = g.view(ctx, rel.Tag)
+	if err != nil {
+		return nil, false, err
+	}
+	return pub, true, nil
+}
+
+func (g *GitLabCLI) view(ctx context.Context, tag string) (*Published, error) {
+	out, err := g.cli.call(ctx, "get GitLab release "+tag, "api", "projects/"+url.PathEscape(g.Project)+"/releases/"+url.PathEscape(tag))
+	if err != nil {
+		return nil, err
+	}
+	var existing gitlabRelease
+	if err := json.Unmarshal([]byte(out), &existing); err != nil {
+		return nil, &APIError{Op: "get GitLab release " + tag, Err: fmt.Errorf("decode glab output: %w", err)}
+	}
+	pub := existing.published()
+	pub.Tag = tag
+	return pub, nil
+}
+
+func (g *GitLabCLI) DeleteRelease(ctx context.Context, tag string) (bool, error) {
+	if _, err := g.view(ctx, tag); err != nil {
+		if isCLINotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if _, err := g.cli.call(ctx, "delete GitLab release "+tag, "release", "delete", tag, "--repo", g.Project, "--yes"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (g *GitLabCLI) UploadAsset(ctx context.Context, pub *Published, asset Asset) error {
+	_, err := g.cli.call(ctx, "upload asset "+asset.Name, "release", "upload", pub.Tag, asset.Path, "--repo", g.Project)
+	return err
+}
+
+func (g *GitLabCLI) OpenPullRequest(ctx context.Context, pr PullRequest) (*OpenedPullRequest, error) {
+	out, err := g.cli.call(ctx, "open GitLab merge request for "+pr.Head, "mr", "create", "--repo", g.Project, "--source-branch", pr.Head, "--target-branch", pr.Base, "--title", pr.Title, "--description", pr.Body, "--remove-source-branch", "--yes")
+	if err != nil {
+		return nil, err
+	}
+	return openedFromURL(lastLine(out)), nil
+}
+
+func lastLine(out string) string {
+	out = strings.TrimSpace(out)
+	if i := strings.LastIndex(out, "\n"); i >= 0 {
+		return strings.TrimSpace(out[i+1:])
+	}
+	return out
+}
+
+func openedFromURL(u string) *OpenedPullRequest {
+	number, _ := strconv.ParseInt(path.Base(u), 10, 64)
+	return &OpenedPullRequest{Number: number, URL: u}
+}
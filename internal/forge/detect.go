@@ -0,0 +1,154 @@
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	KindGitHub    = "github"
+	KindGitLab    = "gitlab"
+	KindGitea     = "gitea"
+	KindBitbucket = "bitbucket"
+)
+
+type Remote struct {
+	Kind string
+	Host string
+	Repo string
+}
+
+func ParseRemoteURL(raw string) (Remote, error) {
+	raw = strings.TrimSpace(raw)
+	var host, path string
+	switch {
+	case strings.Contains(raw, "://"):
+		u, err := url.Parse(raw)
+		if err != nil {
+			return Remote{}, fmt.Errorf("parse remote URL %q: %w", raw, err)
+		}
+		host, path = u.Hostname(), u.Path
+	case strings.Contains(raw, ":"):
+		// scp-like syntax: [user@]host:path
+		hostPart, pathPart, _ := strings.Cut(raw, ":")
+		if i := strings.LastIndex(hostPart, "@"); i >= 0 {
+			hostPart = hostPart[i+1:]
+		}
+		host, path = hostPart, pathPart
+	default:
+		return Remote{}, fmt.Errorf("remote URL %q is a local path, not a forge URL", raw)
+	}
+	repo := strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(repo, "/") {
+		return Remote{}, fmt.Errorf("remote URL %q does not name a host and owner/repo", raw)
+	}
+	host = strings.ToLower(host)
+	// GitHub's SSH-over-HTTPS endpoint serves the same repositories.
+	if host == "ssh.github.com" {
+		host = "github.com"
+	}
+	return Remote{Kind: detectKind(host), Host: host, Repo: repo}, nil
+}
+
+func detectKind(host string) string {
+	switch {
+	case host == "github.com" || strings.Contains(host, "github"):
+		return KindGitHub
+	case host == "gitlab.com" || strings.Contains(host, "gitlab"):
+		return KindGitLab
+	case host == "bitbucket.org":
+		return KindBitbucket
+	case host == "codeberg.org" || strings.Contains(host, "gitea") || strings.Contains(host, "forgejo"):
+		return KindGitea
+	default:
+		return ""
+	}
+}
+
+func (r Remote) APIURL() string {
+	switch r.Kind {
+	case KindGitHub:
+		if r.Host == "github.com" {
+			return DefaultGitHubAPIURL
+		}
+		return "https://" + r.Host + "/api/v3"
+	case KindGitea:
+		return "https://" + r.Host + "/api/v1"
+	case KindBitbucket:
+		return DefaultBitbucketAPIURL
+	default:
+		return "https://" + r.Host
+	}
+}
+
+func (r Remote) WebURL() string {
+	return "https://" + r.Host + "/" + r.Repo
+}
+
+func (r Remote) ReleaseURL(tag string) string {
+	switch r.Kind {
+	case KindGitHub, KindGitea:
+		return r.WebURL() + "/releases/tag/" + escapeRef(tag)
+	case KindGitLab:
+		return r.WebURL() + "/-/releases/" + escapeRef(tag)
+	case KindBitbucket:
+		return r.WebURL() + "/src/" + escapeRef(tag)
+	default:
+		return ""
+	}
+}
+
+func (r Remote) CompareURL(from, to string) string {
+	switch r.Kind {
+	case KindGitHub, KindGitea:
+		return r.WebURL() + "/compare/" + escapeRef(from) + "..." + escapeRef(to)
+	case KindGitLab:
+		return r.WebURL() + "/-/compare/" + escapeRef(from) + "..." + escapeRef(to)
+	case KindBitbucket:
+		return r.WebURL() + "/branches/compare/" + escapeRef(to) + "%0D" + escapeRef(from)
+	default:
+		return ""
+	}
+}
+
+func escapeRef(ref string) string {
+	parts := strings.Split(ref, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+func RemoteFor(kind, apiURL, repo string) Remote {
+	host := ""
+	if u, err := url.Parse(apiURL); err == nil && apiURL != "" {
+		host = strings.ToLower(u.Hostname())
+	}
+	switch kind {
+	case KindGitHub:
+		if host == "" || host == "api.github.com" {
+			host = "github.com"
+		}
+	case KindGitLab:
+		if host == "" {
+			host = "gitlab.com"
+		}
+	case KindBitbucket:
+		host = "bitbucket.org"
+	}
+	return Remote{Kind: kind, Host: host, Repo: strings.Trim(repo, "/")}
+}
+
+func (r Remote) AssetURL(tag, name string) string {
+	switch r.Kind {
+	case KindGitHub, KindGitea:
+		return r.WebURL() + "/releases/download/" + escapeRef(tag) + "/" + url.PathEscape(name)
+	case KindGitLab:
+		return fmt.Sprintf("https://%s/api/v4/projects/%s/packages/generic/%s/%s/%s", r.Host, url.PathEscape(r.Repo), url.PathEscape(packageName(r.Repo)), url.PathEscape(tag), url.PathEscape(name))
+	case KindBitbucket:
+		return r.WebURL() + "/downloads/" + url.PathEscape(name)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,227 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+)
+
+const (
+	DefaultAttempts = 3
+	DefaultBackoff  = 2 * time.Second
+)
+
+type Release struct {
+	Tag    string
+	Name   string
+	Body   string
+	Commit string
+	// Recreate replaces an existing release for Tag (and drops its assets)
+	// instead of reusing it, for tags that were force-retagged.
+	Recreate bool
+}
+
+type Published struct {
+	Tag       string
+	ID        int64
+	URL       string
+	UploadURL string
+	Assets    []string
+}
+
+type Asset struct {
+	Path string
+	Name string
+	Size int64
+}
+
+type Backend interface {
+	Name() string
+	EnsureRelease(ctx context.Context, rel Release) (*Published, bool, error)
+	UploadAsset(ctx context.Context, pub *Published, asset Asset) error
+}
+
+type PullRequest struct {
+	Head  string
+	Base  string
+	Title string
+	Body  string
+}
+
+type OpenedPullRequest struct {
+	Number int64
+	URL    string
+}
+
+type PullRequester interface {
+	OpenPullRequest(ctx context.Context, pr PullRequest) (*OpenedPullRequest, error)
+}
+
+type ReleaseState struct {
+	URL    string
+	Draft  bool
+	Commit string
+}
+
+type ReleaseInspector interface {
+	InspectRelease(ctx context.Context, tag string) (*ReleaseState, error)
+}
+
+// ReleaseDeleter removes the release for a tag, including a draft left
+// behind by deleting the tag. It reports false when there was none.
+type ReleaseDeleter interface {
+	DeleteRelease(ctx context.Context, tag string) (bool, error)
+}
+
+// CommitVerifier reports whether the forge shows a commit as verified, that
+// is signed by a key it trusts, and if not, why.
+type CommitVerifier interface {
+	VerifyCommit(ctx context.Context, commit string) (bool, string, error)
+}
+
+type NotesGenerator interface {
+	GenerateNotes(ctx context.Context, tag, previousTag, commit string) (string, error)
+}
+
+type FileWriter interface {
+	WriteFile(ctx context.Context, path string, content []byte, message string) (bool, error)
+}
+
+type APIError struct {
+	Op         string
+	StatusCode int
+	Message    string
+	Err        error
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.Err != nil:
+		return fmt.Sprintf("%s: %v", e.Op, e.Err)
+	case e.Message != "":
+		return fmt.Sprintf("%s: HTTP %d: %s", e.Op, e.StatusCode, e.Message)
+	default:
+		return fmt.Sprintf("%s: HTTP %d", e.Op, e.StatusCode)
+	}
+}
+
+func (e *APIError) Unwrap() error { return e.Err }
+
+func ExpandAssets(patterns []string) ([]Asset, error) {
+	var assets []Asset
+	seen := map[string]string{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
+		}
+		sort.Strings(matches)
+		matched := 0
+		for _, path := range matches {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, fmt.Errorf("stat asset %s: %w", path, err)
+			}
+			if info.IsDir() {
+				continue
+			}
+			matched++
+			name := filepath.Base(path)
+			if prev, ok := seen[name]; ok {
+				if filepath.Clean(prev) == filepath.Clean(path) {
+					continue
+				}
+				return nil, fmt.Errorf("assets %s and %s share the file name %s", prev, path, name)
+			}
+			seen[name] = path
+			assets = append(assets, Asset{Path: path, Name: name, Size: info.Size()})
+		}
+		if matched == 0 {
+			return nil, fmt.Errorf("asset pattern %q matched no files", pattern)
+		}
+	}
+	return assets, nil
+}
+
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+type Uploader struct {
+	Backend  Backend
+	Out      io.Writer
+	Attempts int
+	Backoff  time.Duration
+}
+
+func (u Uploader) Upload(ctx context.Context, pub *Published, assets []Asset) error {
+	attempts := max(u.Attempts, 1)
+	var errs []error
+	for i, asset := range assets {
+		prefix := fmt.Sprintf("[%d/%d] %s (%s)", i+1, len(assets), asset.Name, FormatSize(asset.Size))
+		if slices.Contains(pub.Assets, asset.Name) {
+			u.printf("%s: already attached, skipping\n", prefix)
+			continue
+		}
+		u.printf("Uploading %s...\n", prefix)
+		var err error
+		for attempt := 1; attempt <= attempts; attempt++ {
+			start := time.Now()
+			err = u.Backend.UploadAsset(ctx, pub, asset)
+			if err == nil {
+				u.printf("  uploaded in %s\n", time.Since(start).Round(time.Millisecond))
+				pub.Assets = append(pub.Assets, asset.Name)
+				break
+			}
+			if ctx.Err() != nil {
+				return errors.Join(append(errs, err)...)
+			}
+			if attempt < attempts {
+				u.printf("  attempt %d/%d failed: %v; retrying\n", attempt, attempts, err)
+				if err := sleep(ctx, u.Backoff*time.Duration(attempt)); err != nil {
+					return errors.Join(append(errs, err)...)
+				}
+			}
+		}
+		if err != nil {
+			u.printf("  failed after %d attempt(s): %v\n", attempts, err)
+			errs = append(errs, fmt.Errorf("upload %s: %w", asset.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (u Uploader) printf(format string, args ...any) {
+	if u.Out != nil {
+		_, _ = fmt.Fprintf(u.Out, format, args...)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,148 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+type Gitea struct {
+	BaseURL string
+	Owner   string
+	Repo    string
+
+	api apiClient
+}
+
+func NewGitea(baseURL, token, repository string, client *http.Client) (*Gitea, error) {
+	owner, repo, ok := strings.Cut(strings.Trim(repository, "/"), "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("invalid Gitea repository %q (expected owner/repo)", repository)
+	}
+	if baseURL == "" {
+		return nil, errors.New("a Gitea server URL is required (--forge-url)")
+	}
+	headers := map[string]string{"Accept": "application/json"}
+	if token != "" {
+		headers["Authorization"] = "token " + token
+	}
+	return &Gitea{
+		BaseURL: strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/api/v1"),
+		Owner:   owner,
+		Repo:    repo,
+		api:     apiClient{client: client, headers: headers},
+	}, nil
+}
+
+func (g *Gitea) Name() string { return "gitea" }
+
+func (g *Gitea) EnsureRelease(ctx context.Context, rel Release) (*Published, bool, error) {
+	var existing githubRelease
+	status, err := g.api.do(ctx, http.MethodGet, g.repoURL("releases/tags/"+url.PathEscape(rel.Tag)), nil, &existing)
+	switch {
+	case err == nil && rel.Recreate:
+		if _, err := g.api.do(ctx, http.MethodDelete, g.repoURL(fmt.Sprintf("releases/%d", existing.ID)), nil, nil); err != nil {
+			return nil, false, wrapAPIError("delete Gitea release "+rel.Tag, err)
+		}
+	case err == nil:
+		pub := existing.published()
+		pub.Tag = rel.Tag
+		return pub, false, nil
+	case status != http.StatusNotFound:
+		return nil, false, wrapAPIError("get Gitea release "+rel.Tag, err)
+	}
+
+	payload := map[string]any{
+		"tag_name": rel.Tag,
+		"name":     rel.Name,
+		"body":     rel.Body,
+	}
+	var created githubRelease
+	if _, err := g.api.do(ctx, http.MethodPost, g.repoURL("releases"), payload, &created); err != nil {
+		return nil, false, wrapAPIError("create Gitea release "+rel.Tag, err)
+	}
+	pub := created.published()
+	pub.Tag = rel.Tag
+	return pub, true, nil
+}
+
+func (g *Gitea) InspectRelease(ctx context.Context, tag string) (*ReleaseState, error) {
+	var rel githubRelease
+	status, err := g.api.do(ctx, http.MethodGet, g.repoURL("releases/tags/"+url.PathEscape(tag)), nil, &rel)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, wrapAPIError("get Gitea release "+tag, err)
+	}
+	var ref struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	if status, err := g.api.do(ctx, http.MethodGet, g.repoURL("tags/"+url.PathEscape(tag)), nil, &ref); err != nil && status != http.StatusNotFound {
+		return nil, wrapAPIError("get Gitea tag "+tag, err)
+	}
+	return &ReleaseState{URL: rel.HTMLURL, Draft: rel.Draft, Commit: ref.Commit.SHA}, nil
+}
+
+func (g *Gitea) DeleteRelease(ctx context.Context, tag string) (bool, error) {
+	var rel githubRelease
+	status, err := g.api.do(ctx, http.MethodGet, g.repoURL("releases/tags/"+url.PathEscape(tag)), nil, &rel)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return false, nil
+		}
+		return false, wrapAPIError("get Gitea release "+tag, err)
+	}
+	if _, err := g.api.do(ctx, http.MethodDelete, g.repoURL(fmt.Sprintf("releases/%d", rel.ID)), nil, nil); err != nil {
+		return false, wrapAPIError("delete Gitea release "+tag, err)
+	}
+	return true, nil
+}
+
+func (g *Gitea) VerifyCommit(ctx context.Context, commit string) (bool, string, error) {
+	var c githubCommit
+	if _, err := g.api.do(ctx, http.MethodGet, g.repoURL("git/commits/"+url.PathEscape(commit)), nil, &c); err != nil {
+		return false, "", wrapAPIError("get Gitea commit "+commit, err)
+	}
+	return c.Commit.Verification.Verified, c.Commit.Verification.Reason, nil
+}
+
+func (g *Gitea) UploadAsset(ctx context.Context, pub *Published, asset Asset) error {
+	file, err := os.Open(asset.Path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+	endpoint := g.repoURL(fmt.Sprintf("releases/%d/assets?name=%s", pub.ID, url.QueryEscape(asset.Name)))
+	if _, err := g.api.uploadForm(ctx, endpoint, "attachment", asset.Name, file); err != nil {
+		return wrapAPIError("upload asset "+asset.Name, err)
+	}
+	return nil
+}
+
+func (g *Gitea) OpenPullRequest(ctx context.Context, pr PullRequest) (*OpenedPullRequest, error) {
+	payload := map[string]any{
+		"title": pr.Title,
+		"head":  pr.Head,
+		"base":  pr.Base,
+		"body":  pr.Body,
+	}
+	var created struct {
+		Number  int64  `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if _, err := g.api.do(ctx, http.MethodPost, g.repoURL("pulls"), payload, &created); err != nil {
+		return nil, wrapAPIError("open Gitea pull request for "+pr.Head, err)
+	}
+	return &OpenedPullRequest{Number: created.Number, URL: created.HTMLURL}, nil
+}
+
+func (g *Gitea) repoURL(path string) string {
+	return fmt.Sprintf("%s/api/v1/repos/%s/%s/%s", g.BaseURL, url.PathEscape(g.Owner), url.PathEscape(g.Repo), path)
+}
@@ -0,0 +1,286 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const DefaultGitHubAPIURL = "https://api.github.com"
+
+type GitHub struct {
+	BaseURL string
+	Token   string
+	Owner   string
+	Repo    string
+
+	api apiClient
+}
+
+func NewGitHub(baseURL, token, repository string, client *http.Client) (*GitHub, error) {
+	owner, repo, ok := strings.Cut(strings.Trim(repository, "/"), "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return nil, fmt.Errorf("invalid GitHub repository %q (expected owner/repo)", repository)
+	}
+	if baseURL == "" {
+		baseURL = DefaultGitHubAPIURL
+	}
+	headers := map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return &GitHub{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		Owner:   owner,
+		Repo:    repo,
+		api:     apiClient{client: client, headers: headers},
+	}, nil
+}
+
+func (g *GitHub) Name() string { return "github" }
+
+type githubRelease struct {
+	ID        int64  `json:"id"`
+	HTMLURL   string `json:"html_url"`
+	UploadURL string `json:"upload_url"`
+	Draft     bool   `json:"draft"`
+	Assets    []struct {
+		Name string `json:"name"`
+	} `json:"assets"`
+}
+
+func (r githubRelease) published() *Published {
+	pub := &Published{ID: r.ID, URL: r.HTMLURL, UploadURL: r.UploadURL}
+	if i := strings.Index(pub.UploadURL, "{"); i >= 0 {
+		pub.UploadURL = pub.UploadURL[:i]
+	}
+	for _, a := range r.Assets {
+		pub.Assets = append(pub.Assets, a.Name)
+	}
+	return pub
+}
+
+func (g *GitHub) EnsureRelease(ctx context.Context, rel Release) (*Published, bool, error) {
+	var existing githubRelease
+	status, err := g.api.do(ctx, http.MethodGet, g.repoURL("releases/tags/"+url.PathEscape(rel.Tag)), nil, &existing)
+	switch {
+	case err == nil && !rel.Recreate:
+		pub := existing.published()
+		pub.Tag = rel.Tag
+		return pub, false, nil
+	case err == nil:
+	case status != http.StatusNotFound:
+		return nil, false, wrapAPIError("get GitHub release "+rel.Tag, err)
+	case rel.Recreate:
+		// Deleting a tag turns its release into a draft, which the
+		// tag lookup no longer finds.
+		if existing, err = g.findDraft(ctx, rel.Tag); err != nil {
+			return nil, false, err
+		}
+	}
+	if existing.ID != 0 {
+		if _, err := g.api.do(ctx, http.MethodDelete, g.repoURL(fmt.Sprintf("releases/%d", existing.ID)), nil, nil); err != nil {
+			return nil, false, wrapAPIError("delete GitHub release "+rel.Tag, err)
+		}
+	}
+
+	payload := map[string]any{
+		"tag_name": rel.Tag,
+		"name":     rel.Name,
+		"body":     rel.Body,
+	}
+	var created githubRelease
+	if _, err := g.api.do(ctx, http.MethodPost, g.repoURL("releases"), payload, &created); err != nil {
+		return nil, false, wrapAPIError("create GitHub release "+rel.Tag, err)
+	}
+	pub := created.published()
+	pub.Tag = rel.Tag
+	return pub, true, nil
+}
+
+func (g *GitHub) GenerateNotes(ctx context.Context, tag, previousTag, commit string) (string, error) {
+	payload := map[string]any{"tag_name": tag}
+	if previousTag != "" {
+		payload["previous_tag_name"] = previousTag
+	}
+	if commit != "" {
+		payload["target_commitish"] = commit
+	}
+	var notes struct {
+		Body string `json:"body"`
+	}
+	if _, err := g.api.do(ctx, http.MethodPost, g.repoURL("releases/generate-notes"), payload, &notes); err != nil {
+		return "", wrapAPIError("generate GitHub release notes for "+tag, err)
+	}
+	return strings.TrimSpace(notes.Body), nil
+}
+
+func (g *GitHub) OpenPullRequest(ctx context.Context, pr PullRequest) (*OpenedPullRequest, error) {
+	payload := map[string]any{
+		"title": pr.Title,
+		"head":  pr.Head,
+		"base":  pr.Base,
+		"body":  pr.Body,
+	}
+	var created struct {
+		Number  int64  `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if _, err := g.api.do(ctx, http.MethodPost, g.repoURL("pulls"), payload, &created); err != nil {
+		return nil, wrapAPIError("open GitHub pull request for "+pr.Head, err)
+	}
+	return &OpenedPullRequest{Number: created.Number, URL: created.HTMLURL}, nil
+}
+
+func (g *GitHub) findDraft(ctx context.Context, tag string) (githubRelease, error) {
+	var releases []struct {
+		githubRelease
+		TagName string `json:"tag_name"`
+	}
+	if _, err := g.api.do(ctx, http.MethodGet, g.repoURL("releases?per_page=100"), nil, &releases); err != nil {
+		return githubRelease{}, wrapAPIError("list GitHub releases", err)
+	}
+	for _, r := range releases {
+		if r.Draft && r.TagName == tag {
+			return r.githubRelease, nil
+		}
+	}
+	return githubRelease{}, nil
+}
+
+func (g *GitHub) InspectRelease(ctx context.Context, tag string) (*ReleaseState, error) {
+	var rel githubRelease
+	status, err := g.api.do(ctx, http.MethodGet, g.repoURL("releases/tags/"+url.PathEscape(tag)), nil, &rel)
+	switch {
+	case err == nil:
+	case status != http.StatusNotFound:
+		return nil, wrapAPIError("get GitHub release "+tag, err)
+	default:
+		if rel, err = g.findDraft(ctx, tag); err != nil {
+			return nil, err
+		}
+		if rel.ID == 0 {
+			return nil, nil
+		}
+	}
+	commit, err := g.tagCommit(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &ReleaseState{URL: rel.HTMLURL, Draft: rel.Draft, Commit: commit}, nil
+}
+
+func (g *GitHub) DeleteRelease(ctx context.Context, tag string) (bool, error) {
+	var rel githubRelease
+	status, err := g.api.do(ctx, http.MethodGet, g.repoURL("releases/tags/"+url.PathEscape(tag)), nil, &rel)
+	switch {
+	case err == nil:
+	case status != http.StatusNotFound:
+		return false, wrapAPIError("get GitHub release "+tag, err)
+	default:
+		if rel, err = g.findDraft(ctx, tag); err != nil {
+			return false, err
+		}
+		if rel.ID == 0 {
+			return false, nil
+		}
+	}
+	if _, err := g.api.do(ctx, http.MethodDelete, g.repoURL(fmt.Sprintf("releases/%d", rel.ID)), nil, nil); err != nil {
+		return false, wrapAPIError("delete GitHub release "+tag, err)
+	}
+	return true, nil
+}
+
+// githubCommit is the part of a GitHub or Gitea commit that carries its
+// signature verification.
+type githubCommit struct {
+	Commit struct {
+		Verification struct {
+			Verified bool   `json:"verified"`
+			Reason   string `json:"reason"`
+		} `json:"verification"`
+	} `json:"commit"`
+}
+
+func (g *GitHub) VerifyCommit(ctx context.Context, commit string) (bool, string, error) {
+	var c githubCommit
+	if _, err := g.api.do(ctx, http.MethodGet, g.repoURL("commits/"+url.PathEscape(commit)), nil, &c); err != nil {
+		return false, "", wrapAPIError("get GitHub commit "+commit, err)
+	}
+	return c.Commit.Verification.Verified, c.Commit.Verification.Reason, nil
+}
+
+func (g *GitHub) tagCommit(ctx context.Context, tag string) (string, error) {
+	var ref struct {
+		Object struct {
+			SHA  string `json:"sha"`
+			Type string `json:"type"`
+		} `json:"object"`
+	}
+	status, err := g.api.do(ctx, http.MethodGet, g.repoURL("git/ref/tags/"+url.PathEscape(tag)), nil, &ref)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return "", nil
+		}
+		return "", wrapAPIError("get GitHub tag "+tag, err)
+	}
+	for ref.Object.Type == "tag" {
+		if _, err := g.api.do(ctx, http.MethodGet, g.repoURL("git/tags/"+ref.Object.SHA), nil, &ref); err != nil {
+			return "", wrapAPIError("get GitHub tag "+tag, err)
+		}
+	}
+	return ref.Object.SHA, nil
+}
+
+func (g *GitHub) UploadAsset(ctx context.Context, pub *Published, asset Asset) error {
+	uploadURL := pub.UploadURL
+	if uploadURL == "" {
+		uploadURL = g.repoURL(fmt.Sprintf("releases/%d/assets", pub.ID))
+	}
+	if _, err := g.api.upload(ctx, http.MethodPost, uploadURL+"?name="+url.QueryEscape(asset.Name), asset); err != nil {
+		return wrapAPIError("upload asset "+asset.Name, err)
+	}
+	return nil
+}
+
+func (g *GitHub) WriteFile(ctx context.Context, filePath string, content []byte, message string) (bool, error) {
+	var existing struct {
+		SHA     string `json:"sha"`
+		Content string `json:"content"`
+	}
+	endpoint := g.repoURL("contents/" + escapeRef(filePath))
+	status, err := g.api.do(ctx, http.MethodGet, endpoint, nil, &existing)
+	if err != nil && status != http.StatusNotFound {
+		return false, wrapAPIError("get "+filePath+" in "+g.Owner+"/"+g.Repo, err)
+	}
+	if err == nil {
+		// The contents API wraps base64 at 60 columns.
+		current, decodeErr := base64.StdEncoding.DecodeString(strings.ReplaceAll(existing.Content, "\n", ""))
+		if decodeErr == nil && bytes.Equal(current, content) {
+			return false, nil
+		}
+	}
+	payload := map[string]any{
+		"message": message,
+		"content": base64.StdEncoding.EncodeToString(content),
+	}
+	if existing.SHA != "" {
+		payload["sha"] = existing.SHA
+	}
+	if _, err := g.api.do(ctx, http.MethodPut, endpoint, payload, nil); err != nil {
+		return false, wrapAPIError("write "+filePath+" in "+g.Owner+"/"+g.Repo, err)
+	}
+	return true, nil
+}
+
+func (g *GitHub) repoURL(path string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/%s", g.BaseURL, url.PathEscape(g.Owner), url.PathEscape(g.Repo), path)
+}
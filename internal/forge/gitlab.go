@@ -0,0 +1,177 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const DefaultGitLabURL = "https://gitlab.com"
+
+type GitLab struct {
+	BaseURL string
+	Project string
+
+	api apiClient
+}
+
+func NewGitLab(baseURL, token, project string, jobToken bool, client *http.Client) (*GitLab, error) {
+	project = strings.Trim(project, "/")
+	if !strings.Contains(project, "/") {
+		return nil, fmt.Errorf("invalid GitLab project %q (expected group/project)", project)
+	}
+	if baseURL == "" {
+		baseURL = DefaultGitLabURL
+	}
+	baseURL = strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/api/v4")
+	headers := map[string]string{}
+	switch {
+	case token == "":
+	case jobToken:
+		headers["JOB-TOKEN"] = token
+	default:
+		headers["PRIVATE-TOKEN"] = token
+	}
+	return &GitLab{
+		BaseURL: baseURL,
+		Project: project,
+		api:     apiClient{client: client, headers: headers},
+	}, nil
+}
+
+func (g *GitLab) Name() string { return "gitlab" }
+
+type gitlabRelease struct {
+	TagName string `json:"tag_name"`
+	Commit  struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+	Links struct {
+		Self string `json:"self"`
+	} `json:"_links"`
+	Assets struct {
+		Links []struct {
+			Name string `json:"name"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+func (r gitlabRelease) published() *Published {
+	pub := &Published{URL: r.Links.Self}
+	for _, link := range r.Assets.Links {
+		pub.Assets = append(pub.Assets, link.Name)
+	}
+	return pub
+}
+
+func (g *GitLab) EnsureRelease(ctx context.Context, rel Release) (*Published, bool, error) {
+	var existing gitlabRelease
+	status, err := g.api.do(ctx, http.MethodGet, g.projectURL("releases/"+url.PathEscape(rel.Tag)), nil, &existing)
+	switch {
+	case err == nil && rel.Recreate:
+		if _, err := g.api.do(ctx, http.MethodDelete, g.projectURL("releases/"+url.PathEscape(rel.Tag)), nil, nil); err != nil {
+			return nil, false, wrapAPIError("delete GitLab release "+rel.Tag, err)
+		}
+	case err == nil:
+		pub := existing.published()
+		pub.Tag = rel.Tag
+		return pub, false, nil
+	case status != http.StatusNotFound:
+		return nil, false, wrapAPIError("get GitLab release "+rel.Tag, err)
+	}
+
+	payload := map[string]any{
+		"tag_name":    rel.Tag,
+		"name":        rel.Name,
+		"description": rel.Body,
+	}
+	var created gitlabRelease
+	if _, err := g.api.do(ctx, http.MethodPost, g.projectURL("releases"), payload, &created); err != nil {
+		return nil, false, wrapAPIError("create GitLab release "+rel.Tag, err)
+	}
+	pub := created.published()
+	pub.Tag = rel.Tag
+	return pub, true, nil
+}
+
+func (g *GitLab) DeleteRelease(ctx context.Context, tag string) (bool, error) {
+	status, err := g.api.do(ctx, http.MethodDelete, g.projectURL("releases/"+url.PathEscape(tag)), nil, nil)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return false, nil
+		}
+		return false, wrapAPIError("delete GitLab release "+tag, err)
+	}
+	return true, nil
+}
+
+func (g *GitLab) VerifyCommit(ctx context.Context, commit string) (bool, string, error) {
+	var sig struct {
+		Status string `json:"verification_status"`
+	}
+	status, err := g.api.do(ctx, http.MethodGet, g.projectURL("repository/commits/"+url.PathEscape(commit)+"/signature"), nil, &sig)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return false, "unsigned", nil
+		}
+		return false, "", wrapAPIError("get GitLab commit signature "+commit, err)
+	}
+	return sig.Status == "verified", sig.Status, nil
+}
+
+func (g *GitLab) InspectRelease(ctx context.Context, tag string) (*ReleaseState, error) {
+	var rel gitlabRelease
+	status, err := g.api.do(ctx, http.MethodGet, g.projectURL("releases/"+url.PathEscape(tag)), nil, &rel)
+	if err != nil {
+		if status == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, wrapAPIError("get GitLab release "+tag, err)
+	}
+	return &ReleaseState{URL: rel.Links.Self, Commit: rel.Commit.ID}, nil
+}
+
+func (g *GitLab) UploadAsset(ctx context.Context, pub *Published, asset Asset) error {
+	packageURL := g.projectURL(path.Join("packages/generic", url.PathEscape(packageName(g.Project)), url.PathEscape(pub.Tag), url.PathEscape(asset.Name)))
+	if _, err := g.api.upload(ctx, http.MethodPut, packageURL, asset); err != nil {
+		return wrapAPIError("upload asset "+asset.Name, err)
+	}
+	link := map[string]any{
+		"name":      asset.Name,
+		"url":       packageURL,
+		"link_type": "package",
+	}
+	if _, err := g.api.do(ctx, http.MethodPost, g.projectURL("releases/"+url.PathEscape(pub.Tag)+"/assets/links"), link, nil); err != nil {
+		return wrapAPIError("link asset "+asset.Name, err)
+	}
+	return nil
+}
+
+func (g *GitLab) OpenPullRequest(ctx context.Context, pr PullRequest) (*OpenedPullRequest, error) {
+	payload := map[string]any{
+		"source_branch":        pr.Head,
+		"target_branch":        pr.Base,
+		"title":                pr.Title,
+		"description":          pr.Body,
+		"remove_source_branch": true,
+	}
+	var created struct {
+		IID    int64  `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	if _, err := g.api.do(ctx, http.MethodPost, g.projectURL("merge_requests"), payload, &created); err != nil {
+		return nil, wrapAPIError("open GitLab merge request for "+pr.Head, err)
+	}
+	return &OpenedPullRequest{Number: created.IID, URL: created.WebURL}, nil
+}
+
+func (g *GitLab) projectURL(p string) string {
+	return fmt.Sprintf("%s/api/v4/projects/%s/%s", g.BaseURL, url.PathEscape(g.Project), p)
+}
+
+func packageName(project string) string {
+	return path.Base(project)
+}
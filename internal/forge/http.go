@@ -0,0 +1,140 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const userAgent = "mdrelease"
+
+type apiClient struct {
+	client  *http.Client
+	headers map[string]string
+}
+
+func (c apiClient) do(ctx context.Context, method, endpoint string, payload, out any) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+func (c apiClient) upload(ctx context.Context, method, endpoint string, asset Asset) (int, error) {
+	file, err := os.Open(asset.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, file)
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = asset.Size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return c.send(req, nil)
+}
+
+func (c apiClient) uploadForm(ctx context.Context, endpoint, field, name string, content io.Reader) (int, error) {
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		part, err := form.CreateFormFile(field, name)
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, pr)
+	if err != nil {
+		_ = pr.Close()
+		return 0, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	status, err := c.send(req, nil)
+	_ = pr.Close()
+	return status, err
+}
+
+func (c apiClient) send(req *http.Request, out any) (int, error) {
+	req.Header.Set("User-Agent", userAgent)
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	client := c.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, &APIError{StatusCode: resp.StatusCode, Message: apiMessage(data)}
+	}
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func apiMessage(data []byte) string {
+	var payload struct {
+		Message any    `json:"message"`
+		Error   string `json:"error"`
+	}
+	if json.Unmarshal(data, &payload) == nil {
+		switch msg := payload.Message.(type) {
+		case string:
+			if msg != "" {
+				return msg
+			}
+		case nil:
+		default:
+			if encoded, err := json.Marshal(msg); err == nil {
+				return string(encoded)
+			}
+		}
+		if payload.Error != "" {
+			return payload.Error
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func wrapAPIError(op string, err error) error {
+	if apiErr, ok := err.(*APIError); ok {
+		apiErr.Op = op
+		return apiErr
+	}
+	return &APIError{Op: op, Err: err}
+}
@@ -0,0 +1,71 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+type ImagePublisher struct {
+	Source string
+
+	run RunFunc
+}
+
+func NewImagePublisher(source string, run RunFunc) (*ImagePublisher, error) {
+	if ImageRepository(source) == "" {
+		return nil, fmt.Errorf("invalid --image %q (expected registry/name:tag or registry/name@digest)", source)
+	}
+	if run == nil {
+		if _, err := exec.LookPath("docker"); err != nil {
+			return nil, fmt.Errorf("docker not found on PATH (install docker with buildx or drop --image)")
+		}
+		run = runCLI
+	}
+	return &ImagePublisher{Source: source, run: run}, nil
+}
+
+func ImageRepository(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	if ref == "" || strings.ContainsAny(ref, " \t") {
+		return ""
+	}
+	return ref
+}
+
+func (p *ImagePublisher) Targets(version string, latest bool) []string {
+	repo := ImageRepository(p.Source)
+	targets := []string{repo + ":" + version}
+	if latest {
+		targets = append(targets, repo+":latest")
+	}
+	return targets
+}
+
+func (p *ImagePublisher) Inspect(ctx context.Context) error {
+	if _, err := p.run(ctx, "docker", "buildx", "imagetools", "inspect", p.Source); err != nil {
+		return fmt.Errorf("inspect image %s: %w", p.Source, err)
+	}
+	return nil
+}
+
+// Publish copies the source manifest (including every platform of a
+// multi-arch image) to the target tags on the registry without pulling it.
+func (p *ImagePublisher) Publish(ctx context.Context, targets []string) error {
+	args := []string{"buildx", "imagetools", "create"}
+	for _, t := range targets {
+		args = append(args, "--tag", t)
+	}
+	args = append(args, p.Source)
+	if _, err := p.run(ctx, "docker", args...); err != nil {
+		return fmt.Errorf("tag image %s as %s: %w", p.Source, strings.Join(targets, ", "), err)
+	}
+	return nil
+}
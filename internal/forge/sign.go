@@ -0,0 +1,72 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+)
+
+type Signer struct {
+	Key     string
+	Pattern string
+	Dir     string
+
+	run RunFunc
+}
+
+func NewSigner(key, pattern, dir string, run RunFunc) (*Signer, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid --sign-pattern %q: %w", pattern, err)
+	}
+	if run == nil {
+		if _, err := exec.LookPath("cosign"); err != nil {
+			return nil, fmt.Errorf("cosign not found on PATH (install sigstore cosign or drop --sign)")
+		}
+		run = runCLI
+	}
+	return &Signer{Key: key, Pattern: pattern, Dir: dir, run: run}, nil
+}
+
+func (s *Signer) Keyless() bool { return s.Key == "" }
+
+func (s *Signer) Selects(asset Asset) bool {
+	ok, _ := path.Match(s.Pattern, asset.Name)
+	return ok
+}
+
+func (s *Signer) Sign(ctx context.Context, assets []Asset) ([]Asset, error) {
+	var signatures []Asset
+	for _, asset := range assets {
+		if !s.Selects(asset) {
+			continue
+		}
+		sig := filepath.Join(s.Dir, asset.Name+".sig")
+		args := []string{"sign-blob", "--yes", "--output-signature", sig}
+		outputs := []string{sig}
+		if s.Keyless() {
+			cert := filepath.Join(s.Dir, asset.Name+".pem")
+			args = append(args, "--output-certificate", cert)
+			outputs = append(outputs, cert)
+		} else {
+			args = append(args, "--key", s.Key)
+		}
+		args = append(args, asset.Path)
+		if _, err := s.run(ctx, "cosign", args...); err != nil {
+			return nil, fmt.Errorf("cosign sign-blob %s: %w", asset.Name, err)
+		}
+		for _, out := range outputs {
+			info, err := os.Stat(out)
+			if err != nil {
+				return nil, fmt.Errorf("cosign sign-blob %s: %w", asset.Name, err)
+			}
+			signatures = append(signatures, Asset{Path: out, Name: filepath.Base(out), Size: info.Size()})
+		}
+	}
+	return signatures, nil
+}
@@ -2,16 +2,27 @@ package gitutil
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 type GitError struct {
-	Op  string
-	Err error
+	Op       string
+	Err      error
+	Args     []string
+	ExitCode int
+	Stderr   string
+	Output   string
 }
 
 func (e *GitError) Error() string {
@@ -23,10 +34,154 @@ func (e *GitError) Error() string {
 
 func (e *GitError) Unwrap() error { return e.Err }
 
+func (e *GitError) Command() string {
+	return strings.Join(e.Args, " ")
+}
+
+func (e *GitError) IsAuthFailure() bool {
+	out := strings.ToLower(e.Output + "\n" + e.Stderr)
+	for _, marker := range authFailureMarkers {
+		if strings.Contains(out, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+var authFailureMarkers = []string{
+	"terminal prompts disabled",
+	"could not read username",
+	"could not read password",
+	"authentication failed",
+	"invalid username or password",
+	"permission denied (publickey",
+	"the requested url returned error: 403",
+	"the requested url returned error: 401",
+}
+
+func (e *GitError) OutputTail(n int) []string {
+	out := e.Output
+	if out == "" {
+		out = e.Stderr
+	}
+	return tailLines(out, n)
+}
+
+func newGitError(op string, err error) *GitError {
+	ge := &GitError{Op: op, Err: err}
+	var ce *commandError
+	if errors.As(err, &ce) {
+		ge.Args = ce.args
+		ge.ExitCode = ce.exitCode
+		ge.Stderr = ce.stderr
+		ge.Output = ce.output
+	}
+	return ge
+}
+
+type commandError struct {
+	args     []string
+	exitCode int
+	stderr   string
+	output   string
+	err      error
+}
+
+func (e *commandError) Error() string {
+	if last := tailLines(e.output, 1); len(last) > 0 {
+		return fmt.Sprintf("%v: %s", e.err, last[0])
+	}
+	return e.err.Error()
+}
+
+func (e *commandError) Unwrap() error { return e.err }
+
+func tailLines(s string, n int) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		if line = strings.TrimRight(line, "\r "); strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newCommandError records cmd's argv as run, with the identity options and
+// --git-path that command adds.
+func newCommandError(cmd *exec.Cmd, stderr string, err error) *commandError {
+	exitCode := -1
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	}
+	return &commandError{
+		args:     slices.Clone(cmd.Args),
+		exitCode: exitCode,
+		stderr:   strings.TrimSpace(stderr),
+		output:   strings.TrimSpace(stderr),
+		err:      err,
+	}
+}
+
 type Client struct {
-	Stdout io.Writer
-	Stderr io.Writer
-	DryRun bool
+	Context     context.Context
+	Stdout      io.Writer
+	Stderr      io.Writer
+	DryRun      bool
+	AllowPrompt bool
+	Token       string
+	TokenUser   string
+	CommitDate  string
+	GitPath     string
+	UserName    string
+	UserEmail   string
+	// Dir runs git in this directory instead of the current one.
+	Dir string
+	// Trace, when set, receives every git command with its duration, exit
+	// status, and captured output.
+	Trace io.Writer
+	// TagCache, when set, answers remote tag queries from one listing per
+	// remote shared with other clients.
+	TagCache *RemoteTagCache
+
+	extraEnv []string
+}
+
+type Options struct {
+	Context     context.Context
+	Stdout      io.Writer
+	Stderr      io.Writer
+	DryRun      bool
+	AllowPrompt bool
+	Token       string
+	TokenUser   string
+	CommitDate  string
+	GitPath     string
+	UserName    string
+	UserEmail   string
+	Dir         string
+	Trace       io.Writer
+	TagCache    *RemoteTagCache
 }
 
 func NewClient(stdout, stderr io.Writer, dryRun bool) *Client {
@@ -37,6 +192,112 @@ func NewClient(stdout, stderr io.Writer, dryRun bool) *Client {
 	}
 }
 
+func New(opts Options) *Client {
+	return &Client{
+		Context:     opts.Context,
+		Stdout:      opts.Stdout,
+		Stderr:      opts.Stderr,
+		DryRun:      opts.DryRun,
+		AllowPrompt: opts.AllowPrompt,
+		Token:       opts.Token,
+		TokenUser:   opts.TokenUser,
+		CommitDate:  opts.CommitDate,
+		GitPath:     opts.GitPath,
+		UserName:    opts.UserName,
+		UserEmail:   opts.UserEmail,
+		Dir:         opts.Dir,
+		Trace:       opts.Trace,
+		TagCache:    opts.TagCache,
+	}
+}
+
+// MinVersion is the oldest git mdrelease runs, and MinTokenVersion the oldest
+// it runs with a token.
+var (
+	MinVersion      = Version{Major: 2, Minor: 20}
+	MinTokenVersion = Version{Major: 2, Minor: 31}
+)
+
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+func ParseVersion(s string) (Version, error) {
+	fields := strings.Fields(strings.TrimSpace(s))
+	if len(fields) >= 3 && fields[0] == "git" && fields[1] == "version" {
+		fields = fields[2:]
+	}
+	if len(fields) == 0 {
+		return Version{}, fmt.Errorf("empty git version")
+	}
+	parts := strings.Split(fields[0], ".")
+	var nums [3]int
+	for i := 0; i < len(nums) && i < len(parts); i++ {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			if i < 2 {
+				return Version{}, fmt.Errorf("unrecognized git version %q", strings.TrimSpace(s))
+			}
+			break
+		}
+		nums[i] = n
+	}
+	if len(parts) < 2 {
+		return Version{}, fmt.Errorf("unrecognized git version %q", strings.TrimSpace(s))
+	}
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+func (v Version) AtLeast(min Version) bool {
+	if v.Major != min.Major {
+		return v.Major > min.Major
+	}
+	if v.Minor != min.Minor {
+		return v.Minor > min.Minor
+	}
+	return v.Patch >= min.Patch
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+func (c *Client) Version() (Version, error) {
+	out, err := c.output("git", "--version")
+	if err != nil {
+		return Version{}, newGitError("read git version", err)
+	}
+	return ParseVersion(out)
+}
+
+func (c *Client) HasCommitterIdentity() (bool, error) {
+	_, err := c.output("git", "var", "GIT_COMMITTER_IDENT")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, nil
+		}
+		return false, newGitError("check committer identity", err)
+	}
+	return true, nil
+}
+
+// VerifyCommit reports whether git verify-commit accepts rev's signature:
+// it is signed with a key gpg (or ssh's allowed signers file) trusts.
+func (c *Client) VerifyCommit(rev string) (bool, error) {
+	_, err := c.output("git", "verify-commit", rev)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, nil
+		}
+		return false, newGitError("verify commit signature", err)
+	}
+	return true, nil
+}
+
 func (c *Client) EnsureRepo() error {
 	out, err := c.output("git", "rev-parse", "--is-inside-work-tree")
 	if err != nil || strings.TrimSpace(out) != "true" {
@@ -45,6 +306,25 @@ func (c *Client) EnsureRepo() error {
 	return nil
 }
 
+// GitDir returns the absolute path of the git directory every worktree
+// shares. git prints it relative to the directory it runs in; the
+// --path-format option that would make it absolute needs git 2.31.
+func (c *Client) GitDir() (string, error) {
+	out, err := c.output("git", "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", newGitError("resolve git directory", err)
+	}
+	dir := strings.TrimSpace(out)
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	dir, err = filepath.Abs(filepath.Join(c.Dir, dir))
+	if err != nil {
+		return "", newGitError("resolve git directory", err)
+	}
+	return dir, nil
+}
+
 func (c *Client) EnsureRemote(remote string) error {
 	if err := c.run("git", "remote", "get-url", remote); err != nil {
 		return &GitError{
@@ -59,13 +339,74 @@ func (c *Client) EnsureRemote(remote string) error {
 	return nil
 }
 
+// EnsureRemoteReachable contacts the remote, asking only for its HEAD so
+// large repositories do not list every ref.
+func (c *Client) EnsureRemoteReachable(remote string) error {
+	if _, err := c.output("git", "ls-remote", remote, "HEAD"); err != nil {
+		return newGitError("contact git remote", err)
+	}
+	return nil
+}
+
+func (c *Client) RemoteURL(remote string) (string, error) {
+	out, err := c.output("git", "remote", "get-url", remote)
+	if err != nil {
+		return "", newGitError("read remote URL", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (c *Client) Remotes() ([]string, error) {
+	out, err := c.output("git", "remote")
+	if err != nil {
+		return nil, newGitError("list remotes", err)
+	}
+	return strings.Fields(out), nil
+}
+
+func (c *Client) DefaultBranch(remote string) (string, error) {
+	out, err := c.output("git", "symbolic-ref", "--quiet", "--short", "refs/remotes/"+remote+"/HEAD")
+	if err != nil {
+		// The remote HEAD is only recorded for clones; treat it as unknown.
+		return "", nil
+	}
+	return strings.TrimPrefix(strings.TrimSpace(out), remote+"/"), nil
+}
+
+func (c *Client) TopLevel() (string, error) {
+	out, err := c.output("git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", newGitError("resolve repository root", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (c *Client) IsShallow() (bool, error) {
+	out, err := c.output("git", "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, newGitError("check shallow repository", err)
+	}
+	return strings.TrimSpace(out) == "true", nil
+}
+
+func (c *Client) Unshallow(remote string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git fetch --unshallow --tags %s\n", remote)
+		return nil
+	}
+	if err := c.runWithStreams("git", "fetch", "--unshallow", "--tags", remote); err != nil {
+		return newGitError("unshallow repository", err)
+	}
+	return nil
+}
+
 func (c *Client) FetchTags() error {
 	if c.DryRun {
 		c.printf("[dry-run] git fetch --tags\n")
 		return nil
 	}
 	if err := c.runWithStreams("git", "fetch", "--tags"); err != nil {
-		return &GitError{Op: "fetch tags", Err: err}
+		return newGitError("fetch tags", err)
 	}
 	return nil
 }
@@ -76,7 +417,7 @@ func (c *Client) FetchRemote(remote string) error {
 		return nil
 	}
 	if err := c.runWithStreams("git", "fetch", "--tags", "--prune", remote); err != nil {
-		return &GitError{Op: "fetch remote refs", Err: err}
+		return newGitError("fetch remote refs", err)
 	}
 	return nil
 }
@@ -87,15 +428,115 @@ func (c *Client) PullFFOnly(remote string) error {
 		return nil
 	}
 	if err := c.runWithStreams("git", "pull", "--ff-only", remote); err != nil {
-		return &GitError{Op: "pull fast-forward", Err: err}
+		return newGitError("pull fast-forward", err)
+	}
+	return nil
+}
+
+func (c *Client) PullRebase(remote string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git pull --rebase %s\n", remote)
+		return nil
+	}
+	if err := c.runWithStreams("git", "pull", "--rebase", remote); err != nil {
+		return newGitError("pull rebase", err)
 	}
 	return nil
 }
 
+func (c *Client) CurrentBranch() (string, error) {
+	out, err := c.output("git", "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", newGitError("resolve current branch", err)
+	}
+	branch := strings.TrimSpace(out)
+	if branch == "HEAD" {
+		return "", nil
+	}
+	return branch, nil
+}
+
+func (c *Client) RemoteAhead(remote string) (int, string, error) {
+	branch, err := c.CurrentBranch()
+	if err != nil {
+		return 0, "", err
+	}
+	if branch == "" {
+		return 0, "", nil
+	}
+	ref := "refs/remotes/" + remote + "/" + branch
+	if err := c.ensureValidRef(ref); err != nil {
+		return 0, "", newGitError("check branch divergence", err)
+	}
+	err = c.runQuietAllowNotFound("git", "show-ref", "--verify", "--quiet", ref)
+	if err != nil {
+		var nf *notFoundError
+		if errors.As(err, &nf) {
+			return 0, "", nil
+		}
+		return 0, "", newGitError("check branch divergence", err)
+	}
+	out, err := c.output("git", "rev-list", "--count", "HEAD.."+ref)
+	if err != nil {
+		return 0, "", newGitError("check branch divergence", err)
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(out))
+	if err != nil {
+		return 0, "", &GitError{Op: "check branch divergence", Err: fmt.Errorf("unexpected rev-list output %q", strings.TrimSpace(out))}
+	}
+	return count, remote + "/" + branch, nil
+}
+
+func (c *Client) RemoteBranchRef(remote string) (string, error) {
+	branch, err := c.CurrentBranch()
+	if err != nil {
+		return "", err
+	}
+	if branch != "" {
+		ref := "refs/remotes/" + remote + "/" + branch
+		ok, err := c.refExists(ref)
+		if err != nil {
+			return "", newGitError("resolve remote branch", err)
+		}
+		if ok {
+			return ref, nil
+		}
+	}
+	out, err := c.output("git", "symbolic-ref", "--quiet", "refs/remotes/"+remote+"/HEAD")
+	if err != nil {
+		return "", nil
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (c *Client) IsAncestor(commit, ref string) (bool, error) {
+	err := c.runQuietAllowNotFound("git", "merge-base", "--is-ancestor", commit, ref)
+	if err != nil {
+		var nf *notFoundError
+		if errors.As(err, &nf) {
+			return false, nil
+		}
+		return false, newGitError("check commit ancestry", err)
+	}
+	return true, nil
+}
+
+func (c *Client) refExists(ref string) (bool, error) {
+	err := c.runQuietAllowNotFound("git", "show-ref", "--verify", "--quiet", ref)
+	if err != nil {
+		var nf *notFoundError
+		if errors.As(err, &nf) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 func (c *Client) EnsureTagAbsent(tag string) error {
 	ref := "refs/tags/" + tag
 	if err := c.ensureValidRef(ref); err != nil {
-		return &GitError{Op: "validate tag absence", Err: err}
+		return newGitError("validate tag absence", err)
 	}
 	err := c.runQuietAllowNotFound("git", "show-ref", "--verify", "--quiet", ref)
 	if err == nil {
@@ -105,13 +546,13 @@ func (c *Client) EnsureTagAbsent(tag string) error {
 	if errors.As(err, &nf) {
 		return nil
 	}
-	return &GitError{Op: "validate tag absence", Err: err}
+	return newGitError("validate tag absence", err)
 }
 
 func (c *Client) EnsureTagPresent(tag string) error {
 	ref := "refs/tags/" + tag
 	if err := c.ensureValidRef(ref); err != nil {
-		return &GitError{Op: "validate local tag", Err: err}
+		return newGitError("validate local tag", err)
 	}
 	err := c.runQuietAllowNotFound("git", "show-ref", "--verify", "--quiet", ref)
 	if err != nil {
@@ -119,7 +560,7 @@ func (c *Client) EnsureTagPresent(tag string) error {
 		if errors.As(err, &nf) {
 			return &GitError{Op: "validate local tag", Err: fmt.Errorf("tag %s does not exist locally", tag)}
 		}
-		return &GitError{Op: "validate local tag", Err: err}
+		return newGitError("validate local tag", err)
 	}
 	return nil
 }
@@ -127,7 +568,7 @@ func (c *Client) EnsureTagPresent(tag string) error {
 func (c *Client) HasLocalTag(tag string) (bool, error) {
 	ref := "refs/tags/" + tag
 	if err := c.ensureValidRef(ref); err != nil {
-		return false, &GitError{Op: "check local tag", Err: err}
+		return false, newGitError("check local tag", err)
 	}
 	err := c.runQuietAllowNotFound("git", "show-ref", "--verify", "--quiet", ref)
 	if err != nil {
@@ -135,7 +576,7 @@ func (c *Client) HasLocalTag(tag string) (bool, error) {
 		if errors.As(err, &nf) {
 			return false, nil
 		}
-		return false, &GitError{Op: "check local tag", Err: err}
+		return false, newGitError("check local tag", err)
 	}
 	return true, nil
 }
@@ -143,22 +584,201 @@ func (c *Client) HasLocalTag(tag string) (bool, error) {
 func (c *Client) HasRemoteTag(remote, tag string) (bool, error) {
 	ref := "refs/tags/" + tag
 	if err := c.ensureValidRef(ref); err != nil {
-		return false, &GitError{Op: "check remote tag", Err: err}
+		return false, newGitError("check remote tag", err)
+	}
+	if c.TagCache != nil {
+		tags, err := c.cachedRemoteTags(remote)
+		_, ok := tags[tag]
+		return ok, err
 	}
 	out, err := c.output("git", "ls-remote", "--tags", "--refs", remote, ref)
 	if err != nil {
-		return false, &GitError{Op: "check remote tag", Err: err}
+		return false, newGitError("check remote tag", err)
 	}
 	return strings.TrimSpace(out) != "", nil
 }
 
+// Tags lists the local tags that start with prefix.
+func (c *Client) Tags(prefix string) ([]string, error) {
+	out, err := c.output("git", "tag", "--list", prefix+"*")
+	if err != nil {
+		return nil, newGitError("list tags", err)
+	}
+	return strings.Fields(out), nil
+}
+
+// RemoteTags lists the remote's tags that start with prefix.
+func (c *Client) RemoteTags(remote, prefix string) ([]string, error) {
+	if c.TagCache != nil {
+		return c.cachedRemoteTagsWithPrefix(remote, prefix)
+	}
+	out, err := c.output("git", "ls-remote", "--tags", "--refs", remote, "refs/tags/"+prefix+"*")
+	if err != nil {
+		return nil, newGitError("list remote tags", err)
+	}
+	var tags []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			if tag, ok := strings.CutPrefix(fields[1], "refs/tags/"); ok && strings.HasPrefix(tag, prefix) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags, nil
+}
+
+func (c *Client) PreviousTag(rev, prefix string) (string, error) {
+	out, err := c.output("git", "describe", "--tags", "--abbrev=0", "--match", prefix+"*", rev)
+	if err != nil {
+		var cmdErr *commandError
+		if errors.As(err, &cmdErr) && (strings.Contains(cmdErr.stderr, "cannot describe") || strings.Contains(cmdErr.stderr, "No tags can describe") || strings.Contains(cmdErr.stderr, "Not a valid object name")) {
+			return "", nil
+		}
+		return "", newGitError("find previous tag", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+type Commit struct {
+	Hash    string
+	Subject string
+	Body    string
+}
+
+// CommitsSince lists the commits after since up to HEAD, newest first, that
+// touch one of paths (any path when none are given). An empty since lists
+// the whole history.
+func (c *Client) CommitsSince(since string, paths ...string) ([]Commit, error) {
+	rev := "HEAD"
+	if since != "" {
+		// since is a tag name; check-ref-format rejects one-level names such
+		// as v1.2.3 on their own, and a leading "-" would read as an option.
+		if err := c.ensureValidRef("refs/tags/" + since); err != nil || strings.HasPrefix(since, "-") {
+			return nil, newGitError("list commits", fmt.Errorf("invalid tag name %q", since))
+		}
+		rev = since + "..HEAD"
+	}
+	args := append([]string{"log", "--format=%h%x1f%s%x1f%b%x1e", rev, "--"}, paths...)
+	out, err := c.output("git", args...)
+	if err != nil {
+		return nil, newGitError("list commits", err)
+	}
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, Commit{Hash: fields[0], Subject: fields[1], Body: strings.TrimSpace(fields[2])})
+	}
+	return commits, nil
+}
+
+// LineAddedAt returns the commit time of the oldest commit whose diff of
+// path adds or removes a line matching pattern, an extended regular
+// expression, which is when such a line was first added. It returns the zero
+// time when no commit has touched a matching line yet.
+func (c *Client) LineAddedAt(path, pattern string) (time.Time, error) {
+	out, err := c.output("git", "log", "--reverse", "--format=%ct", "-G", pattern, "--", path)
+	if err != nil {
+		return time.Time{}, newGitError("find when a line was added", err)
+	}
+	first, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	if first == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return time.Time{}, newGitError("find when a line was added", fmt.Errorf("unexpected commit time %q", first))
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// Tag is one tag listed by TagDetails. Date is the tagger date of an
+// annotated tag and the commit date of a lightweight one; Subject and Body
+// are the tag message, without any signature, and are empty for a
+// lightweight tag.
+type Tag struct {
+	Name      string
+	Annotated bool
+	Date      time.Time
+	Subject   string
+	Body      string
+}
+
+// TagDetails lists the local tags that start with prefix, in refname order.
+func (c *Client) TagDetails(prefix string) ([]Tag, error) {
+	out, err := c.output("git", "for-each-ref", "--format=%(refname:strip=2)%1f%(objecttype)%1f%(creatordate:unix)%1f%(contents:subject)%1f%(contents:body)%1e", "refs/tags/"+prefix+"*")
+	if err != nil {
+		return nil, newGitError("list tags", err)
+	}
+	var tags []Tag
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 5)
+		if len(fields) != 5 || !strings.HasPrefix(fields[0], prefix) {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, newGitError("list tags", fmt.Errorf("unexpected date %q for tag %s", fields[2], fields[0]))
+		}
+		tag := Tag{Name: fields[0], Annotated: fields[1] == "tag", Date: time.Unix(seconds, 0)}
+		if tag.Annotated {
+			tag.Subject, tag.Body = strings.TrimSpace(fields[3]), strings.TrimSpace(fields[4])
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func (c *Client) TagMessage(tag string) (string, error) {
+	ref := "refs/tags/" + tag
+	if err := c.ensureValidRef(ref); err != nil {
+		return "", newGitError("read tag message", err)
+	}
+	out, err := c.output("git", "for-each-ref", "--format=%(contents)", ref)
+	if err != nil {
+		return "", newGitError("read tag message", err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (c *Client) RemoteTagCommit(remote, tag string) (string, error) {
+	ref := "refs/tags/" + tag
+	if err := c.ensureValidRef(ref); err != nil {
+		return "", newGitError("check remote tag", err)
+	}
+	if c.TagCache != nil {
+		tags, err := c.cachedRemoteTags(remote)
+		return tags[tag], err
+	}
+	out, err := c.output("git", "ls-remote", "--tags", remote, ref, ref+"^{}")
+	if err != nil {
+		return "", newGitError("check remote tag", err)
+	}
+	commit := ""
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[1] {
+		case ref + "^{}":
+			return fields[0], nil
+		case ref:
+			commit = fields[0]
+		}
+	}
+	return commit, nil
+}
+
 func (c *Client) DeleteLocalTag(tag string) error {
 	if c.DryRun {
 		c.printf("[dry-run] git tag -d %s\n", tag)
 		return nil
 	}
 	if err := c.runWithStreams("git", "tag", "-d", tag); err != nil {
-		return &GitError{Op: "delete local tag", Err: err}
+		return newGitError("delete local tag", err)
 	}
 	return nil
 }
@@ -166,14 +786,91 @@ func (c *Client) DeleteLocalTag(tag string) error {
 func (c *Client) DeleteRemoteTag(remote, tag string) error {
 	ref := "refs/tags/" + tag
 	if err := c.ensureValidRef(ref); err != nil {
-		return &GitError{Op: "delete remote tag", Err: err}
+		return newGitError("delete remote tag", err)
 	}
 	if c.DryRun {
 		c.printf("[dry-run] git push %s :%s\n", remote, ref)
 		return nil
 	}
+	c.TagCache.forget(remote)
 	if err := c.runWithStreams("git", "push", remote, ":"+ref); err != nil {
-		return &GitError{Op: "delete remote tag", Err: err}
+		return newGitError("delete remote tag", err)
+	}
+	return nil
+}
+
+func (c *Client) HasLocalBranch(branch string) (bool, error) {
+	ref := "refs/heads/" + branch
+	if err := c.ensureValidRef(ref); err != nil {
+		return false, newGitError("check local branch", err)
+	}
+	err := c.runQuietAllowNotFound("git", "show-ref", "--verify", "--quiet", ref)
+	if err != nil {
+		var nf *notFoundError
+		if errors.As(err, &nf) {
+			return false, nil
+		}
+		return false, newGitError("check local branch", err)
+	}
+	return true, nil
+}
+
+func (c *Client) HasRemoteBranch(remote, branch string) (bool, error) {
+	ref := "refs/heads/" + branch
+	if err := c.ensureValidRef(ref); err != nil {
+		return false, newGitError("check remote branch", err)
+	}
+	out, err := c.output("git", "ls-remote", "--heads", remote, ref)
+	if err != nil {
+		return false, newGitError("check remote branch", err)
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+func (c *Client) CreateBranch(branch, start string) error {
+	if start == "" {
+		start = "HEAD"
+	}
+	if c.DryRun {
+		c.printf("[dry-run] git branch %s %s\n", branch, start)
+		return nil
+	}
+	if err := c.run("git", "branch", branch, start); err != nil {
+		return newGitError("create branch", err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteLocalBranch(branch string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git branch -D %s\n", branch)
+		return nil
+	}
+	if err := c.run("git", "branch", "-D", branch); err != nil {
+		return newGitError("delete local branch", err)
+	}
+	return nil
+}
+
+func (c *Client) ResetSoft(rev string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git reset --soft %s\n", rev)
+		return nil
+	}
+	if err := c.run("git", "reset", "--soft", rev); err != nil {
+		return newGitError("reset release commit", err)
+	}
+	return nil
+}
+
+func (c *Client) PushBranch(remote, branch string) error {
+	ref := "refs/heads/" + branch
+	if c.DryRun {
+		c.printf("[dry-run] git push %s %s:%s\n", remote, ref, ref)
+		return nil
+	}
+	if err := c.runWithStreams("git", "push", remote, ref+":"+ref); err != nil {
+		return newGitError("push branch", err)
 	}
 	return nil
 }
@@ -195,19 +892,16 @@ func (e *notFoundError) Error() string {
 }
 
 func (c *Client) runQuietAllowNotFound(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
+	cmd := c.command(name, args...)
 	var stderr bytes.Buffer
 	cmd.Stdout = io.Discard
 	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
+	if err := c.runCmd(cmd); err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
 			return &notFoundError{name: name, args: args}
 		}
-		if stderr.Len() > 0 {
-			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
-		}
-		return err
+		return newCommandError(cmd, stderr.String(), err)
 	}
 	return nil
 }
@@ -218,7 +912,19 @@ func (c *Client) StageAll() error {
 		return nil
 	}
 	if err := c.runWithStreams("git", "add", "-A"); err != nil {
-		return &GitError{Op: "stage changes", Err: err}
+		return newGitError("stage changes", err)
+	}
+	return nil
+}
+
+func (c *Client) StagePaths(paths ...string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git add -- %s\n", strings.Join(paths, " "))
+		return nil
+	}
+	args := append([]string{"add", "--"}, paths...)
+	if err := c.runWithStreams("git", args...); err != nil {
+		return newGitError("stage changes", err)
 	}
 	return nil
 }
@@ -226,13 +932,47 @@ func (c *Client) StageAll() error {
 func (c *Client) HasStagedChanges() (bool, error) {
 	out, err := c.output("git", "diff", "--cached", "--name-only")
 	if err != nil {
-		return false, &GitError{Op: "check staged changes", Err: err}
+		return false, newGitError("check staged changes", err)
 	}
 	return strings.TrimSpace(out) != "", nil
 }
 
+// ChangedFiles lists the paths a commit would include: every change `git add
+// -A` would stage when all is set, otherwise only what is already staged.
+func (c *Client) ChangedFiles(all bool) ([]string, error) {
+	args := []string{"diff", "--cached", "--name-only", "-z"}
+	if all {
+		args = []string{"status", "--porcelain", "-z", "--untracked-files=all"}
+	}
+	out, err := c.output("git", args...)
+	if err != nil {
+		return nil, newGitError("list changed files", err)
+	}
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	var paths []string
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if !all {
+			if field != "" {
+				paths = append(paths, field)
+			}
+			continue
+		}
+		if len(field) < 4 {
+			continue
+		}
+		paths = append(paths, field[3:])
+		if field[0] == 'R' || field[0] == 'C' {
+			// Renames and copies are followed by their original path.
+			i++
+		}
+	}
+	return paths, nil
+}
+
 func (c *Client) Commit(summary, description string) error {
 	if c.DryRun {
+		c.printDateEnv()
 		c.printf("[dry-run] git commit -m %q", summary)
 		if description != "" {
 			c.printf(" -m <description>")
@@ -245,18 +985,22 @@ func (c *Client) Commit(summary, description string) error {
 	if description != "" {
 		args = append(args, "-m", description)
 	}
-	if err := c.runWithStreams("git", args...); err != nil {
-		return &GitError{Op: "commit changes", Err: err}
+	if err := c.dated().runWithStreams("git", args...); err != nil {
+		return newGitError("commit changes", err)
 	}
 	return nil
 }
 
-func (c *Client) CreateTag(tag, summary, description string) error {
+func (c *Client) CreateTag(tag, target, summary, description string) error {
 	if c.DryRun {
+		c.printDateEnv()
 		c.printf("[dry-run] git tag -a %s -m %q", tag, summary)
 		if description != "" {
 			c.printf(" (with description)")
 		}
+		if target != "" {
+			c.printf(" %s", target)
+		}
 		c.printf("\n")
 		return nil
 	}
@@ -265,19 +1009,57 @@ func (c *Client) CreateTag(tag, summary, description string) error {
 	if description != "" {
 		message = summary + "\n\n" + description
 	}
-	if err := c.run("git", "tag", "-a", tag, "-m", message); err != nil {
-		return &GitError{Op: "create tag", Err: err}
+	args := []string{"tag", "-a", tag, "-m", message}
+	if target != "" {
+		args = append(args, target)
+	}
+	if err := c.dated().run("git", args...); err != nil {
+		return newGitError("create tag", err)
 	}
 	return nil
 }
 
+func (c *Client) UsesLFS() (bool, error) {
+	out, err := c.output("git", "ls-files", ":(attr:filter=lfs)")
+	if err != nil {
+		return false, newGitError("detect git lfs", err)
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+func (c *Client) HasLFS() bool {
+	return c.run("git", "lfs", "version") == nil
+}
+
+func (c *Client) LFSPush(remote, ref string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git lfs push %s %s\n", remote, ref)
+		return nil
+	}
+	if err := c.runWithStreams("git", "lfs", "push", remote, ref); err != nil {
+		ge := newGitError("push lfs objects", err)
+		if isLFSMissingObjects(ge.Output) {
+			ge.Err = fmt.Errorf("LFS objects referenced by %s are missing locally (run `git lfs fetch --all` or re-add the files before releasing): %w", ref, err)
+		}
+		return ge
+	}
+	return nil
+}
+
+func isLFSMissingObjects(output string) bool {
+	out := strings.ToLower(output)
+	return strings.Contains(out, "missing object") ||
+		strings.Contains(out, "unable to find source") ||
+		strings.Contains(out, "missing objects")
+}
+
 func (c *Client) PushHead(remote string) error {
 	if c.DryRun {
 		c.printf("[dry-run] git push %s HEAD\n", remote)
 		return nil
 	}
 	if err := c.runWithStreams("git", "push", remote, "HEAD"); err != nil {
-		return &GitError{Op: "push commit", Err: err}
+		return newGitError("push commit", err)
 	}
 	return nil
 }
@@ -287,44 +1069,205 @@ func (c *Client) PushTag(remote, tag string) error {
 		c.printf("[dry-run] git push %s %s\n", remote, tag)
 		return nil
 	}
+	c.TagCache.forget(remote)
 	if err := c.runWithStreams("git", "push", remote, tag); err != nil {
-		return &GitError{Op: "push tag", Err: err}
+		return newGitError("push tag", err)
 	}
 	return nil
 }
 
-func (c *Client) output(name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	out, err := cmd.Output()
+func (c *Client) ResolveCommit(rev string) (string, error) {
+	out, err := c.output("git", "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		ge := newGitError("resolve commit", err)
+		ge.Err = fmt.Errorf("cannot resolve %q to a commit", rev)
+		return "", ge
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (c *Client) ShowFile(rev, path string) (string, error) {
+	out, err := c.output("git", "show", rev+":"+path)
+	if err != nil {
+		return "", newGitError("read file at revision", err)
+	}
+	return out, nil
+}
+
+func (c *Client) HasUncommittedChanges() (bool, error) {
+	out, err := c.output("git", "status", "--porcelain", "--untracked-files=no")
 	if err != nil {
-		if stderr.Len() > 0 {
-			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		return false, newGitError("check working tree", err)
+	}
+	return strings.TrimSpace(out) != "", nil
+}
+
+func (c *Client) Checkout(branch string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git checkout %s\n", branch)
+		return nil
+	}
+	if err := c.runWithStreams("git", "checkout", branch); err != nil {
+		return newGitError("checkout branch", err)
+	}
+	return nil
+}
+
+func (c *Client) CherryPick(commit string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git cherry-pick -x %s\n", commit)
+		return nil
+	}
+	if err := c.runWithStreams("git", "cherry-pick", "-x", commit); err != nil {
+		return newGitError("cherry-pick commit", err)
+	}
+	return nil
+}
+
+func (c *Client) Revert(commit string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git revert --no-edit %s\n", commit)
+		return nil
+	}
+	if err := c.runWithStreams("git", "revert", "--no-edit", commit); err != nil {
+		return newGitError("revert commit", err)
+	}
+	return nil
+}
+
+const tokenCredentialHelper = `!f() { test "$1" = get || exit 0; echo "username=${MDRELEASE_GIT_TOKEN_USER:-x-access-token}"; echo "password=${MDRELEASE_GIT_TOKEN}"; }; f`
+
+func (c *Client) command(name string, args ...string) *exec.Cmd {
+	ctx := c.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if name == "git" {
+		args = append(c.identityArgs(), args...)
+		if c.GitPath != "" {
+			name = c.GitPath
 		}
-		return "", err
 	}
-	return string(out), nil
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = c.Dir
+	var env []string
+	if !c.AllowPrompt {
+		env = append(env, "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+	}
+	if c.Token != "" {
+		env = append(env,
+			"MDRELEASE_GIT_TOKEN="+c.Token,
+			"MDRELEASE_GIT_TOKEN_USER="+c.TokenUser,
+			"GIT_CONFIG_COUNT=2",
+			"GIT_CONFIG_KEY_0=credential.helper",
+			"GIT_CONFIG_VALUE_0=",
+			"GIT_CONFIG_KEY_1=credential.helper",
+			"GIT_CONFIG_VALUE_1="+tokenCredentialHelper,
+		)
+	}
+	env = append(env, c.extraEnv...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+func (c *Client) identityArgs() []string {
+	var args []string
+	if c.UserName != "" {
+		args = append(args, "-c", "user.name="+c.UserName)
+	}
+	if c.UserEmail != "" {
+		args = append(args, "-c", "user.email="+c.UserEmail)
+	}
+	return args
+}
+
+func (c *Client) dated() *Client {
+	if c.CommitDate == "" {
+		return c
+	}
+	dated := *c
+	dated.extraEnv = []string{"GIT_AUTHOR_DATE=" + c.CommitDate, "GIT_COMMITTER_DATE=" + c.CommitDate}
+	return &dated
+}
+
+func (c *Client) printDateEnv() {
+	if c.CommitDate != "" {
+		c.printf("[dry-run] GIT_AUTHOR_DATE=%q GIT_COMMITTER_DATE=%q\n", c.CommitDate, c.CommitDate)
+	}
+}
+
+func (c *Client) output(name string, args ...string) (string, error) {
+	cmd := c.command(name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := c.runCmd(cmd); err != nil {
+		return "", newCommandError(cmd, stderr.String(), err)
+	}
+	return stdout.String(), nil
 }
 
 func (c *Client) run(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
+	cmd := c.command(name, args...)
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		if stderr.Len() > 0 {
-			return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
-		}
-		return err
+	if err := c.runCmd(cmd); err != nil {
+		return newCommandError(cmd, stderr.String(), err)
 	}
 	return nil
 }
 
 func (c *Client) runWithStreams(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
-	cmd.Stdout = c.Stdout
-	cmd.Stderr = c.Stderr
-	return cmd.Run()
+	cmd := c.command(name, args...)
+	var stderr bytes.Buffer
+	var combined syncBuffer
+	stdoutWriters := []io.Writer{&combined}
+	stderrWriters := []io.Writer{&combined, &stderr}
+	if c.Stdout != nil {
+		stdoutWriters = append(stdoutWriters, c.Stdout)
+	}
+	if c.Stderr != nil {
+		stderrWriters = append(stderrWriters, c.Stderr)
+	}
+	cmd.Stdout = io.MultiWriter(stdoutWriters...)
+	cmd.Stderr = io.MultiWriter(stderrWriters...)
+	if err := c.runCmd(cmd); err != nil {
+		ce := newCommandError(cmd, stderr.String(), err)
+		ce.output = strings.TrimSpace(combined.String())
+		return ce
+	}
+	return nil
+}
+
+// runCmd runs cmd, tracing it to c.Trace when set. Only the arguments are
+// traced, never the environment, which can hold the git token.
+func (c *Client) runCmd(cmd *exec.Cmd) error {
+	if c.Trace == nil {
+		return cmd.Run()
+	}
+	var output syncBuffer
+	cmd.Stdout = teeWriter(cmd.Stdout, &output)
+	cmd.Stderr = teeWriter(cmd.Stderr, &output)
+	start := time.Now()
+	err := cmd.Run()
+	status := "ok"
+	if err != nil {
+		status = err.Error()
+	}
+	_, _ = fmt.Fprintf(c.Trace, "+ %s (%s, %s)\n", strings.Join(cmd.Args, " "), time.Since(start).Round(time.Millisecond), status)
+	if out := strings.TrimRight(output.String(), "\n"); out != "" {
+		_, _ = fmt.Fprintf(c.Trace, "  | %s\n", strings.ReplaceAll(out, "\n", "\n  | "))
+	}
+	return err
+}
+
+func teeWriter(w io.Writer, capture io.Writer) io.Writer {
+	if w == nil {
+		return capture
+	}
+	return io.MultiWriter(w, capture)
 }
 
 func (c *Client) printf(format string, args ...any) {
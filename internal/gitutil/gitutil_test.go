@@ -2,11 +2,17 @@ package gitutil
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestEnsureTagChecksUseExactTagRefs(t *testing.T) {
@@ -42,6 +48,25 @@ func TestEnsureTagAbsent_InvalidRefReturnsError(t *testing.T) {
 	}
 }
 
+func TestEnsureRemoteReachable(t *testing.T) {
+	repo := initRepo(t)
+	remoteRoot := t.TempDir()
+	remote := filepath.Join(remoteRoot, "origin.git")
+	runGit(t, remoteRoot, "init", "--bare", remote)
+	runGit(t, repo, "remote", "add", "origin", remote)
+	runGit(t, repo, "remote", "add", "gone", filepath.Join(remoteRoot, "missing.git"))
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error { return c.EnsureRemoteReachable("origin") }); err != nil {
+		t.Fatalf("EnsureRemoteReachable(origin) = %v", err)
+	}
+	err := withDir(repo, func() error { return c.EnsureRemoteReachable("gone") })
+	var ge *GitError
+	if !errors.As(err, &ge) {
+		t.Fatalf("EnsureRemoteReachable(gone) = %v, want GitError", err)
+	}
+}
+
 func TestHasRemoteTagAndDeleteRemoteTag(t *testing.T) {
 	repo := initRepo(t)
 	remoteRoot := t.TempDir()
@@ -81,6 +106,125 @@ func TestHasRemoteTagAndDeleteRemoteTag(t *testing.T) {
 	}
 }
 
+func TestRemoteLockIsExclusiveAcrossClones(t *testing.T) {
+	repo := initRepo(t)
+	remoteRoot := t.TempDir()
+	remote := filepath.Join(remoteRoot, "origin.git")
+	runGit(t, remoteRoot, "init", "--bare", remote)
+	runGit(t, repo, "remote", "add", "origin", remote)
+	runGit(t, repo, "push", "-u", "origin", "HEAD")
+	other := filepath.Join(t.TempDir(), "other")
+	runGit(t, remoteRoot, "clone", remote, other)
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	const ref = "refs/mdrelease/lock"
+	var lock string
+	if err := withDir(repo, func() error {
+		var err error
+		lock, err = c.AcquireRemoteLock("origin", ref, "mdrelease lock for v1.2.3\n\nhost: build-1", false)
+		return err
+	}); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	if err := withDir(other, func() error {
+		_, err := c.AcquireRemoteLock("origin", ref, "mdrelease lock for v1.2.4", false)
+		var held *RemoteLockHeldError
+		if !errors.As(err, &held) || !strings.Contains(held.Holder, "host: build-1") {
+			t.Fatalf("second acquire: error = %v, want RemoteLockHeldError naming build-1", err)
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := withDir(repo, func() error { return c.ReleaseRemoteLock("origin", ref, lock) }); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if err := withDir(other, func() error {
+		if _, err := c.AcquireRemoteLock("origin", ref, "mdrelease lock for v1.2.4", false); err != nil {
+			return err
+		}
+		// --break-lock replaces a lock left behind.
+		_, err := c.AcquireRemoteLock("origin", ref, "mdrelease lock for v1.2.4", true)
+		return err
+	}); err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+}
+
+func TestTagsAndRemoteTags(t *testing.T) {
+	repo := initRepo(t)
+	remoteRoot := t.TempDir()
+	remote := filepath.Join(remoteRoot, "origin.git")
+	runGit(t, remoteRoot, "init", "--bare", remote)
+	runGit(t, repo, "remote", "add", "origin", remote)
+	for _, tag := range []string{"v1.0.0", "v1.1.0", "api/v2.0.0"} {
+		runGit(t, repo, "tag", tag)
+	}
+	runGit(t, repo, "push", "origin", "v1.0.0", "api/v2.0.0")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	var local, remoteTags []string
+	if err := withDir(repo, func() (err error) {
+		if local, err = c.Tags("v"); err != nil {
+			return err
+		}
+		remoteTags, err = c.RemoteTags("origin", "v")
+		return err
+	}); err != nil {
+		t.Fatalf("list tags: %v", err)
+	}
+	if strings.Join(local, ",") != "v1.0.0,v1.1.0" {
+		t.Fatalf("local tags = %v", local)
+	}
+	if strings.Join(remoteTags, ",") != "v1.0.0" {
+		t.Fatalf("remote tags = %v", remoteTags)
+	}
+}
+
+func TestRemoteTagCacheListsRemoteOnce(t *testing.T) {
+	repo := initRepo(t)
+	remoteRoot := t.TempDir()
+	remote := filepath.Join(remoteRoot, "origin.git")
+	runGit(t, remoteRoot, "init", "--bare", remote)
+	runGit(t, repo, "remote", "add", "origin", remote)
+	runGit(t, repo, "tag", "-a", "v1.0.0", "-m", "First")
+	runGit(t, repo, "tag", "api/v2.0.0")
+	runGit(t, repo, "push", "origin", "v1.0.0", "api/v2.0.0")
+
+	var trace bytes.Buffer
+	cache := NewRemoteTagCache()
+	c := New(Options{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Trace: &trace, TagCache: cache, Dir: repo})
+	other := New(Options{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Trace: &trace, TagCache: cache, Dir: repo})
+	head, err := c.ResolveCommit("HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := c.RemoteTagCommit("origin", "v1.0.0")
+	if err != nil || commit != head {
+		t.Fatalf("RemoteTagCommit = %q, %v; want %s", commit, err, head)
+	}
+	if ok, err := other.HasRemoteTag("origin", "api/v2.0.0"); err != nil || !ok {
+		t.Fatalf("HasRemoteTag(api/v2.0.0) = %v, %v", ok, err)
+	}
+	if ok, err := other.HasRemoteTag("origin", "v9.9.9"); err != nil || ok {
+		t.Fatalf("HasRemoteTag(v9.9.9) = %v, %v", ok, err)
+	}
+	if n := strings.Count(trace.String(), "git ls-remote"); n != 1 {
+		t.Fatalf("ls-remote ran %d times, want 1:\n%s", n, trace.String())
+	}
+
+	runGit(t, repo, "tag", "v1.1.0")
+	if err := c.PushTag("origin", "v1.1.0"); err != nil {
+		t.Fatal(err)
+	}
+	tags, err := other.RemoteTags("origin", "v")
+	if err != nil || strings.Join(tags, ",") != "v1.0.0,v1.1.0" {
+		t.Fatalf("RemoteTags after push = %v, %v", tags, err)
+	}
+}
+
 func TestHasLocalTagAndDeleteLocalTag(t *testing.T) {
 	repo := initRepo(t)
 	runGit(t, repo, "tag", "v1.2.3")
@@ -113,6 +257,959 @@ func TestHasLocalTagAndDeleteLocalTag(t *testing.T) {
 	}
 }
 
+func TestRemoteAheadCountsCommitsMissingFromHead(t *testing.T) {
+	repo := initRepo(t)
+	remoteRoot := t.TempDir()
+	remote := filepath.Join(remoteRoot, "origin.git")
+	runGit(t, remoteRoot, "init", "--bare", remote)
+	runGit(t, repo, "remote", "add", "origin", remote)
+	runGit(t, repo, "push", "-u", "origin", "HEAD")
+
+	other := filepath.Join(t.TempDir(), "other")
+	runGit(t, remoteRoot, "clone", remote, other)
+	runGit(t, other, "config", "user.name", "Test User")
+	runGit(t, other, "config", "user.email", "test@example.com")
+	runGit(t, other, "commit", "--allow-empty", "-m", "remote change")
+	runGit(t, other, "push", "origin", "HEAD")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		ahead, _, err := c.RemoteAhead("origin")
+		if err != nil {
+			return err
+		}
+		if ahead != 0 {
+			t.Fatalf("ahead before fetch = %d, want 0", ahead)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("RemoteAhead failed: %v", err)
+	}
+
+	runGit(t, repo, "fetch", "origin")
+	if err := withDir(repo, func() error {
+		ahead, upstream, err := c.RemoteAhead("origin")
+		if err != nil {
+			return err
+		}
+		if ahead != 1 {
+			t.Fatalf("ahead after fetch = %d, want 1", ahead)
+		}
+		if upstream == "" {
+			t.Fatal("expected upstream name")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("RemoteAhead failed: %v", err)
+	}
+}
+
+func TestIsShallowAndUnshallow(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "commit", "--allow-empty", "-m", "second")
+	shallow := filepath.Join(t.TempDir(), "shallow")
+	runGit(t, t.TempDir(), "clone", "--depth", "1", "file://"+repo, shallow)
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(shallow, func() error {
+		ok, err := c.IsShallow()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			t.Fatal("expected shallow clone to be detected")
+		}
+		if err := c.Unshallow("origin"); err != nil {
+			return err
+		}
+		ok, err = c.IsShallow()
+		if err != nil {
+			return err
+		}
+		if ok {
+			t.Fatal("expected repository to be unshallowed")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("shallow flow failed: %v", err)
+	}
+}
+
+func TestCreateAndPushBranch(t *testing.T) {
+	repo := initRepo(t)
+	remoteRoot := t.TempDir()
+	remote := filepath.Join(remoteRoot, "origin.git")
+	runGit(t, remoteRoot, "init", "--bare", remote)
+	runGit(t, repo, "remote", "add", "origin", remote)
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		if err := c.CreateBranch("release/v1.2.3", ""); err != nil {
+			return err
+		}
+		ok, err := c.HasLocalBranch("release/v1.2.3")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			t.Fatal("expected local branch to exist")
+		}
+		if err := c.PushBranch("origin", "release/v1.2.3"); err != nil {
+			return err
+		}
+		ok, err = c.HasRemoteBranch("origin", "release/v1.2.3")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			t.Fatal("expected remote branch to exist")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("branch flow failed: %v", err)
+	}
+}
+
+func TestCherryPickOntoMaintenanceBranch(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "branch", "release-1.x")
+	if err := os.WriteFile(filepath.Join(repo, "fix.txt"), []byte("fix\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, repo, "add", "fix.txt")
+	runGit(t, repo, "commit", "-m", "fix")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		commit, err := c.ResolveCommit("HEAD")
+		if err != nil {
+			return err
+		}
+		content, err := c.ShowFile(commit, "fix.txt")
+		if err != nil {
+			return err
+		}
+		if content != "fix\n" {
+			t.Fatalf("ShowFile = %q", content)
+		}
+		dirty, err := c.HasUncommittedChanges()
+		if err != nil {
+			return err
+		}
+		if dirty {
+			t.Fatal("expected clean working tree")
+		}
+		if err := c.Checkout("release-1.x"); err != nil {
+			return err
+		}
+		if err := c.CherryPick(commit); err != nil {
+			return err
+		}
+		branch, err := c.CurrentBranch()
+		if err != nil {
+			return err
+		}
+		if branch != "release-1.x" {
+			t.Fatalf("CurrentBranch = %q", branch)
+		}
+		_, err = os.Stat("fix.txt")
+		return err
+	}); err != nil {
+		t.Fatalf("cherry-pick flow failed: %v", err)
+	}
+}
+
+func TestVerifyCommitRejectsUnsignedCommit(t *testing.T) {
+	repo := initRepo(t)
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		verified, err := c.VerifyCommit("HEAD")
+		if err != nil {
+			return err
+		}
+		if verified {
+			t.Fatal("expected an unsigned commit to fail verification")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("verify commit: %v", err)
+	}
+}
+
+func TestRevertUndoesCommit(t *testing.T) {
+	repo := initRepo(t)
+	if err := os.WriteFile(filepath.Join(repo, "release.txt"), []byte("1.2.3\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, repo, "add", "release.txt")
+	runGit(t, repo, "commit", "-m", "1.2.3")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		if err := c.Revert("HEAD"); err != nil {
+			return err
+		}
+		if _, err := os.Stat("release.txt"); !os.IsNotExist(err) {
+			t.Fatalf("release.txt after revert: %v", err)
+		}
+		dirty, err := c.HasUncommittedChanges()
+		if err != nil {
+			return err
+		}
+		if dirty {
+			t.Fatal("expected the revert to be committed")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("revert failed: %v", err)
+	}
+}
+
+func TestLineAddedAtFindsOldestMatchingCommit(t *testing.T) {
+	repo := initRepo(t)
+	commit := func(content, date string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, "changelog.md"), []byte(content), 0o644); err != nil {
+			t.Fatalf("write changelog: %v", err)
+		}
+		runGit(t, repo, "add", "changelog.md")
+		cmd := exec.Command("git", "commit", "-m", "changelog")
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE="+date)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("commit: %v\n%s", err, out)
+		}
+	}
+	commit("# 1.2.30 - Old\n", "@1000000000 +0000")
+	commit("# 1.2.3 - New\n\n# 1.2.30 - Old\n", "@1100000000 +0000")
+	commit("# 1.2.3 - Renamed\n\n# 1.2.30 - Old\n", "@1200000000 +0000")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		added, err := c.LineAddedAt("changelog.md", `^#[[:space:]]*1\.2\.3([^0-9A-Za-z.+-]|$)`)
+		if err != nil {
+			return err
+		}
+		if added.Unix() != 1100000000 {
+			t.Fatalf("added = %d, want 1100000000", added.Unix())
+		}
+		added, err = c.LineAddedAt("changelog.md", `^#[[:space:]]*1\.2\.4([^0-9A-Za-z.+-]|$)`)
+		if err != nil {
+			return err
+		}
+		if !added.IsZero() {
+			t.Fatalf("added = %v, want zero for an uncommitted line", added)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("LineAddedAt failed: %v", err)
+	}
+}
+
+func TestGitErrorCarriesCommandContext(t *testing.T) {
+	repo := initRepo(t)
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+
+	err := withDir(repo, func() error { return c.PushHead("missing-remote") })
+	if err == nil {
+		t.Fatal("expected push to unknown remote to fail")
+	}
+	var ge *GitError
+	if !errors.As(err, &ge) {
+		t.Fatalf("error type = %T, want *GitError", err)
+	}
+	if got := ge.Command(); got != "git push missing-remote HEAD" {
+		t.Fatalf("Command() = %q", got)
+	}
+	if ge.ExitCode <= 0 {
+		t.Fatalf("ExitCode = %d, want non-zero", ge.ExitCode)
+	}
+	if ge.Stderr == "" {
+		t.Fatal("expected captured stderr from streamed command")
+	}
+}
+
+func TestStreamedCommandErrorIncludesLastOutputLine(t *testing.T) {
+	repo := initRepo(t)
+	var stderr bytes.Buffer
+	c := NewClient(&bytes.Buffer{}, &stderr, false)
+
+	err := withDir(repo, func() error { return c.FetchRemote("missing-remote") })
+	if err == nil {
+		t.Fatal("expected fetch from unknown remote to fail")
+	}
+	var ge *GitError
+	if !errors.As(err, &ge) {
+		t.Fatalf("error type = %T, want *GitError", err)
+	}
+	tail := ge.OutputTail(1)
+	if len(tail) != 1 {
+		t.Fatalf("OutputTail(1) = %v", tail)
+	}
+	if !strings.Contains(err.Error(), tail[0]) {
+		t.Fatalf("error %q should include last output line %q", err.Error(), tail[0])
+	}
+	if stderr.Len() == 0 {
+		t.Fatal("streamed stderr should still reach the caller's writer")
+	}
+}
+
+func TestCommandDisablesTerminalPromptByDefault(t *testing.T) {
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if env := strings.Join(c.command("git", "fetch").Env, "\n"); !strings.Contains(env, "GIT_TERMINAL_PROMPT=0") {
+		t.Fatal("expected GIT_TERMINAL_PROMPT=0 in command environment")
+	}
+
+	c = New(Options{AllowPrompt: true})
+	if env := c.command("git", "fetch").Env; env != nil {
+		t.Fatalf("expected inherited environment when prompts are allowed, got %d entries", len(env))
+	}
+}
+
+func TestTokenCredentialHelperSuppliesToken(t *testing.T) {
+	repo := initRepo(t)
+	c := New(Options{Token: "s3cret", TokenUser: "oauth2"})
+
+	cmd := c.command("git", "credential", "fill")
+	cmd.Dir = repo
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=example.com\n\n")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git credential fill failed: %v", err)
+	}
+	got := string(out)
+	if !strings.Contains(got, "username=oauth2\n") || !strings.Contains(got, "password=s3cret\n") {
+		t.Fatalf("credential fill output = %q", got)
+	}
+	for _, arg := range cmd.Args {
+		if strings.Contains(arg, "s3cret") {
+			t.Fatal("token must not appear in git argv")
+		}
+	}
+}
+
+func TestGitErrorRecordsArgvAsRun(t *testing.T) {
+	repo := initRepo(t)
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git not installed")
+	}
+	c := New(Options{Dir: repo, GitPath: gitPath, UserName: "Release Bot"})
+
+	var ge *GitError
+	if err := c.PushHead("missing-remote"); !errors.As(err, &ge) {
+		t.Fatalf("error = %v, want *GitError", err)
+	}
+	if got, want := ge.Command(), gitPath+" -c user.name=Release Bot push missing-remote HEAD"; got != want {
+		t.Fatalf("Command() = %q, want %q", got, want)
+	}
+
+	_, err = c.ResolveCommit("no-such-rev")
+	if !errors.As(err, &ge) || err.Error() != `resolve commit: cannot resolve "no-such-rev" to a commit` {
+		t.Fatalf("error = %v", err)
+	}
+	if !slices.Contains(ge.Args, "rev-parse") || ge.Args[0] != gitPath || ge.ExitCode <= 0 {
+		t.Fatalf("Args = %q, ExitCode = %d", ge.Args, ge.ExitCode)
+	}
+}
+
+func TestGitErrorDetectsAuthFailure(t *testing.T) {
+	ge := &GitError{Output: "remote: Invalid username or password.\nfatal: Authentication failed for 'https://example.com/repo.git/'"}
+	if !ge.IsAuthFailure() {
+		t.Fatal("expected auth failure to be detected")
+	}
+	ge = &GitError{Output: "error: failed to push some refs"}
+	if ge.IsAuthFailure() {
+		t.Fatal("unexpected auth failure detection")
+	}
+}
+
+func TestCreateTagAtTargetAndAncestry(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "commit", "--allow-empty", "-m", "second")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		first, err := c.ResolveCommit("HEAD~1")
+		if err != nil {
+			return err
+		}
+		if err := c.CreateTag("v1.0.0", first, "First", ""); err != nil {
+			return err
+		}
+		tagged, err := c.ResolveCommit("v1.0.0")
+		if err != nil {
+			return err
+		}
+		if tagged != first {
+			t.Fatalf("tag points at %s, want %s", tagged, first)
+		}
+		ok, err := c.IsAncestor(first, "HEAD")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			t.Fatal("expected HEAD~1 to be an ancestor of HEAD")
+		}
+		ok, err = c.IsAncestor("HEAD", first)
+		if err != nil {
+			return err
+		}
+		if ok {
+			t.Fatal("expected HEAD not to be an ancestor of HEAD~1")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("target tag flow failed: %v", err)
+	}
+}
+
+func TestCommitDateIsReproducible(t *testing.T) {
+	repo := initRepo(t)
+	c := New(Options{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, CommitDate: "@1700000000 +0000"})
+	if err := withDir(repo, func() error {
+		if err := os.WriteFile("file.txt", []byte("x\n"), 0o644); err != nil {
+			return err
+		}
+		if err := c.StageAll(); err != nil {
+			return err
+		}
+		if err := c.Commit("Release", ""); err != nil {
+			return err
+		}
+		return c.CreateTag("v1.0.0", "", "Release", "")
+	}); err != nil {
+		t.Fatalf("dated release failed: %v", err)
+	}
+
+	checks := map[string][]string{
+		"commit": {"log", "-1", "--format=%at %ct"},
+		"tag":    {"for-each-ref", "--format=%(creatordate:unix)", "refs/tags/v1.0.0"},
+	}
+	for name, args := range checks {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("read %s date: %v", name, err)
+		}
+		for _, field := range strings.Fields(string(out)) {
+			if field != "1700000000" {
+				t.Fatalf("%s date = %q, want 1700000000", name, strings.TrimSpace(string(out)))
+			}
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	cases := map[string]Version{
+		"git version 2.39.5":                   {2, 39, 5},
+		"git version 2.39.3 (Apple Git-146)\n": {2, 39, 3},
+		"git version 2.45.1.windows.1":         {2, 45, 1},
+		"2.31":                                 {2, 31, 0},
+	}
+	for in, want := range cases {
+		got, err := ParseVersion(in)
+		if err != nil {
+			t.Fatalf("ParseVersion(%q): %v", in, err)
+		}
+		if got != want {
+			t.Fatalf("ParseVersion(%q) = %v, want %v", in, got, want)
+		}
+	}
+	if _, err := ParseVersion("git version unknown"); err == nil {
+		t.Fatal("expected error for unparseable version")
+	}
+	if !(Version{2, 31, 0}).AtLeast(Version{2, 20, 0}) || (Version{2, 19, 9}).AtLeast(Version{2, 20, 0}) {
+		t.Fatal("AtLeast comparison is wrong")
+	}
+}
+
+func TestVersionUsesGitPath(t *testing.T) {
+	c := New(Options{})
+	v, err := c.Version()
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if v.Major < 2 {
+		t.Fatalf("unexpected git version %v", v)
+	}
+
+	c = New(Options{GitPath: filepath.Join(t.TempDir(), "missing-git")})
+	if _, err := c.Version(); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Version with missing git path = %v, want not-exist error", err)
+	}
+}
+
+func TestIdentityOverridesApplyOnlyToClientCommands(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	repo := t.TempDir()
+	runGit(t, repo, "init")
+
+	if err := withDir(repo, func() error {
+		ok, err := New(Options{}).HasCommitterIdentity()
+		if err != nil {
+			return err
+		}
+		if ok {
+			t.Fatal("expected missing committer identity")
+		}
+
+		c := New(Options{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, UserName: "Release Bot", UserEmail: "bot@example.com"})
+		ok, err = c.HasCommitterIdentity()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			t.Fatal("expected identity from -c overrides")
+		}
+		if err := os.WriteFile("file.txt", []byte("x\n"), 0o644); err != nil {
+			return err
+		}
+		if err := c.StageAll(); err != nil {
+			return err
+		}
+		if err := c.Commit("Release", ""); err != nil {
+			return err
+		}
+		return c.CreateTag("v1.0.0", "", "Release", "")
+	}); err != nil {
+		t.Fatalf("identity flow failed: %v", err)
+	}
+
+	cmd := exec.Command("git", "log", "-1", "--format=%cn <%ce>")
+	cmd.Dir = repo
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git log: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "Release Bot <bot@example.com>" {
+		t.Fatalf("committer = %q", got)
+	}
+	cmd = exec.Command("git", "config", "--get", "user.name")
+	cmd.Dir = repo
+	if out, err := cmd.Output(); err == nil {
+		t.Fatalf("repo config should not be modified, got user.name=%q", strings.TrimSpace(string(out)))
+	}
+}
+
+func TestTagMessageAndRemoteTagCommit(t *testing.T) {
+	repo := initRepo(t)
+	remoteRoot := t.TempDir()
+	remote := filepath.Join(remoteRoot, "origin.git")
+	runGit(t, remoteRoot, "init", "--bare", remote)
+	runGit(t, repo, "remote", "add", "origin", remote)
+	runGit(t, repo, "tag", "-a", "v1.2.3", "-m", "Summary\n\n- Bullet")
+	runGit(t, repo, "push", "origin", "v1.2.3")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		msg, err := c.TagMessage("v1.2.3")
+		if err != nil {
+			return err
+		}
+		if msg != "Summary\n\n- Bullet" {
+			t.Fatalf("TagMessage = %q", msg)
+		}
+		head, err := c.ResolveCommit("HEAD")
+		if err != nil {
+			return err
+		}
+		remoteCommit, err := c.RemoteTagCommit("origin", "v1.2.3")
+		if err != nil {
+			return err
+		}
+		if remoteCommit != head {
+			t.Fatalf("RemoteTagCommit = %q, want peeled commit %q", remoteCommit, head)
+		}
+		missing, err := c.RemoteTagCommit("origin", "v9.9.9")
+		if err != nil {
+			return err
+		}
+		if missing != "" {
+			t.Fatalf("RemoteTagCommit for missing tag = %q", missing)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("tag message flow failed: %v", err)
+	}
+}
+
+func TestTagDetailsListsMessagesAndDates(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "tag", "-a", "v1.0.0", "-m", "First release\n\n- Bullet one\n- Bullet two")
+	runGit(t, repo, "tag", "v1.1.0")
+	runGit(t, repo, "tag", "-a", "other-1.0.0", "-m", "Other")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	var tags []Tag
+	if err := withDir(repo, func() (err error) {
+		tags, err = c.TagDetails("v")
+		return err
+	}); err != nil {
+		t.Fatalf("TagDetails: %v", err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("TagDetails = %+v, want v1.0.0 and v1.1.0", tags)
+	}
+	if got := tags[0]; got.Name != "v1.0.0" || !got.Annotated || got.Subject != "First release" || got.Body != "- Bullet one\n- Bullet two" || time.Since(got.Date) > time.Hour {
+		t.Fatalf("annotated tag = %+v", got)
+	}
+	if got := tags[1]; got.Name != "v1.1.0" || got.Annotated || got.Subject != "" || got.Date.IsZero() {
+		t.Fatalf("lightweight tag = %+v", got)
+	}
+}
+
+func TestRemoteURLAndPreviousTag(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "remote", "add", "origin", "git@github.com:acme/tool.git")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		url, err := c.RemoteURL("origin")
+		if err != nil {
+			return err
+		}
+		if url != "git@github.com:acme/tool.git" {
+			t.Fatalf("RemoteURL = %q", url)
+		}
+		prev, err := c.PreviousTag("HEAD", "v")
+		if err != nil {
+			return err
+		}
+		if prev != "" {
+			t.Fatalf("PreviousTag without tags = %q", prev)
+		}
+		runGit(t, repo, "tag", "-a", "v1.0.0", "-m", "first")
+		runGit(t, repo, "tag", "-a", "other-1", "-m", "other")
+		runGit(t, repo, "commit", "--allow-empty", "-m", "next")
+		runGit(t, repo, "tag", "-a", "v1.1.0", "-m", "second")
+		prev, err = c.PreviousTag("v1.1.0^", "v")
+		if err != nil {
+			return err
+		}
+		if prev != "v1.0.0" {
+			t.Fatalf("PreviousTag = %q, want v1.0.0", prev)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("remote URL flow failed: %v", err)
+	}
+}
+
+func TestRemotesDefaultBranchAndTopLevel(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "remote", "add", "upstream", "git@github.com:acme/tool.git")
+	runGit(t, repo, "update-ref", "refs/remotes/upstream/trunk", "HEAD")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error {
+		remotes, err := c.Remotes()
+		if err != nil {
+			return err
+		}
+		if len(remotes) != 1 || remotes[0] != "upstream" {
+			t.Fatalf("Remotes = %v", remotes)
+		}
+		branch, err := c.DefaultBranch("upstream")
+		if err != nil {
+			return err
+		}
+		if branch != "" {
+			t.Fatalf("DefaultBranch without remote HEAD = %q", branch)
+		}
+		runGit(t, repo, "symbolic-ref", "refs/remotes/upstream/HEAD", "refs/remotes/upstream/trunk")
+		if branch, err = c.DefaultBranch("upstream"); err != nil {
+			return err
+		}
+		if branch != "trunk" {
+			t.Fatalf("DefaultBranch = %q, want trunk", branch)
+		}
+		top, err := c.TopLevel()
+		if err != nil {
+			return err
+		}
+		want, _ := filepath.EvalSymlinks(repo)
+		if got, _ := filepath.EvalSymlinks(top); got != want {
+			t.Fatalf("TopLevel = %q, want %q", top, repo)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("remote discovery failed: %v", err)
+	}
+}
+
+func TestStagePathsStagesOnlyThosePaths(t *testing.T) {
+	repo := initRepo(t)
+	for _, name := range []string{"package.json", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	if err := withDir(repo, func() error { return c.StagePaths("package.json") }); err != nil {
+		t.Fatalf("StagePaths: %v", err)
+	}
+	cmd := exec.Command("git", "diff", "--cached", "--name-only")
+	cmd.Dir = repo
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "package.json" {
+		t.Fatalf("staged = %q, want package.json", got)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "mv", "README.md", "DOCS.md")
+	if err := os.MkdirAll(filepath.Join(repo, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"pkg/new file.go", "changelog.md"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, repo, "add", "changelog.md")
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	var staged, all []string
+	if err := withDir(repo, func() (err error) {
+		if staged, err = c.ChangedFiles(false); err != nil {
+			return err
+		}
+		all, err = c.ChangedFiles(true)
+		return err
+	}); err != nil {
+		t.Fatalf("ChangedFiles: %v", err)
+	}
+	if got := strings.Join(staged, ","); got != "DOCS.md,changelog.md" {
+		t.Fatalf("staged = %q", got)
+	}
+	if got := strings.Join(all, ","); got != "DOCS.md,changelog.md,pkg/new file.go" {
+		t.Fatalf("all = %q", got)
+	}
+}
+
+func TestCommitsSince(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "tag", "api/v1.0.0")
+	runGit(t, repo, "tag", "v1.0.0")
+	if err := os.MkdirAll(filepath.Join(repo, "api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"api/main.go", "README.md"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte("change "+strconv.Itoa(i)+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, repo, "add", name)
+		runGit(t, repo, "commit", "-m", "change "+name, "-m", "BREAKING CHANGE: renamed")
+	}
+
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	var api, root, all []Commit
+	if err := withDir(repo, func() (err error) {
+		if api, err = c.CommitsSince("api/v1.0.0", "api"); err != nil {
+			return err
+		}
+		if root, err = c.CommitsSince("v1.0.0"); err != nil {
+			return err
+		}
+		all, err = c.CommitsSince("")
+		return err
+	}); err != nil {
+		t.Fatalf("CommitsSince: %v", err)
+	}
+	if len(api) != 1 || api[0].Subject != "change api/main.go" || api[0].Body != "BREAKING CHANGE: renamed" || api[0].Hash == "" {
+		t.Fatalf("api commits = %+v", api)
+	}
+	if len(root) != 2 || root[0].Subject != "change README.md" {
+		t.Fatalf("commits since v1.0.0 = %+v", root)
+	}
+	if len(all) != 3 || all[2].Subject != "init" || all[2].Body != "" {
+		t.Fatalf("all commits = %+v", all)
+	}
+	if err := withDir(repo, func() error {
+		_, err := c.CommitsSince("-v1")
+		return err
+	}); err == nil || !strings.Contains(err.Error(), `invalid tag name "-v1"`) {
+		t.Fatalf("CommitsSince(bad) error = %v", err)
+	}
+}
+
+func TestTraceLogsCommands(t *testing.T) {
+	repo := initRepo(t)
+	var trace bytes.Buffer
+	c := New(Options{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, Trace: &trace, UserName: "Rel Bot"})
+	if err := withDir(repo, func() error {
+		if _, err := c.TopLevel(); err != nil {
+			return err
+		}
+		if err := c.EnsureTagPresent("v9.9.9"); err == nil {
+			t.Fatal("EnsureTagPresent found a missing tag")
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	out := trace.String()
+	top, _ := filepath.EvalSymlinks(repo)
+	for _, want := range []string{"+ git -c user.name=Rel Bot rev-parse --show-toplevel (", ", ok)\n", "v9.9.9", "exit status"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("trace missing %q:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(out, "  | "+repo) && !strings.Contains(out, "  | "+top) {
+		t.Fatalf("trace missing command output:\n%s", out)
+	}
+}
+
+func TestUsesLFSDetectsLFSAttributes(t *testing.T) {
+	repo := initRepo(t)
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+
+	if err := withDir(repo, func() error {
+		ok, err := c.UsesLFS()
+		if err != nil {
+			return err
+		}
+		if ok {
+			t.Fatal("expected repository without LFS attributes")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("UsesLFS failed: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, ".gitattributes"), []byte("*.bin filter=lfs diff=lfs merge=lfs -text\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "asset.bin"), []byte("data\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	runGit(t, repo, "-c", "filter.lfs.required=false", "add", ".gitattributes", "asset.bin")
+
+	if err := withDir(repo, func() error {
+		ok, err := c.UsesLFS()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			t.Fatal("expected LFS-tracked file to be detected")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("UsesLFS failed: %v", err)
+	}
+}
+
+func TestIsLFSMissingObjects(t *testing.T) {
+	if !isLFSMissingObjects("Unable to find source for object 4d7a21 (try running git lfs fetch --all)") {
+		t.Fatal("expected missing LFS object output to be detected")
+	}
+	if isLFSMissingObjects("Uploading LFS objects: 100% (1/1)") {
+		t.Fatal("unexpected missing object detection")
+	}
+}
+
+func TestGitDirReturnsAbsoluteCommonDir(t *testing.T) {
+	repo := initRepo(t)
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+
+	if err := withDir(repo, func() error {
+		dir, err := c.GitDir()
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(dir) || filepath.Base(dir) != ".git" {
+			t.Fatalf("GitDir = %q, want absolute .git path", dir)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("GitDir failed: %v", err)
+	}
+
+	// git prints a relative path from a subdirectory or a linked worktree.
+	want, err := filepath.EvalSymlinks(filepath.Join(repo, ".git"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Join(t.TempDir(), "wt")
+	runGit(t, repo, "worktree", "add", "-q", worktree)
+	for _, dir := range []string{sub, worktree} {
+		got, err := New(Options{Dir: dir}).GitDir()
+		if err != nil {
+			t.Fatalf("GitDir in %s: %v", dir, err)
+		}
+		if resolved, _ := filepath.EvalSymlinks(got); !filepath.IsAbs(got) || resolved != want {
+			t.Errorf("GitDir in %s = %q, want %q", dir, got, want)
+		}
+	}
+}
+
+func TestCanceledContextStopsGitCommands(t *testing.T) {
+	repo := initRepo(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := New(Options{Context: ctx, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}})
+
+	err := withDir(repo, func() error { return c.StageAll() })
+	if err == nil {
+		t.Fatal("expected canceled context to stop git")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+}
+
+func TestResetSoftAndDeleteLocalBranch(t *testing.T) {
+	repo := initRepo(t)
+	runGit(t, repo, "commit", "--allow-empty", "-m", "release")
+	c := NewClient(&bytes.Buffer{}, &bytes.Buffer{}, false)
+
+	if err := withDir(repo, func() error {
+		before, err := c.ResolveCommit("HEAD~1")
+		if err != nil {
+			return err
+		}
+		if err := c.CreateBranch("release/v1", ""); err != nil {
+			return err
+		}
+		if err := c.ResetSoft("HEAD~1"); err != nil {
+			return err
+		}
+		after, err := c.ResolveCommit("HEAD")
+		if err != nil {
+			return err
+		}
+		if after != before {
+			t.Fatalf("HEAD = %s, want %s", after, before)
+		}
+		if err := c.DeleteLocalBranch("release/v1"); err != nil {
+			return err
+		}
+		ok, err := c.HasLocalBranch("release/v1")
+		if err != nil {
+			return err
+		}
+		if ok {
+			t.Fatal("expected branch to be deleted")
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("rollback helpers failed: %v", err)
+	}
+}
+
 func initRepo(t *testing.T) string {
 	t.Helper()
 	dir := t.TempDir()
@@ -0,0 +1,91 @@
+package gitutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// emptyTree is git's well-known empty tree, which every repository has, so
+// a lock commit adds no content to the remote.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// RemoteLockHeldError reports that a remote lock ref already exists, so
+// another release holds the lock.
+type RemoteLockHeldError struct {
+	Remote string
+	Ref    string
+	// Holder is the lock commit's message, which names who took the lock
+	// and when, or empty when it could not be fetched.
+	Holder string
+}
+
+func (e *RemoteLockHeldError) Error() string {
+	msg := fmt.Sprintf("%s on %s is held by another release", e.Ref, e.Remote)
+	if e.Holder != "" {
+		msg += " (" + strings.Join(strings.Fields(e.Holder), " ") + ")"
+	}
+	return msg
+}
+
+// AcquireRemoteLock pushes ref to remote, pointing at a new commit that
+// carries message, only if ref does not exist there yet; the push is atomic
+// on the remote, so of two releases racing for the lock one fails with a
+// RemoteLockHeldError. With force it replaces a lock left behind. It returns
+// the lock commit to pass to ReleaseRemoteLock.
+func (c *Client) AcquireRemoteLock(remote, ref, message string, force bool) (string, error) {
+	if err := c.ensureValidRef(ref); err != nil {
+		return "", newGitError("acquire remote lock", err)
+	}
+	if c.DryRun {
+		c.printf("[dry-run] git push %s <lock commit>:%s\n", remote, ref)
+		return "", nil
+	}
+	locker := *c
+	locker.extraEnv = []string{"GIT_AUTHOR_NAME=mdrelease", "GIT_AUTHOR_EMAIL=mdrelease@localhost", "GIT_COMMITTER_NAME=mdrelease", "GIT_COMMITTER_EMAIL=mdrelease@localhost"}
+	out, err := locker.output("git", "commit-tree", emptyTree, "-m", message)
+	if err != nil {
+		return "", newGitError("create remote lock commit", err)
+	}
+	commit := strings.TrimSpace(out)
+	lease := "--force-with-lease=" + ref + ":"
+	if force {
+		lease = "--force"
+	}
+	if err := c.run("git", "push", "--quiet", "--no-verify", lease, remote, commit+":"+ref); err != nil {
+		if holder, held := c.remoteLockHolder(remote, ref); held {
+			return "", &RemoteLockHeldError{Remote: remote, Ref: ref, Holder: holder}
+		}
+		return "", newGitError("acquire remote lock", err)
+	}
+	return commit, nil
+}
+
+// ReleaseRemoteLock deletes ref from remote if it still points at commit,
+// leaving a lock someone else took after --break-lock alone.
+func (c *Client) ReleaseRemoteLock(remote, ref, commit string) error {
+	if c.DryRun {
+		c.printf("[dry-run] git push %s :%s\n", remote, ref)
+		return nil
+	}
+	if err := c.run("git", "push", "--quiet", "--no-verify", "--force-with-lease="+ref+":"+commit, remote, ":"+ref); err != nil {
+		return newGitError("release remote lock", err)
+	}
+	return nil
+}
+
+// remoteLockHolder reports whether ref exists on remote and, when it can be
+// fetched, the message of the lock commit.
+func (c *Client) remoteLockHolder(remote, ref string) (string, bool) {
+	out, err := c.output("git", "ls-remote", remote, ref)
+	if err != nil || strings.TrimSpace(out) == "" {
+		return "", false
+	}
+	if _, err := c.output("git", "fetch", "--quiet", "--no-tags", remote, ref); err != nil {
+		return "", true
+	}
+	msg, err := c.output("git", "log", "-1", "--format=%B", "FETCH_HEAD")
+	if err != nil {
+		return "", true
+	}
+	return strings.TrimSpace(msg), true
+}
@@ -0,0 +1,81 @@
+package gitutil
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RemoteTagCache keeps one `git ls-remote --tags` listing per remote for
+// the clients that share it, so checking many tags (one per component, say)
+// costs one round trip instead of one git process per tag. A client that
+// pushes or deletes a tag drops its remote's listing.
+type RemoteTagCache struct {
+	mu      sync.Mutex
+	remotes map[string]map[string]string
+}
+
+func NewRemoteTagCache() *RemoteTagCache {
+	return &RemoteTagCache{remotes: map[string]map[string]string{}}
+}
+
+func (rc *RemoteTagCache) forget(remote string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	delete(rc.remotes, remote)
+}
+
+// cachedRemoteTags returns the commit of every tag on remote, peeled for
+// annotated tags, listing the remote on first use.
+func (c *Client) cachedRemoteTags(remote string) (map[string]string, error) {
+	rc := c.TagCache
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if tags, ok := rc.remotes[remote]; ok {
+		return tags, nil
+	}
+	out, err := c.output("git", "ls-remote", "--tags", remote)
+	if err != nil {
+		return nil, newGitError("list remote tags", err)
+	}
+	tags := map[string]string{}
+	peeled := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		tag, ok := strings.CutPrefix(fields[1], "refs/tags/")
+		if !ok {
+			continue
+		}
+		if name, isPeeled := strings.CutSuffix(tag, "^{}"); isPeeled {
+			peeled[name] = fields[0]
+		} else {
+			tags[tag] = fields[0]
+		}
+	}
+	for tag, commit := range peeled {
+		tags[tag] = commit
+	}
+	rc.remotes[remote] = tags
+	return tags, nil
+}
+
+func (c *Client) cachedRemoteTagsWithPrefix(remote, prefix string) ([]string, error) {
+	all, err := c.cachedRemoteTags(remote)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	for tag := range all {
+		if strings.HasPrefix(tag, prefix) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
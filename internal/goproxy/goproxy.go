@@ -0,0 +1,263 @@
+package goproxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultURL      = "https://proxy.golang.org"
+	DefaultTimeout  = 5 * time.Minute
+	DefaultInterval = 10 * time.Second
+)
+
+type Info struct {
+	Version string    `json:"Version"`
+	Time    time.Time `json:"Time"`
+}
+
+func ModulePath(goModPath string) (string, error) {
+	f, err := os.Open(goModPath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		rest, ok := strings.CutPrefix(line, "module")
+		if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		path := strings.TrimSpace(rest)
+		if unquoted, err := strconv.Unquote(path); err == nil {
+			path = unquoted
+		}
+		if path != "" {
+			return path, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no module directive", goModPath)
+}
+
+// PathMajor returns the major version a module path requires of its releases:
+// N for a "/vN" suffix (or gopkg.in's ".vN") with N >= 2, and 1 otherwise,
+// which allows v0 and v1.
+func PathMajor(module string) int {
+	last := module[strings.LastIndex(module, "/")+1:]
+	suffix, ok := strings.CutPrefix(last, "v")
+	if strings.HasPrefix(module, "gopkg.in/") {
+		_, suffix, ok = strings.Cut(last, ".v")
+	}
+	if !ok || suffix == "" || suffix[0] == '0' {
+		return 1
+	}
+	n, err := strconv.Atoi(suffix)
+	if err != nil || n < 2 {
+		return 1
+	}
+	return n
+}
+
+// Requires returns the module versions a go.mod requires, from both single
+// require lines and require blocks.
+func Requires(goModPath string) (map[string]string, error) {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return nil, err
+	}
+	requires := map[string]string{}
+	forEachRequire(string(data), func(_ int, fields []string) {
+		requires[fields[0]] = fields[1]
+	})
+	return requires, nil
+}
+
+// SetRequire rewrites the version of an existing requirement on module. It
+// reports whether the file changed; a go.mod that does not require module is
+// left alone.
+func SetRequire(goModPath, module, version string) (bool, error) {
+	data, err := os.ReadFile(goModPath)
+	if err != nil {
+		return false, err
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	changed := false
+	forEachRequire(string(data), func(i int, fields []string) {
+		if fields[0] != module || fields[1] == version {
+			return
+		}
+		lines[i] = strings.Replace(lines[i], fields[1], version, 1)
+		changed = true
+	})
+	if !changed {
+		return false, nil
+	}
+	return true, os.WriteFile(goModPath, []byte(strings.Join(lines, "")), 0o644)
+}
+
+// forEachRequire calls fn with the line index and the module and version
+// fields of every requirement in a go.mod.
+func forEachRequire(content string, fn func(int, []string)) {
+	inBlock := false
+	for i, line := range strings.SplitAfter(content, "\n") {
+		line = strings.TrimSpace(line)
+		if j := strings.Index(line, "//"); j >= 0 {
+			line = strings.TrimSpace(line[:j])
+		}
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case inBlock:
+		case line == "require (" || line == "require(":
+			inBlock = true
+			continue
+		default:
+			rest, ok := strings.CutPrefix(line, "require")
+			if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+				continue
+			}
+			line = rest
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(fields[0]); err == nil {
+			fields[0] = unquoted
+		}
+		fn(i, fields)
+	}
+}
+
+func ProxyURL(goproxy string) string {
+	for _, entry := range strings.FieldsFunc(goproxy, func(r rune) bool { return r == ',' || r == '|' }) {
+		entry = strings.TrimSpace(entry)
+		if strings.HasPrefix(entry, "https://") || strings.HasPrefix(entry, "http://") {
+			return strings.TrimRight(entry, "/")
+		}
+	}
+	return DefaultURL
+}
+
+// EscapePath applies the module proxy's case encoding, which writes each
+// upper-case letter as '!' followed by its lower-case form.
+func EscapePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type Warmer struct {
+	BaseURL  string
+	Client   *http.Client
+	Timeout  time.Duration
+	Interval time.Duration
+	Out      io.Writer
+}
+
+var errNotIndexed = errors.New("not indexed yet")
+
+func (w Warmer) Warm(ctx context.Context, module, version string) (*Info, error) {
+	endpoint := fmt.Sprintf("%s/%s/@v/%s.info", strings.TrimRight(w.BaseURL, "/"), EscapePath(module), EscapePath(version))
+	var info *Info
+	err := poll(ctx, w.Timeout, w.Interval, func(attempt int, err error) {
+		if w.Out != nil {
+			_, _ = fmt.Fprintf(w.Out, "  %s@%s not available from the proxy yet (attempt %d): %v\n", module, version, attempt, err)
+		}
+	}, func(ctx context.Context) (err error) {
+		info, err = w.fetch(ctx, endpoint)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s@%s was not available from %s: %w", module, version, w.BaseURL, err)
+	}
+	return info, nil
+}
+
+// Latest asks the proxy for the newest release version of module, without
+// retrying.
+func Latest(ctx context.Context, baseURL string, client *http.Client, module string) (*Info, error) {
+	endpoint := fmt.Sprintf("%s/%s/@latest", strings.TrimRight(baseURL, "/"), EscapePath(module))
+	return Warmer{Client: client}.fetch(ctx, endpoint)
+}
+
+func poll(ctx context.Context, timeout, interval time.Duration, retrying func(int, error), try func(context.Context) error) error {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for attempt := 1; ; attempt++ {
+		err := try(ctx)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, errNotIndexed) && ctx.Err() == nil {
+			return err
+		}
+		retrying(attempt, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after %s: %w", timeout, err)
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (w Warmer) fetch(ctx context.Context, endpoint string) (*Info, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNotIndexed, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone || resp.StatusCode >= 500:
+		return nil, fmt.Errorf("%w: HTTP %d: %s", errNotIndexed, resp.StatusCode, strings.TrimSpace(string(data)))
+	default:
+		return nil, fmt.Errorf("GET %s: HTTP %d: %s", endpoint, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", endpoint, err)
+	}
+	return &info, nil
+}
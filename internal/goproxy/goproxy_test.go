@@ -0,0 +1,149 @@
+package goproxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestModulePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go.mod")
+	if err := os.WriteFile(path, []byte("// comment\nmodule \"github.com/Acme/tool\" // main module\n\ngo 1.25\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ModulePath(path)
+	if err != nil || got != "github.com/Acme/tool" {
+		t.Fatalf("ModulePath = %q, %v", got, err)
+	}
+	if err := os.WriteFile(path, []byte("go 1.25\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ModulePath(path); err == nil {
+		t.Fatal("ModulePath without module directive: want error")
+	}
+}
+
+func TestPathMajor(t *testing.T) {
+	tests := map[string]int{
+		"github.com/acme/tool":     1,
+		"github.com/acme/tool/v2":  2,
+		"github.com/acme/tool/v10": 10,
+		"github.com/acme/v2ray":    1,
+		"github.com/acme/tool/v1":  1,
+		"github.com/acme/tool/v02": 1,
+		"gopkg.in/yaml.v3":         3,
+		"gopkg.in/check.v1":        1,
+	}
+	for module, want := range tests {
+		if got := PathMajor(module); got != want {
+			t.Errorf("PathMajor(%q) = %d, want %d", module, got, want)
+		}
+	}
+}
+
+func TestRequiresAndSetRequire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go.mod")
+	content := "module example.com/repo/web\n\ngo 1.25\n\nrequire example.com/repo/api v1.1.0\n\nrequire (\n\texample.com/lib v0.3.0 // indirect\n\t\"example.com/quoted\" v2.0.0\n)\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Requires(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"example.com/repo/api": "v1.1.0", "example.com/lib": "v0.3.0", "example.com/quoted": "v2.0.0"}
+	if len(got) != len(want) {
+		t.Fatalf("Requires = %v, want %v", got, want)
+	}
+	for module, version := range want {
+		if got[module] != version {
+			t.Fatalf("Requires = %v, want %v", got, want)
+		}
+	}
+
+	changed, err := SetRequire(path, "example.com/lib", "v0.4.0")
+	if err != nil || !changed {
+		t.Fatalf("SetRequire = %v, %v", changed, err)
+	}
+	if changed, err := SetRequire(path, "example.com/missing", "v1.0.0"); err != nil || changed {
+		t.Fatalf("SetRequire on a missing module = %v, %v", changed, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(content, "v0.3.0", "v0.4.0", 1); string(data) != want {
+		t.Fatalf("go.mod =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestProxyURL(t *testing.T) {
+	tests := map[string]string{
+		"":                                       DefaultURL,
+		"direct":                                 DefaultURL,
+		"https://goproxy.example.com/,direct":    "https://goproxy.example.com",
+		"off|https://goproxy.example.com|direct": "https://goproxy.example.com",
+	}
+	for in, want := range tests {
+		if got := ProxyURL(in); got != want {
+			t.Errorf("ProxyURL(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github.com/!acme/tool/@latest" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, `{"Version":"v1.4.0","Time":"2026-01-02T03:04:05Z"}`)
+	}))
+	defer server.Close()
+
+	info, err := Latest(context.Background(), server.URL+"/", nil, "github.com/Acme/tool")
+	if err != nil || info.Version != "v1.4.0" {
+		t.Fatalf("Latest = %+v, %v", info, err)
+	}
+	if _, err := Latest(context.Background(), server.URL, nil, "github.com/acme/other"); err == nil {
+		t.Fatal("Latest for an unknown module: want error")
+	}
+}
+
+func TestWarmRetriesUntilIndexed(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/github.com/!acme/tool/@v/v1.2.3.info" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		requests++
+		if requests < 3 {
+			http.Error(w, "not found: unknown revision v1.2.3", http.StatusNotFound)
+			return
+		}
+		_, _ = io.WriteString(w, `{"Version":"v1.2.3","Time":"2026-01-02T03:04:05Z"}`)
+	}))
+	defer server.Close()
+
+	var out strings.Builder
+	w := Warmer{BaseURL: server.URL, Client: server.Client(), Interval: time.Millisecond, Out: &out}
+	info, err := w.Warm(context.Background(), "github.com/Acme/tool", "v1.2.3")
+	if err != nil || info.Version != "v1.2.3" || requests != 3 {
+		t.Fatalf("Warm = %+v, %v after %d requests", info, err, requests)
+	}
+	if !strings.Contains(out.String(), "attempt 2") {
+		t.Fatalf("progress output = %q", out.String())
+	}
+
+	w.Timeout = 20 * time.Millisecond
+	requests = -100
+	if _, err := w.Warm(context.Background(), "github.com/Acme/tool", "v1.2.3"); err == nil || !strings.Contains(err.Error(), "was not available") {
+		t.Fatalf("Warm timeout error = %v", err)
+	}
+}
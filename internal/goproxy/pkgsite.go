@@ -0,0 +1,80 @@
+package goproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPkgsiteURL     = "https://pkg.go.dev"
+	DefaultPkgsiteTimeout = 10 * time.Minute
+)
+
+type Pkgsite struct {
+	BaseURL  string
+	Client   *http.Client
+	Timeout  time.Duration
+	Interval time.Duration
+	Out      io.Writer
+}
+
+func (p Pkgsite) DocsURL(module, version string) string {
+	return fmt.Sprintf("%s/%s@%s", strings.TrimRight(p.BaseURL, "/"), module, version)
+}
+
+// Refresh asks pkg.go.dev to fetch the version and polls its page until the
+// documentation is served. The fetch request is best effort: pkg.go.dev also
+// picks up new versions from the proxy index on its own.
+func (p Pkgsite) Refresh(ctx context.Context, module, version string) (string, error) {
+	docs := p.DocsURL(module, version)
+	fetch := fmt.Sprintf("%s/fetch/%s@%s", strings.TrimRight(p.BaseURL, "/"), module, version)
+	if status, err := p.request(ctx, http.MethodPost, fetch); err != nil && p.Out != nil {
+		_, _ = fmt.Fprintf(p.Out, "  pkg.go.dev fetch request failed (continuing): %v\n", err)
+	} else if status >= 400 && p.Out != nil {
+		_, _ = fmt.Fprintf(p.Out, "  pkg.go.dev fetch request returned HTTP %d (continuing)\n", status)
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPkgsiteTimeout
+	}
+	err := poll(ctx, timeout, p.Interval, func(attempt int, err error) {
+		if p.Out != nil {
+			_, _ = fmt.Fprintf(p.Out, "  %s@%s docs not live yet (attempt %d): %v\n", module, version, attempt, err)
+		}
+	}, func(ctx context.Context) error {
+		status, err := p.request(ctx, http.MethodGet, docs)
+		switch {
+		case err != nil:
+			return fmt.Errorf("%w: %v", errNotIndexed, err)
+		case status == http.StatusOK:
+			return nil
+		case status == http.StatusNotFound || status >= 500:
+			return fmt.Errorf("%w: HTTP %d", errNotIndexed, status)
+		default:
+			return fmt.Errorf("GET %s: HTTP %d", docs, status)
+		}
+	})
+	return docs, err
+}
+
+func (p Pkgsite) request(ctx context.Context, method, endpoint string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
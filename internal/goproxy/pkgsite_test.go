@@ -0,0 +1,38 @@
+package goproxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPkgsiteRefreshWaitsForDocs(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case len(requests) < 3:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var out strings.Builder
+	site := Pkgsite{BaseURL: server.URL, Client: server.Client(), Interval: time.Millisecond, Out: &out}
+	docs, err := site.Refresh(context.Background(), "example.com/tool", "v1.2.3")
+	if err != nil || docs != server.URL+"/example.com/tool@v1.2.3" {
+		t.Fatalf("Refresh = %s, %v", docs, err)
+	}
+	want := "POST /fetch/example.com/tool@v1.2.3,GET /example.com/tool@v1.2.3,GET /example.com/tool@v1.2.3"
+	if got := strings.Join(requests, ","); got != want {
+		t.Fatalf("requests = %s, want %s", got, want)
+	}
+	if !strings.Contains(out.String(), "HTTP 405 (continuing)") {
+		t.Fatalf("output = %q", out.String())
+	}
+}
@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+const DefaultAnnouncement = "{{if .Repo}}{{.Repo}} {{end}}{{.Tag}} released: {{.Summary}}"
+
+// discordColor is the embed accent (green).
+const discordColor = 0x2EB67D
+
+func ParseAnnouncement(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultAnnouncement
+	}
+	return template.New("announcement").Option("missingkey=error").Parse(text)
+}
+
+func RenderAnnouncement(tmpl *template.Template, r Release) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, r); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// SlackPayload formats a release as a Block Kit message for an incoming
+// webhook: the announcement, the changelog notes, and links.
+func SlackPayload(r Release, announcement string) ([]byte, error) {
+	msg := struct {
+		Text   string       `json:"text"`
+		Blocks []slackBlock `json:"blocks"`
+	}{Text: announcement}
+	msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: truncate("*"+slackEscape(announcement)+"*", 3000)}})
+	if notes := strings.TrimSpace(r.Notes); notes != "" {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: truncate(SlackMarkdown(notes), 3000)}})
+	}
+	var links []string
+	if r.ReleaseURL != "" {
+		links = append(links, "<"+r.ReleaseURL+"|Release notes>")
+	}
+	if r.CompareURL != "" {
+		links = append(links, "<"+r.CompareURL+"|Compare changes>")
+	}
+	if len(links) > 0 {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: strings.Join(links, " · ")}}})
+	}
+	return json.Marshal(msg)
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Color       int    `json:"color"`
+	Footer      *struct {
+		Text string `json:"text"`
+	} `json:"footer,omitempty"`
+}
+
+// DiscordPayload formats a release as a webhook message with one embed;
+// Discord renders the changelog's Markdown as is.
+func DiscordPayload(r Release, announcement string) ([]byte, error) {
+	embed := discordEmbed{
+		Title:       truncate(r.Tag+" - "+r.Summary, 256),
+		URL:         r.ReleaseURL,
+		Description: truncate(strings.TrimSpace(r.Notes), 4096),
+		Color:       discordColor,
+	}
+	if r.CompareURL != "" {
+		embed.Description = truncate(strings.TrimSpace(embed.Description+"\n\n[Compare changes]("+r.CompareURL+")"), 4096)
+	}
+	if r.Repo != "" {
+		embed.Footer = &struct {
+			Text string `json:"text"`
+		}{Text: r.Repo}
+	}
+	return json.Marshal(struct {
+		Content string         `json:"content"`
+		Embeds  []discordEmbed `json:"embeds"`
+	}{Content: truncate(announcement, 2000), Embeds: []discordEmbed{embed}})
+}
+
+var (
+	markdownLink    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownBold    = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	markdownHeading = regexp.MustCompile(`^#{1,6}\s+(.*)$`)
+	markdownBullet  = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+)
+
+// SlackMarkdown converts the common changelog Markdown (bullets, bold,
+// headings, links) to Slack mrkdwn.
+func SlackMarkdown(md string) string {
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		line = slackEscape(line)
+		if m := markdownHeading.FindStringSubmatch(line); m != nil {
+			line = "*" + m[1] + "*"
+		} else {
+			line = markdownBullet.ReplaceAllString(line, "$1• ")
+			line = markdownBold.ReplaceAllString(line, "*$1$2*")
+		}
+		lines[i] = markdownLink.ReplaceAllString(line, "<$2|$1>")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSlackMarkdown(t *testing.T) {
+	in := "### Fixes\n- **Bold** fix for <tags> & [docs](https://example.com/docs)\n  * nested"
+	want := "*Fixes*\n• *Bold* fix for &lt;tags&gt; &amp; <https://example.com/docs|docs>\n  • nested"
+	if got := SlackMarkdown(in); got != want {
+		t.Fatalf("SlackMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestChatPayloads(t *testing.T) {
+	r := Release{Version: "1.2.3", Tag: "v1.2.3", Summary: "Title", Notes: "- change", Repo: "acme/app", ReleaseURL: "https://example.com/r", CompareURL: "https://example.com/c"}
+	tmpl, err := ParseAnnouncement("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := RenderAnnouncement(tmpl, r)
+	if err != nil || text != "acme/app v1.2.3 released: Title" {
+		t.Fatalf("RenderAnnouncement = %q, %v", text, err)
+	}
+
+	body, err := SlackPayload(r, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var slack struct {
+		Text   string
+		Blocks []struct {
+			Type     string
+			Text     struct{ Text string }
+			Elements []struct{ Text string }
+		}
+	}
+	if err := json.Unmarshal(body, &slack); err != nil {
+		t.Fatal(err)
+	}
+	if slack.Text != text || len(slack.Blocks) != 3 || slack.Blocks[1].Text.Text != "• change" || !strings.Contains(slack.Blocks[2].Elements[0].Text, "<https://example.com/c|Compare changes>") {
+		t.Fatalf("slack payload = %s", body)
+	}
+
+	body, err = DiscordPayload(r, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var discord struct {
+		Content string
+		Embeds  []struct {
+			Title, URL, Description string
+			Footer                  struct{ Text string }
+		}
+	}
+	if err := json.Unmarshal(body, &discord); err != nil {
+		t.Fatal(err)
+	}
+	e := discord.Embeds[0]
+	if discord.Content != text || e.Title != "v1.2.3 - Title" || e.URL != r.ReleaseURL || e.Description != "- change\n\n[Compare changes](https://example.com/c)" || e.Footer.Text != "acme/app" {
+		t.Fatalf("discord payload = %s", body)
+	}
+}
+
+func TestAnnouncementTemplateErrors(t *testing.T) {
+	if _, err := ParseAnnouncement("{{.Version"); err == nil {
+		t.Fatal("ParseAnnouncement accepted an unterminated action")
+	}
+	tmpl, err := ParseAnnouncement("{{.Missing}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RenderAnnouncement(tmpl, Release{}); err == nil {
+		t.Fatal("RenderAnnouncement accepted an unknown field")
+	}
+	if got := truncate("abcdef", 4); got != "abc…" {
+		t.Fatalf("truncate = %q", got)
+	}
+}
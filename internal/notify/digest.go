@@ -0,0 +1,149 @@
+package notify
+
+import (
+	"bytes"
+	"html/template"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"regexp"
+	"strings"
+)
+
+// Digest renders releases as an email body: plain text, and HTML that keeps
+// every style inline and loads no external assets, since mail clients drop
+// <style> blocks and block remote content. A single release's text is its
+// notes alone, as the subject names it; several get a heading each.
+func Digest(releases []Release) (text, html string, err error) {
+	var sections []string
+	for _, r := range releases {
+		section := strings.TrimSpace(r.Notes)
+		if r.Error != "" {
+			section = "Error: " + r.Error + "\n\n" + section
+		}
+		var links []string
+		if r.ReleaseURL != "" {
+			links = append(links, "Release: "+r.ReleaseURL)
+		}
+		if r.CompareURL != "" {
+			links = append(links, "Changes: "+r.CompareURL)
+		}
+		if len(links) > 0 {
+			section = strings.TrimSpace(section + "\n\n" + strings.Join(links, "\n"))
+		}
+		if len(releases) > 1 {
+			heading := r.Tag + " - " + r.Summary
+			section = strings.TrimSpace(heading + "\n" + strings.Repeat("=", len([]rune(heading))) + "\n\n" + section)
+		}
+		sections = append(sections, section)
+	}
+	var b bytes.Buffer
+	if err := digestHTML.Execute(&b, releases); err != nil {
+		return "", "", err
+	}
+	return strings.Join(sections, "\n\n") + "\n", b.String(), nil
+}
+
+// notesBlock is a run of bullets or a paragraph of release notes.
+type notesBlock struct {
+	Bullets []string
+	Text    string
+}
+
+func notesBlocks(notes string) []notesBlock {
+	var blocks []notesBlock
+	for _, line := range strings.Split(strings.TrimSpace(notes), "\n") {
+		line = strings.TrimSpace(line)
+		bullet, isBullet := strings.CutPrefix(line, "- ")
+		switch {
+		case line == "":
+		case isBullet && len(blocks) > 0 && blocks[len(blocks)-1].Bullets != nil:
+			blocks[len(blocks)-1].Bullets = append(blocks[len(blocks)-1].Bullets, bullet)
+		case isBullet:
+			blocks = append(blocks, notesBlock{Bullets: []string{bullet}})
+		default:
+			blocks = append(blocks, notesBlock{Text: line})
+		}
+	}
+	return blocks
+}
+
+var codeSpan = regexp.MustCompile("`([^`]+)`")
+
+// inlineCode escapes text and renders its `code` spans.
+func inlineCode(text string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, m := range codeSpan.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:m[0]]))
+		b.WriteString(`<code style="font-family:SFMono-Regular,Consolas,monospace;font-size:13px;background:#f0f2f4;padding:1px 4px;border-radius:4px">` + template.HTMLEscapeString(text[m[2]:m[3]]) + "</code>")
+		last = m[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+	return template.HTML(b.String())
+}
+
+var digestHTML = template.Must(template.New("digest").Funcs(template.FuncMap{"blocks": notesBlocks, "inline": inlineCode}).Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:16px;background:#ffffff">
+<div style="font-family:-apple-system,'Segoe UI',Helvetica,Arial,sans-serif;font-size:15px;line-height:1.5;color:#1f2328;max-width:640px">
+{{- range .}}
+<h2 style="font-size:18px;margin:24px 0 8px">{{.Tag}} - {{inline .Summary}}</h2>
+{{- with .Error}}
+<p style="margin:0 0 12px;color:#cf222e;font-weight:600">Error: {{.}}</p>
+{{- end}}
+{{- range blocks .Notes}}
+{{- if .Bullets}}
+<ul style="margin:0 0 12px;padding-left:20px">
+{{- range .Bullets}}
+<li style="margin:4px 0">{{inline .}}</li>
+{{- end}}
+</ul>
+{{- else}}
+<p style="margin:0 0 12px">{{inline .Text}}</p>
+{{- end}}
+{{- end}}
+{{- if or .ReleaseURL .CompareURL}}
+<p style="margin:0 0 12px">
+{{- with .ReleaseURL}}<a href="{{.}}" style="color:#0969da">View release</a>{{end}}
+{{- if and .ReleaseURL .CompareURL}} &middot; {{end}}
+{{- with .CompareURL}}<a href="{{.}}" style="color:#0969da">Compare changes</a>{{end -}}
+</p>
+{{- end}}
+{{- end}}
+</div>
+</body>
+</html>
+`))
+
+// Multipart encodes text and html as a multipart/alternative body, with the
+// plain-text part first so clients that cannot show HTML fall back to it,
+// and returns the Content-Type header value that goes with it.
+func Multipart(text, html string) (string, []byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	} {
+		pw, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", nil, err
+		}
+		qp := quotedprintable.NewWriter(pw)
+		content := strings.ReplaceAll(strings.TrimRight(part.content, "\n"), "\n", "\r\n") + "\r\n"
+		if _, err := qp.Write([]byte(content)); err != nil {
+			return "", nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+	return "multipart/alternative; boundary=" + w.Boundary(), body.Bytes(), nil
+}
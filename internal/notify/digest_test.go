@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+func TestDigestRendersEachRelease(t *testing.T) {
+	text, html, err := Digest([]Release{
+		{Tag: "v1.3.0", Summary: "Add `--flag`", Notes: "- Escape <b> & `a<b>`\n- Second", ReleaseURL: "https://example.com/r", CompareURL: "https://example.com/c"},
+		{Tag: "v1.2.0", Summary: "Second", Notes: "Intro line\n- Only bullet"},
+	})
+	if err != nil {
+		t.Fatalf("Digest: %v", err)
+	}
+	wantText := "v1.3.0 - Add `--flag`\n=====================\n\n- Escape <b> & `a<b>`\n- Second\n\nRelease: https://example.com/r\nChanges: https://example.com/c\n\n" +
+		"v1.2.0 - Second\n===============\n\nIntro line\n- Only bullet\n"
+	if text != wantText {
+		t.Fatalf("text = %q, want %q", text, wantText)
+	}
+	for _, want := range []string{
+		`<h2 style="font-size:18px;margin:24px 0 8px">v1.3.0 - Add <code style=`,
+		`<li style="margin:4px 0">Escape &lt;b&gt; &amp; <code style="`,
+		`>a&lt;b&gt;</code></li>`,
+		`<a href="https://example.com/r" style="color:#0969da">View release</a> &middot; <a href="https://example.com/c"`,
+		`<p style="margin:0 0 12px">Intro line</p>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("html missing %q:\n%s", want, html)
+		}
+	}
+	if strings.Contains(html, "<style") || strings.Contains(html, "<link") || strings.Contains(html, "<img") {
+		t.Fatalf("html references styles or assets outside the message:\n%s", html)
+	}
+}
+
+func TestMultipartHasTextThenHTML(t *testing.T) {
+	contentType, body, err := Multipart("plain\n", "<p>rich</p>\n")
+	if err != nil {
+		t.Fatalf("Multipart: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q (%v)", contentType, err)
+	}
+	r := multipart.NewReader(strings.NewReader(string(body)), params["boundary"])
+	for _, want := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", "plain\r\n"},
+		{"text/html; charset=utf-8", "<p>rich</p>\r\n"},
+	} {
+		part, err := r.NextPart()
+		if err != nil {
+			t.Fatalf("NextPart: %v", err)
+		}
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := part.Header.Get("Content-Type"); got != want.contentType || string(content) != want.content {
+			t.Fatalf("part = %q %q, want %q %q", got, content, want.contentType, want.content)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type SendMailFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// Email sends release notes through an SMTP relay. Addr is host:port; the
+// relay's STARTTLS is used when offered, and credentials are only sent when
+// Username is set.
+type Email struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+	SendMail SendMailFunc
+}
+
+func (e Email) Send(r Release, date time.Time) error {
+	if len(e.To) == 0 {
+		return errors.New("email: no recipients")
+	}
+	host, _, err := net.SplitHostPort(e.Addr)
+	if err != nil {
+		return fmt.Errorf("email: invalid SMTP address %q: %w", e.Addr, err)
+	}
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	msg, err := EmailMessage(e.From, e.To, r, date)
+	if err != nil {
+		return err
+	}
+	send := e.SendMail
+	if send == nil {
+		send = smtp.SendMail
+	}
+	if err := send(e.Addr, auth, e.From, e.To, msg); err != nil {
+		return fmt.Errorf("email via %s: %w", e.Addr, err)
+	}
+	return nil
+}
+
+func EmailSubject(r Release) string {
+	subject := r.Tag + " released: " + r.Summary
+	if r.Status == StatusFailure {
+		subject = r.Tag + " release failed: " + r.Summary
+	}
+	if r.Repo != "" {
+		subject = "[" + r.Repo + "] " + subject
+	}
+	return subject
+}
+
+// EmailMessage renders an RFC 5322 message whose body is the release's
+// Digest, as plain text with an HTML alternative.
+func EmailMessage(from string, to []string, r Release, date time.Time) ([]byte, error) {
+	text, html, err := Digest([]Release{r})
+	if err != nil {
+		return nil, err
+	}
+	contentType, body, err := Multipart(text, html)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	headers := [][2]string{
+		{"From", from},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", EmailSubject(r))},
+		{"Date", date.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", contentType},
+	}
+	for _, h := range headers {
+		if strings.ContainsAny(h[1], "\r\n") {
+			return nil, fmt.Errorf("email: invalid %s header", h[0])
+		}
+		fmt.Fprintf(&msg, "%s: %s\r\n", h[0], h[1])
+	}
+	msg.WriteString("\r\n")
+	msg.Write(body)
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmailSend(t *testing.T) {
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotAuth smtp.Auth
+	var gotMsg string
+	e := Email{
+		Addr:     "smtp.example.com:587",
+		Username: "releases",
+		Password: "secret",
+		From:     "releases@example.com",
+		To:       []string{"team@example.com", "ops@example.com"},
+		SendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			gotAddr, gotAuth, gotFrom, gotTo, gotMsg = addr, a, from, to, string(msg)
+			return nil
+		},
+	}
+	r := Release{Status: StatusSuccess, Tag: "v1.2.3", Summary: "Release títle", Notes: "- First change", Repo: "acme/app", ReleaseURL: "https://example.com/r"}
+	if err := e.Send(r, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotAddr != e.Addr || gotFrom != e.From || len(gotTo) != 2 || gotAuth == nil {
+		t.Fatalf("SendMail(%q, %v, %q, %q)", gotAddr, gotAuth, gotFrom, gotTo)
+	}
+	for _, want := range []string{
+		"From: releases@example.com\r\n",
+		"To: team@example.com, ops@example.com\r\n",
+		"Subject: =?utf-8?q?[acme/app]_v1.2.3_released:_Release_t=C3=ADtle?=\r\n",
+		"Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n",
+		"\r\n\r\n- First change\r\n\r\nRelease: https://example.com/r\r\n",
+	} {
+		if !strings.Contains(gotMsg, want) {
+			t.Errorf("message missing %q:\n%s", want, gotMsg)
+		}
+	}
+
+	e.Username = ""
+	e.SendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if a != nil {
+			t.Error("auth sent without a username")
+		}
+		return errors.New("connection refused")
+	}
+	if err := e.Send(r, time.Now()); err == nil || !strings.Contains(err.Error(), "smtp.example.com:587") {
+		t.Fatalf("Send error = %v", err)
+	}
+}
+
+func TestEmailMessageRejectsHeaderInjection(t *testing.T) {
+	if _, err := EmailMessage("a@example.com\r\nBcc: x@example.com", []string{"b@example.com"}, Release{Tag: "v1"}, time.Now()); err == nil {
+		t.Fatal("EmailMessage accepted a newline in From")
+	}
+	if err := (Email{Addr: "no-port", From: "a@example.com", To: []string{"b@example.com"}}).Send(Release{}, time.Now()); err == nil {
+		t.Fatal("Send accepted an address without a port")
+	}
+}
@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTimeout  = 10 * time.Second
+	DefaultAttempts = 3
+	DefaultBackoff  = 2 * time.Second
+
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+type Release struct {
+	Status     string `json:"status"`
+	Version    string `json:"version"`
+	Tag        string `json:"tag"`
+	Summary    string `json:"summary"`
+	Notes      string `json:"notes"`
+	Repo       string `json:"repo,omitempty"`
+	CompareURL string `json:"compareURL,omitempty"`
+	ReleaseURL string `json:"releaseURL,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type Webhook struct {
+	URL    string
+	Client *http.Client
+	// Timeout bounds each attempt; failed attempts are retried after Backoff,
+	// doubling each time, up to Attempts in total.
+	Timeout  time.Duration
+	Attempts int
+	Backoff  time.Duration
+}
+
+// retryableError marks network failures, timeouts, rate limits, and server
+// errors, which are worth another attempt.
+type retryableError struct{ err error }
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (w Webhook) Send(ctx context.Context, r Release) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return w.Post(ctx, "application/json", body)
+}
+
+func (w Webhook) Post(ctx context.Context, contentType string, body []byte) error {
+	attempts, backoff := w.Attempts, w.Backoff
+	if attempts <= 0 {
+		attempts = DefaultAttempts
+	}
+	if backoff <= 0 {
+		backoff = DefaultBackoff
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = w.post(ctx, contentType, body); err == nil || !errors.As(err, new(*retryableError)) {
+			break
+		}
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("POST %s: %w", Redact(w.URL), ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	if err != nil {
+		return fmt.Errorf("POST %s: %w", Redact(w.URL), err)
+	}
+	return nil
+}
+
+func (w Webhook) post(ctx context.Context, contentType string, body []byte) error {
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "mdrelease")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &retryableError{err: redactErr(err, w.URL)}
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return &retryableError{err: fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))}
+	default:
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+}
+
+// Redact drops the path and query of a webhook URL, which often embed the
+// secret (as in Slack and Discord webhook URLs).
+func Redact(raw string) string {
+	scheme, rest, ok := strings.Cut(raw, "://")
+	if !ok {
+		return "webhook"
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	host, _, _ = strings.Cut(host, "?")
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	return scheme + "://" + host + "/…"
+}
+
+// redactErr strips the webhook URL that net/http includes in its errors.
+func redactErr(err error, raw string) error {
+	return errors.New(strings.ReplaceAll(err.Error(), raw, Redact(raw)))
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSendRetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	var got Release
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	hook := Webhook{URL: srv.URL + "/hooks/secret", Backoff: time.Millisecond}
+	r := Release{Status: StatusSuccess, Version: "1.2.3", Tag: "v1.2.3", Summary: "Title", Notes: "- change", Repo: "acme/app"}
+	if err := hook.Send(context.Background(), r); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if calls.Load() != 3 || got != r {
+		t.Fatalf("calls = %d, payload = %+v", calls.Load(), got)
+	}
+}
+
+func TestWebhookSendGivesUp(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := Webhook{URL: srv.URL + "/hooks/secret", Attempts: 2, Backoff: time.Millisecond}.Send(context.Background(), Release{})
+	if err == nil || calls.Load() != 2 || !strings.Contains(err.Error(), "HTTP 502") || strings.Contains(err.Error(), "secret") {
+		t.Fatalf("err = %v, calls = %d", err, calls.Load())
+	}
+
+	calls.Store(0)
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "no", http.StatusForbidden)
+	}))
+	defer bad.Close()
+	err = Webhook{URL: bad.URL, Backoff: time.Millisecond}.Send(context.Background(), Release{})
+	if err == nil || calls.Load() != 1 || !strings.Contains(err.Error(), "HTTP 403") {
+		t.Fatalf("client error: err = %v, calls = %d", err, calls.Load())
+	}
+}
+
+func TestWebhookTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	err := Webhook{URL: srv.URL + "/hooks/secret", Timeout: 20 * time.Millisecond, Attempts: 2, Backoff: time.Millisecond}.Send(context.Background(), Release{})
+	if err == nil || time.Since(start) > 150*time.Millisecond || strings.Contains(err.Error(), "secret") {
+		t.Fatalf("err = %v after %s", err, time.Since(start))
+	}
+}
@@ -0,0 +1,90 @@
+package packaging
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type Formula struct {
+	Name      string
+	Desc      string
+	Homepage  string
+	Version   string
+	License   string
+	Bin       string
+	Artifacts []Artifact
+}
+
+func (f Formula) Path() string { return "Formula/" + f.Name + ".rb" }
+
+func FormulaClass(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case r == '-' || r == '_' || r == '.':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func RenderFormula(f Formula) (string, error) {
+	blocks := []struct {
+		os    string
+		block string
+	}{{"darwin", "on_macos"}, {"linux", "on_linux"}}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "class %s < Formula\n", FormulaClass(f.Name))
+	if f.Desc != "" {
+		fmt.Fprintf(&b, "  desc %s\n", quote(f.Desc))
+	}
+	if f.Homepage != "" {
+		fmt.Fprintf(&b, "  homepage %s\n", quote(f.Homepage))
+	}
+	fmt.Fprintf(&b, "  version %s\n", quote(f.Version))
+	if f.License != "" {
+		fmt.Fprintf(&b, "  license %s\n", quote(f.License))
+	}
+
+	found := false
+	for _, blk := range blocks {
+		arm, intel := find(f.Artifacts, blk.os, "arm64"), find(f.Artifacts, blk.os, "amd64")
+		if arm == nil && intel == nil {
+			continue
+		}
+		found = true
+		fmt.Fprintf(&b, "\n  %s do\n", blk.block)
+		for _, a := range []struct {
+			cpu string
+			art *Artifact
+		}{{"arm?", arm}, {"intel?", intel}} {
+			if a.art == nil {
+				continue
+			}
+			fmt.Fprintf(&b, "    if Hardware::CPU.%s\n", a.cpu)
+			fmt.Fprintf(&b, "      url %s\n", quote(a.art.URL))
+			fmt.Fprintf(&b, "      sha256 %s\n", quote(a.art.SHA256))
+			b.WriteString("    end\n")
+		}
+		b.WriteString("  end\n")
+	}
+	if !found {
+		return "", fmt.Errorf("no macOS or Linux assets found for Homebrew formula %s (name assets with darwin/linux and amd64/arm64)", f.Name)
+	}
+
+	bin := f.Bin
+	if bin == "" {
+		bin = f.Name
+	}
+	fmt.Fprintf(&b, "\n  def install\n    bin.install %s\n  end\n", quote(bin))
+	fmt.Fprintf(&b, "\n  test do\n    system \"#{bin}/%s\", \"--version\"\n  end\nend\n", bin)
+	return b.String(), nil
+}
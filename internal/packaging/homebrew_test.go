@@ -0,0 +1,61 @@
+package packaging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectPlatform(t *testing.T) {
+	tests := []struct {
+		name, os, arch string
+		ok             bool
+	}{
+		{"tool_Darwin_x86_64.tar.gz", "darwin", "amd64", true},
+		{"tool-macos-arm64.zip", "darwin", "arm64", true},
+		{"tool_linux_aarch64.tar.gz", "linux", "arm64", true},
+		{"tool_windows_amd64.zip", "windows", "amd64", true},
+		{"tool_linux_amd64.tar.gz.sig", "", "", false},
+		{"checksums.txt", "", "", false},
+	}
+	for _, tt := range tests {
+		goos, goarch, ok := DetectPlatform(tt.name)
+		if goos != tt.os || goarch != tt.arch || ok != tt.ok {
+			t.Errorf("DetectPlatform(%s) = %s, %s, %v", tt.name, goos, goarch, ok)
+		}
+	}
+}
+
+func TestRenderFormula(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tool_linux_amd64.tar.gz")
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	artifact, ok, err := NewArtifact(path, "tool_linux_amd64.tar.gz", "https://example.com/tool_linux_amd64.tar.gz")
+	if err != nil || !ok {
+		t.Fatalf("NewArtifact = %v, %v", ok, err)
+	}
+	out, err := RenderFormula(Formula{Name: "my-tool", Desc: `A "tool"`, Version: "1.2.3", Artifacts: []Artifact{artifact}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"class MyTool < Formula\n",
+		`desc "A \"tool\""`,
+		"  on_linux do\n    if Hardware::CPU.intel?\n",
+		`sha256 "3a6eb0790f39ac87c94f3856b2dd2c5d110e6811602261a9a923d3bb23adc8b7"`,
+		`bin.install "my-tool"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("formula missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "on_macos") {
+		t.Fatalf("formula has on_macos without darwin assets:\n%s", out)
+	}
+
+	if _, err := RenderFormula(Formula{Name: "tool", Version: "1.0.0"}); err == nil {
+		t.Fatal("RenderFormula without assets: want error")
+	}
+}
@@ -0,0 +1,102 @@
+package packaging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+type Artifact struct {
+	Name   string
+	URL    string
+	SHA256 string
+	OS     string
+	Arch   string
+}
+
+var (
+	osPatterns = []struct {
+		os string
+		re *regexp.Regexp
+	}{
+		{"darwin", regexp.MustCompile(`(?i)(darwin|macos|osx|apple)`)},
+		{"linux", regexp.MustCompile(`(?i)linux`)},
+		{"windows", regexp.MustCompile(`(?i)(windows|win64|win32|\.exe$)`)},
+	}
+	archPatterns = []struct {
+		arch string
+		re   *regexp.Regexp
+	}{
+		{"arm64", regexp.MustCompile(`(?i)(arm64|aarch64)`)},
+		{"amd64", regexp.MustCompile(`(?i)(amd64|x86_64|x64|win64)`)},
+		{"386", regexp.MustCompile(`(?i)(i386|386|x86|win32)`)},
+	}
+	skipPattern = regexp.MustCompile(`(?i)(\.sig|\.pem|\.sha256|checksums?|sums|\.txt|\.sbom|\.json)$`)
+)
+
+func DetectPlatform(name string) (goos, goarch string, ok bool) {
+	if skipPattern.MatchString(name) {
+		return "", "", false
+	}
+	for _, p := range osPatterns {
+		if p.re.MatchString(name) {
+			goos = p.os
+			break
+		}
+	}
+	for _, p := range archPatterns {
+		if p.re.MatchString(name) {
+			goarch = p.arch
+			break
+		}
+	}
+	if goos == "" {
+		return "", "", false
+	}
+	if goarch == "" {
+		goarch = "amd64"
+	}
+	return goos, goarch, true
+}
+
+func NewArtifact(path, name, url string) (Artifact, bool, error) {
+	goos, goarch, ok := DetectPlatform(name)
+	if !ok {
+		return Artifact{}, false, nil
+	}
+	sum, err := FileSHA256(path)
+	if err != nil {
+		return Artifact{}, false, err
+	}
+	return Artifact{Name: name, URL: url, SHA256: sum, OS: goos, Arch: goarch}, true, nil
+}
+
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("checksum %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func find(artifacts []Artifact, goos, goarch string) *Artifact {
+	for i := range artifacts {
+		if artifacts[i].OS == goos && artifacts[i].Arch == goarch {
+			return &artifacts[i]
+		}
+	}
+	return nil
+}
+
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
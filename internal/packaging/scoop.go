@@ -0,0 +1,56 @@
+package packaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+type ScoopManifest struct {
+	Name      string
+	Desc      string
+	Homepage  string
+	Version   string
+	License   string
+	Bin       string
+	Artifacts []Artifact
+}
+
+func (m ScoopManifest) Path() string { return "bucket/" + m.Name + ".json" }
+
+type scoopArch struct {
+	URL  string `json:"url"`
+	Hash string `json:"hash"`
+}
+
+func RenderScoop(m ScoopManifest) (string, error) {
+	arch := map[string]scoopArch{}
+	for key, goarch := range map[string]string{"64bit": "amd64", "32bit": "386", "arm64": "arm64"} {
+		if a := find(m.Artifacts, "windows", goarch); a != nil {
+			arch[key] = scoopArch{URL: a.URL, Hash: a.SHA256}
+		}
+	}
+	if len(arch) == 0 {
+		return "", fmt.Errorf("no Windows assets found for Scoop manifest %s (name assets with windows and amd64/arm64)", m.Name)
+	}
+	bin := m.Bin
+	if bin == "" {
+		bin = m.Name
+	}
+	manifest := struct {
+		Version      string               `json:"version"`
+		Description  string               `json:"description,omitempty"`
+		Homepage     string               `json:"homepage,omitempty"`
+		License      string               `json:"license,omitempty"`
+		Architecture map[string]scoopArch `json:"architecture"`
+		Bin          string               `json:"bin"`
+	}{m.Version, m.Desc, m.Homepage, m.License, arch, bin + ".exe"}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(manifest); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
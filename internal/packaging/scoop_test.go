@@ -0,0 +1,40 @@
+package packaging
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRenderScoop(t *testing.T) {
+	artifacts := []Artifact{
+		{Name: "tool_windows_amd64.zip", URL: "https://example.com/tool_windows_amd64.zip", SHA256: "aa", OS: "windows", Arch: "amd64"},
+		{Name: "tool_windows_arm64.zip", URL: "https://example.com/tool_windows_arm64.zip", SHA256: "bb", OS: "windows", Arch: "arm64"},
+		{Name: "tool_linux_amd64.tar.gz", URL: "https://example.com/tool_linux_amd64.tar.gz", SHA256: "cc", OS: "linux", Arch: "amd64"},
+	}
+	out, err := RenderScoop(ScoopManifest{Name: "tool", Desc: "A tool", Version: "1.2.3", License: "MIT", Artifacts: artifacts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Version      string `json:"version"`
+		License      string `json:"license"`
+		Bin          string `json:"bin"`
+		Architecture map[string]struct {
+			URL  string `json:"url"`
+			Hash string `json:"hash"`
+		} `json:"architecture"`
+	}
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("manifest is not JSON: %v\n%s", err, out)
+	}
+	if got.Version != "1.2.3" || got.License != "MIT" || got.Bin != "tool.exe" || len(got.Architecture) != 2 {
+		t.Fatalf("manifest = %+v", got)
+	}
+	if a := got.Architecture["64bit"]; a.URL != artifacts[0].URL || a.Hash != "aa" {
+		t.Fatalf("64bit = %+v", a)
+	}
+
+	if _, err := RenderScoop(ScoopManifest{Name: "tool", Version: "1.0.0", Artifacts: artifacts[2:]}); err == nil {
+		t.Fatal("RenderScoop without Windows assets: want error")
+	}
+}
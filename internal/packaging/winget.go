@@ -0,0 +1,95 @@
+package packaging
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const wingetManifestVersion = "1.6.0"
+
+type WingetManifest struct {
+	ID        string
+	Desc      string
+	Homepage  string
+	Version   string
+	License   string
+	Bin       string
+	Artifacts []Artifact
+}
+
+type File struct {
+	Path    string
+	Content string
+}
+
+func ValidWingetID(id string) bool {
+	publisher, name, ok := strings.Cut(id, ".")
+	return ok && publisher != "" && name != "" && !strings.ContainsAny(id, "/\\ ")
+}
+
+func (m WingetManifest) Dir() string {
+	parts := []string{"manifests", strings.ToLower(m.ID[:1])}
+	parts = append(parts, strings.Split(m.ID, ".")...)
+	return path.Join(append(parts, m.Version)...)
+}
+
+func RenderWinget(m WingetManifest) ([]File, error) {
+	if !ValidWingetID(m.ID) {
+		return nil, fmt.Errorf("invalid winget package identifier %q (expected Publisher.Package)", m.ID)
+	}
+	publisher, name, _ := strings.Cut(m.ID, ".")
+	bin := m.Bin
+	if bin == "" {
+		bin = strings.ToLower(name[strings.LastIndex(name, ".")+1:])
+	}
+	desc := m.Desc
+	if desc == "" {
+		desc = name
+	}
+	license := m.License
+	if license == "" {
+		license = "Proprietary"
+	}
+
+	var installers strings.Builder
+	for _, arch := range []struct{ goarch, winget string }{{"amd64", "x64"}, {"arm64", "arm64"}, {"386", "x86"}} {
+		a := find(m.Artifacts, "windows", arch.goarch)
+		if a == nil {
+			continue
+		}
+		fmt.Fprintf(&installers, "- Architecture: %s\n", arch.winget)
+		if strings.HasSuffix(strings.ToLower(a.Name), ".exe") {
+			installers.WriteString("  InstallerType: portable\n")
+			fmt.Fprintf(&installers, "  Commands:\n  - %s\n", quote(bin))
+		} else {
+			installers.WriteString("  InstallerType: zip\n  NestedInstallerType: portable\n")
+			fmt.Fprintf(&installers, "  NestedInstallerFiles:\n  - RelativeFilePath: %s\n    PortableCommandAlias: %s\n", quote(bin+".exe"), quote(bin))
+		}
+		fmt.Fprintf(&installers, "  InstallerUrl: %s\n", quote(a.URL))
+		fmt.Fprintf(&installers, "  InstallerSha256: %s\n", strings.ToUpper(a.SHA256))
+	}
+	if installers.Len() == 0 {
+		return nil, fmt.Errorf("no Windows assets found for winget package %s (name assets with windows and amd64/arm64)", m.ID)
+	}
+
+	header := fmt.Sprintf("PackageIdentifier: %s\nPackageVersion: %s\n", m.ID, quote(m.Version))
+	footer := func(kind string) string {
+		return fmt.Sprintf("ManifestType: %s\nManifestVersion: %s\n", kind, wingetManifestVersion)
+	}
+	var locale strings.Builder
+	locale.WriteString(header)
+	locale.WriteString("PackageLocale: en-US\n")
+	fmt.Fprintf(&locale, "Publisher: %s\nPackageName: %s\nLicense: %s\nShortDescription: %s\n", quote(publisher), quote(name), quote(license), quote(desc))
+	if m.Homepage != "" {
+		fmt.Fprintf(&locale, "PackageUrl: %s\n", quote(m.Homepage))
+	}
+	locale.WriteString(footer("defaultLocale"))
+
+	dir := m.Dir()
+	return []File{
+		{Path: path.Join(dir, m.ID+".yaml"), Content: header + "DefaultLocale: en-US\n" + footer("version")},
+		{Path: path.Join(dir, m.ID+".installer.yaml"), Content: header + "Installers:\n" + installers.String() + footer("installer")},
+		{Path: path.Join(dir, m.ID+".locale.en-US.yaml"), Content: locale.String()},
+	}, nil
+}
@@ -0,0 +1,46 @@
+package packaging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderWinget(t *testing.T) {
+	artifacts := []Artifact{
+		{Name: "tool_windows_amd64.zip", URL: "https://example.com/tool_windows_amd64.zip", SHA256: "abc", OS: "windows", Arch: "amd64"},
+		{Name: "tool_windows_arm64.exe", URL: "https://example.com/tool_windows_arm64.exe", SHA256: "def", OS: "windows", Arch: "arm64"},
+	}
+	files, err := RenderWinget(WingetManifest{ID: "Acme.Tool", Desc: "A tool", Version: "1.2.3", Artifacts: artifacts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := "manifests/a/Acme/Tool/1.2.3/"
+	want := []string{dir + "Acme.Tool.yaml", dir + "Acme.Tool.installer.yaml", dir + "Acme.Tool.locale.en-US.yaml"}
+	for i, f := range files {
+		if f.Path != want[i] {
+			t.Fatalf("files[%d] = %s, want %s", i, f.Path, want[i])
+		}
+		if !strings.HasPrefix(f.Content, "PackageIdentifier: Acme.Tool\nPackageVersion: \"1.2.3\"\n") {
+			t.Fatalf("%s header:\n%s", f.Path, f.Content)
+		}
+	}
+	installer := files[1].Content
+	for _, s := range []string{
+		"- Architecture: x64\n  InstallerType: zip\n  NestedInstallerType: portable\n",
+		"PortableCommandAlias: \"tool\"",
+		"InstallerSha256: ABC\n",
+		"- Architecture: arm64\n  InstallerType: portable\n",
+		"ManifestType: installer\n",
+	} {
+		if !strings.Contains(installer, s) {
+			t.Fatalf("installer manifest missing %q:\n%s", s, installer)
+		}
+	}
+	if !strings.Contains(files[2].Content, `Publisher: "Acme"`) || !strings.Contains(files[2].Content, `License: "Proprietary"`) {
+		t.Fatalf("locale manifest:\n%s", files[2].Content)
+	}
+
+	if _, err := RenderWinget(WingetManifest{ID: "tool", Version: "1.0.0", Artifacts: artifacts}); err == nil {
+		t.Fatal("RenderWinget with invalid ID: want error")
+	}
+}
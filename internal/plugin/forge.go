@@ -0,0 +1,46 @@
+package plugin
+
+import (
+	"context"
+
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+	"github.com/jasonwillschiu/mdrelease/internal/notify"
+)
+
+// Forge adapts a plugin to forge.Backend, for forges mdrelease has no
+// built-in client for.
+type Forge struct {
+	Plugin *Plugin
+	Kind   string
+	Repo   string
+	URL    string
+}
+
+func (f *Forge) Name() string { return "plugin " + f.Plugin.Name }
+
+func (f *Forge) EnsureRelease(ctx context.Context, rel forge.Release) (*forge.Published, bool, error) {
+	resp, err := f.Plugin.Call(ctx, Request{
+		Event:   EventForgeRelease,
+		Release: notify.Release{Tag: rel.Tag, Summary: rel.Name, Notes: rel.Body, Repo: f.Repo},
+		Forge:   &ForgeRequest{Kind: f.Kind, Repo: f.Repo, URL: f.URL, Name: rel.Name, Body: rel.Body, Commit: rel.Commit, Recreate: rel.Recreate},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &forge.Published{Tag: rel.Tag, ID: resp.ID, URL: resp.URL, Assets: resp.Assets}, resp.Created, nil
+}
+
+func (f *Forge) UploadAsset(ctx context.Context, pub *forge.Published, asset forge.Asset) error {
+	_, err := f.Plugin.Call(ctx, Request{
+		Event:   EventForgeAsset,
+		Release: notify.Release{Tag: pub.Tag, Repo: f.Repo, ReleaseURL: pub.URL},
+		Forge: &ForgeRequest{
+			Kind:      f.Kind,
+			Repo:      f.Repo,
+			URL:       f.URL,
+			ReleaseID: pub.ID,
+			Asset:     &Asset{Path: asset.Path, Name: asset.Name, Size: asset.Size},
+		},
+	})
+	return err
+}
@@ -0,0 +1,144 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/jasonwillschiu/mdrelease/internal/notify"
+)
+
+// Protocol is the version sent with every request; it changes only when a
+// plugin would have to behave differently to keep working.
+const Protocol = 1
+
+// Prefix is prepended to a plugin name to find its executable on PATH, the
+// same way git finds git-<name> subcommands.
+const Prefix = "mdrelease-"
+
+const (
+	EventReleased     = "released"
+	EventForgeRelease = "forge-release"
+	EventForgeAsset   = "forge-asset"
+)
+
+// Request is written to the plugin's stdin as a single JSON document.
+type Request struct {
+	Protocol int            `json:"protocol"`
+	Event    string         `json:"event"`
+	DryRun   bool           `json:"dryRun,omitempty"`
+	Release  notify.Release `json:"release"`
+	Forge    *ForgeRequest  `json:"forge,omitempty"`
+}
+
+type ForgeRequest struct {
+	Kind      string `json:"kind,omitempty"`
+	Repo      string `json:"repo,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Body      string `json:"body,omitempty"`
+	Commit    string `json:"commit,omitempty"`
+	Recreate  bool   `json:"recreate,omitempty"`
+	ReleaseID int64  `json:"releaseID,omitempty"`
+	Asset     *Asset `json:"asset,omitempty"`
+}
+
+type Asset struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// Response is read from the plugin's stdout. Empty output is the same as
+// {}; a non-empty error fails the call like a non-zero exit status.
+type Response struct {
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	ID      int64    `json:"id,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Created bool     `json:"created,omitempty"`
+	Assets  []string `json:"assets,omitempty"`
+}
+
+type RunFunc func(ctx context.Context, path string, stdin []byte, stderr io.Writer) ([]byte, error)
+
+type Plugin struct {
+	Name   string
+	Path   string
+	Run    RunFunc
+	Stderr io.Writer
+}
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Find resolves mdrelease-<name> on PATH. With a non-nil run the lookup is
+// skipped, so callers can substitute the executable.
+func Find(name string, run RunFunc, stderr io.Writer) (*Plugin, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("invalid plugin name %q", name)
+	}
+	p := &Plugin{Name: name, Path: Prefix + name, Run: run, Stderr: stderr}
+	if run == nil {
+		path, err := exec.LookPath(Prefix + name)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: %s not found on PATH", name, Prefix+name)
+		}
+		p.Path, p.Run = path, runExec
+	}
+	return p, nil
+}
+
+func (p *Plugin) Call(ctx context.Context, req Request) (*Response, error) {
+	req.Protocol = Protocol
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	stderr := p.Stderr
+	if stderr == nil {
+		stderr = io.Discard
+	}
+	out, err := p.Run(ctx, p.Path, in, stderr)
+	resp := &Response{}
+	if trimmed := bytes.TrimSpace(out); len(trimmed) > 0 {
+		if jsonErr := json.Unmarshal(trimmed, resp); jsonErr != nil && err == nil {
+			return nil, fmt.Errorf("plugin %s: %s: invalid response: %w", p.Name, req.Event, jsonErr)
+		}
+	}
+	switch {
+	case resp.Error != "":
+		return resp, fmt.Errorf("plugin %s: %s: %s", p.Name, req.Event, resp.Error)
+	case err != nil:
+		return resp, fmt.Errorf("plugin %s: %s: %w", p.Name, req.Event, err)
+	}
+	return resp, nil
+}
+
+func runExec(ctx context.Context, path string, stdin []byte, stderr io.Writer) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		err = fmt.Errorf("exited with status %d", exitErr.ExitCode())
+	}
+	return stdout.Bytes(), err
+}
+
+// Text returns the plugin's message for display, if any.
+func (r *Response) Text() string {
+	if r == nil {
+		return ""
+	}
+	return strings.TrimSpace(r.Message)
+}
@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jasonwillschiu/mdrelease/internal/forge"
+)
+
+func TestFindAndCallExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script plugin")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\nread -r req\ncase \"$req\" in\n*'\"event\":\"pre-tag\"'*) echo '{\"message\":\"checked\"}' ;;\n*) echo 'boom' >&2; exit 3 ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, "mdrelease-check"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+
+	var stderr strings.Builder
+	p, err := Find("check", nil, &stderr)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	resp, err := p.Call(context.Background(), Request{Event: "pre-tag"})
+	if err != nil || resp.Text() != "checked" {
+		t.Fatalf("Call = %+v, %v", resp, err)
+	}
+	if _, err := p.Call(context.Background(), Request{Event: "post-push"}); err == nil || !strings.Contains(err.Error(), "plugin check: post-push: exited with status 3") {
+		t.Fatalf("Call error = %v", err)
+	}
+	if stderr.String() != "boom\n" {
+		t.Fatalf("stderr = %q", stderr.String())
+	}
+
+	if _, err := Find("missing", nil, nil); err == nil || !strings.Contains(err.Error(), "mdrelease-missing not found on PATH") {
+		t.Fatalf("Find missing = %v", err)
+	}
+	if _, err := Find("../evil", nil, nil); err == nil {
+		t.Fatal("Find accepted a path as a plugin name")
+	}
+}
+
+func TestCallResponses(t *testing.T) {
+	var got Request
+	reply, replyErr := "", error(nil)
+	p, err := Find("fake", func(_ context.Context, path string, stdin []byte, _ io.Writer) ([]byte, error) {
+		if path != "mdrelease-fake" {
+			t.Errorf("path = %q", path)
+		}
+		if err := json.Unmarshal(stdin, &got); err != nil {
+			t.Errorf("request: %v", err)
+		}
+		return []byte(reply), replyErr
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.Call(context.Background(), Request{Event: EventReleased}); err != nil || got.Protocol != Protocol || got.Event != EventReleased {
+		t.Fatalf("empty reply: err = %v, request = %+v", err, got)
+	}
+	reply = `{"error":"not allowed"}`
+	if _, err := p.Call(context.Background(), Request{Event: "pre-commit"}); err == nil || err.Error() != "plugin fake: pre-commit: not allowed" {
+		t.Fatalf("error reply = %v", err)
+	}
+	reply = "not json"
+	if _, err := p.Call(context.Background(), Request{Event: "pre-commit"}); err == nil || !strings.Contains(err.Error(), "invalid response") {
+		t.Fatalf("bad reply = %v", err)
+	}
+	reply, replyErr = "not json", errors.New("exited with status 1")
+	if _, err := p.Call(context.Background(), Request{Event: "pre-commit"}); err == nil || !strings.Contains(err.Error(), "exited with status 1") {
+		t.Fatalf("failed run = %v", err)
+	}
+}
+
+func TestForgeBackend(t *testing.T) {
+	var requests []Request
+	p, _ := Find("codeberg", func(_ context.Context, _ string, stdin []byte, _ io.Writer) ([]byte, error) {
+		var req Request
+		if err := json.Unmarshal(stdin, &req); err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+		if req.Event == EventForgeRelease {
+			return []byte(`{"id":7,"url":"https://codeberg.org/acme/app/releases/tag/v1.2.3","created":true}`), nil
+		}
+		return nil, nil
+	}, nil)
+	f := &Forge{Plugin: p, Kind: "gitea", Repo: "acme/app"}
+	var _ forge.Backend = f
+
+	pub, created, err := f.EnsureRelease(context.Background(), forge.Release{Tag: "v1.2.3", Name: "v1.2.3 - Title", Body: "- change", Commit: "abc"})
+	if err != nil || !created || pub.ID != 7 || pub.URL != "https://codeberg.org/acme/app/releases/tag/v1.2.3" {
+		t.Fatalf("EnsureRelease = %+v, %t, %v", pub, created, err)
+	}
+	if err := f.UploadAsset(context.Background(), pub, forge.Asset{Path: "/tmp/app.tar.gz", Name: "app.tar.gz", Size: 42}); err != nil {
+		t.Fatalf("UploadAsset: %v", err)
+	}
+	if len(requests) != 2 || requests[0].Forge.Commit != "abc" || requests[1].Event != EventForgeAsset || requests[1].Forge.ReleaseID != 7 || requests[1].Forge.Asset.Name != "app.tar.gz" {
+		t.Fatalf("requests = %+v", requests)
+	}
+}
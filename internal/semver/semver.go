@@ -0,0 +1,237 @@
+// Package semver parses, compares, and increments versions as Semantic
+// Versioning 2.0.0 defines them (https://semver.org).
+package semver
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a MAJOR.MINOR.PATCH version with optional prerelease and build
+// metadata identifiers, such as 1.4.0-rc.1+g0123456.
+type Version struct {
+	Major, Minor, Patch int
+	// Prerelease holds the dot-separated identifiers after "-", such as
+	// ["rc", "1"]; it is empty for a release.
+	Prerelease []string
+	// Build holds the dot-separated identifiers after "+", which precedence
+	// ignores.
+	Build []string
+}
+
+// ParseError reports a string that is not a semantic version.
+type ParseError struct {
+	Version string
+	Msg     string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid semantic version %q: %s", e.Version, e.Msg)
+}
+
+// Parse reads a version strictly: exactly three numeric components without
+// leading zeros, no "v" prefix, and identifiers of letters, digits, and
+// hyphens, where numeric prerelease identifiers have no leading zeros.
+func Parse(s string) (Version, error) {
+	fail := func(format string, args ...any) (Version, error) {
+		return Version{}, &ParseError{Version: s, Msg: fmt.Sprintf(format, args...)}
+	}
+	var v Version
+	rest, build, hasBuild := strings.Cut(s, "+")
+	core, pre, hasPre := strings.Cut(rest, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return fail("want MAJOR.MINOR.PATCH")
+	}
+	var nums [3]int
+	for i, part := range parts {
+		n, err := numeric(part)
+		if err != nil {
+			return fail("%s %s", [...]string{"major", "minor", "patch"}[i], err)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	if hasPre {
+		v.Prerelease = strings.Split(pre, ".")
+		for _, id := range v.Prerelease {
+			if err := identifier(id); err != nil {
+				return fail("prerelease %s", err)
+			}
+			if isNumeric(id) {
+				if _, err := numeric(id); err != nil {
+					return fail("prerelease %s", err)
+				}
+			}
+		}
+	}
+	if hasBuild {
+		v.Build = strings.Split(build, ".")
+		for _, id := range v.Build {
+			if err := identifier(id); err != nil {
+				return fail("build metadata %s", err)
+			}
+		}
+	}
+	return v, nil
+}
+
+// MustParse is Parse for versions known to be valid; it panics otherwise.
+func MustParse(s string) Version {
+	v, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Valid reports whether s is a semantic version.
+func Valid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// ValidBuild reports whether s, without its leading "+", is valid build
+// metadata.
+func ValidBuild(s string) bool {
+	_, err := Parse("0.0.0+" + s)
+	return s != "" && err == nil
+}
+
+func numeric(s string) (int, error) {
+	switch {
+	case s == "":
+		return 0, fmt.Errorf("is empty")
+	case !isNumeric(s):
+		return 0, fmt.Errorf("%q is not a number", s)
+	case len(s) > 1 && s[0] == '0':
+		return 0, fmt.Errorf("%q has a leading zero", s)
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is too large", s)
+	}
+	return n, nil
+}
+
+func identifier(s string) error {
+	if s == "" {
+		return fmt.Errorf("has an empty identifier")
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r == '-') {
+			return fmt.Errorf("identifier %q has a character other than letters, digits, and hyphens", s)
+		}
+	}
+	return nil
+}
+
+func isNumeric(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// String formats the version as Parse reads it.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// IsPrerelease reports whether the version has prerelease identifiers.
+func (v Version) IsPrerelease() bool { return len(v.Prerelease) > 0 }
+
+// Core is the MAJOR.MINOR.PATCH release the version belongs to, without
+// prerelease or build metadata.
+func (v Version) Core() Version {
+	return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch}
+}
+
+// Compare orders a and b by precedence: -1 when a comes first, 1 when b
+// does, and 0 when they are equal apart from build metadata. A prerelease
+// comes before its release; prerelease identifiers compare numerically when
+// both are numbers and as text otherwise, with numbers first, and more
+// identifiers win a tie.
+func Compare(a, b Version) int {
+	if c := cmp.Or(cmp.Compare(a.Major, b.Major), cmp.Compare(a.Minor, b.Minor), cmp.Compare(a.Patch, b.Patch)); c != 0 {
+		return c
+	}
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		x, y := a.Prerelease[i], b.Prerelease[i]
+		switch xn, yn := isNumeric(x), isNumeric(y); {
+		case xn && yn:
+			// Numeric identifiers have no leading zeros, so the longer is larger.
+			if c := cmp.Or(cmp.Compare(len(x), len(y)), strings.Compare(x, y)); c != 0 {
+				return c
+			}
+		case xn:
+			return -1
+		case yn:
+			return 1
+		default:
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+		}
+	}
+	return cmp.Compare(len(a.Prerelease), len(b.Prerelease))
+}
+
+// Level is the part of a version an increment raises.
+type Level int
+
+const (
+	Patch Level = iota + 1
+	Minor
+	Major
+)
+
+func (l Level) String() string {
+	switch l {
+	case Major:
+		return "major"
+	case Minor:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// Increment returns the next release at level, dropping prerelease and build
+// metadata. A prerelease already leads up to its release, so when that
+// release is at least the level asked for it is the next version: a patch
+// increment of 1.2.3-rc.1 is 1.2.3 and a minor increment of 1.3.0-rc.1 is
+// 1.3.0, while a minor increment of 1.2.3-rc.1 is 1.3.0.
+func (v Version) Increment(level Level) Version {
+	core := v.Core()
+	if v.IsPrerelease() {
+		switch {
+		case level == Patch,
+			level == Minor && v.Patch == 0,
+			level == Major && v.Minor == 0 && v.Patch == 0:
+			return core
+		}
+	}
+	switch level {
+	case Major:
+		return Version{Major: v.Major + 1}
+	case Minor:
+		return Version{Major: v.Major, Minor: v.Minor + 1}
+	default:
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+	}
+}
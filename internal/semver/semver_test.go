@@ -0,0 +1,70 @@
+package semver
+
+import (
+	"cmp"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	v, err := Parse("1.4.0-rc.1+g0123456.dirty")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if v.Major != 1 || v.Minor != 4 || v.Patch != 0 || !slices.Equal(v.Prerelease, []string{"rc", "1"}) || !slices.Equal(v.Build, []string{"g0123456", "dirty"}) {
+		t.Fatalf("Parse = %+v", v)
+	}
+	if v.String() != "1.4.0-rc.1+g0123456.dirty" || v.Core().String() != "1.4.0" || !v.IsPrerelease() {
+		t.Fatalf("String = %s, Core = %s", v, v.Core())
+	}
+	for _, valid := range []string{"0.0.0", "10.20.30", "1.0.0-alpha-beta.0", "1.0.0+001", "1.0.0-x.7.z.92"} {
+		if !Valid(valid) {
+			t.Errorf("Valid(%q) = false", valid)
+		}
+	}
+	for _, invalid := range []string{"", "1.2", "1.2.3.4", "v1.2.3", "01.2.3", "1.2.3-", "1.2.3-rc..1", "1.2.3-01", "1.2.3+", "1.2.3-rc_1", "1.2.x", "99999999999999999999.0.0"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("Parse(%q) succeeded", invalid)
+		}
+	}
+	if !ValidBuild("g0123456.2") || ValidBuild("") || ValidBuild("a..b") {
+		t.Error("ValidBuild accepted or rejected the wrong metadata")
+	}
+}
+
+func TestComparePrecedence(t *testing.T) {
+	// The ordering example from the specification.
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+	for i := range ordered {
+		for j := range ordered {
+			if got, want := Compare(MustParse(ordered[i]), MustParse(ordered[j])), cmp.Compare(i, j); got != want {
+				t.Errorf("Compare(%s, %s) = %d, want %d", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+	if Compare(MustParse("1.2.3+a"), MustParse("1.2.3+b")) != 0 {
+		t.Error("build metadata changed precedence")
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	for _, tt := range []struct {
+		version string
+		level   Level
+		want    string
+	}{
+		{"1.2.3", Patch, "1.2.4"},
+		{"1.2.3", Minor, "1.3.0"},
+		{"1.2.3", Major, "2.0.0"},
+		{"1.2.3+build", Patch, "1.2.4"},
+		{"1.2.3-rc.1", Patch, "1.2.3"},
+		{"1.2.3-rc.1", Minor, "1.3.0"},
+		{"1.3.0-rc.1", Minor, "1.3.0"},
+		{"1.3.0-rc.1", Major, "2.0.0"},
+		{"2.0.0-rc.1", Major, "2.0.0"},
+	} {
+		if got := MustParse(tt.version).Increment(tt.level).String(); got != tt.want {
+			t.Errorf("%s.Increment(%s) = %s, want %s", tt.version, tt.level, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package versionfile
+
+import (
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Plain renders a VERSION file: the version and a newline.
+func Plain(version string) []byte {
+	return []byte(version + "\n")
+}
+
+// GoSource renders a generated Go file declaring const Version, for
+// programs that would otherwise embed and parse the changelog at startup.
+func GoSource(pkg, version string) ([]byte, error) {
+	src := fmt.Sprintf("// Code generated by mdrelease; DO NOT EDIT.\n\npackage %s\n\n// Version is the release version from the changelog.\nconst Version = %q\n", pkg, version)
+	return format.Source([]byte(src))
+}
+
+// GoPackage picks the package name for a generated Go file: the existing
+// file's package, else the package of the other Go files in its directory,
+// else the directory name.
+func GoPackage(path string) (string, error) {
+	if name, ok := packageClause(path); ok {
+		return name, nil
+	}
+	dir := filepath.Dir(path)
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	for _, match := range matches {
+		if strings.HasSuffix(match, "_test.go") {
+			continue
+		}
+		if name, ok := packageClause(match); ok {
+			return name, nil
+		}
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(abs))
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		return "", fmt.Errorf("%s: cannot derive a Go package name from the directory", path)
+	}
+	return name, nil
+}
+
+func packageClause(path string) (string, bool) {
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return "", false
+	}
+	return f.Name.Name, true
+}
+
+// Write replaces path with content when it differs, creating parent
+// directories, and reports whether it changed.
+func Write(path string, content []byte) (bool, error) {
+	if existing, err := os.ReadFile(path); err == nil && string(existing) == string(content) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, content, 0o644)
+}
@@ -0,0 +1,55 @@
+package versionfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoSourceAndPackage(t *testing.T) {
+	src, err := GoSource("buildinfo", "v1.2.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "// Code generated by mdrelease; DO NOT EDIT.\n\npackage buildinfo\n\n// Version is the release version from the changelog.\nconst Version = \"v1.2.3\"\n"
+	if string(src) != want {
+		t.Fatalf("GoSource =\n%s", src)
+	}
+
+	dir := filepath.Join(t.TempDir(), "my-tool")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "version.go")
+	if pkg, err := GoPackage(path); err != nil || pkg != "mytool" {
+		t.Fatalf("GoPackage from directory = %q, %v", pkg, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tool.go"), []byte("// Package main runs the tool.\npackage main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tool_test.go"), []byte("package main_test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if pkg, err := GoPackage(path); err != nil || pkg != "main" {
+		t.Fatalf("GoPackage from sibling = %q, %v", pkg, err)
+	}
+	if err := os.WriteFile(path, []byte("package version\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if pkg, err := GoPackage(path); err != nil || pkg != "version" {
+		t.Fatalf("GoPackage from file = %q, %v", pkg, err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "VERSION")
+	if changed, err := Write(path, Plain("1.2.3")); err != nil || !changed {
+		t.Fatalf("Write = %t, %v", changed, err)
+	}
+	if changed, err := Write(path, Plain("1.2.3")); err != nil || changed {
+		t.Fatalf("second Write = %t, %v", changed, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "1.2.3\n" {
+		t.Fatalf("VERSION = %q", data)
+	}
+}
@@ -0,0 +1,155 @@
+package versionfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const markerPrefix = "marker:"
+
+// builtins are used when a rule names a well-known manifest without a
+// pattern. They replace only the first match, which is the project's own
+// version in each of these formats.
+var builtins = map[string]string{
+	"package.json":   `"version"\s*:\s*"([^"]*)"`,
+	"pyproject.toml": `(?m)^version\s*=\s*["']([^"']*)["']`,
+	"Cargo.toml":     `(?m)^version\s*=\s*"([^"]*)"`,
+	"Chart.yaml":     `(?m)^version:\s*["']?([^"'\s]+)["']?`,
+}
+
+// versionToken finds the version to replace on a marker line.
+var versionToken = regexp.MustCompile(`v?\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?`)
+
+// Rule rewrites the version in one file. Pattern is a regular expression
+// whose first capture group (or whole match) holds the version, a
+// "marker:<text>" that selects the lines to update, or empty for a
+// well-known manifest.
+type Rule struct {
+	Path    string
+	Pattern string
+
+	re     *regexp.Regexp
+	marker string
+	first  bool
+}
+
+// Parse reads a rule written as "path" or "path=pattern".
+func Parse(spec string) (Rule, error) {
+	path, pattern, _ := strings.Cut(spec, "=")
+	return New(strings.TrimSpace(path), pattern)
+}
+
+func New(path, pattern string) (Rule, error) {
+	r := Rule{Path: path, Pattern: pattern}
+	if path == "" {
+		return r, errors.New("missing file path")
+	}
+	switch {
+	case strings.HasPrefix(pattern, markerPrefix):
+		r.marker = strings.TrimPrefix(pattern, markerPrefix)
+		if strings.TrimSpace(r.marker) == "" {
+			return r, fmt.Errorf("%s: empty marker", path)
+		}
+		return r, nil
+	case pattern == "":
+		builtin, ok := builtins[filepath.Base(path)]
+		if !ok {
+			return r, fmt.Errorf("%s: no built-in version pattern for this file (add =<regexp> or =marker:<text>)", path)
+		}
+		pattern, r.first = builtin, true
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return r, fmt.Errorf("%s: invalid pattern: %w", path, err)
+	}
+	r.re = re
+	return r, nil
+}
+
+func (r Rule) String() string {
+	if r.Pattern == "" {
+		return r.Path
+	}
+	return r.Path + "=" + r.Pattern
+}
+
+// Rewrite returns content with every version the rule selects set to
+// version. It fails when the rule matches nothing, so a stale pattern is
+// caught before the release commit.
+func (r Rule) Rewrite(content, version string) (string, error) {
+	if r.marker != "" {
+		return r.rewriteMarker(content, version)
+	}
+	matches := r.re.FindAllStringSubmatchIndex(content, -1)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%s: pattern %q matched nothing", r.Path, r.re.String())
+	}
+	if r.first {
+		matches = matches[:1]
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		b.WriteString(content[last:start])
+		b.WriteString(version)
+		last = end
+	}
+	b.WriteString(content[last:])
+	return b.String(), nil
+}
+
+func (r Rule) rewriteMarker(content, version string) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	found := false
+	for i, line := range lines {
+		if !strings.Contains(line, r.marker) {
+			continue
+		}
+		loc := versionToken.FindStringIndex(line)
+		if loc == nil {
+			return "", fmt.Errorf("%s:%d: no version next to marker %q", r.Path, i+1, r.marker)
+		}
+		v := version
+		if strings.HasPrefix(line[loc[0]:loc[1]], "v") {
+			v = "v" + version
+		}
+		lines[i] = line[:loc[0]] + v + line[loc[1]:]
+		found = true
+	}
+	if !found {
+		return "", fmt.Errorf("%s: marker %q not found", r.Path, r.marker)
+	}
+	return strings.Join(lines, ""), nil
+}
+
+// Update rewrites the file in place and reports whether it changed. With
+// write false it only checks that the rule applies.
+func (r Rule) Update(version string, write bool) (bool, error) {
+	data, err := os.ReadFile(r.Path)
+	if err != nil {
+		return false, err
+	}
+	updated, err := r.Rewrite(string(data), version)
+	if err != nil {
+		return false, err
+	}
+	if updated == string(data) {
+		return false, nil
+	}
+	if !write {
+		return true, nil
+	}
+	info, err := os.Stat(r.Path)
+	if err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(r.Path, []byte(updated), info.Mode().Perm())
+}
@@ -0,0 +1,95 @@
+package versionfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		content string
+		want    string
+	}{
+		{"package.json", "web/package.json", "{\n  \"name\": \"app\",\n  \"version\": \"1.2.2\",\n  \"devDependencies\": {\"x\": {\"version\": \"9.9.9\"}}\n}\n", "{\n  \"name\": \"app\",\n  \"version\": \"1.2.3\",\n  \"devDependencies\": {\"x\": {\"version\": \"9.9.9\"}}\n}\n"},
+		{"pyproject", "pyproject.toml", "[project]\nname = \"app\"\nversion = '1.2.2'\n", "[project]\nname = \"app\"\nversion = '1.2.3'\n"},
+		{"cargo", "Cargo.toml", "[package]\nversion = \"1.2.2\"\n\n[dependencies.x]\nversion = \"0.1\"\n", "[package]\nversion = \"1.2.3\"\n\n[dependencies.x]\nversion = \"0.1\"\n"},
+		{"chart", "charts/app/Chart.yaml", "apiVersion: v2\nversion: 1.2.2\nappVersion: \"1.2.2\"\n", "apiVersion: v2\nversion: 1.2.3\nappVersion: \"1.2.2\"\n"},
+		{"regexp group", `README.md=app@v(\d+\.\d+\.\d+)`, "go install app@v1.2.2\ngo run app@v1.2.2\n", "go install app@v1.2.3\ngo run app@v1.2.3\n"},
+		{"regexp whole match", `version.txt=\d+\.\d+\.\d+`, "1.2.2\n", "1.2.3\n"},
+		{"marker", "main.go=marker:x-release-version", "const version = \"v1.2.2\" // x-release-version\nconst other = \"1.0.0\"\n", "const version = \"v1.2.3\" // x-release-version\nconst other = \"1.0.0\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			got, err := rule.Rewrite(tt.content, "1.2.3")
+			if err != nil {
+				t.Fatalf("Rewrite: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Rewrite =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleErrors(t *testing.T) {
+	for spec, want := range map[string]string{
+		"":                   "missing file path",
+		"setup.cfg":          "no built-in version pattern",
+		"a.txt=(":            "invalid pattern",
+		"a.txt=marker:":      "empty marker",
+		"a.txt=marker: ":     "empty marker",
+		"=version: (.*)":     "missing file path",
+		"b.txt=version (.*)": "",
+	} {
+		_, err := Parse(spec)
+		if (want == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), want)) {
+			t.Errorf("Parse(%q) = %v, want %q", spec, err, want)
+		}
+	}
+	rule, _ := Parse("a.txt=marker:VERSION")
+	if _, err := rule.Rewrite("no marker here\n", "1.2.3"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing marker = %v", err)
+	}
+	if _, err := rule.Rewrite("x // VERSION\n", "1.2.3"); err == nil || !strings.Contains(err.Error(), "a.txt:1: no version") {
+		t.Errorf("marker without version = %v", err)
+	}
+	rule, _ = Parse("a.txt=version: (.*)")
+	if _, err := rule.Rewrite("name: x\n", "1.2.3"); err == nil || !strings.Contains(err.Error(), "matched nothing") {
+		t.Errorf("no match = %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "package.json")
+	if err := os.WriteFile(path, []byte(`{"version": "1.2.2"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rule, err := New(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := rule.Update("1.2.3", false); err != nil || !changed {
+		t.Fatalf("check = %t, %v", changed, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != `{"version": "1.2.2"}` {
+		t.Fatalf("check wrote the file: %s", data)
+	}
+	if changed, err := rule.Update("1.2.3", true); err != nil || !changed {
+		t.Fatalf("Update = %t, %v", changed, err)
+	}
+	if changed, err := rule.Update("1.2.3", true); err != nil || changed {
+		t.Fatalf("second Update = %t, %v", changed, err)
+	}
+	info, _ := os.Stat(path)
+	if data, _ := os.ReadFile(path); string(data) != `{"version": "1.2.3"}` || info.Mode().Perm() != 0o600 {
+		t.Fatalf("file = %s (%v)", data, info.Mode())
+	}
+}
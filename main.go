@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	_ "embed"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/jasonwillschiu/mdrelease/internal/app"
 	"github.com/jasonwillschiu/mdrelease/internal/changelog"
@@ -25,5 +28,8 @@ func init() {
 }
 
 func main() {
-	os.Exit(app.Run(os.Args[1:], os.Stdout, os.Stderr))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := app.RunContext(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
 }
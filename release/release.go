@@ -0,0 +1,266 @@
+// Package release runs mdrelease's release flow from Go programs: it reads
+// the latest changelog entry, then stages, commits, tags, and pushes it as
+// `mdrelease --stage-all --commit --tag --push` would. Forge releases, hooks,
+// notifications, and the resume journal stay with the command.
+package release
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/jasonwillschiu/mdrelease/gitops"
+	"github.com/jasonwillschiu/mdrelease/internal/changelog"
+	"github.com/jasonwillschiu/mdrelease/internal/gitutil"
+)
+
+// Step names one stage of the pipeline, as reported in Result.Completed.
+type Step string
+
+const (
+	StepStage      Step = "stage-all"
+	StepCommit     Step = "commit"
+	StepTag        Step = "tag"
+	StepPushCommit Step = "push-commit"
+	StepPushTag    Step = "push-tag"
+)
+
+var (
+	// ErrAlreadyReleased reports that the changelog version is already tagged.
+	ErrAlreadyReleased = errors.New("release: changelog version is already tagged")
+	// ErrNothingToCommit reports that staging left nothing to commit.
+	ErrNothingToCommit = errors.New("release: no changes to commit")
+	// ErrNoIdentity reports that git has no committer name and email.
+	ErrNoIdentity = errors.New("release: git committer identity is not configured")
+	// ErrGitTooOld reports a git older than the command supports.
+	ErrGitTooOld = errors.New("release: git is too old")
+	// ErrDiverged reports that the remote branch has commits not in HEAD.
+	ErrDiverged = errors.New("release: branch diverged")
+)
+
+// Actions selects the steps to run. The zero value runs them all.
+type Actions struct {
+	StageAll   bool
+	Commit     bool
+	Tag        bool
+	PushCommit bool
+	PushTag    bool
+}
+
+func (a Actions) none() bool { return a == Actions{} }
+
+// Options configures a Pipeline. Empty fields take the command's defaults.
+type Options struct {
+	// Dir is the repository directory (default: the current directory).
+	Dir string
+	// Changelog is the changelog path, relative to Dir (default: changelog.md).
+	Changelog string
+	Remote    string // default: origin
+	TagPrefix string // default: v
+	// Sync is how push actions update the branch after fetching, as with
+	// --sync: "ff-only" (default), "rebase", or "none".
+	Sync    string
+	Actions Actions
+	// DryRun prints the git commands to Stdout instead of running the
+	// mutating ones.
+	DryRun bool
+	// Stdout and Stderr receive git's output (default: discarded).
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// GitPath, Token, TokenUser, UserName, and UserEmail match the
+	// --git-path, --git-token, --git-token-user, --git-user-name, and
+	// --git-user-email flags.
+	GitPath   string
+	Token     string
+	TokenUser string
+	UserName  string
+	UserEmail string
+
+	// Git replaces the default backend, which runs the git executable with
+	// the options above. It must already run in the repository.
+	Git gitops.Client
+}
+
+// Result describes a release. On error it holds what completed before the
+// failure, so callers can report or undo it.
+type Result struct {
+	Version     string
+	Tag         string
+	Summary     string
+	Description string
+	Completed   []Step
+}
+
+// Pipeline releases the latest changelog entry of one repository.
+type Pipeline struct {
+	opts Options
+}
+
+// New returns a Pipeline for opts with defaults filled in.
+func New(opts Options) *Pipeline {
+	if opts.Changelog == "" {
+		opts.Changelog = "changelog.md"
+	}
+	if opts.Remote == "" {
+		opts.Remote = "origin"
+	}
+	if opts.TagPrefix == "" {
+		opts.TagPrefix = "v"
+	}
+	if opts.Sync == "" {
+		opts.Sync = "ff-only"
+	}
+	if opts.TokenUser == "" {
+		opts.TokenUser = "x-access-token"
+	}
+	if opts.Actions.none() {
+		opts.Actions = Actions{StageAll: true, Commit: true, Tag: true, PushCommit: true, PushTag: true}
+	}
+	if opts.Stdout == nil {
+		opts.Stdout = io.Discard
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = io.Discard
+	}
+	return &Pipeline{opts: opts}
+}
+
+// Run releases the latest changelog entry. It checks the git version, the
+// repository, the committer identity, and the remote, and for push actions
+// fetches and syncs with the remote and checks that it has not diverged, then
+// checks that the tag is free locally and on the remote, all before changing
+// anything but the sync.
+func (p *Pipeline) Run(ctx context.Context) (*Result, error) {
+	o := p.opts
+	if o.Sync != "ff-only" && o.Sync != "rebase" && o.Sync != "none" {
+		return nil, fmt.Errorf("release: invalid Sync %q (want ff-only, rebase, or none)", o.Sync)
+	}
+	path := o.Changelog
+	if !filepath.IsAbs(path) && o.Dir != "" {
+		path = filepath.Join(o.Dir, path)
+	}
+	entry, err := changelog.ParseLatest(path)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Version: entry.Version, Tag: o.TagPrefix + entry.Version, Summary: entry.Summary, Description: entry.Description}
+
+	git := o.Git
+	if git == nil {
+		git = gitops.New(gitops.Options{
+			Context:   ctx,
+			Stdout:    o.Stdout,
+			Stderr:    o.Stderr,
+			DryRun:    o.DryRun,
+			Token:     o.Token,
+			TokenUser: o.TokenUser,
+			GitPath:   o.GitPath,
+			UserName:  o.UserName,
+			UserEmail: o.UserEmail,
+			Dir:       o.Dir,
+		})
+	}
+	a := o.Actions
+	version, err := git.Version()
+	if err != nil {
+		return res, err
+	}
+	minimum := gitutil.MinVersion
+	if o.Token != "" {
+		minimum = gitutil.MinTokenVersion
+	}
+	if !version.AtLeast(minimum) {
+		return res, fmt.Errorf("%w: git %s, need %s or newer", ErrGitTooOld, version, minimum)
+	}
+	if err := git.EnsureRepo(); err != nil {
+		return res, err
+	}
+	if a.Commit || a.Tag {
+		ok, err := git.HasCommitterIdentity()
+		if err != nil {
+			return res, err
+		}
+		if !ok {
+			return res, ErrNoIdentity
+		}
+	}
+	if a.PushCommit || a.PushTag {
+		if err := git.EnsureRemote(o.Remote); err != nil {
+			return res, err
+		}
+		if err := git.FetchRemote(o.Remote); err != nil {
+			return res, err
+		}
+		switch o.Sync {
+		case "ff-only":
+			err = git.PullFFOnly(o.Remote)
+		case "rebase":
+			err = git.PullRebase(o.Remote)
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+	if a.PushCommit {
+		ahead, upstream, err := git.RemoteAhead(o.Remote)
+		if err != nil {
+			return res, err
+		}
+		if ahead > 0 {
+			return res, fmt.Errorf("%w: %s has %d commit(s) not in HEAD", ErrDiverged, upstream, ahead)
+		}
+	}
+	if a.Tag {
+		if err := git.EnsureTagAbsent(res.Tag); err != nil {
+			return res, fmt.Errorf("%w: %s", ErrAlreadyReleased, res.Tag)
+		}
+		if a.PushTag {
+			onRemote, err := git.HasRemoteTag(o.Remote, res.Tag)
+			if err != nil {
+				return res, err
+			}
+			if onRemote {
+				return res, fmt.Errorf("%w: %s on %s", ErrAlreadyReleased, res.Tag, o.Remote)
+			}
+		}
+	}
+
+	steps := []struct {
+		step Step
+		on   bool
+		run  func() error
+	}{
+		{StepStage, a.StageAll, git.StageAll},
+		{StepCommit, a.Commit, func() error {
+			if !o.DryRun || !a.StageAll {
+				staged, err := git.HasStagedChanges()
+				if err != nil {
+					return err
+				}
+				if !staged {
+					return ErrNothingToCommit
+				}
+			}
+			return git.Commit(entry.Summary, entry.Description)
+		}},
+		{StepTag, a.Tag, func() error { return git.CreateTag(res.Tag, "", entry.Summary, entry.Description) }},
+		{StepPushCommit, a.PushCommit, func() error { return git.PushHead(o.Remote) }},
+		{StepPushTag, a.PushTag, func() error { return git.PushTag(o.Remote, res.Tag) }},
+	}
+	for _, s := range steps {
+		if !s.on {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		if err := s.run(); err != nil {
+			return res, err
+		}
+		res.Completed = append(res.Completed, s.step)
+	}
+	return res, nil
+}
@@ -0,0 +1,178 @@
+package release
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jasonwillschiu/mdrelease/gitops"
+)
+
+func TestPipelineReleasesLatestEntry(t *testing.T) {
+	repo, remote := initRepo(t)
+	writeFile(t, filepath.Join(repo, "changelog.md"), "# 1.2.0 - Add export\n- Add CSV export\n")
+
+	res, err := New(Options{Dir: repo}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := []Step{StepStage, StepCommit, StepTag, StepPushCommit, StepPushTag}
+	if res.Tag != "v1.2.0" || res.Summary != "Add export" || !slices.Equal(res.Completed, want) {
+		t.Fatalf("result = %+v", res)
+	}
+	if got := git(t, repo, "log", "-1", "--format=%s"); got != "Add export" {
+		t.Fatalf("commit subject = %q", got)
+	}
+	if got := git(t, remote, "tag", "--list"); got != "v1.2.0" {
+		t.Fatalf("remote tags = %q", got)
+	}
+
+	_, err = New(Options{Dir: repo, Actions: Actions{Tag: true}}).Run(context.Background())
+	if !errors.Is(err, ErrAlreadyReleased) {
+		t.Fatalf("second release: error = %v, want ErrAlreadyReleased", err)
+	}
+}
+
+func TestPipelineStopsWhenNothingToCommit(t *testing.T) {
+	repo, _ := initRepo(t)
+	writeFile(t, filepath.Join(repo, "changelog.md"), "# 1.0.0 - First\n- Start\n")
+	git(t, repo, "add", "changelog.md")
+	git(t, repo, "commit", "-m", "changelog")
+
+	res, err := New(Options{Dir: repo, TagPrefix: "rel-"}).Run(context.Background())
+	if !errors.Is(err, ErrNothingToCommit) {
+		t.Fatalf("error = %v, want ErrNothingToCommit", err)
+	}
+	if res.Tag != "rel-1.0.0" || !slices.Equal(res.Completed, []Step{StepStage}) {
+		t.Fatalf("result = %+v", res)
+	}
+	if got := git(t, repo, "tag", "--list"); got != "" {
+		t.Fatalf("no tag should be created, got %q", got)
+	}
+}
+
+func TestPipelineSyncsWithRemoteBeforePushing(t *testing.T) {
+	repo, remote := initRepo(t)
+	other := filepath.Join(t.TempDir(), "other")
+	git(t, repo, "clone", remote, other)
+	git(t, other, "config", "user.name", "Other User")
+	git(t, other, "config", "user.email", "other@example.com")
+	writeFile(t, filepath.Join(other, "NOTES.md"), "notes\n")
+	git(t, other, "add", "NOTES.md")
+	git(t, other, "commit", "-m", "add notes")
+	git(t, other, "push", "origin", "HEAD")
+
+	writeFile(t, filepath.Join(repo, "changelog.md"), "# 1.1.0 - Add notes\n- Add notes\n")
+	if _, err := New(Options{Dir: repo}).Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := git(t, repo, "log", "--format=%s"); got != "Add notes\nadd notes\ninit" {
+		t.Fatalf("history = %q, want the release on top of the pulled commit", got)
+	}
+
+	// A commit the fast-forward pull cannot take is a divergence.
+	git(t, other, "pull", "origin", "HEAD")
+	writeFile(t, filepath.Join(other, "NOTES.md"), "more notes\n")
+	git(t, other, "commit", "-am", "more notes")
+	git(t, other, "push", "origin", "HEAD")
+	writeFile(t, filepath.Join(repo, "changelog.md"), "# 1.2.0 - Local\n- Local change\n")
+	git(t, repo, "commit", "-am", "local change")
+	res, err := New(Options{Dir: repo, Sync: "none"}).Run(context.Background())
+	if !errors.Is(err, ErrDiverged) || len(res.Completed) != 0 {
+		t.Fatalf("error = %v, completed = %v, want ErrDiverged before any step", err, res.Completed)
+	}
+}
+
+func TestPipelineChecksRemoteTag(t *testing.T) {
+	for _, dryRun := range []bool{false, true} {
+		repo, remote := initRepo(t)
+		other := filepath.Join(t.TempDir(), "other")
+		git(t, repo, "clone", remote, other)
+		git(t, other, "tag", "v1.0.0")
+		git(t, other, "push", "origin", "v1.0.0")
+
+		// A dry run skips the fetch, so only the remote check sees the tag.
+		writeFile(t, filepath.Join(repo, "changelog.md"), "# 1.0.0 - First\n- Start\n")
+		res, err := New(Options{Dir: repo, DryRun: dryRun}).Run(context.Background())
+		if !errors.Is(err, ErrAlreadyReleased) || len(res.Completed) != 0 {
+			t.Fatalf("dry run %t: error = %v, completed = %v, want ErrAlreadyReleased before any step", dryRun, err, res.Completed)
+		}
+	}
+}
+
+type fakeGit struct {
+	gitops.Client
+	calls   []string
+	version gitops.Version
+}
+
+func (f *fakeGit) Version() (gitops.Version, error) {
+	if f.version == (gitops.Version{}) {
+		return gitops.Version{Major: 2, Minor: 40}, nil
+	}
+	return f.version, nil
+}
+func (f *fakeGit) EnsureRepo() error                   { return nil }
+func (f *fakeGit) HasCommitterIdentity() (bool, error) { return true, nil }
+func (f *fakeGit) EnsureTagAbsent(string) error        { return nil }
+func (f *fakeGit) CreateTag(tag, target, summary, description string) error {
+	f.calls = append(f.calls, "CreateTag:"+tag+":"+summary)
+	return nil
+}
+
+func TestPipelineUsesSuppliedBackend(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "changelog.md"), "# 2.0.0 - Break things\n- Remove v1 API\n")
+	fg := &fakeGit{}
+
+	res, err := New(Options{Dir: dir, Actions: Actions{Tag: true}, Git: fg}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !slices.Equal(fg.calls, []string{"CreateTag:v2.0.0:Break things"}) || !slices.Equal(res.Completed, []Step{StepTag}) {
+		t.Fatalf("calls = %v, result = %+v", fg.calls, res)
+	}
+
+	fg = &fakeGit{version: gitops.Version{Major: 2, Minor: 19}}
+	if _, err := New(Options{Dir: dir, Actions: Actions{Tag: true}, Git: fg}).Run(context.Background()); !errors.Is(err, ErrGitTooOld) || len(fg.calls) != 0 {
+		t.Fatalf("error = %v, calls = %v, want ErrGitTooOld", err, fg.calls)
+	}
+}
+
+func initRepo(t *testing.T) (repo, remote string) {
+	t.Helper()
+	repo, remote = t.TempDir(), filepath.Join(t.TempDir(), "origin.git")
+	git(t, repo, "init", "--bare", remote)
+	git(t, repo, "init")
+	git(t, repo, "config", "user.name", "Test User")
+	git(t, repo, "config", "user.email", "test@example.com")
+	writeFile(t, filepath.Join(repo, "README.md"), "test\n")
+	git(t, repo, "add", "README.md")
+	git(t, repo, "commit", "-m", "init")
+	git(t, repo, "remote", "add", "origin", remote)
+	git(t, repo, "push", "-u", "origin", "HEAD")
+	return repo, remote
+}
+
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,39 @@
+// Package semver parses, compares, and increments Semantic Versioning 2.0.0
+// versions the way mdrelease does, so programs that embed it order and
+// validate versions exactly as releases, `mdrelease next`, and the
+// version-increment rule see them.
+package semver
+
+import "github.com/jasonwillschiu/mdrelease/internal/semver"
+
+type (
+	// Version is a MAJOR.MINOR.PATCH version with optional prerelease and
+	// build metadata identifiers.
+	Version = semver.Version
+	// ParseError reports a string that is not a semantic version.
+	ParseError = semver.ParseError
+	// Level is the part of a version Version.Increment raises.
+	Level = semver.Level
+)
+
+const (
+	Patch = semver.Patch
+	Minor = semver.Minor
+	Major = semver.Major
+)
+
+// Parse reads a version strictly, without a "v" prefix.
+func Parse(s string) (Version, error) { return semver.Parse(s) }
+
+// MustParse is Parse for versions known to be valid; it panics otherwise.
+func MustParse(s string) Version { return semver.MustParse(s) }
+
+// Valid reports whether s is a semantic version.
+func Valid(s string) bool { return semver.Valid(s) }
+
+// ValidBuild reports whether s, without its leading "+", is valid build
+// metadata.
+func ValidBuild(s string) bool { return semver.ValidBuild(s) }
+
+// Compare orders a and b by precedence, ignoring build metadata.
+func Compare(a, b Version) int { return semver.Compare(a, b) }
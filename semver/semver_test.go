@@ -0,0 +1,87 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, want, core string
+		prerelease     bool
+		wantErr        bool
+	}{
+		{in: "1.4.0", want: "1.4.0", core: "1.4.0"},
+		{in: "1.4.0-rc.1+g0123456", want: "1.4.0-rc.1+g0123456", core: "1.4.0", prerelease: true},
+		{in: "v1.4.0", wantErr: true},
+		{in: "1.4", wantErr: true},
+		{in: "01.4.0", wantErr: true},
+		{in: "1.4.0-rc..1", wantErr: true},
+	}
+	for _, tt := range tests {
+		v, err := Parse(tt.in)
+		if tt.wantErr {
+			var pe *ParseError
+			if !errors.As(err, &pe) || pe.Version != tt.in || Valid(tt.in) {
+				t.Errorf("Parse(%q) error = %v, want ParseError", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || !Valid(tt.in) {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if v.String() != tt.want || v.Core().String() != tt.core || v.IsPrerelease() != tt.prerelease {
+			t.Errorf("Parse(%q) = %s (core %s, prerelease %t)", tt.in, v, v.Core(), v.IsPrerelease())
+		}
+	}
+	if !ValidBuild("g0123456.dirty") || ValidBuild("") || ValidBuild("a..b") {
+		t.Error("ValidBuild accepted or rejected the wrong metadata")
+	}
+}
+
+func TestMustParsePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustParse did not panic on an invalid version")
+		}
+	}()
+	MustParse("1.2")
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.9.0", "1.10.0", -1},
+		{"1.3.0-rc.2", "1.3.0-rc.10", -1},
+		{"1.3.0-rc.1", "1.3.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.2.3+a", "1.2.3+b", 0},
+	}
+	for _, tt := range tests {
+		if got := Compare(MustParse(tt.a), MustParse(tt.b)); got != tt.want {
+			t.Errorf("Compare(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	tests := []struct {
+		from  string
+		level Level
+		want  string
+	}{
+		{"1.2.3", Patch, "1.2.4"},
+		{"1.2.3", Minor, "1.3.0"},
+		{"1.2.3", Major, "2.0.0"},
+		{"1.3.0-rc.1+g0123456", Minor, "1.3.0"},
+		{"1.2.3-rc.1", Minor, "1.3.0"},
+	}
+	for _, tt := range tests {
+		if got := MustParse(tt.from).Increment(tt.level).String(); got != tt.want {
+			t.Errorf("%s %s increment = %s, want %s", tt.from, tt.level, got, tt.want)
+		}
+	}
+}